@@ -0,0 +1,628 @@
+package analytics
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// RegressionMetrics calcula RMSE, MAE y R² entre valores predichos y reales
+func RegressionMetrics(predicted, actual []float64) (rmse, mae, r2 float64) {
+	n := len(predicted)
+	if n == 0 || n != len(actual) {
+		return 0, 0, 0
+	}
+	var sumSq, sumAbs, sumActual float64
+	for i := range predicted {
+		diff := predicted[i] - actual[i]
+		sumSq += diff * diff
+		sumAbs += math.Abs(diff)
+		sumActual += actual[i]
+	}
+	mean := sumActual / float64(n)
+	var totalSq float64
+	for _, a := range actual {
+		totalSq += (a - mean) * (a - mean)
+	}
+	rmse = math.Sqrt(sumSq / float64(n))
+	mae = sumAbs / float64(n)
+	if totalSq > 0 {
+		r2 = 1 - sumSq/totalSq
+	}
+	return rmse, mae, r2
+}
+
+// AUC calcula el área bajo la curva ROC mediante el estadístico de Mann-Whitney,
+// asignando rangos promedio a los empates
+func AUC(scores []float64, labels []bool) float64 {
+	n := len(scores)
+	if n == 0 || n != len(labels) {
+		return 0
+	}
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(i, j int) bool { return scores[idx[i]] < scores[idx[j]] })
+
+	var positives, negatives int
+	var rankSum float64
+	for i := 0; i < n; {
+		j := i
+		for j < n && scores[idx[j]] == scores[idx[i]] {
+			j++
+		}
+		// Rango promedio (base 1) para el grupo de empates
+		avgRank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if labels[idx[k]] {
+				rankSum += avgRank
+				positives++
+			} else {
+				negatives++
+			}
+		}
+		i = j
+	}
+	if positives == 0 || negatives == 0 {
+		return 0
+	}
+	p, q := float64(positives), float64(negatives)
+	return (rankSum - p*(p+1)/2) / (p * q)
+}
+
+// Confusion cuenta verdaderos/falsos positivos y negativos para un umbral dado
+func Confusion(scores []float64, labels []bool, threshold float64) (tp, fp, tn, fn int) {
+	for i, s := range scores {
+		predictedPositive := s >= threshold
+		switch {
+		case predictedPositive && labels[i]:
+			tp++
+		case predictedPositive && !labels[i]:
+			fp++
+		case !predictedPositive && labels[i]:
+			fn++
+		default:
+			tn++
+		}
+	}
+	return tp, fp, tn, fn
+}
+
+// PrecisionRecall calcula precisión y exhaustividad para un umbral dado
+func PrecisionRecall(scores []float64, labels []bool, threshold float64) (precision, recall float64) {
+	tp, fp, _, fn := Confusion(scores, labels, threshold)
+	if tp+fp > 0 {
+		precision = float64(tp) / float64(tp+fp)
+	}
+	if tp+fn > 0 {
+		recall = float64(tp) / float64(tp+fn)
+	}
+	return precision, recall
+}
+
+// CalibrationBin representa un tramo de la curva de calibración
+type CalibrationBin struct {
+	Lower         float64
+	Upper         float64
+	MeanPredicted float64
+	ObservedRate  float64
+	Count         int
+}
+
+// Calibration agrupa las probabilidades en tramos de igual anchura sobre [0, 1]
+// y compara la probabilidad media con la tasa observada de positivos
+func Calibration(scores []float64, labels []bool, bins int) []CalibrationBin {
+	if bins <= 0 {
+		return nil
+	}
+	result := make([]CalibrationBin, bins)
+	sums := make([]float64, bins)
+	positives := make([]int, bins)
+	width := 1.0 / float64(bins)
+	for i := range result {
+		result[i].Lower = float64(i) * width
+		result[i].Upper = float64(i+1) * width
+	}
+	for i, s := range scores {
+		b := int(s / width)
+		if b >= bins {
+			b = bins - 1
+		}
+		if b < 0 {
+			b = 0
+		}
+		result[b].Count++
+		sums[b] += s
+		if labels[i] {
+			positives[b]++
+		}
+	}
+	for i := range result {
+		if result[i].Count > 0 {
+			result[i].MeanPredicted = sums[i] / float64(result[i].Count)
+			result[i].ObservedRate = float64(positives[i]) / float64(result[i].Count)
+		}
+	}
+	return result
+}
+
+// StratifiedFolds asigna cada muestra a uno de k pliegues manteniendo la
+// proporción de cada estrato en todos los pliegues
+func StratifiedFolds(strata []string, k int, seed int64) []int {
+	folds := make([]int, len(strata))
+	if k <= 1 {
+		return folds
+	}
+	rng := rand.New(rand.NewSource(seed))
+	for _, members := range groupIndexes(strata) {
+		rng.Shuffle(len(members), func(i, j int) { members[i], members[j] = members[j], members[i] })
+		for pos, idx := range members {
+			folds[idx] = pos % k
+		}
+	}
+	return folds
+}
+
+// StratifiedSplit marca como prueba una fracción testSize de cada estrato
+func StratifiedSplit(strata []string, testSize float64, seed int64) []bool {
+	test := make([]bool, len(strata))
+	rng := rand.New(rand.NewSource(seed))
+	for _, members := range groupIndexes(strata) {
+		rng.Shuffle(len(members), func(i, j int) { members[i], members[j] = members[j], members[i] })
+		nTest := int(math.Round(float64(len(members)) * testSize))
+		for _, idx := range members[:nTest] {
+			test[idx] = true
+		}
+	}
+	return test
+}
+
+// groupIndexes agrupa los índices por estrato en orden determinista
+func groupIndexes(strata []string) [][]int {
+	byKey := make(map[string][]int)
+	var keys []string
+	for i, s := range strata {
+		if _, ok := byKey[s]; !ok {
+			keys = append(keys, s)
+		}
+		byKey[s] = append(byKey[s], i)
+	}
+	sort.Strings(keys)
+	groups := make([][]int, 0, len(keys))
+	for _, k := range keys {
+		groups = append(groups, byKey[k])
+	}
+	return groups
+}
+
+// ZScores estandariza los valores con la media y desviación típica poblacional
+func ZScores(values []float64) []float64 {
+	result := make([]float64, len(values))
+	if len(values) == 0 {
+		return result
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	std := math.Sqrt(variance / float64(len(values)))
+	if std == 0 {
+		return result
+	}
+	for i, v := range values {
+		result[i] = (v - mean) / std
+	}
+	return result
+}
+
+// PercentileRanks devuelve el percentil (0-100) de cada valor dentro del conjunto,
+// contando la mitad de los empates
+func PercentileRanks(values []float64) []float64 {
+	n := len(values)
+	result := make([]float64, n)
+	if n == 0 {
+		return result
+	}
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(i, j int) bool { return values[idx[i]] < values[idx[j]] })
+	for i := 0; i < n; {
+		j := i
+		for j < n && values[idx[j]] == values[idx[i]] {
+			j++
+		}
+		rank := (float64(i) + float64(j-i)/2) / float64(n) * 100
+		for k := i; k < j; k++ {
+			result[idx[k]] = rank
+		}
+		i = j
+	}
+	return result
+}
+
+// ResidualSummary resume los residuos (predicho - real) de un conjunto de muestras
+type ResidualSummary struct {
+	Count         int
+	MeanPredicted float64
+	MeanActual    float64
+	MeanResidual  float64
+	StdResidual   float64
+	MAE           float64
+	RMSE          float64
+}
+
+// Residuals calcula sesgo, dispersión y errores absolutos y cuadráticos
+func Residuals(predicted, actual []float64) ResidualSummary {
+	n := len(predicted)
+	summary := ResidualSummary{Count: n}
+	if n == 0 || n != len(actual) {
+		return summary
+	}
+	var sumPredicted, sumActual, sumResidual, sumAbs, sumSq float64
+	for i := range predicted {
+		residual := predicted[i] - actual[i]
+		sumPredicted += predicted[i]
+		sumActual += actual[i]
+		sumResidual += residual
+		sumAbs += math.Abs(residual)
+		sumSq += residual * residual
+	}
+	count := float64(n)
+	summary.MeanPredicted = sumPredicted / count
+	summary.MeanActual = sumActual / count
+	summary.MeanResidual = sumResidual / count
+	summary.MAE = sumAbs / count
+	summary.RMSE = math.Sqrt(sumSq / count)
+	summary.StdResidual = math.Sqrt(math.Max(sumSq/count-summary.MeanResidual*summary.MeanResidual, 0))
+	return summary
+}
+
+// PercentilePosition posiciones (base 0) de los dos valores ordenados entre los
+// que se interpola linealmente el percentil p (en [0, 1]) de n valores
+func PercentilePosition(n int, p float64) (lower, upper int, fraction float64) {
+	if n <= 1 {
+		return 0, 0, 0
+	}
+	h := p * float64(n-1)
+	lower, upper = int(math.Floor(h)), int(math.Ceil(h))
+	return lower, upper, h - float64(lower)
+}
+
+// Percentiles calcula percentiles exactos (p en [0, 1]) de valores ordenados de
+// menor a mayor, interpolando linealmente entre posiciones
+func Percentiles(sorted []float64, ps []float64) []float64 {
+	result := make([]float64, len(ps))
+	if len(sorted) == 0 {
+		return result
+	}
+	for i, p := range ps {
+		lower, upper, fraction := PercentilePosition(len(sorted), p)
+		result[i] = sorted[lower] + fraction*(sorted[upper]-sorted[lower])
+	}
+	return result
+}
+
+// AutoBucket tramo de AutoBuckets: [Min, Max) salvo el último, que incluye Max
+type AutoBucket struct {
+	Min   float64
+	Max   float64
+	Count int
+}
+
+// AutoBuckets reparte valores ordenados en k tramos de tamaño parecido sin
+// separar valores iguales, con los mismos límites que $bucketAuto de MongoDB:
+// cada tramo acaba donde empieza el siguiente y el último en el valor máximo
+func AutoBuckets(sorted []float64, k int) []AutoBucket {
+	n := len(sorted)
+	if n == 0 || k <= 0 {
+		return nil
+	}
+	size := int(math.Round(float64(n) / float64(k)))
+	if size < 1 {
+		size = 1
+	}
+	var buckets []AutoBucket
+	for start := 0; start < n; {
+		end := start + size
+		if len(buckets) == k-1 || end > n {
+			end = n
+		}
+		for end < n && sorted[end] == sorted[end-1] {
+			end++
+		}
+		bucket := AutoBucket{Min: sorted[start], Max: sorted[n-1], Count: end - start}
+		if end < n {
+			bucket.Max = sorted[end]
+		}
+		buckets = append(buckets, bucket)
+		start = end
+	}
+	return buckets
+}
+
+// Median devuelve la mediana de los valores sin modificar el slice original
+func Median(values []float64) float64 {
+	n := len(values)
+	if n == 0 {
+		return 0
+	}
+	sorted := make([]float64, n)
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// Pearson calcula el coeficiente de correlación lineal entre x e y; con una
+// variable binaria (0/1) equivale a la correlación biserial puntual
+func Pearson(x, y []float64) float64 {
+	n := len(x)
+	if n < 2 || n != len(y) {
+		return 0
+	}
+	var sumX, sumY float64
+	for i := range x {
+		sumX += x[i]
+		sumY += y[i]
+	}
+	meanX, meanY := sumX/float64(n), sumY/float64(n)
+	var cov, varX, varY float64
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varX*varY)
+}
+
+// SurvivalPoint es un paso de la curva de Kaplan-Meier
+type SurvivalPoint struct {
+	Time     int
+	AtRisk   int
+	Events   int
+	Censored int
+	Survival float64
+	Lower    float64
+	Upper    float64
+}
+
+// KaplanMeier estima la función de supervivencia para cada instante entero entre
+// 0 y el máximo observado. events[i] indica si el sujeto i tuvo el evento en
+// times[i] o si quedó censurado. El intervalo de confianza al 95% usa la varianza
+// de Greenwood
+func KaplanMeier(times []int, events []bool) []SurvivalPoint {
+	n := len(times)
+	if n == 0 || n != len(events) {
+		return nil
+	}
+	maxTime := 0
+	for _, t := range times {
+		if t > maxTime {
+			maxTime = t
+		}
+	}
+	eventCounts := make([]int, maxTime+1)
+	censorCounts := make([]int, maxTime+1)
+	for i, t := range times {
+		if t < 0 {
+			t = 0
+		}
+		if events[i] {
+			eventCounts[t]++
+		} else {
+			censorCounts[t]++
+		}
+	}
+
+	points := make([]SurvivalPoint, 0, maxTime+1)
+	atRisk := n
+	survival := 1.0
+	var greenwood float64
+	for t := 0; t <= maxTime; t++ {
+		d, c := eventCounts[t], censorCounts[t]
+		if atRisk > 0 && d > 0 {
+			survival *= 1 - float64(d)/float64(atRisk)
+			if atRisk > d {
+				greenwood += float64(d) / float64(atRisk*(atRisk-d))
+			}
+		}
+		margin := 1.96 * survival * math.Sqrt(greenwood)
+		points = append(points, SurvivalPoint{
+			Time:     t,
+			AtRisk:   atRisk,
+			Events:   d,
+			Censored: c,
+			Survival: survival,
+			Lower:    math.Max(survival-margin, 0),
+			Upper:    math.Min(survival+margin, 1),
+		})
+		atRisk -= d + c
+	}
+	return points
+}
+
+// MedianSurvival devuelve el primer instante en que la supervivencia cae a 0.5 o
+// menos, o false si la curva no lo alcanza
+func MedianSurvival(points []SurvivalPoint) (int, bool) {
+	for _, p := range points {
+		if p.Survival <= 0.5 {
+			return p.Time, true
+		}
+	}
+	return 0, false
+}
+
+// Ranks devuelve el rango (base 1) de cada valor, asignando el rango promedio a los empates
+func Ranks(values []float64) []float64 {
+	n := len(values)
+	ranks := make([]float64, n)
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(i, j int) bool { return values[idx[i]] < values[idx[j]] })
+	for i := 0; i < n; {
+		j := i
+		for j < n && values[idx[j]] == values[idx[i]] {
+			j++
+		}
+		avgRank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			ranks[idx[k]] = avgRank
+		}
+		i = j
+	}
+	return ranks
+}
+
+// Spearman calcula la correlación de rangos de Spearman como la correlación de
+// Pearson entre los rangos de x e y
+func Spearman(x, y []float64) float64 {
+	if len(x) != len(y) {
+		return 0
+	}
+	return Pearson(Ranks(x), Ranks(y))
+}
+
+// KMeans agrupa los puntos en k clústeres con inicialización k-means++ y
+// devuelve la asignación de cada punto, los centroides y la inercia (suma de
+// distancias al cuadrado a su centroide)
+func KMeans(points [][]float64, k int, seed int64, maxIterations int) ([]int, [][]float64, float64) {
+	n := len(points)
+	if n == 0 || k <= 0 {
+		return nil, nil, 0
+	}
+	if k > n {
+		k = n
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	// Inicialización k-means++: cada nuevo centroide se elige con probabilidad
+	// proporcional a la distancia al cuadrado al centroide más cercano
+	centroids := [][]float64{append([]float64{}, points[rng.Intn(n)]...)}
+	distances := make([]float64, n)
+	for i := range distances {
+		distances[i] = math.Inf(1)
+	}
+	for len(centroids) < k {
+		var total float64
+		for i, p := range points {
+			distances[i] = math.Min(distances[i], squaredDistance(p, centroids[len(centroids)-1]))
+			total += distances[i]
+		}
+		next := rng.Intn(n)
+		if total > 0 {
+			target := rng.Float64() * total
+			for i, d := range distances {
+				target -= d
+				if target <= 0 {
+					next = i
+					break
+				}
+			}
+		}
+		centroids = append(centroids, append([]float64{}, points[next]...))
+	}
+
+	assignments := make([]int, n)
+	for i := range assignments {
+		assignments[i] = -1
+	}
+	var inertia float64
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		changed := false
+		inertia = 0
+		for i, p := range points {
+			best, bestDistance := 0, math.Inf(1)
+			for c, centroid := range centroids {
+				if d := squaredDistance(p, centroid); d < bestDistance {
+					best, bestDistance = c, d
+				}
+			}
+			if assignments[i] != best {
+				assignments[i] = best
+				changed = true
+			}
+			inertia += bestDistance
+		}
+		if !changed {
+			break
+		}
+		centroids = Centroids(points, assignments, k)
+	}
+	return assignments, centroids, inertia
+}
+
+// Centroids calcula la media de los puntos asignados a cada uno de los k clústeres
+func Centroids(points [][]float64, assignments []int, k int) [][]float64 {
+	if len(points) == 0 {
+		return nil
+	}
+	dims := len(points[0])
+	centroids := make([][]float64, k)
+	counts := make([]int, k)
+	for c := range centroids {
+		centroids[c] = make([]float64, dims)
+	}
+	for i, p := range points {
+		c := assignments[i]
+		counts[c]++
+		for d, v := range p {
+			centroids[c][d] += v
+		}
+	}
+	for c := range centroids {
+		if counts[c] == 0 {
+			continue
+		}
+		for d := range centroids[c] {
+			centroids[c][d] /= float64(counts[c])
+		}
+	}
+	return centroids
+}
+
+// Standardize escala cada columna a media 0 y desviación típica 1
+func Standardize(points [][]float64) [][]float64 {
+	if len(points) == 0 {
+		return nil
+	}
+	dims := len(points[0])
+	scaled := make([][]float64, len(points))
+	for i := range scaled {
+		scaled[i] = make([]float64, dims)
+	}
+	column := make([]float64, len(points))
+	for d := 0; d < dims; d++ {
+		for i, p := range points {
+			column[i] = p[d]
+		}
+		for i, z := range ZScores(column) {
+			scaled[i][d] = z
+		}
+	}
+	return scaled
+}
+
+func squaredDistance(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		diff := a[i] - b[i]
+		sum += diff * diff
+	}
+	return sum
+}
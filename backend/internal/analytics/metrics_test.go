@@ -0,0 +1,385 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+)
+
+const tolerance = 1e-9
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < tolerance
+}
+
+func equalFloats(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !almostEqual(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRegressionMetrics(t *testing.T) {
+	tests := []struct {
+		name              string
+		predicted, actual []float64
+		rmse, mae, r2     float64
+	}{
+		{"perfecta", []float64{1, 2, 3}, []float64{1, 2, 3}, 0, 0, 1},
+		{"con error", []float64{1, 2, 3}, []float64{1, 2, 5}, math.Sqrt(4.0 / 3), 2.0 / 3, 7.0 / 13},
+		{"real constante", []float64{1, 3}, []float64{2, 2}, 1, 1, 0},
+		{"longitudes distintas", []float64{1}, []float64{1, 2}, 0, 0, 0},
+		{"vacía", nil, nil, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rmse, mae, r2 := RegressionMetrics(tt.predicted, tt.actual)
+			if !almostEqual(rmse, tt.rmse) || !almostEqual(mae, tt.mae) || !almostEqual(r2, tt.r2) {
+				t.Errorf("RegressionMetrics = (%v, %v, %v), se esperaba (%v, %v, %v)", rmse, mae, r2, tt.rmse, tt.mae, tt.r2)
+			}
+		})
+	}
+}
+
+func TestAUC(t *testing.T) {
+	tests := []struct {
+		name   string
+		scores []float64
+		labels []bool
+		want   float64
+	}{
+		{"separación perfecta", []float64{0.1, 0.2, 0.8, 0.9}, []bool{false, false, true, true}, 1},
+		{"invertida", []float64{0.9, 0.8, 0.2, 0.1}, []bool{false, false, true, true}, 0},
+		{"parcial", []float64{0.1, 0.4, 0.35, 0.8}, []bool{false, false, true, true}, 0.75},
+		{"empates", []float64{0.5, 0.5, 0.5, 0.5}, []bool{false, true, false, true}, 0.5},
+		{"una sola clase", []float64{0.1, 0.9}, []bool{true, true}, 0},
+		{"longitudes distintas", []float64{0.1, 0.9}, []bool{true}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AUC(tt.scores, tt.labels); !almostEqual(got, tt.want) {
+				t.Errorf("AUC = %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfusionAndPrecisionRecall(t *testing.T) {
+	tests := []struct {
+		name              string
+		scores            []float64
+		labels            []bool
+		threshold         float64
+		tp, fp, tn, fn    int
+		precision, recall float64
+	}{
+		{"mixta", []float64{0.9, 0.6, 0.4, 0.2}, []bool{true, false, true, false}, 0.5, 1, 1, 1, 1, 0.5, 0.5},
+		{"umbral incluido", []float64{0.5, 0.2}, []bool{true, false}, 0.5, 1, 0, 1, 0, 1, 1},
+		{"sin positivos predichos", []float64{0.1, 0.2}, []bool{true, false}, 0.5, 0, 0, 1, 1, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp, fp, tn, fn := Confusion(tt.scores, tt.labels, tt.threshold)
+			if tp != tt.tp || fp != tt.fp || tn != tt.tn || fn != tt.fn {
+				t.Errorf("Confusion = (%d, %d, %d, %d), se esperaba (%d, %d, %d, %d)", tp, fp, tn, fn, tt.tp, tt.fp, tt.tn, tt.fn)
+			}
+			precision, recall := PrecisionRecall(tt.scores, tt.labels, tt.threshold)
+			if !almostEqual(precision, tt.precision) || !almostEqual(recall, tt.recall) {
+				t.Errorf("PrecisionRecall = (%v, %v), se esperaba (%v, %v)", precision, recall, tt.precision, tt.recall)
+			}
+		})
+	}
+}
+
+func TestCalibration(t *testing.T) {
+	bins := Calibration([]float64{0.2, 0.4, 0.6, 1.0}, []bool{false, true, true, true}, 2)
+	want := []CalibrationBin{
+		{Lower: 0, Upper: 0.5, MeanPredicted: 0.3, ObservedRate: 0.5, Count: 2},
+		{Lower: 0.5, Upper: 1, MeanPredicted: 0.8, ObservedRate: 1, Count: 2},
+	}
+	if len(bins) != len(want) {
+		t.Fatalf("Calibration devolvió %d tramos, se esperaban %d", len(bins), len(want))
+	}
+	for i, w := range want {
+		b := bins[i]
+		if !almostEqual(b.Lower, w.Lower) || !almostEqual(b.Upper, w.Upper) || b.Count != w.Count ||
+			!almostEqual(b.MeanPredicted, w.MeanPredicted) || !almostEqual(b.ObservedRate, w.ObservedRate) {
+			t.Errorf("tramo %d = %+v, se esperaba %+v", i, b, w)
+		}
+	}
+	if Calibration([]float64{0.5}, []bool{true}, 0) != nil {
+		t.Error("Calibration con 0 tramos debería devolver nil")
+	}
+}
+
+func TestStratifiedFolds(t *testing.T) {
+	strata := make([]string, 0, 30)
+	for i := 0; i < 20; i++ {
+		strata = append(strata, "a")
+	}
+	for i := 0; i < 10; i++ {
+		strata = append(strata, "b")
+	}
+	folds := StratifiedFolds(strata, 5, 42)
+	counts := map[string][]int{"a": make([]int, 5), "b": make([]int, 5)}
+	for i, f := range folds {
+		counts[strata[i]][f]++
+	}
+	want := map[string]int{"a": 4, "b": 2}
+	for stratum, perFold := range counts {
+		for f, c := range perFold {
+			if c != want[stratum] {
+				t.Errorf("estrato %s, pliegue %d: %d muestras, se esperaban %d", stratum, f, c, want[stratum])
+			}
+		}
+	}
+	again := StratifiedFolds(strata, 5, 42)
+	for i := range folds {
+		if folds[i] != again[i] {
+			t.Fatal("StratifiedFolds no es determinista con la misma semilla")
+		}
+	}
+	for _, f := range StratifiedFolds(strata, 1, 42) {
+		if f != 0 {
+			t.Fatal("con k = 1 todas las muestras deberían ir al pliegue 0")
+		}
+	}
+}
+
+func TestStratifiedSplit(t *testing.T) {
+	tests := []struct {
+		name     string
+		strata   []string
+		testSize float64
+		want     map[string]int
+	}{
+		{"20%", []string{"a", "a", "a", "a", "a", "b", "b", "b", "b", "b"}, 0.2, map[string]int{"a": 1, "b": 1}},
+		{"sin prueba", []string{"a", "b"}, 0, map[string]int{}},
+		{"todo prueba", []string{"a", "b", "b"}, 1, map[string]int{"a": 1, "b": 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]int{}
+			for i, test := range StratifiedSplit(tt.strata, tt.testSize, 7) {
+				if test {
+					got[tt.strata[i]]++
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("StratifiedSplit = %v, se esperaba %v", got, tt.want)
+			}
+			for stratum, n := range tt.want {
+				if got[stratum] != n {
+					t.Errorf("estrato %s: %d de prueba, se esperaban %d", stratum, got[stratum], n)
+				}
+			}
+		})
+	}
+}
+
+func TestZScoresAndStandardize(t *testing.T) {
+	if got := ZScores([]float64{1, 3}); !equalFloats(got, []float64{-1, 1}) {
+		t.Errorf("ZScores = %v", got)
+	}
+	if got := ZScores([]float64{2, 2, 2}); !equalFloats(got, []float64{0, 0, 0}) {
+		t.Errorf("ZScores constante = %v", got)
+	}
+	scaled := Standardize([][]float64{{1, 10}, {3, 10}})
+	if !equalFloats(scaled[0], []float64{-1, 0}) || !equalFloats(scaled[1], []float64{1, 0}) {
+		t.Errorf("Standardize = %v", scaled)
+	}
+}
+
+func TestPercentileRanks(t *testing.T) {
+	got := PercentileRanks([]float64{30, 10, 20, 20})
+	if want := []float64{87.5, 12.5, 50, 50}; !equalFloats(got, want) {
+		t.Errorf("PercentileRanks = %v, se esperaba %v", got, want)
+	}
+}
+
+func TestResiduals(t *testing.T) {
+	got := Residuals([]float64{2, 4}, []float64{1, 5})
+	want := ResidualSummary{Count: 2, MeanPredicted: 3, MeanActual: 3, MeanResidual: 0, StdResidual: 1, MAE: 1, RMSE: 1}
+	if got != want {
+		t.Errorf("Residuals = %+v, se esperaba %+v", got, want)
+	}
+}
+
+func TestPercentiles(t *testing.T) {
+	got := Percentiles([]float64{1, 2, 3, 4, 5}, []float64{0, 0.25, 0.5, 0.9, 1})
+	if want := []float64{1, 2, 3, 4.6, 5}; !equalFloats(got, want) {
+		t.Errorf("Percentiles = %v, se esperaba %v", got, want)
+	}
+	if got := Percentiles(nil, []float64{0.5}); !equalFloats(got, []float64{0}) {
+		t.Errorf("Percentiles vacío = %v", got)
+	}
+}
+
+func TestAutoBuckets(t *testing.T) {
+	tests := []struct {
+		name   string
+		sorted []float64
+		k      int
+		want   []AutoBucket
+	}{
+		{"tramos iguales", []float64{1, 2, 3, 4}, 2, []AutoBucket{{1, 3, 2}, {3, 4, 2}}},
+		{"sin separar empates", []float64{1, 1, 1, 2}, 2, []AutoBucket{{1, 2, 3}, {2, 2, 1}}},
+		{"más tramos que valores", []float64{1, 2}, 5, []AutoBucket{{1, 2, 1}, {2, 2, 1}}},
+		{"vacío", nil, 3, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AutoBuckets(tt.sorted, tt.k)
+			if len(got) != len(tt.want) {
+				t.Fatalf("AutoBuckets = %v, se esperaba %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("tramo %d = %+v, se esperaba %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   float64
+	}{
+		{"impar", []float64{3, 1, 2}, 2},
+		{"par", []float64{4, 1, 3, 2}, 2.5},
+		{"vacía", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Median(tt.values); got != tt.want {
+				t.Errorf("Median = %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+	values := []float64{3, 1, 2}
+	Median(values)
+	if values[0] != 3 {
+		t.Error("Median no debería ordenar el slice original")
+	}
+}
+
+func TestCorrelations(t *testing.T) {
+	tests := []struct {
+		name              string
+		x, y              []float64
+		pearson, spearman float64
+	}{
+		{"lineal", []float64{1, 2, 3}, []float64{2, 4, 6}, 1, 1},
+		{"inversa", []float64{1, 2, 3}, []float64{3, 2, 1}, -1, -1},
+		{"constante", []float64{1, 2, 3}, []float64{5, 5, 5}, 0, 0},
+		{"longitudes distintas", []float64{1, 2}, []float64{1}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if p := Pearson(tt.x, tt.y); !almostEqual(p, tt.pearson) {
+				t.Errorf("Pearson = %v, se esperaba %v", p, tt.pearson)
+			}
+			if s := Spearman(tt.x, tt.y); !almostEqual(s, tt.spearman) {
+				t.Errorf("Spearman = %v, se esperaba %v", s, tt.spearman)
+			}
+		})
+	}
+}
+
+func TestSpearmanMonotonic(t *testing.T) {
+	x, y := []float64{1, 2, 3, 4}, []float64{1, 8, 27, 1000}
+	if s := Spearman(x, y); !almostEqual(s, 1) {
+		t.Errorf("Spearman = %v, se esperaba 1", s)
+	}
+	if p := Pearson(x, y); p >= 0.99 {
+		t.Errorf("Pearson = %v, una relación no lineal no debería dar 1", p)
+	}
+}
+
+func TestRanks(t *testing.T) {
+	got := Ranks([]float64{10, 20, 20, 30})
+	if want := []float64{1, 2.5, 2.5, 4}; !equalFloats(got, want) {
+		t.Errorf("Ranks = %v, se esperaba %v", got, want)
+	}
+}
+
+func TestKaplanMeier(t *testing.T) {
+	points := KaplanMeier([]int{1, 2, 2, 3}, []bool{true, true, false, true})
+	want := []struct {
+		atRisk, events, censored int
+		survival                 float64
+	}{
+		{4, 0, 0, 1},
+		{4, 1, 0, 0.75},
+		{3, 1, 1, 0.5},
+		{1, 1, 0, 0},
+	}
+	if len(points) != len(want) {
+		t.Fatalf("KaplanMeier devolvió %d puntos, se esperaban %d", len(points), len(want))
+	}
+	for i, w := range want {
+		p := points[i]
+		if p.Time != i || p.AtRisk != w.atRisk || p.Events != w.events || p.Censored != w.censored || !almostEqual(p.Survival, w.survival) {
+			t.Errorf("punto %d = %+v, se esperaba %+v", i, p, w)
+		}
+		if p.Lower > p.Survival || p.Upper < p.Survival || p.Lower < 0 || p.Upper > 1 {
+			t.Errorf("punto %d: intervalo [%v, %v] no contiene %v", i, p.Lower, p.Upper, p.Survival)
+		}
+	}
+	if median, ok := MedianSurvival(points); !ok || median != 2 {
+		t.Errorf("MedianSurvival = (%d, %v), se esperaba (2, true)", median, ok)
+	}
+
+	censored := KaplanMeier([]int{1, 2}, []bool{false, false})
+	if _, ok := MedianSurvival(censored); ok {
+		t.Error("sin eventos la mediana no debería alcanzarse")
+	}
+	if KaplanMeier(nil, nil) != nil || KaplanMeier([]int{1}, nil) != nil {
+		t.Error("KaplanMeier con entradas vacías o desiguales debería devolver nil")
+	}
+}
+
+func TestKMeans(t *testing.T) {
+	points := [][]float64{{0, 0}, {0, 1}, {1, 0}, {10, 10}, {10, 11}, {11, 10}}
+	for _, seed := range []int64{1, 2, 3} {
+		assignments, centroids, inertia := KMeans(points, 2, seed, 100)
+		if len(centroids) != 2 {
+			t.Fatalf("semilla %d: %d centroides", seed, len(centroids))
+		}
+		if assignments[0] != assignments[1] || assignments[0] != assignments[2] ||
+			assignments[3] != assignments[4] || assignments[3] != assignments[5] || assignments[0] == assignments[3] {
+			t.Errorf("semilla %d: asignación %v no separa los dos grupos", seed, assignments)
+		}
+		if !almostEqual(inertia, 8.0/3) {
+			t.Errorf("semilla %d: inercia %v, se esperaba %v", seed, inertia, 8.0/3)
+		}
+		if c := centroids[assignments[0]]; !equalFloats(c, []float64{1.0 / 3, 1.0 / 3}) {
+			t.Errorf("semilla %d: centroide %v", seed, c)
+		}
+	}
+
+	assignments, centroids, inertia := KMeans(points[:2], 5, 1, 10)
+	if len(centroids) != 2 || assignments[0] == assignments[1] || inertia != 0 {
+		t.Errorf("k mayor que n: asignación %v, centroides %v, inercia %v", assignments, centroids, inertia)
+	}
+	if a, c, _ := KMeans(nil, 2, 1, 10); a != nil || c != nil {
+		t.Error("KMeans sin puntos debería devolver nil")
+	}
+}
+
+func TestCentroids(t *testing.T) {
+	got := Centroids([][]float64{{0, 0}, {2, 4}, {5, 5}}, []int{0, 0, 1}, 3)
+	want := [][]float64{{1, 2}, {5, 5}, {0, 0}}
+	for i := range want {
+		if !equalFloats(got[i], want[i]) {
+			t.Errorf("centroide %d = %v, se esperaba %v", i, got[i], want[i])
+		}
+	}
+}
@@ -1,13 +1,32 @@
 package app
 
 import (
+	"backend/internal/auth"
+	"backend/internal/config"
 	"backend/internal/entity"
+	"backend/internal/repository"
 	"backend/internal/service"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
 
+// Cabeceras con los metadatos de las vistas materializadas
+const (
+	viewNameHeader        = "X-View-Name"
+	viewRefreshedAtHeader = "X-View-Refreshed-At"
+	viewStaleHeader       = "X-View-Stale"
+	viewSourceRunHeader   = "X-View-Source-Run"
+)
+
+// ViewHeaders cabeceras que deben exponerse a los clientes CORS
+var ViewHeaders = []string{viewNameHeader, viewRefreshedAtHeader, viewStaleHeader, viewSourceRunHeader}
+
 type app struct {
 	service service.Service
 }
@@ -26,6 +45,10 @@ func NewApp(service service.Service) App {
 	}
 }
 func (a *app) ConfigRoutes(e *echo.Echo) {
+	e.Use(a.authenticate, rawStudentIDs)
+	e.POST("/api_backend/auth/login", a.Login)
+	e.POST("/api_backend/auth/refresh", a.RefreshTokens)
+	e.POST("/api_backend/auth/logout", a.Logout)
 	e.GET("/api_backend/load_data", a.LoadBatchData)
 	e.GET("/api_backend/download_data", a.DownloadData)
 	e.GET("/api_backend/get_files", a.GetFiles)
@@ -36,9 +59,104 @@ func (a *app) ConfigRoutes(e *echo.Echo) {
 	e.GET("/api_backend/get_score_distribution_prediction_assessments", a.GetScoreDistributionPredictionAssessments)
 	e.GET("/api_backend/get_average_predicted_score_by_assessment_type", a.GetAveragePredictedScoreByAssessmentType)
 	e.GET("/api_backend/get_student_count_by_assessment_id", a.GetStudentCountByAssessmentID)
+	e.POST("/api_backend/models/:id/evaluate", a.EvaluateModel)
+	e.GET("/api_backend/prediction_runs", a.GetPredictionRuns)
+	e.POST("/api_backend/prediction_runs/:id/activate", a.ActivatePredictionRun)
+	e.GET("/api_backend/vle_scoring_configs", a.GetVleScoringConfigs)
+	e.PUT("/api_backend/vle_scoring_configs/:code_module", a.SaveVleScoringConfig)
+	e.GET("/api_backend/analytics/actual_vs_predicted", a.GetActualVsPredicted)
+	e.GET("/api_backend/analytics/fairness", a.GetFairnessReport)
+	e.GET("/api_backend/analytics/outcomes", a.GetOutcomes)
+	e.GET("/api_backend/analytics/engagement", a.GetEngagementSeries)
+	e.GET("/api_backend/analytics/item_analysis", a.GetItemAnalysis)
+	e.GET("/api_backend/analytics/survival", a.GetWithdrawalSurvival)
+	e.GET("/api_backend/analytics/correlations", a.GetFeatureCorrelations)
+	e.GET("/api_backend/predictions/:student_id/explain", a.ExplainStudentPredictions)
+	e.POST("/api_backend/analytics/clusters", a.ClusterStudents)
+	e.GET("/api_backend/analytics/clusters", a.GetStudentClusters)
+	e.GET("/api_backend/schema/violations", a.GetSchemaViolations)
+	e.POST("/api_backend/integrity/check", a.RunIntegrityCheck)
+	e.GET("/api_backend/integrity/report", a.GetIntegrityReport)
+	e.POST("/api_backend/profiles", a.ProfileDataset)
+	e.GET("/api_backend/profiles", a.GetDataProfile)
+	e.GET("/api_backend/profiles/diff", a.DiffDataProfiles)
+	e.GET("/api_backend/pseudonyms/:pseudonym", a.GetStudentPseudonym, requireAdmin)
+	e.DELETE("/api_backend/students/:id", a.EraseStudent, requireAdmin)
+	e.GET("/api_backend/students/erasures", a.GetErasureAudit, requireAdmin)
+	e.GET("/api_backend/views", a.GetMaterializedViews)
+	e.POST("/api_backend/views/refresh", a.RefreshMaterializedViews)
+	e.POST("/api_backend/views/:name/refresh", a.RefreshMaterializedViews)
+}
+func (a *app) Login(c echo.Context) error {
+	var request entity.LoginRequest
+	if err := c.Bind(&request); err != nil || request.Username == "" || request.Password == "" {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Invalid request payload)",
+			Message: "username and password are required",
+		})
+	}
+	tokens, err := a.service.Login(c.Request().Context(), request)
+	if err == auth.ErrInvalidCredentials {
+		return c.JSON(http.StatusUnauthorized, entity.ResponseGeneric{
+			Status:  "Failed (Login)",
+			Message: err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Login)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, tokens)
 }
+
+func (a *app) RefreshTokens(c echo.Context) error {
+	var request entity.RefreshRequest
+	if err := c.Bind(&request); err != nil || request.RefreshToken == "" {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Invalid request payload)",
+			Message: "refresh_token is required",
+		})
+	}
+	tokens, err := a.service.RefreshTokens(c.Request().Context(), request)
+	if err == auth.ErrInvalidRefreshToken {
+		return c.JSON(http.StatusUnauthorized, entity.ResponseGeneric{
+			Status:  "Failed (Refresh Token)",
+			Message: err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Refresh Token)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, tokens)
+}
+
+func (a *app) Logout(c echo.Context) error {
+	var request entity.RefreshRequest
+	if err := c.Bind(&request); err != nil || request.RefreshToken == "" {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Invalid request payload)",
+			Message: "refresh_token is required",
+		})
+	}
+	if err := a.service.Logout(c.Request().Context(), request); err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Logout)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, entity.ResponseGeneric{
+		Status:  "Success",
+		Message: "Logged out successfully",
+	})
+}
+
 func (a *app) LoadBatchData(c echo.Context) error {
-	err := a.service.LoadBatchData()
+	err := a.service.LoadBatchData(c.Request().Context())
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
 			Status:  "Failed (Load Data)",
@@ -52,7 +170,7 @@ func (a *app) LoadBatchData(c echo.Context) error {
 }
 
 func (a *app) DownloadData(c echo.Context) error {
-	err := a.service.DownloadData()
+	err := a.service.DownloadData(c.Request().Context())
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
 			Status:  "Failed (Download Data)",
@@ -65,7 +183,7 @@ func (a *app) DownloadData(c echo.Context) error {
 	})
 }
 func (a *app) GetFiles(c echo.Context) error {
-	files, err := a.service.GetFiles()
+	files, err := a.service.GetFiles(c.Request().Context())
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
 			Status:  "Failed (Download Data)",
@@ -83,9 +201,9 @@ func (a *app) GetData(c echo.Context) error {
 			Message: "Missing collection_name or filter parameter",
 		})
 	}
-	data, err := a.service.GetData(collectionName)
+	data, err := a.service.GetData(c.Request().Context(), collectionName)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+		return c.JSON(errorStatus(err), entity.ResponseGeneric{
 			Status:  "Failed (Download Data)",
 			Message: err.Error(),
 		})
@@ -101,7 +219,7 @@ func (a *app) GetAllCountData(c echo.Context) error {
 			Message: err.Error(),
 		})
 	}
-	data, err := a.service.GetAllCountData(reqBody.Collections)
+	data, err := a.service.GetAllCountData(c.Request().Context(), reqBody.Collections)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
 			Status:  "Failed (Getting Data)",
@@ -111,7 +229,7 @@ func (a *app) GetAllCountData(c echo.Context) error {
 	return c.JSON(http.StatusOK, data)
 }
 func (a *app) ProcessDataPredictionAssessments(c echo.Context) error {
-	data, err := a.service.ProcessDataPredictionAssessments()
+	data, err := a.service.ProcessDataPredictionAssessments(c.Request().Context())
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
 			Status:  "Failed (Getting Data)",
@@ -121,7 +239,7 @@ func (a *app) ProcessDataPredictionAssessments(c echo.Context) error {
 	return c.JSON(http.StatusOK, data)
 }
 func (a *app) ProcessDataVlePredictions(c echo.Context) error {
-	data, err := a.service.ProcessDataVlePredictions()
+	data, err := a.service.ProcessDataVlePredictions(c.Request().Context())
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
 			Status:  "Failed (Getting Data)",
@@ -132,29 +250,490 @@ func (a *app) ProcessDataVlePredictions(c echo.Context) error {
 }
 
 func (a *app) GetScoreDistributionPredictionAssessments(c echo.Context) error {
-	data, err := a.service.GetScoreDistributionPredictionAssessments()
+	query := entity.ScoreDistributionQuery{
+		CodeModule:       c.QueryParam("code_module"),
+		CodePresentation: c.QueryParam("code_presentation"),
+		AssessmentType:   c.QueryParam("assessment_type"),
+	}
+	if bins := c.QueryParam("bins"); bins != "" {
+		for _, edge := range strings.Split(bins, ",") {
+			value, err := strconv.ParseFloat(strings.TrimSpace(edge), 64)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+					Status:  "Failed (Invalid query parameters)",
+					Message: fmt.Sprintf("invalid bin edge %q", edge),
+				})
+			}
+			query.Bins = append(query.Bins, value)
+		}
+	}
+	if binCount := c.QueryParam("bin_count"); binCount != "" {
+		value, err := strconv.Atoi(binCount)
+		if err != nil || value <= 0 {
+			return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+				Status:  "Failed (Invalid query parameters)",
+				Message: fmt.Sprintf("invalid bin_count %q", binCount),
+			})
+		}
+		query.BinCount = value
+	}
+	data, err := a.service.GetScoreDistributionPredictionAssessments(c.Request().Context(), query)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
 			Status:  "Failed (Getting Data)",
 			Message: err.Error(),
 		})
 	}
+	a.setViewHeaders(c, config.ViewScoreHistogram)
 	return c.JSON(http.StatusOK, data)
 }
 
 func (a *app) GetAveragePredictedScoreByAssessmentType(c echo.Context) error {
-	data, err := a.service.GetAveragePredictedScoreByAssessmentType()
+	data, err := a.service.GetAveragePredictedScoreByAssessmentType(c.Request().Context())
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
 			Status:  "Failed (Getting Data)",
 			Message: err.Error(),
 		})
 	}
+	a.setViewHeaders(c, config.ViewAvgScoreByAssessmentType)
 	return c.JSON(http.StatusOK, data)
 }
 
 func (a *app) GetStudentCountByAssessmentID(c echo.Context) error {
-	data, err := a.service.GetStudentCountByAssessmentID()
+	data, err := a.service.GetStudentCountByAssessmentID(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Getting Data)",
+			Message: err.Error(),
+		})
+	}
+	a.setViewHeaders(c, config.ViewStudentCountByAssessment)
+	return c.JSON(http.StatusOK, data)
+}
+
+func (a *app) EvaluateModel(c echo.Context) error {
+	reqBody := new(entity.ModelEvaluationRequest)
+	if err := c.Bind(reqBody); err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Invalid request payload)",
+			Message: err.Error(),
+		})
+	}
+	data, err := a.service.EvaluateModel(c.Request().Context(), c.Param("id"), *reqBody)
+	if err != nil {
+		return c.JSON(errorStatus(err), entity.ResponseGeneric{
+			Status:  "Failed (Evaluating Model)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+func (a *app) GetPredictionRuns(c echo.Context) error {
+	data, err := a.service.GetPredictionRuns(c.Request().Context(), c.QueryParam("kind"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Getting Data)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+func (a *app) ActivatePredictionRun(c echo.Context) error {
+	if err := a.service.ActivatePredictionRun(c.Request().Context(), c.Param("id")); err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Activating Prediction Run)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, entity.ResponseGeneric{
+		Status:  "Success",
+		Message: "Prediction run activated successfully",
+	})
+}
+
+func (a *app) GetVleScoringConfigs(c echo.Context) error {
+	data, err := a.service.GetVleScoringConfigs(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Getting Data)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+// SaveVleScoringConfig aplica la petición sobre la configuración vigente del
+// módulo: los campos omitidos conservan su valor y los pesos se combinan por
+// tipo de actividad
+func (a *app) SaveVleScoringConfig(c echo.Context) error {
+	configs, err := a.service.GetVleScoringConfigs(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Getting Data)",
+			Message: err.Error(),
+		})
+	}
+	reqBody := vleScoringBase(configs, c.Param("code_module"))
+	if err := c.Bind(reqBody); err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Invalid request payload)",
+			Message: err.Error(),
+		})
+	}
+	reqBody.CodeModule = c.Param("code_module")
+	rescore := c.QueryParam("rescore") == "true"
+	count, err := a.service.SaveVleScoringConfig(c.Request().Context(), *reqBody, rescore)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Saving Scoring Config)",
+			Message: err.Error(),
+		})
+	}
+	message := "Scoring config saved successfully"
+	if rescore {
+		message = fmt.Sprintf("Scoring config saved and %d VLE predictions re-scored", count)
+	}
+	return c.JSON(http.StatusOK, entity.ResponseGeneric{
+		Status:  "Success",
+		Message: message,
+	})
+}
+
+// vleScoringBase copia la configuración guardada para el módulo o, si no tiene,
+// la del módulo por defecto
+func vleScoringBase(configs []entity.VleScoringConfig, codeModule string) *entity.VleScoringConfig {
+	base := repository.DefaultVleScoringConfig()
+	for _, cfg := range configs {
+		if cfg.CodeModule == codeModule {
+			base = cfg
+			break
+		}
+		if cfg.CodeModule == repository.DefaultVleScoringModule {
+			base = cfg
+		}
+	}
+	weights := make(map[string]float64, len(base.Weights))
+	for activity, weight := range base.Weights {
+		weights[activity] = weight
+	}
+	base.Weights = weights
+	return &base
+}
+
+func (a *app) GetActualVsPredicted(c echo.Context) error {
+	var query entity.ActualVsPredictedQuery
+	var err error
+	if query.SampleSize, err = queryInt(c, "sample_size"); err == nil {
+		if query.WorstCount, err = queryInt(c, "worst"); err == nil {
+			query.CalibrationWidth, err = queryFloat(c, "calibration_width")
+		}
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Invalid query parameters)",
+			Message: err.Error(),
+		})
+	}
+	data, err := a.service.GetActualVsPredicted(c.Request().Context(), query)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Getting Data)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+func (a *app) GetFairnessReport(c echo.Context) error {
+	query := entity.FairnessQuery{
+		ModelID:    c.QueryParam("model"),
+		RunID:      c.QueryParam("run_id"),
+		Attributes: queryList(c, "attributes"),
+	}
+	var err error
+	if query.Tolerance, err = queryFloat(c, "tolerance"); err == nil {
+		query.PassMark, err = queryFloat(c, "pass_mark")
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Invalid query parameters)",
+			Message: err.Error(),
+		})
+	}
+	data, err := a.service.GetFairnessReport(c.Request().Context(), query)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Getting Data)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+func (a *app) GetOutcomes(c echo.Context) error {
+	data, err := a.service.GetOutcomes(c.Request().Context(), queryList(c, "group_by"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Getting Data)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+func (a *app) GetItemAnalysis(c echo.Context) error {
+	data, err := a.service.GetItemAnalysis(c.Request().Context(), entity.ItemAnalysisQuery{
+		CodeModule:       c.QueryParam("code_module"),
+		CodePresentation: c.QueryParam("code_presentation"),
+		AssessmentType:   c.QueryParam("assessment_type"),
+	})
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Getting Data)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+func (a *app) GetWithdrawalSurvival(c echo.Context) error {
+	data, err := a.service.GetWithdrawalSurvival(c.Request().Context(), entity.SurvivalQuery{
+		CodeModule:       c.QueryParam("code_module"),
+		CodePresentation: c.QueryParam("code_presentation"),
+		GroupBy:          queryList(c, "group_by"),
+	})
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Getting Data)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+func (a *app) GetFeatureCorrelations(c echo.Context) error {
+	data, err := a.service.GetFeatureCorrelations(c.Request().Context(), entity.CorrelationQuery{
+		CodeModule:       c.QueryParam("code_module"),
+		CodePresentation: c.QueryParam("code_presentation"),
+		Target:           c.QueryParam("target"),
+	})
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Getting Data)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+func (a *app) ExplainStudentPredictions(c echo.Context) error {
+	studentID, err := strconv.Atoi(c.Param("student_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Invalid student_id)",
+			Message: fmt.Sprintf("invalid student_id %q", c.Param("student_id")),
+		})
+	}
+	top, err := queryInt(c, "top")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Invalid query parameters)",
+			Message: err.Error(),
+		})
+	}
+	data, err := a.service.ExplainStudentPredictions(c.Request().Context(), entity.ExplanationQuery{
+		StudentID:        studentID,
+		ModelID:          c.QueryParam("model"),
+		CodeModule:       c.QueryParam("code_module"),
+		CodePresentation: c.QueryParam("code_presentation"),
+		Top:              top,
+	})
+	if err != nil {
+		return c.JSON(errorStatus(err), entity.ResponseGeneric{
+			Status:  "Failed (Getting Data)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+func (a *app) ClusterStudents(c echo.Context) error {
+	reqBody := new(entity.ClusteringRequest)
+	if err := c.Bind(reqBody); err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Invalid request payload)",
+			Message: err.Error(),
+		})
+	}
+	data, err := a.service.ClusterStudents(c.Request().Context(), *reqBody)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Clustering Students)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+func (a *app) GetStudentClusters(c echo.Context) error {
+	data, err := a.service.GetStudentClusters(c.Request().Context(), c.QueryParam("code_module"), c.QueryParam("code_presentation"))
+	if err != nil {
+		return c.JSON(errorStatus(err), entity.ResponseGeneric{
+			Status:  "Failed (Getting Data)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+func (a *app) GetSchemaViolations(c echo.Context) error {
+	limit, err := queryInt(c, "limit")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Invalid query parameters)",
+			Message: err.Error(),
+		})
+	}
+	data, err := a.service.GetSchemaViolations(c.Request().Context(), entity.SchemaViolationQuery{
+		Collection: c.QueryParam("collection"),
+		Limit:      limit,
+	})
+	if err != nil {
+		return c.JSON(errorStatus(err), entity.ResponseGeneric{
+			Status:  "Failed (Getting Data)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+func (a *app) RunIntegrityCheck(c echo.Context) error {
+	data, err := a.service.RunIntegrityCheck(c.Request().Context())
+	if err != nil {
+		return c.JSON(errorStatus(err), entity.ResponseGeneric{
+			Status:  "Failed (Integrity Check)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+func (a *app) GetIntegrityReport(c echo.Context) error {
+	data, err := a.service.GetIntegrityReport(c.Request().Context())
+	if err != nil {
+		return c.JSON(errorStatus(err), entity.ResponseGeneric{
+			Status:  "Failed (Getting Data)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+func (a *app) ProfileDataset(c echo.Context) error {
+	data, err := a.service.ProfileDataset(c.Request().Context())
+	if err != nil {
+		return c.JSON(errorStatus(err), entity.ResponseGeneric{
+			Status:  "Failed (Profiling Data)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+func (a *app) GetDataProfile(c echo.Context) error {
+	version, err := queryInt(c, "version")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Invalid query parameters)",
+			Message: err.Error(),
+		})
+	}
+	data, err := a.service.GetDataProfile(c.Request().Context(), version)
+	if err != nil {
+		return c.JSON(errorStatus(err), entity.ResponseGeneric{
+			Status:  "Failed (Getting Data)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+func (a *app) DiffDataProfiles(c echo.Context) error {
+	from, err := queryInt(c, "from")
+	var to int
+	if err == nil {
+		to, err = queryInt(c, "to")
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Invalid query parameters)",
+			Message: err.Error(),
+		})
+	}
+	data, err := a.service.DiffDataProfiles(c.Request().Context(), from, to)
+	if err != nil {
+		return c.JSON(errorStatus(err), entity.ResponseGeneric{
+			Status:  "Failed (Getting Data)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+// GetStudentPseudonym reidentifica un seudónimo; solo para el rol de administración
+func (a *app) GetStudentPseudonym(c echo.Context) error {
+	pseudonym, err := strconv.Atoi(c.Param("pseudonym"))
+	if err != nil || pseudonym <= 0 {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Invalid pseudonym)",
+			Message: fmt.Sprintf("invalid pseudonym %q", c.Param("pseudonym")),
+		})
+	}
+	data, err := a.service.GetStudentPseudonym(c.Request().Context(), pseudonym)
+	if err != nil {
+		return c.JSON(errorStatus(err), entity.ResponseGeneric{
+			Status:  "Failed (Getting Data)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+// EraseStudent atiende una solicitud de supresión; solo para el rol de administración
+func (a *app) EraseStudent(c echo.Context) error {
+	studentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || studentID <= 0 {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Invalid student_id)",
+			Message: fmt.Sprintf("invalid student_id %q", c.Param("id")),
+		})
+	}
+	entry, err := a.service.EraseStudent(c.Request().Context(), studentID)
+	if err != nil {
+		return c.JSON(errorStatus(err), entity.ResponseGeneric{
+			Status:  "Failed (Erasing Student)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, entry)
+}
+
+func (a *app) GetErasureAudit(c echo.Context) error {
+	data, err := a.service.GetErasureAudit(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Getting Data)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+func (a *app) GetMaterializedViews(c echo.Context) error {
+	data, err := a.service.GetMaterializedViews(c.Request().Context())
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
 			Status:  "Failed (Getting Data)",
@@ -163,3 +742,114 @@ func (a *app) GetStudentCountByAssessmentID(c echo.Context) error {
 	}
 	return c.JSON(http.StatusOK, data)
 }
+
+func (a *app) RefreshMaterializedViews(c echo.Context) error {
+	data, err := a.service.RefreshMaterializedViews(c.Request().Context(), c.Param("name"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Refreshing Views)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+// setViewHeaders añade a la respuesta la fecha de actualización de la vista
+// materializada que la respalda y si está desactualizada
+func (a *app) setViewHeaders(c echo.Context, name string) {
+	metadata, err := a.service.GetViewMetadata(c.Request().Context(), name)
+	if err != nil {
+		return
+	}
+	header := c.Response().Header()
+	header.Set(viewNameHeader, metadata.Name)
+	header.Set(viewStaleHeader, strconv.FormatBool(metadata.Stale))
+	if !metadata.RefreshedAt.IsZero() {
+		header.Set(viewRefreshedAtHeader, metadata.RefreshedAt.UTC().Format(time.RFC3339))
+	}
+	if metadata.SourceRunID != "" {
+		header.Set(viewSourceRunHeader, metadata.SourceRunID)
+	}
+}
+
+// Atributos de studentInfo aceptados como filtro de cohorte en la participación
+var cohortParams = []string{"gender", "region", "highest_education", "imd_band", "age_band", "disability"}
+
+func (a *app) GetEngagementSeries(c echo.Context) error {
+	studentID, err := queryInt(c, "id_student")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Invalid query parameters)",
+			Message: err.Error(),
+		})
+	}
+	query := entity.EngagementQuery{
+		CodeModule:       c.QueryParam("code_module"),
+		CodePresentation: c.QueryParam("code_presentation"),
+		StudentID:        studentID,
+		Granularity:      c.QueryParam("granularity"),
+		Cohort:           map[string]string{},
+	}
+	for _, param := range cohortParams {
+		if value := c.QueryParam(param); value != "" {
+			query.Cohort[param] = value
+		}
+	}
+	data, err := a.service.GetEngagementSeries(c.Request().Context(), query)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+			Status:  "Failed (Getting Data)",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+// queryList lee un parámetro opcional con valores separados por comas
+func queryList(c echo.Context, name string) []string {
+	var values []string
+	for _, value := range strings.Split(c.QueryParam(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// queryInt lee un parámetro entero opcional; devuelve 0 si no se envía
+func queryInt(c echo.Context, name string) (int, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return n, nil
+}
+
+// queryFloat lee un parámetro decimal opcional; devuelve 0 si no se envía
+func queryFloat(c echo.Context, name string) (float64, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return f, nil
+}
+
+// errorStatus devuelve 404 para los errores de recurso inexistente, 501 para las
+// operaciones que el backend de almacenamiento no implementa y 400 para el resto
+func errorStatus(err error) int {
+	if errors.Is(err, entity.ErrNotFound) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, entity.ErrUnsupported) {
+		return http.StatusNotImplemented
+	}
+	return http.StatusBadRequest
+}
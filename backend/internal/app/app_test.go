@@ -0,0 +1,245 @@
+package app
+
+import (
+	"backend/internal/auth"
+	"backend/internal/config"
+	"backend/internal/entity"
+	"backend/internal/model"
+	"backend/internal/privacy"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/spf13/viper"
+)
+
+const (
+	testKey    = "test-pseudonymization-key-0123456789"
+	testSecret = "test-jwt-signing-secret-0123456789abcdef"
+	testDB     = "test"
+)
+
+type testServer struct {
+	echo          *echo.Echo
+	repos         repository.Repositories
+	pseudonymizer *privacy.Pseudonymizer
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	viper.Reset()
+	viper.Set(config.DbnameQa, testDB)
+	t.Cleanup(viper.Reset)
+
+	pseudonymizer, err := privacy.NewPseudonymizer(testKey, config.PseudonymizationExport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authenticator, err := auth.NewAuthenticator(testSecret, time.Minute, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loggers := &entity.Loggers{
+		InfoLogger:  log.New(io.Discard, "", 0),
+		ErrorLogger: log.New(io.Discard, "", 0),
+	}
+	repos := repository.NewMemoryRepositories()
+	service := service.NewService(model.NewModel(repos, loggers, pseudonymizer), loggers, pseudonymizer, authenticator)
+	e := echo.New()
+	NewApp(service).ConfigRoutes(e)
+
+	ctx := context.Background()
+	for username, role := range map[string]string{"admin": config.RoleAdmin, "ana": config.RoleAnalyst} {
+		hash, err := auth.HashPassword(username + "-password")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := repos.Users.SaveUser(ctx, testDB, entity.User{Username: username, PasswordHash: hash, Role: role}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	students := []interface{}{
+		entity.StudentInfo{IdStudent: 11391, CodeModule: "AAA", CodePresentation: "2013J", Gender: "M", FinalResult: "Pass"},
+		entity.StudentInfo{IdStudent: 28400, CodeModule: "AAA", CodePresentation: "2013J", Gender: "F", FinalResult: "Fail"},
+	}
+	if err := repos.Dataset.BatchInsert(ctx, testDB, "studentInfo", students, 100); err != nil {
+		t.Fatal(err)
+	}
+	return &testServer{echo: e, repos: repos, pseudonymizer: pseudonymizer}
+}
+
+func (s *testServer) do(method, target, token, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if token != "" {
+		request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	s.echo.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func (s *testServer) login(t *testing.T, username string) entity.AuthTokens {
+	t.Helper()
+	response := s.do(http.MethodPost, "/api_backend/auth/login", "", `{"username":"`+username+`","password":"`+username+`-password"}`)
+	if response.Code != http.StatusOK {
+		t.Fatalf("login %s: %d %s", username, response.Code, response.Body)
+	}
+	var tokens entity.AuthTokens
+	if err := json.Unmarshal(response.Body.Bytes(), &tokens); err != nil {
+		t.Fatal(err)
+	}
+	return tokens
+}
+
+func TestRoutesRequireToken(t *testing.T) {
+	s := newTestServer(t)
+
+	response := s.do(http.MethodGet, "/api_backend/analytics/outcomes", "", "")
+	if response.Code != http.StatusUnauthorized || response.Header().Get(echo.HeaderWWWAuthenticate) != "Bearer" {
+		t.Errorf("sin token: %d %q", response.Code, response.Header().Get(echo.HeaderWWWAuthenticate))
+	}
+	response = s.do(http.MethodGet, "/api_backend/analytics/outcomes", "not-a-jwt", "")
+	if response.Code != http.StatusUnauthorized {
+		t.Errorf("token no válido: %d", response.Code)
+	}
+	response = s.do(http.MethodPost, "/api_backend/auth/login", "", `{"username":"ana","password":"wrong"}`)
+	if response.Code != http.StatusUnauthorized {
+		t.Errorf("contraseña incorrecta: %d", response.Code)
+	}
+}
+
+func TestLoginRefreshAndOutcomes(t *testing.T) {
+	s := newTestServer(t)
+	tokens := s.login(t, "ana")
+
+	response := s.do(http.MethodGet, "/api_backend/analytics/outcomes?group_by=gender", tokens.AccessToken, "")
+	if response.Code != http.StatusOK {
+		t.Fatalf("outcomes: %d %s", response.Code, response.Body)
+	}
+	var report entity.OutcomeReport
+	if err := json.Unmarshal(response.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Groups) != 2 || report.Groups[0].Group["gender"] != "F" || report.Groups[0].Counts["Fail"] != 1 {
+		t.Errorf("informe inesperado: %+v", report)
+	}
+
+	response = s.do(http.MethodPost, "/api_backend/auth/refresh", "", `{"refresh_token":"`+tokens.RefreshToken+`"}`)
+	if response.Code != http.StatusOK {
+		t.Fatalf("refresh: %d %s", response.Code, response.Body)
+	}
+	response = s.do(http.MethodPost, "/api_backend/auth/refresh", "", `{"refresh_token":"`+tokens.RefreshToken+`"}`)
+	if response.Code != http.StatusUnauthorized {
+		t.Errorf("refresh reutilizado: %d", response.Code)
+	}
+}
+
+func TestAdminOnlyRoutes(t *testing.T) {
+	s := newTestServer(t)
+	analyst := s.login(t, "ana").AccessToken
+	admin := s.login(t, "admin").AccessToken
+
+	pseudonym := s.pseudonymizer.StudentID(11391)
+	record := entity.StudentPseudonym{Pseudonym: pseudonym, IdStudent: 11391}
+	if err := s.repos.Pseudonyms.SaveStudentPseudonyms(context.Background(), testDB, []entity.StudentPseudonym{record}); err != nil {
+		t.Fatal(err)
+	}
+	target := "/api_backend/pseudonyms/" + strconv.Itoa(pseudonym)
+
+	if response := s.do(http.MethodGet, target, analyst, ""); response.Code != http.StatusForbidden {
+		t.Errorf("reidentificación como analista: %d", response.Code)
+	}
+	if response := s.do(http.MethodGet, "/api_backend/get_data/studentInfo?raw_ids=true", analyst, ""); response.Code != http.StatusForbidden {
+		t.Errorf("raw_ids como analista: %d", response.Code)
+	}
+	if response := s.do(http.MethodGet, target, admin, ""); response.Code != http.StatusOK || !strings.Contains(response.Body.String(), `"id_student":11391`) {
+		t.Errorf("reidentificación como administrador: %d %s", response.Code, response.Body)
+	}
+	if response := s.do(http.MethodGet, "/api_backend/pseudonyms/42", admin, ""); response.Code != http.StatusNotFound {
+		t.Errorf("seudónimo inexistente: %d", response.Code)
+	}
+}
+
+func TestGetDataPseudonymizesForAnalyst(t *testing.T) {
+	s := newTestServer(t)
+	analyst := s.login(t, "ana").AccessToken
+	admin := s.login(t, "admin").AccessToken
+
+	response := s.do(http.MethodGet, "/api_backend/get_data/studentInfo", analyst, "")
+	if response.Code != http.StatusOK {
+		t.Fatalf("get_data: %d %s", response.Code, response.Body)
+	}
+	if body := response.Body.String(); strings.Contains(body, "11391") || !strings.Contains(body, strconv.Itoa(s.pseudonymizer.StudentID(11391))) {
+		t.Errorf("la respuesta del analista no está seudonimizada: %s", body)
+	}
+	response = s.do(http.MethodGet, "/api_backend/get_data/studentInfo?raw_ids=true", admin, "")
+	if response.Code != http.StatusOK || !strings.Contains(response.Body.String(), "11391") {
+		t.Errorf("raw_ids como administrador: %d %s", response.Code, response.Body)
+	}
+}
+
+func TestGetDataRejectsInternalCollections(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.login(t, "admin").AccessToken
+
+	for _, collection := range []string{"users", "refresh_tokens", "student_pseudonyms", "erasure_audit", "unknown"} {
+		response := s.do(http.MethodGet, "/api_backend/get_data/"+collection+"?raw_ids=true", admin, "")
+		if response.Code != http.StatusNotFound || strings.Contains(response.Body.String(), "password") {
+			t.Errorf("get_data %s: %d %s", collection, response.Code, response.Body)
+		}
+	}
+}
+
+func TestSaveVleScoringConfigKeepsOmittedFields(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.login(t, "admin").AccessToken
+	ctx := context.Background()
+
+	response := s.do(http.MethodPut, "/api_backend/vle_scoring_configs/AAA", admin, `{"normalization":"zscore","weights":{"quiz":3}}`)
+	if response.Code != http.StatusOK {
+		t.Fatalf("PUT: %d %s", response.Code, response.Body)
+	}
+	configs, err := s.repos.VleScoringConfig.GetVleScoringConfigs(ctx, testDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaults := repository.DefaultVleScoringConfig()
+	for _, cfg := range configs {
+		if cfg.CodeModule != "AAA" {
+			continue
+		}
+		if cfg.Normalization != repository.NormalizationZScore || cfg.Weights["quiz"] != 3 {
+			t.Errorf("no se aplicó la petición: %+v", cfg)
+		}
+		if cfg.Weights["forumng"] != defaults.Weights["forumng"] || cfg.DefaultWeight != defaults.DefaultWeight {
+			t.Errorf("los pesos omitidos no conservan su valor: %+v", cfg)
+		}
+		return
+	}
+	t.Fatal("no se guardó la configuración de AAA")
+}
+
+func TestSaveVleScoringConfigRejectsZeroWeights(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.login(t, "admin").AccessToken
+
+	weights := make([]string, 0, len(repository.ActivityTypes))
+	for _, activity := range repository.ActivityTypes {
+		weights = append(weights, `"`+activity+`":0`)
+	}
+	body := `{"default_weight":0,"weights":{` + strings.Join(weights, ",") + `}}`
+	if response := s.do(http.MethodPut, "/api_backend/vle_scoring_configs/AAA", admin, body); response.Code != http.StatusBadRequest {
+		t.Errorf("todos los pesos a 0: %d %s", response.Code, response.Body)
+	}
+}
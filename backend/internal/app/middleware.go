@@ -0,0 +1,96 @@
+package app
+
+import (
+	"backend/internal/auth"
+	"backend/internal/config"
+	"backend/internal/entity"
+	"backend/internal/privacy"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// claimsKey clave del contexto de echo con los claims del token de acceso
+const claimsKey = "claims"
+
+// publicRoutes rutas que no requieren token de acceso
+var publicRoutes = []string{"/api_backend/auth/login", "/api_backend/auth/refresh"}
+
+// authenticate exige un token de acceso válido (Authorization: Bearer) en todas
+// las rutas salvo las de publicRoutes
+func (a *app) authenticate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if slices.Contains(publicRoutes, c.Path()) {
+			return next(c)
+		}
+		token, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+		if !ok || token == "" {
+			c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+			return c.JSON(http.StatusUnauthorized, entity.ResponseGeneric{
+				Status:  "Failed (Unauthorized)",
+				Message: "missing bearer token",
+			})
+		}
+		claims, err := a.service.Authenticate(c.Request().Context(), token)
+		if err != nil {
+			c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+			return c.JSON(http.StatusUnauthorized, entity.ResponseGeneric{
+				Status:  "Failed (Unauthorized)",
+				Message: err.Error(),
+			})
+		}
+		c.Set(claimsKey, claims)
+		return next(c)
+	}
+}
+
+// isAdmin comprueba el rol del token de acceso
+func isAdmin(c echo.Context) bool {
+	claims, ok := c.Get(claimsKey).(*auth.Claims)
+	return ok && claims.Role == config.RoleAdmin
+}
+
+// requireAdmin restringe la ruta al rol de administración
+func requireAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !isAdmin(c) {
+			return c.JSON(http.StatusForbidden, entity.ResponseGeneric{
+				Status:  "Failed (Forbidden)",
+				Message: "admin role required",
+			})
+		}
+		return next(c)
+	}
+}
+
+// rawStudentIDs atiende raw_ids=true: la respuesta conserva los id_student
+// originales, solo para el rol de administración
+func rawStudentIDs(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		value := c.QueryParam("raw_ids")
+		if value == "" {
+			return next(c)
+		}
+		raw, err := strconv.ParseBool(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, entity.ResponseGeneric{
+				Status:  "Failed (Invalid query parameters)",
+				Message: fmt.Sprintf("invalid raw_ids %q", value),
+			})
+		}
+		if raw {
+			if !isAdmin(c) {
+				return c.JSON(http.StatusForbidden, entity.ResponseGeneric{
+					Status:  "Failed (Forbidden)",
+					Message: "admin role required for raw_ids",
+				})
+			}
+			c.SetRequest(c.Request().WithContext(privacy.WithRawIDs(c.Request().Context())))
+		}
+		return next(c)
+	}
+}
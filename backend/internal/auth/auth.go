@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"backend/internal/config"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// minSecretLength longitud mínima de la clave de firma HS256
+const minSecretLength = 32
+
+// issuer emisor de los tokens de acceso
+const issuer = "backend"
+
+// ErrInvalidCredentials usuario o contraseña incorrectos; no distingue cuál de
+// los dos para no revelar qué usuarios existen
+var ErrInvalidCredentials = errors.New("usuario o contraseña incorrectos")
+
+// ErrInvalidRefreshToken token de refresco inexistente, ya usado o caducado
+var ErrInvalidRefreshToken = errors.New("token de refresco no válido")
+
+// dummyHash se compara cuando el usuario no existe para que la respuesta tarde
+// lo mismo que con una contraseña incorrecta
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+// Claims contenido del token de acceso
+type Claims struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// Authenticator emite y valida los tokens de acceso (JWT HS256) y genera los
+// tokens de refresco, que solo se guardan como hash
+type Authenticator struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewAuthenticator(secret string, accessTTL, refreshTTL time.Duration) (*Authenticator, error) {
+	if len(secret) < minSecretLength {
+		return nil, fmt.Errorf("la clave JWT debe tener al menos %d caracteres", minSecretLength)
+	}
+	if config.PlaceholderSecret(secret) {
+		return nil, fmt.Errorf("la clave JWT es un valor de ejemplo; define JWT_SECRET")
+	}
+	if accessTTL <= 0 || refreshTTL <= 0 {
+		return nil, fmt.Errorf("la duración de los tokens debe ser positiva")
+	}
+	return &Authenticator{secret: []byte(secret), accessTTL: accessTTL, refreshTTL: refreshTTL}, nil
+}
+
+// AccessTTL duración de los tokens de acceso
+func (a *Authenticator) AccessTTL() time.Duration {
+	return a.accessTTL
+}
+
+// RefreshTTL duración de los tokens de refresco
+func (a *Authenticator) RefreshTTL() time.Duration {
+	return a.refreshTTL
+}
+
+// IssueAccessToken firma un token de acceso para el usuario
+func (a *Authenticator) IssueAccessToken(username, role string) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   username,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(a.accessTTL)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(a.secret)
+	if err != nil {
+		return "", fmt.Errorf("error al firmar el token: %w", err)
+	}
+	return token, nil
+}
+
+// ParseAccessToken valida la firma, el emisor y la caducidad del token
+func (a *Authenticator) ParseAccessToken(token string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return a.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(issuer), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("token no válido: %w", err)
+	}
+	return claims, nil
+}
+
+// NewRefreshToken genera un token de refresco aleatorio y el hash con el que se guarda
+func NewRefreshToken() (token, hash string, err error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("error al generar el token de refresco: %w", err)
+	}
+	token = base64.RawURLEncoding.EncodeToString(raw)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken hash con el que se busca un token de refresco
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// HashPassword calcula el hash bcrypt de la contraseña
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("error al calcular el hash de la contraseña: %w", err)
+	}
+	return string(hash), nil
+}
+
+// CheckPassword compara la contraseña con su hash; con hash vacío (usuario
+// inexistente) compara igualmente contra dummyHash y devuelve ErrInvalidCredentials
+func CheckPassword(hash, password string) error {
+	if hash == "" {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return ErrInvalidCredentials
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "test-jwt-secret-0123456789abcdefghij"
+
+func newTestAuthenticator(t *testing.T, secret string) *Authenticator {
+	t.Helper()
+	a, err := NewAuthenticator(secret, time.Minute, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestNewAuthenticator(t *testing.T) {
+	tests := []struct {
+		name       string
+		secret     string
+		accessTTL  time.Duration
+		refreshTTL time.Duration
+		wantErr    bool
+	}{
+		{"válido", testSecret, time.Minute, time.Hour, false},
+		{"clave corta", "short", time.Minute, time.Hour, true},
+		{"clave de ejemplo", "change-me-jwt-secret-0123456789abcdef", time.Minute, time.Hour, true},
+		{"duración nula", testSecret, 0, time.Hour, true},
+		{"refresco negativo", testSecret, time.Minute, -time.Hour, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAuthenticator(tt.secret, tt.accessTTL, tt.refreshTTL)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewAuthenticator() error = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAccessToken(t *testing.T) {
+	a := newTestAuthenticator(t, testSecret)
+	token, err := a.IssueAccessToken("ana", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := a.ParseAccessToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "ana" || claims.Role != "admin" || claims.Issuer != issuer {
+		t.Errorf("claims = %+v", claims)
+	}
+
+	// sign firma claims arbitrarios con la clave de a
+	sign := func(claims Claims, method jwt.SigningMethod, key interface{}) string {
+		signed, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	now := time.Now()
+	valid := jwt.RegisteredClaims{Issuer: issuer, Subject: "ana", ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute))}
+	expired := valid
+	expired.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute))
+	otherIssuer := valid
+	otherIssuer.Issuer = "otro"
+	noExpiry := valid
+	noExpiry.ExpiresAt = nil
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"otra clave", sign(Claims{Role: "admin", RegisteredClaims: valid}, jwt.SigningMethodHS256, []byte("another-jwt-secret-0123456789abcdefgh"))},
+		{"caducado", sign(Claims{Role: "admin", RegisteredClaims: expired}, jwt.SigningMethodHS256, a.secret)},
+		{"otro emisor", sign(Claims{Role: "admin", RegisteredClaims: otherIssuer}, jwt.SigningMethodHS256, a.secret)},
+		{"sin caducidad", sign(Claims{Role: "admin", RegisteredClaims: noExpiry}, jwt.SigningMethodHS256, a.secret)},
+		{"otro algoritmo", sign(Claims{Role: "admin", RegisteredClaims: valid}, jwt.SigningMethodHS512, a.secret)},
+		{"sin firma", sign(Claims{Role: "admin", RegisteredClaims: valid}, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType)},
+		{"modificado", token[:len(token)-2] + "xx"},
+		{"vacío", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := a.ParseAccessToken(tt.token); err == nil {
+				t.Error("se esperaba un token no válido")
+			}
+		})
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	token, hash, err := NewRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != HashRefreshToken(token) || strings.Contains(hash, token) {
+		t.Errorf("hash %s no corresponde al token", hash)
+	}
+	other, _, err := NewRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token == other {
+		t.Error("dos tokens de refresco no deberían coincidir")
+	}
+}
+
+func TestPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		hash     string
+		password string
+		wantErr  error
+	}{
+		{"correcta", hash, "correct horse battery staple", nil},
+		{"incorrecta", hash, "tr0ub4dor&3", ErrInvalidCredentials},
+		{"usuario inexistente", "", "correct horse battery staple", ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckPassword(tt.hash, tt.password); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckPassword() = %v, se esperaba %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"backend/internal/analytics"
+	"backend/internal/entity"
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultScatterSampleSize = 500
+	defaultWorstAssessments  = 10
+	defaultCalibrationWidth  = 10
+)
+
+func (m *mongoDBClient) GetActualVsPredicted(ctx context.Context, database string, query entity.ActualVsPredictedQuery) (*entity.ActualVsPredictedReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	samples, err := m.loadPredictionSamples(ctx, m.client.Database(database), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	m.loggers.InfoLogger.Printf("Comparando %d predicciones con puntuaciones reales", len(samples))
+	return actualVsPredictedReport(samples, query), nil
+}
+
+// actualVsPredictedReport resume los residuos de las muestras en conjunto, por
+// evaluación, tipo y presentación, con la calibración y una muestra para el gráfico
+func actualVsPredictedReport(samples []entity.PredictionSample, query entity.ActualVsPredictedQuery) *entity.ActualVsPredictedReport {
+	if query.SampleSize <= 0 {
+		query.SampleSize = defaultScatterSampleSize
+	}
+	if query.WorstCount <= 0 {
+		query.WorstCount = defaultWorstAssessments
+	}
+	if query.CalibrationWidth <= 0 {
+		query.CalibrationWidth = defaultCalibrationWidth
+	}
+
+	byAssessment := residualsBy(samples, func(s entity.PredictionSample) string { return strconv.Itoa(s.AssessmentID) })
+	report := &entity.ActualVsPredictedReport{
+		Overall:          residualStats("all", samples),
+		ByAssessment:     byAssessment,
+		ByAssessmentType: residualsBy(samples, func(s entity.PredictionSample) string { return s.AssessmentType }),
+		ByPresentation:   residualsBy(samples, func(s entity.PredictionSample) string { return s.CodeModule + " " + s.CodePresentation }),
+		Calibration:      scoreCalibration(samples, query.CalibrationWidth),
+		Sample:           scatterSample(samples, query.SampleSize),
+	}
+
+	// Evaluaciones con mayor error absoluto medio
+	worst := make([]entity.ResidualStats, len(byAssessment))
+	copy(worst, byAssessment)
+	sort.SliceStable(worst, func(i, j int) bool { return worst[i].MAE > worst[j].MAE })
+	if len(worst) > query.WorstCount {
+		worst = worst[:query.WorstCount]
+	}
+	report.WorstAssessments = worst
+	return report
+}
+
+func residualStats(key string, samples []entity.PredictionSample) entity.ResidualStats {
+	predicted := make([]float64, len(samples))
+	actual := make([]float64, len(samples))
+	for i, s := range samples {
+		predicted[i] = s.PredictedScore
+		actual[i] = s.ActualScore
+	}
+	summary := analytics.Residuals(predicted, actual)
+	return entity.ResidualStats{
+		Key:           key,
+		Count:         summary.Count,
+		MeanPredicted: summary.MeanPredicted,
+		MeanActual:    summary.MeanActual,
+		MeanResidual:  summary.MeanResidual,
+		StdResidual:   summary.StdResidual,
+		MAE:           summary.MAE,
+		RMSE:          summary.RMSE,
+	}
+}
+
+// residualsBy agrupa las muestras por la clave indicada y resume cada grupo
+func residualsBy(samples []entity.PredictionSample, key func(entity.PredictionSample) string) []entity.ResidualStats {
+	groups := make(map[string][]entity.PredictionSample)
+	for _, s := range samples {
+		k := key(s)
+		groups[k] = append(groups[k], s)
+	}
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	results := make([]entity.ResidualStats, 0, len(keys))
+	for _, k := range keys {
+		results = append(results, residualStats(k, groups[k]))
+	}
+	return results
+}
+
+// scoreCalibration compara la puntuación real media con la predicha en tramos
+// de anchura fija de la puntuación predicha
+func scoreCalibration(samples []entity.PredictionSample, width float64) []entity.CalibrationPoint {
+	type accumulator struct {
+		predicted, actual float64
+		count             int
+	}
+	bins := make(map[int]*accumulator)
+	for _, s := range samples {
+		b := int(math.Floor(s.PredictedScore / width))
+		acc, ok := bins[b]
+		if !ok {
+			acc = &accumulator{}
+			bins[b] = acc
+		}
+		acc.predicted += s.PredictedScore
+		acc.actual += s.ActualScore
+		acc.count++
+	}
+	indexes := make([]int, 0, len(bins))
+	for b := range bins {
+		indexes = append(indexes, b)
+	}
+	sort.Ints(indexes)
+	points := make([]entity.CalibrationPoint, 0, len(indexes))
+	for _, b := range indexes {
+		acc := bins[b]
+		points = append(points, entity.CalibrationPoint{
+			Lower:         float64(b) * width,
+			Upper:         float64(b+1) * width,
+			MeanPredicted: acc.predicted / float64(acc.count),
+			MeanActual:    acc.actual / float64(acc.count),
+			Count:         acc.count,
+		})
+	}
+	return points
+}
+
+// scatterSample toma una muestra sistemática de tamaño fijo para el diagrama de dispersión
+func scatterSample(samples []entity.PredictionSample, size int) []entity.ScatterPoint {
+	if len(samples) == 0 {
+		return []entity.ScatterPoint{}
+	}
+	step := 1
+	if len(samples) > size {
+		step = int(math.Ceil(float64(len(samples)) / float64(size)))
+	}
+	points := make([]entity.ScatterPoint, 0, size)
+	for i := 0; i < len(samples) && len(points) < size; i += step {
+		s := samples[i]
+		points = append(points, entity.ScatterPoint{
+			StudentID:      s.StudentID,
+			AssessmentID:   s.AssessmentID,
+			PredictedScore: s.PredictedScore,
+			ActualScore:    s.ActualScore,
+		})
+	}
+	return points
+}
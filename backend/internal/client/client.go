@@ -0,0 +1,31 @@
+package client
+
+import (
+	"backend/internal/config"
+	"backend/internal/entity"
+	"backend/internal/repository"
+	"context"
+
+	"github.com/spf13/viper"
+)
+
+// Client conecta con el backend de almacenamiento configurado y expone su
+// persistencia y sus consultas a través de los repositorios
+type Client interface {
+	Connect(ctx context.Context) error
+	Disconnect(ctx context.Context) error
+	Repositories() repository.Repositories
+}
+
+// NewClient crea el cliente del backend indicado en STORAGE_DRIVER (MongoDB por defecto)
+func NewClient(loggers *entity.Loggers) Client {
+	switch driver := viper.GetString(config.StorageDriver); driver {
+	case "", config.StorageMongoDB:
+		return NewMongoDBClient(loggers)
+	case config.StorageSQLite, config.StoragePostgres:
+		return NewSQLClient(driver, loggers)
+	default:
+		loggers.ErrorLogger.Printf("Backend de almacenamiento no soportado: %s", driver)
+		return nil
+	}
+}
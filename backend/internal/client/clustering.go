@@ -0,0 +1,299 @@
+package client
+
+import (
+	"backend/internal/analytics"
+	"backend/internal/config"
+	"backend/internal/entity"
+	"backend/internal/repository"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	studentClustersCollection    = "student_clusters"
+	clusterAssignmentsCollection = "student_cluster_assignments"
+	defaultClusters              = 4
+	maxClusters                  = 20
+	kMeansIterations             = 100
+)
+
+// ClusterStudents agrupa a los estudiantes de una presentación con k-means sobre
+// sus clics semanales (log1p) y sus puntuaciones en las evaluaciones ordenadas por
+// fecha, estandarizadas por columna. Guarda los centroides (en unidades
+// originales), los tamaños, la mezcla de resultados finales y la asignación de
+// cada estudiante
+func (m *mongoDBClient) ClusterStudents(ctx context.Context, database string, request entity.ClusteringRequest) (*entity.ClusteringResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	if err := normalizeClusteringRequest(&request); err != nil {
+		return nil, err
+	}
+
+	db := m.client.Database(database)
+	features, students, vectors, err := m.loadClusteringVectors(ctx, db, request.CodeModule, request.CodePresentation)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) < request.K {
+		return nil, fmt.Errorf("no hay suficientes estudiantes (%d) para %d clústeres", len(vectors), request.K)
+	}
+	studentOutcomes, err := loadFinalResults(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	version, err := getDatasetVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	m.loggers.InfoLogger.Printf("Agrupando %d estudiantes de %s %s en %d clústeres", len(vectors), request.CodeModule, request.CodePresentation, request.K)
+
+	result, assignments := clusterStudents(request, features, students, vectors, studentOutcomes, version)
+	writes := make([]mongo.WriteModel, 0, len(students))
+	for i, studentID := range students {
+		writes = append(writes, mongo.NewInsertOneModel().SetDocument(bson.M{
+			"clustering_id": result.ID,
+			"student_id":    studentID,
+			"cluster":       assignments[i],
+		}))
+	}
+
+	if _, err := db.Collection(studentClustersCollection).ReplaceOne(ctx, bson.M{"_id": result.ID}, result, options.Replace().SetUpsert(true)); err != nil {
+		return nil, fmt.Errorf("error al guardar los clústeres: %w", err)
+	}
+	assignmentsCollection := db.Collection(clusterAssignmentsCollection)
+	if _, err := assignmentsCollection.DeleteMany(ctx, bson.M{"clustering_id": result.ID}); err != nil {
+		return nil, fmt.Errorf("error al limpiar las asignaciones anteriores: %w", err)
+	}
+	if _, err := assignmentsCollection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		return nil, fmt.Errorf("error al guardar las asignaciones: %w", err)
+	}
+	return result, nil
+}
+
+func normalizeClusteringRequest(request *entity.ClusteringRequest) error {
+	if request.CodeModule == "" || request.CodePresentation == "" {
+		return fmt.Errorf("se requiere code_module y code_presentation")
+	}
+	if request.K == 0 {
+		request.K = defaultClusters
+	}
+	if request.K < 2 || request.K > maxClusters {
+		return fmt.Errorf("k debe estar entre 2 y %d: %d", maxClusters, request.K)
+	}
+	return nil
+}
+
+// clusterStudents ejecuta k-means sobre los vectores estandarizados y resume cada
+// clúster; devuelve también el clúster asignado a cada estudiante de students
+func clusterStudents(request entity.ClusteringRequest, features []string, students []int, vectors [][]float64, studentOutcomes map[string]string, version int) (*entity.ClusteringResult, []int) {
+	assignments, _, inertia := analytics.KMeans(analytics.Standardize(vectors), request.K, request.Seed, kMeansIterations)
+	centroids := analytics.Centroids(vectors, assignments, request.K)
+
+	result := &entity.ClusteringResult{
+		ID:               request.CodeModule + "|" + request.CodePresentation,
+		CodeModule:       request.CodeModule,
+		CodePresentation: request.CodePresentation,
+		K:                request.K,
+		Seed:             request.Seed,
+		Features:         features,
+		Students:         len(vectors),
+		Inertia:          inertia,
+		DatasetVersion:   version,
+		ComputedAt:       time.Now(),
+		Clusters:         make([]entity.StudentCluster, request.K),
+	}
+	for c := range result.Clusters {
+		result.Clusters[c] = entity.StudentCluster{
+			Cluster:            c,
+			Centroid:           centroids[c],
+			Outcomes:           make(map[string]int, len(repository.FinalResults)),
+			OutcomePercentages: make(map[string]float64, len(repository.FinalResults)),
+		}
+		for _, outcome := range repository.FinalResults {
+			result.Clusters[c].Outcomes[outcome] = 0
+		}
+	}
+	for i, studentID := range students {
+		cluster := &result.Clusters[assignments[i]]
+		cluster.Size++
+		if outcome, ok := studentOutcomes[studentKey(studentID, request.CodeModule, request.CodePresentation)]; ok {
+			cluster.Outcomes[outcome]++
+		}
+	}
+	for c := range result.Clusters {
+		cluster := &result.Clusters[c]
+		for outcome, count := range cluster.Outcomes {
+			if cluster.Size > 0 {
+				cluster.OutcomePercentages[outcome] = float64(count) / float64(cluster.Size) * 100
+			}
+		}
+	}
+	return result, assignments
+}
+
+func (m *mongoDBClient) GetStudentClusters(ctx context.Context, database, codeModule, codePresentation string) (*entity.ClusteringResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var result entity.ClusteringResult
+	err := m.client.Database(database).Collection(studentClustersCollection).FindOne(ctx,
+		bson.M{"_id": codeModule + "|" + codePresentation}).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("agrupamiento %w para %s %s; ejecute el agrupamiento primero", entity.ErrNotFound, codeModule, codePresentation)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los clústeres: %w", err)
+	}
+	return &result, nil
+}
+
+// weeklyClicks clics de un estudiante en una semana
+type weeklyClicks struct {
+	StudentID int
+	Week      int
+	Clicks    int
+}
+
+// assessmentScore puntuación de un estudiante en una evaluación
+type assessmentScore struct {
+	StudentID    int     `bson:"idstudent"`
+	AssessmentID int     `bson:"idassessment"`
+	Score        float64 `bson:"score"`
+}
+
+// loadClusteringVectors construye un vector por estudiante matriculado con los
+// clics de cada semana (log1p, desde la primera semana con actividad hasta el
+// final del curso) y la puntuación de cada evaluación (0 si no la entregó)
+func (m *mongoDBClient) loadClusteringVectors(ctx context.Context, db *mongo.Database, codeModule, codePresentation string) ([]string, []int, [][]float64, error) {
+	presentation := bson.M{"codemodule": codeModule, "codepresentation": codePresentation}
+	values, err := db.Collection("studentInfo").Distinct(ctx, "idstudent", presentation)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error al obtener los estudiantes de la presentación: %w", err)
+	}
+	students := make([]int, 0, len(values))
+	for _, v := range values {
+		if id, err := toInt(v); err == nil {
+			students = append(students, id)
+		}
+	}
+
+	// Clics semanales desde la vista materializada
+	view, err := m.ensureView(ctx, db, config.ViewEngagementWeekly)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	cursor, err := db.Collection(view.Target).Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"code_module": codeModule, "code_presentation": codePresentation}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    bson.M{"student": "$student_id", "week": "$week"},
+			"clicks": bson.M{"$sum": "$clicks"},
+		}}},
+	})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error al agregar los clics semanales: %w", err)
+	}
+	var weeklyRows []struct {
+		ID struct {
+			Student int `bson:"student"`
+			Week    int `bson:"week"`
+		} `bson:"_id"`
+		Clicks int `bson:"clicks"`
+	}
+	if err := cursor.All(ctx, &weeklyRows); err != nil {
+		return nil, nil, nil, fmt.Errorf("error al decodificar los clics semanales: %w", err)
+	}
+	weekly := make([]weeklyClicks, len(weeklyRows))
+	for i, row := range weeklyRows {
+		weekly[i] = weeklyClicks{StudentID: row.ID.Student, Week: row.ID.Week, Clicks: row.Clicks}
+	}
+	courseWeeks, err := loadCourseWeeks(ctx, db)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Evaluaciones de la presentación ordenadas por fecha
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}, {Key: "idassessment", Value: 1}})
+	cursor, err = db.Collection("assessments").Find(ctx, presentation, opts)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error al obtener las evaluaciones: %w", err)
+	}
+	var assessments []struct {
+		AssessmentID int `bson:"idassessment"`
+	}
+	if err := cursor.All(ctx, &assessments); err != nil {
+		return nil, nil, nil, fmt.Errorf("error al decodificar las evaluaciones: %w", err)
+	}
+	assessmentIDs := make([]int, 0, len(assessments))
+	for _, a := range assessments {
+		assessmentIDs = append(assessmentIDs, a.AssessmentID)
+	}
+
+	cursor, err = db.Collection("studentAssessment").Find(ctx,
+		bson.M{"idassessment": bson.M{"$in": assessmentIDs}},
+		options.Find().SetProjection(bson.M{"_id": 0, "idstudent": 1, "idassessment": 1, "score": 1}))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error al obtener las puntuaciones: %w", err)
+	}
+	var scores []assessmentScore
+	if err := cursor.All(ctx, &scores); err != nil {
+		return nil, nil, nil, fmt.Errorf("error al decodificar las puntuaciones: %w", err)
+	}
+	features, vectors := clusteringVectors(students, weekly, courseWeeks[codeModule+"|"+codePresentation], assessmentIDs, scores)
+	return features, students, vectors, nil
+}
+
+// clusteringVectors ordena students y construye sus vectores: una columna por
+// semana, desde la primera con actividad hasta endWeek, y una por evaluación en
+// el orden de assessmentIDs
+func clusteringVectors(students []int, weekly []weeklyClicks, endWeek int, assessmentIDs []int, scores []assessmentScore) ([]string, [][]float64) {
+	sort.Ints(students)
+	index := make(map[int]int, len(students))
+	for i, id := range students {
+		index[id] = i
+	}
+
+	firstWeek, lastWeek := 0, endWeek
+	for _, row := range weekly {
+		if row.Week < firstWeek {
+			firstWeek = row.Week
+		}
+		if row.Week > lastWeek {
+			lastWeek = row.Week
+		}
+	}
+
+	var features []string
+	for week := firstWeek; week <= lastWeek; week++ {
+		features = append(features, fmt.Sprintf("week_%d_clicks", week))
+	}
+	assessmentColumn := make(map[int]int, len(assessmentIDs))
+	for _, id := range assessmentIDs {
+		assessmentColumn[id] = len(features)
+		features = append(features, fmt.Sprintf("assessment_%d_score", id))
+	}
+
+	vectors := make([][]float64, len(students))
+	for i := range vectors {
+		vectors[i] = make([]float64, len(features))
+	}
+	for _, row := range weekly {
+		if i, ok := index[row.StudentID]; ok {
+			vectors[i][row.Week-firstWeek] = math.Log1p(float64(row.Clicks))
+		}
+	}
+	for _, s := range scores {
+		column, known := assessmentColumn[s.AssessmentID]
+		if i, ok := index[s.StudentID]; ok && known {
+			vectors[i][column] = s.Score
+		}
+	}
+	return features, vectors
+}
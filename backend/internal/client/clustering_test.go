@@ -0,0 +1,50 @@
+package client
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestClusteringVectors(t *testing.T) {
+	students := []int{3, 1}
+	weekly := []weeklyClicks{
+		{StudentID: 1, Week: -1, Clicks: 0},
+		{StudentID: 1, Week: 0, Clicks: 9},
+		{StudentID: 3, Week: 2, Clicks: 1},
+		{StudentID: 3, Week: 3, Clicks: 1},
+		// Estudiante no matriculado: no tiene vector
+		{StudentID: 7, Week: 1, Clicks: 5},
+	}
+	scores := []assessmentScore{
+		{StudentID: 1, AssessmentID: 10, Score: 70},
+		{StudentID: 3, AssessmentID: 20, Score: 55},
+		{StudentID: 9, AssessmentID: 10, Score: 99},
+		{StudentID: 1, AssessmentID: 99, Score: 5},
+	}
+
+	features, vectors := clusteringVectors(students, weekly, 2, []int{10, 20}, scores)
+
+	wantFeatures := []string{
+		"week_-1_clicks", "week_0_clicks", "week_1_clicks", "week_2_clicks", "week_3_clicks",
+		"assessment_10_score", "assessment_20_score",
+	}
+	if !reflect.DeepEqual(features, wantFeatures) {
+		t.Errorf("features = %v, se esperaba %v", features, wantFeatures)
+	}
+	if !reflect.DeepEqual(students, []int{1, 3}) {
+		t.Errorf("students debería quedar ordenado: %v", students)
+	}
+	wantVectors := [][]float64{
+		{0, math.Log(10), 0, 0, 0, 70, 0},
+		{0, 0, 0, math.Log(2), math.Log(2), 0, 55},
+	}
+	for i := range wantVectors {
+		for j := range wantVectors[i] {
+			if math.Abs(vectors[i][j]-wantVectors[i][j]) > 1e-9 {
+				t.Errorf("vector %d = %v, se esperaba %v", i, vectors[i], wantVectors[i])
+				break
+			}
+		}
+	}
+}
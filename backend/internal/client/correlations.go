@@ -0,0 +1,132 @@
+package client
+
+import (
+	"backend/internal/analytics"
+	"backend/internal/entity"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	correlationTargetFinalResult = "final_result"
+	correlationTargetScore       = "score"
+)
+
+// Codificación ordinal de final_result para correlacionarlo con las características
+var finalResultOrdinal = map[string]float64{"Withdrawn": 0, "Fail": 1, "Pass": 2, "Distinction": 3}
+
+func (m *mongoDBClient) GetFeatureCorrelations(ctx context.Context, database string, query entity.CorrelationQuery) (*entity.CorrelationReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	if err := normalizeCorrelationQuery(&query); err != nil {
+		return nil, err
+	}
+	set, err := m.buildStudentFeatures(ctx, m.client.Database(database), query.CodeModule, query.CodePresentation)
+	if err != nil {
+		return nil, err
+	}
+	return correlationReport(query, set), nil
+}
+
+func normalizeCorrelationQuery(query *entity.CorrelationQuery) error {
+	if query.Target == "" {
+		query.Target = correlationTargetFinalResult
+	}
+	if query.Target != correlationTargetFinalResult && query.Target != correlationTargetScore {
+		return fmt.Errorf("variable objetivo no soportada: %s", query.Target)
+	}
+	return nil
+}
+
+// correlationReport calcula las matrices de Pearson y Spearman entre las
+// características y la variable objetivo y ordena las características por importancia
+func correlationReport(query entity.CorrelationQuery, set *studentFeatureSet) *entity.CorrelationReport {
+	// La puntuación media es la variable objetivo cuando target=score
+	features := make([]string, 0, len(set.Names))
+	for _, name := range set.Names {
+		if query.Target == correlationTargetScore && name == featureAvgScore {
+			continue
+		}
+		features = append(features, name)
+	}
+	variables := append(append([]string{}, features...), query.Target)
+
+	columns := make([][]float64, len(variables))
+	for v, name := range variables {
+		columns[v] = make([]float64, len(set.Students))
+		for i, s := range set.Students {
+			columns[v][i] = featureValue(s, name)
+		}
+	}
+
+	report := &entity.CorrelationReport{
+		CodeModule:       query.CodeModule,
+		CodePresentation: query.CodePresentation,
+		Target:           query.Target,
+		Students:         len(set.Students),
+		Variables:        variables,
+		Pearson:          make([][]float64, len(variables)),
+		Spearman:         make([][]float64, len(variables)),
+		Importances:      make([]entity.FeatureImportance, 0, len(features)),
+	}
+	for a := range variables {
+		report.Pearson[a] = make([]float64, len(variables))
+		report.Spearman[a] = make([]float64, len(variables))
+		for b := range variables {
+			x, y := completePairs(columns[a], columns[b])
+			report.Pearson[a][b] = analytics.Pearson(x, y)
+			report.Spearman[a][b] = analytics.Spearman(x, y)
+		}
+	}
+
+	// Importancia de cada característica según su correlación con la variable objetivo
+	target := len(variables) - 1
+	for f, name := range features {
+		x, _ := completePairs(columns[f], columns[target])
+		report.Importances = append(report.Importances, entity.FeatureImportance{
+			Feature:  name,
+			Pearson:  report.Pearson[f][target],
+			Spearman: report.Spearman[f][target],
+			Count:    len(x),
+		})
+	}
+	sort.SliceStable(report.Importances, func(i, j int) bool {
+		return math.Abs(report.Importances[i].Spearman) > math.Abs(report.Importances[j].Spearman)
+	})
+	return report
+}
+
+// featureValue devuelve la característica o la variable objetivo del estudiante, o NaN si falta
+func featureValue(s studentFeatures, name string) float64 {
+	switch name {
+	case correlationTargetFinalResult:
+		if v, ok := finalResultOrdinal[s.FinalResult]; ok {
+			return v
+		}
+		return math.NaN()
+	case correlationTargetScore:
+		name = featureAvgScore
+	}
+	if v, ok := s.Values[name]; ok {
+		return v
+	}
+	return math.NaN()
+}
+
+// completePairs descarta las posiciones en las que falta alguno de los dos valores
+func completePairs(x, y []float64) ([]float64, []float64) {
+	px := make([]float64, 0, len(x))
+	py := make([]float64, 0, len(y))
+	for i := range x {
+		if math.IsNaN(x[i]) || math.IsNaN(y[i]) {
+			continue
+		}
+		px = append(px, x[i])
+		py = append(py, y[i])
+	}
+	return px, py
+}
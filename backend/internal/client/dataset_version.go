@@ -0,0 +1,85 @@
+package client
+
+import (
+	"backend/internal/entity"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	datasetMetadataCollection = "dataset_metadata"
+	datasetMetadataID         = "oulad"
+	analyticsCacheCollection  = "analytics_cache"
+)
+
+func (m *mongoDBClient) RegisterDatasetLoad(ctx context.Context, database string) (*entity.DatasetVersion, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	db := m.client.Database(database)
+	var version entity.DatasetVersion
+	err := db.Collection(datasetMetadataCollection).FindOneAndUpdate(ctx,
+		bson.M{"_id": datasetMetadataID},
+		bson.M{"$inc": bson.M{"version": 1}, "$set": bson.M{"loaded_at": time.Now()}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&version)
+	if err != nil {
+		return nil, fmt.Errorf("error al registrar la versión del conjunto de datos: %w", err)
+	}
+	// Los resultados en caché de versiones anteriores ya no son válidos
+	if _, err := db.Collection(analyticsCacheCollection).DeleteMany(ctx, bson.M{"dataset_version": bson.M{"$lt": version.Version}}); err != nil {
+		return nil, fmt.Errorf("error al limpiar la caché de analítica: %w", err)
+	}
+	m.loggers.InfoLogger.Printf("Versión del conjunto de datos: %d", version.Version)
+	return &version, nil
+}
+
+// getDatasetVersion devuelve la versión actual del conjunto de datos (0 si nunca se registró una carga)
+func getDatasetVersion(ctx context.Context, db *mongo.Database) (int, error) {
+	var version entity.DatasetVersion
+	err := db.Collection(datasetMetadataCollection).FindOne(ctx, bson.M{"_id": datasetMetadataID}).Decode(&version)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error al obtener la versión del conjunto de datos: %w", err)
+	}
+	return version.Version, nil
+}
+
+// readAnalyticsCache decodifica en out el resultado guardado para la clave y
+// versión indicadas; devuelve false si no existe
+func readAnalyticsCache(ctx context.Context, db *mongo.Database, key string, version int, out interface{}) (bool, error) {
+	var cached struct {
+		Data bson.Raw `bson:"data"`
+	}
+	err := db.Collection(analyticsCacheCollection).FindOne(ctx, bson.M{"_id": key, "dataset_version": version}).Decode(&cached)
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error al leer la caché %s: %w", key, err)
+	}
+	if err := bson.Unmarshal(cached.Data, out); err != nil {
+		return false, fmt.Errorf("error al decodificar la caché %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// writeAnalyticsCache guarda el resultado de una consulta para la versión indicada
+func writeAnalyticsCache(ctx context.Context, db *mongo.Database, key string, version int, data interface{}) error {
+	_, err := db.Collection(analyticsCacheCollection).ReplaceOne(ctx,
+		bson.M{"_id": key},
+		bson.M{"_id": key, "dataset_version": version, "computed_at": time.Now(), "data": data},
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("error al guardar la caché %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package client
+
+import (
+	"backend/internal/config"
+	"backend/internal/entity"
+	"backend/internal/repository"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	engagementWeeklyCollection = "engagement_weekly"
+	granularityWeek            = "week"
+	granularityDay             = "day"
+)
+
+// engagementWeeklyPipeline calcula los clics, días activos y primer y último día
+// por estudiante, presentación, semana y tipo de actividad
+func engagementWeeklyPipeline(string) mongo.Pipeline {
+	return append(studentVleActivityStages(bson.M{}),
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"student":      "$_id.student",
+				"module":       "$_id.module",
+				"presentation": "$_id.presentation",
+				"week":         bson.M{"$floor": bson.M{"$divide": bson.A{"$_id.date", 7}}},
+				"activity":     "$activity_type",
+			},
+			"clicks":    bson.M{"$sum": "$clicks"},
+			"days":      bson.M{"$addToSet": "$_id.date"},
+			"first_day": bson.M{"$min": "$_id.date"},
+			"last_day":  bson.M{"$max": "$_id.date"},
+		}}},
+		bson.D{{Key: "$project", Value: bson.M{
+			"student_id":        "$_id.student",
+			"code_module":       "$_id.module",
+			"code_presentation": "$_id.presentation",
+			"week":              "$_id.week",
+			"activity_type":     "$_id.activity",
+			"clicks":            1,
+			"days_active":       bson.M{"$size": "$days"},
+			"first_day":         1,
+			"last_day":          1,
+		}}},
+	)
+}
+
+// studentVleActivityStages agrupa studentVle por estudiante, presentación, día y
+// recurso y lo une con vle para añadir el tipo de actividad
+func studentVleActivityStages(match bson.M) mongo.Pipeline {
+	pipeline := mongo.Pipeline{}
+	if len(match) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: match}})
+	}
+	return append(pipeline,
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"student":      "$idstudent",
+				"module":       "$codemodule",
+				"presentation": "$codepresentation",
+				"site":         "$idsite",
+				"date":         "$date",
+			},
+			"clicks": bson.M{"$sum": "$sumclick"},
+		}}},
+		bson.D{{Key: "$lookup", Value: bson.M{
+			"from": "vle",
+			"let": bson.M{
+				"site":         "$_id.site",
+				"module":       "$_id.module",
+				"presentation": "$_id.presentation",
+			},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"$expr": bson.M{"$and": bson.A{
+					bson.M{"$eq": bson.A{"$idsite", "$$site"}},
+					bson.M{"$eq": bson.A{"$codemodule", "$$module"}},
+					bson.M{"$eq": bson.A{"$codepresentation", "$$presentation"}},
+				}}}},
+				bson.M{"$project": bson.M{"_id": 0, "activitytype": 1}},
+			},
+			"as": "site",
+		}}},
+		bson.D{{Key: "$unwind", Value: bson.M{"path": "$site", "preserveNullAndEmptyArrays": true}}},
+		bson.D{{Key: "$addFields", Value: bson.M{
+			"activity_type": bson.M{"$ifNull": bson.A{"$site.activitytype", "unknown"}},
+		}}},
+	)
+}
+
+func (m *mongoDBClient) GetEngagementSeries(ctx context.Context, database string, query entity.EngagementQuery) (*entity.EngagementSeries, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	if query.Granularity == "" {
+		query.Granularity = granularityWeek
+	}
+	if query.StudentID == 0 && (query.CodeModule == "" || query.CodePresentation == "") {
+		return nil, fmt.Errorf("se requiere code_module y code_presentation o id_student")
+	}
+
+	db := m.client.Database(database)
+	var students []int
+	if len(query.Cohort) > 0 {
+		var err error
+		students, err = cohortStudents(ctx, db, query)
+		if err != nil {
+			return nil, err
+		}
+		if len(students) == 0 {
+			return &entity.EngagementSeries{Granularity: query.Granularity, Points: []entity.EngagementPoint{}}, nil
+		}
+	}
+
+	var collection *mongo.Collection
+	var pipeline mongo.Pipeline
+	switch query.Granularity {
+	case granularityWeek:
+		// Semanal: se lee de la colección materializada
+		if _, err := m.ensureView(ctx, db, config.ViewEngagementWeekly); err != nil {
+			return nil, err
+		}
+		match := bson.M{}
+		if query.CodeModule != "" {
+			match["code_module"] = query.CodeModule
+		}
+		if query.CodePresentation != "" {
+			match["code_presentation"] = query.CodePresentation
+		}
+		if query.StudentID != 0 {
+			match["student_id"] = query.StudentID
+		} else if students != nil {
+			match["student_id"] = bson.M{"$in": students}
+		}
+		collection = db.Collection(engagementWeeklyCollection)
+		pipeline = mongo.Pipeline{
+			{{Key: "$match", Value: match}},
+			{{Key: "$group", Value: bson.M{
+				"_id":      bson.M{"period": "$week", "activity": "$activity_type"},
+				"clicks":   bson.M{"$sum": "$clicks"},
+				"students": bson.M{"$addToSet": "$student_id"},
+			}}},
+		}
+	case granularityDay:
+		// Diaria: se agrega directamente desde studentVle con el filtro aplicado
+		match := bson.M{}
+		if query.CodeModule != "" {
+			match["codemodule"] = query.CodeModule
+		}
+		if query.CodePresentation != "" {
+			match["codepresentation"] = query.CodePresentation
+		}
+		if query.StudentID != 0 {
+			match["idstudent"] = query.StudentID
+		} else if students != nil {
+			match["idstudent"] = bson.M{"$in": students}
+		}
+		collection = db.Collection("studentVle")
+		pipeline = append(studentVleActivityStages(match),
+			bson.D{{Key: "$group", Value: bson.M{
+				"_id":      bson.M{"period": "$_id.date", "activity": "$activity_type"},
+				"clicks":   bson.M{"$sum": "$clicks"},
+				"students": bson.M{"$addToSet": "$_id.student"},
+			}}},
+		)
+	default:
+		return nil, fmt.Errorf("granularidad no soportada: %s", query.Granularity)
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$project", Value: bson.M{
+			"_id":           0,
+			"period":        "$_id.period",
+			"activity_type": "$_id.activity",
+			"clicks":        1,
+			"students":      bson.M{"$size": "$students"},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "period", Value: 1}, {Key: "activity_type", Value: 1}}}},
+	)
+
+	cursor, err := collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, fmt.Errorf("error al agregar la participación: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	series := &entity.EngagementSeries{Granularity: query.Granularity, Points: []entity.EngagementPoint{}}
+	if err := cursor.All(ctx, &series.Points); err != nil {
+		return nil, fmt.Errorf("error al decodificar la participación: %w", err)
+	}
+	return series, nil
+}
+
+// cohortStudents devuelve los estudiantes de studentInfo que cumplen el filtro de cohorte
+func cohortStudents(ctx context.Context, db *mongo.Database, query entity.EngagementQuery) ([]int, error) {
+	filter := bson.M{}
+	if query.CodeModule != "" {
+		filter["codemodule"] = query.CodeModule
+	}
+	if query.CodePresentation != "" {
+		filter["codepresentation"] = query.CodePresentation
+	}
+	for attribute, value := range query.Cohort {
+		field, ok := repository.StudentInfoFields[attribute]
+		if !ok {
+			return nil, fmt.Errorf("atributo de cohorte no soportado: %s", attribute)
+		}
+		filter[field] = value
+	}
+	values, err := db.Collection("studentInfo").Distinct(ctx, "idstudent", filter)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los estudiantes de la cohorte: %w", err)
+	}
+	students := make([]int, 0, len(values))
+	for _, v := range values {
+		if id, err := toInt(v); err == nil {
+			students = append(students, id)
+		}
+	}
+	return students, nil
+}
@@ -0,0 +1,298 @@
+package client
+
+import (
+	"backend/internal/config"
+	"backend/internal/entity"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// erasureAuditCollection registro de supresiones; cada entrada incluye el hash
+// de la anterior, de modo que modificar o borrar una entrada rompe la cadena
+const erasureAuditCollection = "erasure_audit"
+
+// Longitud mínima de ERASURE_AUDIT_KEY, la misma que la del resto de claves
+const minErasureAuditKeyLength = 32
+
+// erasureTarget colección con documentos de un único estudiante, identificado por Field
+type erasureTarget struct {
+	Collection string
+	Field      string
+}
+
+// erasureTargets colecciones de las que se eliminan los documentos del estudiante.
+// Las vistas agregadas se recalculan después; sus filas no identifican a nadie
+var erasureTargets = []erasureTarget{
+	{"studentInfo", "idstudent"},
+	{"studentRegistration", "idstudent"},
+	{"studentAssessment", "idstudent"},
+	{"studentVle", "idstudent"},
+	{"prediction_assessments", "student_id"},
+	{"prediction_vle", "student_id"},
+	{engagementWeeklyCollection, "student_id"},
+	{clusterAssignmentsCollection, "student_id"},
+}
+
+// erasureAuditKey devuelve la clave con la que se firma la cadena de auditoría.
+// Con un hash sin clave cualquiera con acceso a la base de datos podría
+// reescribir la cadena completa y recalcular los hashes
+func erasureAuditKey() ([]byte, error) {
+	key := viper.GetString(config.ErasureAuditKey)
+	if len(key) < minErasureAuditKeyLength {
+		return nil, fmt.Errorf("la clave de auditoría de supresiones debe tener al menos %d caracteres", minErasureAuditKeyLength)
+	}
+	if config.PlaceholderSecret(key) {
+		return nil, fmt.Errorf("la clave de auditoría de supresiones es un valor de ejemplo; define ERASURE_AUDIT_KEY")
+	}
+	return []byte(key), nil
+}
+
+// erasureHash calcula el HMAC-SHA256 de la entrada a partir de su contenido y del hash anterior
+func erasureHash(key []byte, entry entity.ErasureAuditEntry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s|%d|%s|%s", entry.PreviousHash, entry.Sequence, entry.Subject, entry.ErasedAt.UTC().Format(time.RFC3339Nano))
+	for _, collection := range entry.Collections {
+		fmt.Fprintf(&b, "|%s:%d:%d", collection.Collection, collection.Deleted, collection.Updated)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(b.String()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newErasureEntry encadena una entrada de auditoría tras la última registrada.
+// La fecha se trunca a milisegundos, la precisión con la que se almacena
+func newErasureEntry(key []byte, last *entity.ErasureAuditEntry, subject string, collections []entity.ErasureCollection) entity.ErasureAuditEntry {
+	entry := entity.ErasureAuditEntry{
+		Sequence:    1,
+		Subject:     subject,
+		ErasedAt:    time.Now().UTC().Truncate(time.Millisecond),
+		Collections: collections,
+	}
+	if last != nil {
+		entry.Sequence = last.Sequence + 1
+		entry.PreviousHash = last.Hash
+	}
+	entry.Hash = erasureHash(key, entry)
+	return entry
+}
+
+// verifyErasureAudit recorre las entradas en orden y comprueba la cadena de hashes
+func verifyErasureAudit(key []byte, entries []entity.ErasureAuditEntry) entity.ErasureAuditLog {
+	log := entity.ErasureAuditLog{Entries: entries, Valid: true}
+	previous := ""
+	for i, entry := range entries {
+		if entry.Sequence != i+1 || entry.PreviousHash != previous || !hmac.Equal([]byte(erasureHash(key, entry)), []byte(entry.Hash)) {
+			log.Valid = false
+			log.BrokenAt = entry.Sequence
+			break
+		}
+		previous = entry.Hash
+	}
+	return log
+}
+
+// erasedDocuments suma los documentos eliminados de las colecciones del estudiante
+func erasedDocuments(collections []entity.ErasureCollection) int64 {
+	var total int64
+	for _, collection := range collections {
+		total += collection.Deleted
+	}
+	return total
+}
+
+// erasureRetries intentos de reservar la siguiente posición de la auditoría
+// cuando una supresión simultánea la ocupa antes
+const erasureRetries = 10
+
+// erasureOperation borrado (Update nil) o actualización que suprime al
+// estudiante de una colección
+type erasureOperation struct {
+	Collection string
+	Filter     bson.M
+	Update     bson.M
+	Options    *options.UpdateOptions
+}
+
+// erasureOperations documentos del estudiante en erasureTargets (en el mismo
+// orden) y restos en la tabla de reidentificación, la caché de analítica y las
+// muestras de los informes de integridad y de perfilado
+func erasureOperations(request entity.ErasureRequest) []erasureOperation {
+	operations := make([]erasureOperation, 0, len(erasureTargets)+4)
+	for _, target := range erasureTargets {
+		operations = append(operations, erasureOperation{Collection: target.Collection, Filter: bson.M{target.Field: request.StudentID}})
+	}
+	samples := bson.M{"$or": bson.A{
+		bson.M{"key.idstudent": request.StudentID},
+		bson.M{"document.idstudent": request.StudentID},
+	}}
+	return append(operations,
+		erasureOperation{Collection: studentPseudonymsCollection, Filter: bson.M{"_id": request.Pseudonym}},
+		// La caché puede contener muestras por estudiante; se invalida entera
+		erasureOperation{Collection: analyticsCacheCollection, Filter: bson.M{}},
+		erasureOperation{
+			Collection: integrityReportsCollection,
+			Filter:     bson.M{"checks.samples": bson.M{"$elemMatch": samples}},
+			Update:     bson.M{"$pull": bson.M{"checks.$[check].samples": samples}},
+			Options: options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{
+				bson.M{"check.samples": bson.M{"$elemMatch": samples}},
+			}}),
+		},
+		erasureOperation{
+			Collection: dataProfilesCollection,
+			Filter:     bson.M{"collections.fields.top_values.value": request.StudentID},
+			Update:     bson.M{"$pull": bson.M{"collections.$[].fields.$[field].top_values": bson.M{"value": request.StudentID}}},
+			Options: options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{
+				bson.M{"field.field": bson.M{"$in": bson.A{"idstudent", "student_id"}}, "field.top_values.value": request.StudentID},
+			}}),
+		},
+	)
+}
+
+// countErasure cuenta los documentos que suprime cada operación
+func countErasure(ctx context.Context, db *mongo.Database, operations []erasureOperation) ([]entity.ErasureCollection, error) {
+	collections := make([]entity.ErasureCollection, 0, len(operations))
+	for _, operation := range operations {
+		count, err := db.Collection(operation.Collection).CountDocuments(ctx, operation.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("error al contar los documentos del estudiante en %s: %w", operation.Collection, err)
+		}
+		collection := entity.ErasureCollection{Collection: operation.Collection, Deleted: count}
+		if operation.Update != nil {
+			collection = entity.ErasureCollection{Collection: operation.Collection, Updated: count}
+		}
+		collections = append(collections, collection)
+	}
+	return collections, nil
+}
+
+// applyErasure ejecuta las operaciones de supresión
+func applyErasure(ctx context.Context, db *mongo.Database, operations []erasureOperation) error {
+	for _, operation := range operations {
+		var err error
+		if operation.Update == nil {
+			_, err = db.Collection(operation.Collection).DeleteMany(ctx, operation.Filter)
+		} else {
+			_, err = db.Collection(operation.Collection).UpdateMany(ctx, operation.Filter, operation.Update, operation.Options)
+		}
+		if err != nil {
+			return fmt.Errorf("error al suprimir el estudiante de %s: %w", operation.Collection, err)
+		}
+	}
+	return nil
+}
+
+// reserveErasureEntry registra la entrada pendiente tras la última de la
+// auditoría. _id es la secuencia: si una supresión simultánea ocupa la misma
+// posición se vuelve a encadenar tras ella
+func reserveErasureEntry(ctx context.Context, db *mongo.Database, key []byte, subject string, collections []entity.ErasureCollection) (*entity.ErasureAuditEntry, error) {
+	audit := db.Collection(erasureAuditCollection)
+	for attempt := 0; attempt < erasureRetries; attempt++ {
+		var last *entity.ErasureAuditEntry
+		var previous entity.ErasureAuditEntry
+		err := audit.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.M{"_id": -1})).Decode(&previous)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("error al obtener la auditoría de supresiones: %w", err)
+		}
+		if err == nil {
+			last = &previous
+		}
+		entry := newErasureEntry(key, last, subject, collections)
+		entry.Pending = true
+		_, err = audit.InsertOne(ctx, entry)
+		if err == nil {
+			return &entry, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("error al registrar la auditoría de supresión: %w", err)
+		}
+	}
+	return nil, fmt.Errorf("no se pudo registrar la auditoría de supresión tras %d intentos", erasureRetries)
+}
+
+// EraseStudent suprime al estudiante. Sin transacciones (MongoDB sin réplica) la
+// entrada de auditoría se registra como pendiente antes de borrar nada, con los
+// documentos que se van a suprimir, y se completa al terminar. Si el borrado se
+// interrumpe, un nuevo intento para el mismo estudiante reanuda esa entrada
+func (m *mongoDBClient) EraseStudent(ctx context.Context, database string, request entity.ErasureRequest) (*entity.ErasureAuditEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	db := m.client.Database(database)
+	audit := db.Collection(erasureAuditCollection)
+	operations := erasureOperations(request)
+
+	entry := &entity.ErasureAuditEntry{}
+	err := audit.FindOne(ctx, bson.M{"subject": request.Subject, "pending": true}).Decode(entry)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("error al obtener la auditoría de supresiones: %w", err)
+	}
+	if err == mongo.ErrNoDocuments {
+		collections, err := countErasure(ctx, db, operations)
+		if err != nil {
+			return nil, err
+		}
+		if erasedDocuments(collections[:len(erasureTargets)]) == 0 {
+			return nil, fmt.Errorf("estudiante %w: %d", entity.ErrNotFound, request.StudentID)
+		}
+		if entry, err = reserveErasureEntry(ctx, db, m.auditKey, request.Subject, collections); err != nil {
+			return nil, err
+		}
+	} else {
+		m.loggers.InfoLogger.Printf("Reanudando la supresión pendiente %d de %s", entry.Sequence, entry.Subject)
+	}
+
+	if err := applyErasure(ctx, db, operations); err != nil {
+		return nil, err
+	}
+	if _, err := audit.UpdateOne(ctx, bson.M{"_id": entry.Sequence}, bson.M{"$unset": bson.M{"pending": ""}}); err != nil {
+		return nil, fmt.Errorf("error al completar la auditoría de supresión: %w", err)
+	}
+	entry.Pending = false
+	m.loggers.InfoLogger.Printf("Supresión %d registrada para %s", entry.Sequence, entry.Subject)
+	return entry, nil
+}
+
+// GetErasureAudit devuelve la auditoría de supresiones y el resultado de verificar la cadena
+func (m *mongoDBClient) GetErasureAudit(ctx context.Context, database string) (*entity.ErasureAuditLog, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	cursor, err := m.client.Database(database).Collection(erasureAuditCollection).Find(ctx, bson.M{},
+		options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener la auditoría de supresiones: %w", err)
+	}
+	entries := []entity.ErasureAuditEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("error al decodificar la auditoría de supresiones: %w", err)
+	}
+	log := verifyErasureAudit(m.auditKey, entries)
+	return &log, nil
+}
+
+func (m *mongoDBClient) GetErasedSubjects(ctx context.Context, database string) (map[string]bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	values, err := m.client.Database(database).Collection(erasureAuditCollection).Distinct(ctx, "subject", bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los estudiantes suprimidos: %w", err)
+	}
+	subjects := make(map[string]bool, len(values))
+	for _, value := range values {
+		if subject, ok := value.(string); ok {
+			subjects[subject] = true
+		}
+	}
+	return subjects, nil
+}
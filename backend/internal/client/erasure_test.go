@@ -0,0 +1,97 @@
+package client
+
+import (
+	"backend/internal/config"
+	"backend/internal/entity"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+const testAuditKey = "test-erasure-audit-key-0123456789"
+
+// erasureChain encadena n entradas de auditoría firmadas con key
+func erasureChain(key []byte, n int) []entity.ErasureAuditEntry {
+	var entries []entity.ErasureAuditEntry
+	var last *entity.ErasureAuditEntry
+	for i := 0; i < n; i++ {
+		entry := newErasureEntry(key, last, "subject", []entity.ErasureCollection{{Collection: "studentInfo", Deleted: int64(i + 1)}})
+		entries = append(entries, entry)
+		last = &entries[len(entries)-1]
+	}
+	return entries
+}
+
+func TestNewErasureEntry(t *testing.T) {
+	entries := erasureChain([]byte(testAuditKey), 2)
+	if entries[0].Sequence != 1 || entries[0].PreviousHash != "" {
+		t.Errorf("primera entrada = %+v", entries[0])
+	}
+	if entries[1].Sequence != 2 || entries[1].PreviousHash != entries[0].Hash {
+		t.Errorf("la segunda entrada no está encadenada con la primera: %+v", entries[1])
+	}
+	if entries[0].Hash == entries[1].Hash || len(entries[0].Hash) != 64 {
+		t.Errorf("hashes no válidos: %s, %s", entries[0].Hash, entries[1].Hash)
+	}
+}
+
+func TestVerifyErasureAudit(t *testing.T) {
+	key := []byte(testAuditKey)
+	tests := []struct {
+		name     string
+		key      []byte
+		tamper   func(entries []entity.ErasureAuditEntry) []entity.ErasureAuditEntry
+		valid    bool
+		brokenAt int
+	}{
+		{"íntegra", key, func(e []entity.ErasureAuditEntry) []entity.ErasureAuditEntry { return e }, true, 0},
+		{"vacía", key, func(e []entity.ErasureAuditEntry) []entity.ErasureAuditEntry { return nil }, true, 0},
+		{"sujeto modificado", key, func(e []entity.ErasureAuditEntry) []entity.ErasureAuditEntry {
+			e[1].Subject = "otro"
+			return e
+		}, false, 2},
+		{"recuento modificado", key, func(e []entity.ErasureAuditEntry) []entity.ErasureAuditEntry {
+			e[2].Collections[0].Deleted = 0
+			return e
+		}, false, 3},
+		{"entrada eliminada", key, func(e []entity.ErasureAuditEntry) []entity.ErasureAuditEntry {
+			return append(e[:1], e[2:]...)
+		}, false, 3},
+		{"hash recalculado sin encadenar", key, func(e []entity.ErasureAuditEntry) []entity.ErasureAuditEntry {
+			e[1].Subject = "otro"
+			e[1].Hash = erasureHash(key, e[1])
+			return e
+		}, false, 3},
+		{"clave distinta", []byte("another-erasure-audit-key-0123456789"), func(e []entity.ErasureAuditEntry) []entity.ErasureAuditEntry { return e }, false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := tt.tamper(erasureChain(key, 3))
+			log := verifyErasureAudit(tt.key, entries)
+			if log.Valid != tt.valid || log.BrokenAt != tt.brokenAt {
+				t.Errorf("Valid = %v, BrokenAt = %d; se esperaba %v, %d", log.Valid, log.BrokenAt, tt.valid, tt.brokenAt)
+			}
+		})
+	}
+}
+
+func TestErasureAuditKey(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{"válida", testAuditKey, false},
+		{"corta", "short", true},
+		{"de ejemplo", "change-me-erasure-audit-key-0123456789", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set(config.ErasureAuditKey, tt.key)
+			if _, err := erasureAuditKey(); (err != nil) != tt.wantErr {
+				t.Errorf("erasureAuditKey() error = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,392 @@
+package client
+
+import (
+	"backend/internal/config"
+	"backend/internal/entity"
+	"backend/internal/repository"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	modelTypeLinear = "linear"
+	// modelTypeLinearApproximation el modelo no es lineal en las características
+	// (normalización por percentil); las contribuciones se dan en la escala bruta
+	modelTypeLinearApproximation = "linear_approximation"
+	defaultExplanationFeatures   = 3
+)
+
+// Modelos con explicación disponible y su tipo. La contribución de cada
+// característica es coeficiente × (valor - media de la presentación), equivalente
+// a los valores SHAP de un modelo lineal. El modelo VLE solo es lineal sin
+// normalizar o con z-score; con percentiles la explicación se marca como
+// aproximada (vleExplanation)
+var explainableModels = map[string]string{
+	config.PredictionKindAssessments: modelTypeLinear,
+	config.PredictionKindVle:         modelTypeLinear,
+}
+
+func (m *mongoDBClient) ExplainStudentPredictions(ctx context.Context, database string, query entity.ExplanationQuery) ([]entity.PredictionExplanation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	db := m.client.Database(database)
+	return explainPredictions(query, func(modelID string, query entity.ExplanationQuery) ([]entity.PredictionExplanation, error) {
+		if modelID == config.PredictionKindVle {
+			return m.explainVlePredictions(ctx, db, query)
+		}
+		return m.explainAssessmentPredictions(ctx, db, query)
+	})
+}
+
+// explainPredictions reúne las explicaciones del modelo pedido, o de todos, y
+// devuelve ErrNotFound si el estudiante no tiene predicciones
+func explainPredictions(query entity.ExplanationQuery, explain func(modelID string, query entity.ExplanationQuery) ([]entity.PredictionExplanation, error)) ([]entity.PredictionExplanation, error) {
+	if query.Top <= 0 {
+		query.Top = defaultExplanationFeatures
+	}
+	models := []string{config.PredictionKindAssessments, config.PredictionKindVle}
+	if query.ModelID != "" {
+		if _, ok := explainableModels[query.ModelID]; !ok {
+			return nil, fmt.Errorf("modelo no registrado o sin explicación disponible: %s", query.ModelID)
+		}
+		models = []string{query.ModelID}
+	}
+
+	explanations := []entity.PredictionExplanation{}
+	for _, modelID := range models {
+		results, err := explain(modelID, query)
+		if err != nil {
+			return nil, err
+		}
+		for i := range results {
+			results[i].ModelID = modelID
+			if results[i].ModelType == "" {
+				results[i].ModelType = explainableModels[modelID]
+			}
+		}
+		explanations = append(explanations, results...)
+	}
+	if len(explanations) == 0 {
+		return nil, fmt.Errorf("estudiante %w: no hay predicciones para %d", entity.ErrNotFound, query.StudentID)
+	}
+	return explanations, nil
+}
+
+// explainAssessmentPredictions explica predicted_score = coeficiente × puntuación
+// frente a la puntuación media de la misma evaluación
+func (m *mongoDBClient) explainAssessmentPredictions(ctx context.Context, db *mongo.Database, query entity.ExplanationQuery) ([]entity.PredictionExplanation, error) {
+	runID, err := getActiveRunID(ctx, db, config.PredictionKindAssessments)
+	if err != nil {
+		return nil, err
+	}
+	samples, err := m.loadPredictionSamples(ctx, db, runID, bson.M{"student_id": query.StudentID})
+	if err != nil {
+		return nil, err
+	}
+
+	var assessmentIDs []int
+	for _, s := range samples {
+		assessmentIDs = append(assessmentIDs, s.AssessmentID)
+	}
+	averages, err := averageAssessmentScores(ctx, db, assessmentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var explanations []entity.PredictionExplanation
+	for _, s := range samples {
+		if matchesPresentation(query, s.CodeModule, s.CodePresentation) {
+			explanations = append(explanations, assessmentExplanation(runID, s, averages[s.AssessmentID], query.Top))
+		}
+	}
+	return explanations, nil
+}
+
+// assessmentExplanation explica la predicción de una entrega a partir de la
+// puntuación registrada en studentAssessment, que es la que usa el modelo
+func assessmentExplanation(runID string, s entity.PredictionSample, average float64, top int) entity.PredictionExplanation {
+	contribution := entity.FeatureContribution{
+		Feature:     "score",
+		Value:       s.ActualScore,
+		Average:     average,
+		Coefficient: assessmentScoreCoefficient,
+	}
+	contribution.Contribution = contribution.Coefficient * (contribution.Value - contribution.Average)
+	explanation := entity.PredictionExplanation{
+		RunID:            runID,
+		StudentID:        s.StudentID,
+		AssessmentID:     s.AssessmentID,
+		CodeModule:       s.CodeModule,
+		CodePresentation: s.CodePresentation,
+		PredictedScore:   s.PredictedScore,
+		Baseline:         assessmentScoreCoefficient * contribution.Average,
+	}
+	rankContributions(&explanation, []entity.FeatureContribution{contribution}, top)
+	explanation.Summary = fmt.Sprintf("Puntuación prevista %.1f en la evaluación %d (%s); la media prevista de la evaluación es %.1f. %s",
+		s.PredictedScore, s.AssessmentID, s.AssessmentType, explanation.Baseline, describeContributions(explanation))
+	return explanation
+}
+
+// averageAssessmentScores devuelve la puntuación media de cada evaluación
+func averageAssessmentScores(ctx context.Context, db *mongo.Database, assessmentIDs []int) (map[int]float64, error) {
+	averages := make(map[int]float64, len(assessmentIDs))
+	if len(assessmentIDs) == 0 {
+		return averages, nil
+	}
+	cursor, err := db.Collection("studentAssessment").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"idassessment": bson.M{"$in": assessmentIDs}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$idassessment", "score": bson.M{"$avg": "$score"}}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error al calcular las puntuaciones medias: %w", err)
+	}
+	var rows []struct {
+		AssessmentID int     `bson:"_id"`
+		Score        float64 `bson:"score"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("error al decodificar las puntuaciones medias: %w", err)
+	}
+	for _, row := range rows {
+		averages[row.AssessmentID] = row.Score
+	}
+	return averages, nil
+}
+
+// explainVlePredictions descompone la puntuación de participación en la
+// contribución de cada tipo de actividad: peso × (clics con decaimiento - media
+// de la presentación). Se usa la configuración de puntuación actual del módulo
+func (m *mongoDBClient) explainVlePredictions(ctx context.Context, db *mongo.Database, query entity.ExplanationQuery) ([]entity.PredictionExplanation, error) {
+	runFilter, err := activeRunMatch(ctx, db, config.PredictionKindVle)
+	if err != nil {
+		return nil, err
+	}
+	runFilter["student_id"] = query.StudentID
+	if query.CodeModule != "" {
+		runFilter["code_module"] = query.CodeModule
+	}
+	if query.CodePresentation != "" {
+		runFilter["code_presentation"] = query.CodePresentation
+	}
+	cursor, err := db.Collection("prediction_vle").Find(ctx, runFilter)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener las predicciones VLE: %w", err)
+	}
+	var predictions []entity.ProcessedPredictionVleResult
+	if err := cursor.All(ctx, &predictions); err != nil {
+		return nil, fmt.Errorf("error al decodificar las predicciones VLE: %w", err)
+	}
+	if len(predictions) == 0 {
+		return nil, nil
+	}
+
+	scoringConfigs, err := loadVleScoringConfigs(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	courseWeeks, err := loadCourseWeeks(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	view, err := m.ensureView(ctx, db, config.ViewEngagementWeekly)
+	if err != nil {
+		return nil, err
+	}
+
+	var explanations []entity.PredictionExplanation
+	for _, p := range predictions {
+		scoringConfig := vleScoringConfigFor(scoringConfigs, p.CodeModule)
+		decayed, err := decayedActivityClicks(ctx, db, view.Target, scoringConfig, p.CodeModule, p.CodePresentation, courseWeeks[p.CodeModule+"|"+p.CodePresentation])
+		if err != nil {
+			return nil, err
+		}
+		explanations = append(explanations, vleExplanation(p, scoringConfig, decayed, query.Top))
+	}
+	return explanations, nil
+}
+
+// vleExplanation descompone la puntuación de participación del estudiante a
+// partir de los clics con decaimiento de todos los estudiantes de la presentación.
+// Con z-score la puntuación sigue siendo lineal: los coeficientes se dividen por
+// la desviación típica de la puntuación bruta y la referencia es 0. Con
+// percentiles no lo es, así que las contribuciones se dan sobre la puntuación
+// bruta y la explicación se marca como aproximada
+func vleExplanation(p entity.ProcessedPredictionVleResult, scoringConfig entity.VleScoringConfig, decayed map[int]map[string]float64, top int) entity.PredictionExplanation {
+	students := float64(len(decayed))
+	averages := make(map[string]float64)
+	for _, activities := range decayed {
+		for activity, value := range activities {
+			averages[activity] += value / students
+		}
+	}
+
+	scale := 1.0
+	if scoringConfig.Normalization == repository.NormalizationZScore {
+		raw := make([]float64, 0, len(decayed))
+		for _, activities := range decayed {
+			var score float64
+			for activity, value := range activities {
+				score += vleActivityWeight(scoringConfig, activity) * value
+			}
+			raw = append(raw, score)
+		}
+		if std := populationStd(raw); std > 0 {
+			scale = 1 / std
+		} else {
+			scale = 0
+		}
+	}
+
+	explanation := entity.PredictionExplanation{
+		RunID:            p.RunID,
+		StudentID:        p.StudentID,
+		CodeModule:       p.CodeModule,
+		CodePresentation: p.CodePresentation,
+		PredictedScore:   p.PredictedScore,
+	}
+	var contributions []entity.FeatureContribution
+	var rawBaseline float64
+	for activity, average := range averages {
+		weight := vleActivityWeight(scoringConfig, activity)
+		value := decayed[p.StudentID][activity]
+		rawBaseline += weight * average
+		contributions = append(contributions, entity.FeatureContribution{
+			Feature:      activityFeaturePrefix + activity,
+			Value:        value,
+			Average:      average,
+			Coefficient:  weight * scale,
+			Contribution: weight * scale * (value - average),
+		})
+	}
+	rankContributions(&explanation, contributions, top)
+
+	var scoreDescription string
+	switch scoringConfig.Normalization {
+	case repository.NormalizationZScore:
+		// La media de los z-scores de la presentación es 0
+		scoreDescription = fmt.Sprintf("Puntuación de participación %.2f (z-score desde %.1f) en %s %s; la media de la presentación es 0.",
+			p.PredictedScore, p.RawScore, p.CodeModule, p.CodePresentation)
+	case repository.NormalizationPercentile:
+		explanation.Baseline = rawBaseline
+		explanation.Approximate = true
+		explanation.ModelType = modelTypeLinearApproximation
+		scoreDescription = fmt.Sprintf("Puntuación de participación %.2f (percentil de la puntuación bruta %.1f) en %s %s; la media bruta de la presentación es %.1f. "+
+			"Explicación aproximada: el percentil no es lineal, las contribuciones se expresan en puntuación bruta.",
+			p.PredictedScore, p.RawScore, p.CodeModule, p.CodePresentation, rawBaseline)
+	default:
+		explanation.Baseline = rawBaseline
+		scoreDescription = fmt.Sprintf("Puntuación de participación %.2f en %s %s; la media de la presentación es %.1f.",
+			p.PredictedScore, p.CodeModule, p.CodePresentation, rawBaseline)
+	}
+	explanation.Summary = scoreDescription + " " + describeContributions(explanation)
+	return explanation
+}
+
+// populationStd desviación típica poblacional, la misma que usa analytics.ZScores
+func populationStd(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(variance / float64(len(values)))
+}
+
+// weeklyActivityClicks fila de engagement_weekly
+type weeklyActivityClicks struct {
+	StudentID    int    `bson:"student_id"`
+	Week         int    `bson:"week"`
+	ActivityType string `bson:"activity_type"`
+	Clicks       int    `bson:"clicks"`
+}
+
+// decayedActivityClicks devuelve, por estudiante y tipo de actividad, los clics
+// de la presentación con el decaimiento semanal de la configuración aplicado
+func decayedActivityClicks(ctx context.Context, db *mongo.Database, collection string, scoringConfig entity.VleScoringConfig, codeModule, codePresentation string, endWeek int) (map[int]map[string]float64, error) {
+	cursor, err := db.Collection(collection).Find(ctx, bson.M{"code_module": codeModule, "code_presentation": codePresentation})
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener la participación semanal: %w", err)
+	}
+	var rows []weeklyActivityClicks
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("error al decodificar la participación semanal: %w", err)
+	}
+	return decayClicks(rows, scoringConfig, endWeek), nil
+}
+
+// decayClicks aplica a cada fila el decaimiento por las semanas que faltan hasta endWeek
+func decayClicks(rows []weeklyActivityClicks, scoringConfig entity.VleScoringConfig, endWeek int) map[int]map[string]float64 {
+	decayed := make(map[int]map[string]float64)
+	for _, row := range rows {
+		activities, ok := decayed[row.StudentID]
+		if !ok {
+			activities = make(map[string]float64)
+			decayed[row.StudentID] = activities
+		}
+		weeksToEnd := endWeek - row.Week
+		if weeksToEnd < 0 {
+			weeksToEnd = 0
+		}
+		activities[row.ActivityType] += float64(row.Clicks) * math.Pow(1-scoringConfig.WeekDecay, float64(weeksToEnd))
+	}
+	return decayed
+}
+
+func matchesPresentation(query entity.ExplanationQuery, codeModule, codePresentation string) bool {
+	return (query.CodeModule == "" || query.CodeModule == codeModule) &&
+		(query.CodePresentation == "" || query.CodePresentation == codePresentation)
+}
+
+// rankContributions guarda las top contribuciones positivas y negativas ordenadas por magnitud
+func rankContributions(explanation *entity.PredictionExplanation, contributions []entity.FeatureContribution, top int) {
+	sort.SliceStable(contributions, func(i, j int) bool {
+		return math.Abs(contributions[i].Contribution) > math.Abs(contributions[j].Contribution)
+	})
+	explanation.TopPositive = []entity.FeatureContribution{}
+	explanation.TopNegative = []entity.FeatureContribution{}
+	for _, c := range contributions {
+		switch {
+		case c.Contribution > 0 && len(explanation.TopPositive) < top:
+			explanation.TopPositive = append(explanation.TopPositive, c)
+		case c.Contribution < 0 && len(explanation.TopNegative) < top:
+			explanation.TopNegative = append(explanation.TopNegative, c)
+		}
+	}
+}
+
+// describeContributions redacta las contribuciones principales en texto legible
+func describeContributions(explanation entity.PredictionExplanation) string {
+	describe := func(contributions []entity.FeatureContribution) string {
+		parts := make([]string, 0, len(contributions))
+		for _, c := range contributions {
+			parts = append(parts, fmt.Sprintf("%s %.1f frente a %.1f de media (%+.1f)", c.Feature, c.Value, c.Average, c.Contribution))
+		}
+		return strings.Join(parts, ", ")
+	}
+	var sentences []string
+	if len(explanation.TopPositive) > 0 {
+		sentences = append(sentences, "Suben la predicción: "+describe(explanation.TopPositive)+".")
+	}
+	if len(explanation.TopNegative) > 0 {
+		sentences = append(sentences, "Bajan la predicción: "+describe(explanation.TopNegative)+".")
+	}
+	if len(sentences) == 0 {
+		return "Ninguna característica se aleja de la media."
+	}
+	return strings.Join(sentences, " ")
+}
@@ -0,0 +1,241 @@
+package client
+
+import (
+	"backend/internal/analytics"
+	"backend/internal/config"
+	"backend/internal/entity"
+	"backend/internal/repository"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const defaultFairnessTolerance = 0.2
+
+// Atributos demográficos evaluados por defecto en el informe de equidad
+var demographicAttributes = []string{"gender", "region", "highest_education", "imd_band", "age_band", "disability"}
+
+func (m *mongoDBClient) GetFairnessReport(ctx context.Context, database string, query entity.FairnessQuery) (*entity.FairnessReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	if err := normalizeFairnessQuery(&query); err != nil {
+		return nil, err
+	}
+	db := m.client.Database(database)
+	if query.RunID == "" {
+		runID, err := getActiveRunID(ctx, db, config.PredictionKindAssessments)
+		if err != nil {
+			return nil, err
+		}
+		query.RunID = runID
+	}
+
+	samples, err := m.loadPredictionSamples(ctx, db, query.RunID, nil)
+	if err != nil {
+		return nil, err
+	}
+	students, err := loadStudentAttributes(ctx, db, query.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	m.loggers.InfoLogger.Printf("Calculando equidad de la ejecución %s con %d muestras", query.RunID, len(samples))
+	return fairnessReport(query, samples, students), nil
+}
+
+// normalizeFairnessQuery valida el modelo y los atributos y aplica los valores por defecto
+func normalizeFairnessQuery(query *entity.FairnessQuery) error {
+	if query.ModelID == "" {
+		query.ModelID = config.PredictionKindAssessments
+	}
+	if _, ok := evaluableModels[query.ModelID]; !ok {
+		return fmt.Errorf("modelo no registrado: %s", query.ModelID)
+	}
+	if len(query.Attributes) == 0 {
+		query.Attributes = demographicAttributes
+	}
+	for _, attribute := range query.Attributes {
+		if _, ok := repository.StudentInfoFields[attribute]; !ok {
+			return fmt.Errorf("atributo no soportado: %s", attribute)
+		}
+	}
+	if query.Tolerance <= 0 {
+		query.Tolerance = defaultFairnessTolerance
+	}
+	if query.PassMark <= 0 {
+		query.PassMark = defaultPassMark
+	}
+	return nil
+}
+
+// fairnessReport compara las métricas de cada grupo demográfico con las globales;
+// students son los atributos de loadStudentAttributes
+func fairnessReport(query entity.FairnessQuery, samples []entity.PredictionSample, students map[string]map[string]string) *entity.FairnessReport {
+	report := &entity.FairnessReport{
+		ModelID:   query.ModelID,
+		RunID:     query.RunID,
+		PassMark:  query.PassMark,
+		Tolerance: query.Tolerance,
+		Overall:   fairnessMetrics("all", "all", samples, query.PassMark),
+		Groups:    []entity.FairnessGroupMetrics{},
+	}
+
+	for _, attribute := range query.Attributes {
+		groups := make(map[string][]entity.PredictionSample)
+		for _, s := range samples {
+			info, ok := students[studentKey(s.StudentID, s.CodeModule, s.CodePresentation)]
+			if !ok {
+				continue
+			}
+			groups[info[attribute]] = append(groups[info[attribute]], s)
+		}
+		names := make([]string, 0, len(groups))
+		for name := range groups {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		metrics := make([]entity.FairnessGroupMetrics, 0, len(names))
+		referenceRate := 0.0
+		for _, name := range names {
+			gm := fairnessMetrics(attribute, name, groups[name], query.PassMark)
+			referenceRate = math.Max(referenceRate, gm.SelectionRate)
+			metrics = append(metrics, gm)
+		}
+		// El impacto dispar se mide contra el grupo con mayor tasa de selección
+		for i := range metrics {
+			if referenceRate > 0 {
+				metrics[i].DisparateImpact = metrics[i].SelectionRate / referenceRate
+			}
+			metrics[i].Flags = fairnessFlags(metrics[i], report.Overall, query.Tolerance)
+			if len(metrics[i].Flags) > 0 {
+				report.Flagged++
+			}
+		}
+		report.Groups = append(report.Groups, metrics...)
+	}
+	return report
+}
+
+// fairnessMetrics calcula medias, errores y tasas de clasificación (aprobado si
+// la puntuación >= passMark) de un grupo de muestras
+func fairnessMetrics(attribute, group string, samples []entity.PredictionSample, passMark float64) entity.FairnessGroupMetrics {
+	predicted := make([]float64, len(samples))
+	actual := make([]float64, len(samples))
+	labels := make([]bool, len(samples))
+	var passes int
+	for i, s := range samples {
+		predicted[i] = s.PredictedScore
+		actual[i] = s.ActualScore
+		labels[i] = s.ActualScore >= passMark
+		if labels[i] {
+			passes++
+		}
+	}
+	summary := analytics.Residuals(predicted, actual)
+	tp, fp, tn, fn := analytics.Confusion(predicted, labels, passMark)
+
+	metrics := entity.FairnessGroupMetrics{
+		Attribute:     attribute,
+		Group:         group,
+		Count:         len(samples),
+		MeanPredicted: summary.MeanPredicted,
+		MeanActual:    summary.MeanActual,
+		MeanResidual:  summary.MeanResidual,
+		MAE:           summary.MAE,
+	}
+	if n := len(samples); n > 0 {
+		metrics.SelectionRate = float64(tp+fp) / float64(n)
+		metrics.PassRate = float64(passes) / float64(n)
+		metrics.ErrorRate = float64(fp+fn) / float64(n)
+	}
+	if fp+tn > 0 {
+		metrics.FalsePositiveRate = float64(fp) / float64(fp+tn)
+	}
+	if fn+tp > 0 {
+		metrics.FalseNegativeRate = float64(fn) / float64(fn+tp)
+	}
+	return metrics
+}
+
+// fairnessFlags marca las métricas del grupo que se alejan de la referencia más
+// de la tolerancia configurada
+func fairnessFlags(group, overall entity.FairnessGroupMetrics, tolerance float64) []string {
+	var flags []string
+	if math.Abs(group.DisparateImpact-1) > tolerance {
+		flags = append(flags, "disparate_impact")
+	}
+	if math.Abs(group.FalsePositiveRate-overall.FalsePositiveRate) > tolerance {
+		flags = append(flags, "false_positive_rate")
+	}
+	if math.Abs(group.FalseNegativeRate-overall.FalseNegativeRate) > tolerance {
+		flags = append(flags, "false_negative_rate")
+	}
+	if overall.MAE > 0 && math.Abs(group.MAE-overall.MAE)/overall.MAE > tolerance {
+		flags = append(flags, "mae")
+	}
+	return flags
+}
+
+func studentKey(studentID int, codeModule, codePresentation string) string {
+	return fmt.Sprintf("%d|%s|%s", studentID, codeModule, codePresentation)
+}
+
+// loadStudentAttributes devuelve los atributos solicitados de studentInfo por
+// (estudiante, módulo, presentación)
+func loadStudentAttributes(ctx context.Context, db *mongo.Database, attributes []string) (map[string]map[string]string, error) {
+	projection := bson.M{"_id": 0, "idstudent": 1, "codemodule": 1, "codepresentation": 1}
+	for _, attribute := range attributes {
+		projection[repository.StudentInfoFields[attribute]] = 1
+	}
+	cursor, err := db.Collection("studentInfo").Find(ctx, bson.M{}, options.Find().SetProjection(projection))
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener studentInfo: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	students := make(map[string]map[string]string)
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("error al decodificar studentInfo: %w", err)
+		}
+		studentID, err := toInt(doc["idstudent"])
+		if err != nil {
+			continue
+		}
+		module, _ := doc["codemodule"].(string)
+		presentation, _ := doc["codepresentation"].(string)
+		values := make(map[string]string, len(attributes))
+		for _, attribute := range attributes {
+			values[attribute] = fmt.Sprint(doc[repository.StudentInfoFields[attribute]])
+		}
+		students[studentKey(studentID, module, presentation)] = values
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("error durante la iteración de studentInfo: %w", err)
+	}
+	return students, nil
+}
+
+// toInt convierte un valor numérico decodificado de BSON a int
+func toInt(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case int32:
+		return int(v), nil
+	case int64:
+		return int(v), nil
+	case float64:
+		return int(v), nil
+	case int:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("tipo no reconocido: %T", v)
+	}
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"backend/internal/entity"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestFairnessReport(t *testing.T) {
+	sample := func(studentID int, predicted, actual float64) entity.PredictionSample {
+		return entity.PredictionSample{StudentID: studentID, CodeModule: "AAA", CodePresentation: "2013J", PredictedScore: predicted, ActualScore: actual}
+	}
+	samples := []entity.PredictionSample{sample(1, 50, 45), sample(2, 60, 30), sample(3, 30, 20), sample(4, 50, 60)}
+	students := map[string]map[string]string{
+		studentKey(1, "AAA", "2013J"): {"gender": "M"},
+		studentKey(2, "AAA", "2013J"): {"gender": "M"},
+		studentKey(3, "AAA", "2013J"): {"gender": "F"},
+		studentKey(4, "AAA", "2013J"): {"gender": "F"},
+	}
+	query := entity.FairnessQuery{Attributes: []string{"gender"}, PassMark: 40, Tolerance: 0.2}
+
+	report := fairnessReport(query, samples, students)
+	if report.Overall.Count != 4 || report.Overall.FalsePositiveRate != 0.5 || report.Overall.MAE != 13.75 {
+		t.Errorf("métricas globales = %+v", report.Overall)
+	}
+
+	tests := []struct {
+		group           string
+		selectionRate   float64
+		disparateImpact float64
+		flags           []string
+	}{
+		// El impacto dispar se mide contra M, el grupo con mayor tasa de selección
+		{"F", 0.5, 0.5, []string{"disparate_impact", "false_positive_rate", "mae"}},
+		{"M", 1, 1, []string{"false_positive_rate", "mae"}},
+	}
+	if len(report.Groups) != len(tests) || report.Flagged != 2 {
+		t.Fatalf("%d grupos y %d marcados, se esperaban %d y 2", len(report.Groups), report.Flagged, len(tests))
+	}
+	for i, tt := range tests {
+		g := report.Groups[i]
+		if g.Attribute != "gender" || g.Group != tt.group {
+			t.Errorf("grupo %d = %s=%s, se esperaba gender=%s", i, g.Attribute, g.Group, tt.group)
+		}
+		if g.SelectionRate != tt.selectionRate || g.DisparateImpact != tt.disparateImpact {
+			t.Errorf("grupo %s: SelectionRate = %v, DisparateImpact = %v", tt.group, g.SelectionRate, g.DisparateImpact)
+		}
+		if !reflect.DeepEqual(g.Flags, tt.flags) {
+			t.Errorf("grupo %s: Flags = %v, se esperaba %v", tt.group, g.Flags, tt.flags)
+		}
+	}
+}
+
+func TestFairnessMetrics(t *testing.T) {
+	samples := []entity.PredictionSample{
+		{PredictedScore: 50, ActualScore: 45}, // verdadero positivo
+		{PredictedScore: 60, ActualScore: 30}, // falso positivo
+		{PredictedScore: 30, ActualScore: 50}, // falso negativo
+		{PredictedScore: 20, ActualScore: 10}, // verdadero negativo
+	}
+	m := fairnessMetrics("gender", "F", samples, 40)
+	want := entity.FairnessGroupMetrics{
+		Attribute: "gender", Group: "F", Count: 4,
+		MeanPredicted: 40, MeanActual: 33.75, MeanResidual: 6.25, MAE: 16.25,
+		SelectionRate: 0.5, PassRate: 0.5, ErrorRate: 0.5,
+		FalsePositiveRate: 0.5, FalseNegativeRate: 0.5,
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("fairnessMetrics = %+v, se esperaba %+v", m, want)
+	}
+	if empty := fairnessMetrics("gender", "X", nil, 40); empty.Count != 0 || empty.SelectionRate != 0 || math.IsNaN(empty.MAE) {
+		t.Errorf("grupo vacío = %+v", empty)
+	}
+}
+
+func TestFairnessFlags(t *testing.T) {
+	overall := entity.FairnessGroupMetrics{FalsePositiveRate: 0.2, FalseNegativeRate: 0.2, MAE: 10}
+	tests := []struct {
+		name  string
+		group entity.FairnessGroupMetrics
+		want  []string
+	}{
+		{"dentro de la tolerancia", entity.FairnessGroupMetrics{DisparateImpact: 0.95, FalsePositiveRate: 0.25, FalseNegativeRate: 0.15, MAE: 10.5}, nil},
+		{"impacto dispar", entity.FairnessGroupMetrics{DisparateImpact: 0.7, FalsePositiveRate: 0.2, FalseNegativeRate: 0.2, MAE: 10}, []string{"disparate_impact"}},
+		{"errores", entity.FairnessGroupMetrics{DisparateImpact: 1, FalsePositiveRate: 0.5, FalseNegativeRate: 0, MAE: 20}, []string{"false_positive_rate", "false_negative_rate", "mae"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fairnessFlags(tt.group, overall, 0.1); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fairnessFlags = %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToInt(t *testing.T) {
+	tests := []struct {
+		value   interface{}
+		want    int
+		wantErr bool
+	}{
+		{int32(7), 7, false},
+		{int64(7), 7, false},
+		{7.0, 7, false},
+		{7, 7, false},
+		{"7", 0, true},
+		{nil, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := toInt(tt.value)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("toInt(%#v) = (%d, %v)", tt.value, got, err)
+		}
+	}
+}
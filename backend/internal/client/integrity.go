@@ -0,0 +1,236 @@
+package client
+
+import (
+	"backend/internal/entity"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	integrityReportsCollection = "integrity_reports"
+	integritySampleSize        = 10
+	integrityOrphan            = "orphan"
+	integrityDuplicate         = "duplicate"
+)
+
+// integrityCheck describe una clave foránea implícita de OULAD (Kind orphan:
+// Keys de Collection deben existir como RefKeys en Reference) o una clave natural
+// que no debe repetirse (Kind duplicate). Prepare añade etapas previas cuando
+// parte de la clave se obtiene de otra colección; SQLSource es su equivalente
+// en SQL, una consulta que sustituye a la tabla Collection
+type integrityCheck struct {
+	Name       string
+	Kind       string
+	Collection string
+	Keys       []string
+	Reference  string
+	RefKeys    []string
+	Prepare    mongo.Pipeline
+	SQLSource  string
+}
+
+var presentationStudentKeys = []string{"codemodule", "codepresentation", "idstudent"}
+
+var integrityChecks = []integrityCheck{
+	{
+		Name: "student_assessment_assessment", Kind: integrityOrphan,
+		Collection: "studentAssessment", Keys: []string{"idassessment"},
+		Reference: "assessments", RefKeys: []string{"idassessment"},
+	},
+	{
+		Name: "student_vle_site", Kind: integrityOrphan,
+		Collection: "studentVle", Keys: []string{"idsite", "codemodule", "codepresentation"},
+		Reference: "vle", RefKeys: []string{"idsite", "codemodule", "codepresentation"},
+	},
+	{
+		Name: "student_assessment_student_info", Kind: integrityOrphan,
+		Collection: "studentAssessment", Keys: presentationStudentKeys,
+		Reference: "studentInfo", RefKeys: presentationStudentKeys,
+		// studentAssessment no guarda la presentación: se toma de la evaluación
+		Prepare: mongo.Pipeline{
+			{{Key: "$lookup", Value: bson.M{
+				"from":         "assessments",
+				"localField":   "idassessment",
+				"foreignField": "idassessment",
+				"as":           "assessment",
+			}}},
+			{{Key: "$unwind", Value: "$assessment"}},
+			{{Key: "$addFields", Value: bson.M{
+				"codemodule":       "$assessment.codemodule",
+				"codepresentation": "$assessment.codepresentation",
+			}}},
+			{{Key: "$project", Value: bson.M{"assessment": 0}}},
+		},
+		SQLSource: `SELECT sa.*, a.codemodule, a.codepresentation
+			FROM studentAssessment sa JOIN assessments a ON a.idassessment = sa.idassessment`,
+	},
+	{
+		Name: "student_vle_student_info", Kind: integrityOrphan,
+		Collection: "studentVle", Keys: presentationStudentKeys,
+		Reference: "studentInfo", RefKeys: presentationStudentKeys,
+	},
+	{
+		Name: "student_registration_student_info", Kind: integrityOrphan,
+		Collection: "studentRegistration", Keys: presentationStudentKeys,
+		Reference: "studentInfo", RefKeys: presentationStudentKeys,
+	},
+	{
+		Name: "assessment_course", Kind: integrityOrphan,
+		Collection: "assessments", Keys: []string{"codemodule", "codepresentation"},
+		Reference: "courses", RefKeys: []string{"codemodule", "codepresentation"},
+	},
+	{
+		Name: "vle_course", Kind: integrityOrphan,
+		Collection: "vle", Keys: []string{"codemodule", "codepresentation"},
+		Reference: "courses", RefKeys: []string{"codemodule", "codepresentation"},
+	},
+	{Name: "course_duplicates", Kind: integrityDuplicate, Collection: "courses", Keys: []string{"codemodule", "codepresentation"}},
+	{Name: "assessment_duplicates", Kind: integrityDuplicate, Collection: "assessments", Keys: []string{"idassessment"}},
+	{Name: "vle_duplicates", Kind: integrityDuplicate, Collection: "vle", Keys: []string{"idsite"}},
+	{Name: "student_info_duplicates", Kind: integrityDuplicate, Collection: "studentInfo", Keys: presentationStudentKeys},
+	{Name: "student_registration_duplicates", Kind: integrityDuplicate, Collection: "studentRegistration", Keys: presentationStudentKeys},
+	{Name: "student_assessment_duplicates", Kind: integrityDuplicate, Collection: "studentAssessment", Keys: []string{"idstudent", "idassessment"}},
+}
+
+// pipeline agrupa los documentos por clave y se queda con los grupos que
+// incumplen la comprobación; devuelve los totales y una muestra de cada grupo
+func (c integrityCheck) pipeline() mongo.Pipeline {
+	key := bson.M{}
+	for _, field := range c.Keys {
+		key[field] = "$" + field
+	}
+	pipeline := append(mongo.Pipeline{}, c.Prepare...)
+	pipeline = append(pipeline, bson.D{{Key: "$group", Value: bson.M{
+		"_id":       key,
+		"documents": bson.M{"$sum": 1},
+		"sample":    bson.M{"$first": "$$ROOT"},
+	}}})
+
+	if c.Kind == integrityDuplicate {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"documents": bson.M{"$gt": 1}}}})
+	} else {
+		let := bson.M{}
+		conditions := bson.A{}
+		for i, field := range c.Keys {
+			variable := fmt.Sprintf("k%d", i)
+			let[variable] = "$_id." + field
+			conditions = append(conditions, bson.M{"$eq": bson.A{"$" + c.RefKeys[i], "$$" + variable}})
+		}
+		pipeline = append(pipeline,
+			bson.D{{Key: "$lookup", Value: bson.M{
+				"from": c.Reference,
+				"let":  let,
+				"pipeline": mongo.Pipeline{
+					{{Key: "$match", Value: bson.M{"$expr": bson.M{"$and": conditions}}}},
+					{{Key: "$limit", Value: 1}},
+					{{Key: "$project", Value: bson.M{"_id": 1}}},
+				},
+				"as": "reference",
+			}}},
+			bson.D{{Key: "$match", Value: bson.M{"reference": bson.M{"$size": 0}}}},
+		)
+	}
+
+	return append(pipeline, bson.D{{Key: "$facet", Value: bson.M{
+		"totals": bson.A{
+			bson.M{"$group": bson.M{"_id": nil, "groups": bson.M{"$sum": 1}, "documents": bson.M{"$sum": "$documents"}}},
+		},
+		"samples": bson.A{
+			bson.M{"$sort": bson.M{"documents": -1}},
+			bson.M{"$limit": integritySampleSize},
+			bson.M{"$project": bson.M{"_id": 0, "key": "$_id", "count": "$documents", "document": "$sample"}},
+		},
+	}}})
+}
+
+// run ejecuta la comprobación sobre la base de datos
+func (c integrityCheck) run(ctx context.Context, db *mongo.Database) (entity.IntegrityCheck, error) {
+	check := entity.IntegrityCheck{
+		Name:       c.Name,
+		Kind:       c.Kind,
+		Collection: c.Collection,
+		Reference:  c.Reference,
+		Keys:       c.Keys,
+		Samples:    []entity.IntegritySample{},
+	}
+	cursor, err := db.Collection(c.Collection).Aggregate(ctx, c.pipeline(), options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return check, fmt.Errorf("error en la comprobación %s: %w", c.Name, err)
+	}
+	var results []struct {
+		Totals []struct {
+			Groups    int64 `bson:"groups"`
+			Documents int64 `bson:"documents"`
+		} `bson:"totals"`
+		Samples []entity.IntegritySample `bson:"samples"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return check, fmt.Errorf("error al decodificar la comprobación %s: %w", c.Name, err)
+	}
+	if len(results) > 0 && len(results[0].Totals) > 0 {
+		check.Groups = results[0].Totals[0].Groups
+		check.Documents = results[0].Totals[0].Documents
+		check.Samples = results[0].Samples
+	}
+	return check, nil
+}
+
+// RunIntegrityCheck comprueba las claves foráneas implícitas y las claves
+// naturales de OULAD y guarda el informe
+func (m *mongoDBClient) RunIntegrityCheck(ctx context.Context, database string) (*entity.IntegrityReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+
+	db := m.client.Database(database)
+	version, err := getDatasetVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	report := &entity.IntegrityReport{
+		ID:             primitive.NewObjectID().Hex(),
+		DatasetVersion: version,
+		StartedAt:      time.Now(),
+		Checks:         make([]entity.IntegrityCheck, 0, len(integrityChecks)),
+	}
+	for _, definition := range integrityChecks {
+		check, err := definition.run(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		if check.Groups > 0 {
+			report.Issues++
+		}
+		report.Checks = append(report.Checks, check)
+	}
+	report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+
+	if _, err := db.Collection(integrityReportsCollection).InsertOne(ctx, report); err != nil {
+		return nil, fmt.Errorf("error al guardar el informe de integridad: %w", err)
+	}
+	m.loggers.InfoLogger.Printf("Comprobación de integridad %s: %d de %d comprobaciones con incidencias en %d ms",
+		report.ID, report.Issues, len(report.Checks), report.DurationMs)
+	return report, nil
+}
+
+// GetIntegrityReport devuelve el informe de integridad más reciente
+func (m *mongoDBClient) GetIntegrityReport(ctx context.Context, database string) (*entity.IntegrityReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var report entity.IntegrityReport
+	err := m.client.Database(database).Collection(integrityReportsCollection).FindOne(ctx, bson.M{},
+		options.FindOne().SetSort(bson.M{"started_at": -1})).Decode(&report)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("informe de integridad %w; ejecute la comprobación primero", entity.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el informe de integridad: %w", err)
+	}
+	return &report, nil
+}
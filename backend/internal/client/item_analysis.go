@@ -0,0 +1,285 @@
+package client
+
+import (
+	"backend/internal/analytics"
+	"backend/internal/entity"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// Umbrales para marcar evaluaciones posiblemente defectuosas
+	lowDiscrimination = 0.2
+	maxPassRate       = 0.95
+	minPassRate       = 0.5
+	maxLateRate       = 0.3
+	examAssessment    = "Exam"
+)
+
+// Tramos de retraso en días respecto a la fecha límite de la evaluación
+var latenessRanges = []struct {
+	Label    string
+	From, To int
+}{
+	{"Antes del plazo", math.MinInt, -1},
+	{"En plazo", 0, 0},
+	{"1-7 días", 1, 7},
+	{"8-14 días", 8, 14},
+	{"Más de 14 días", 15, math.MaxInt},
+}
+
+func (m *mongoDBClient) GetItemAnalysis(ctx context.Context, database string, query entity.ItemAnalysisQuery) (*entity.ItemAnalysisReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	db := m.client.Database(database)
+	version, err := getDatasetVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := itemAnalysisCacheKey(query)
+	report := &entity.ItemAnalysisReport{}
+	found, err := readAnalyticsCache(ctx, db, cacheKey, version, report)
+	if err != nil {
+		m.loggers.ErrorLogger.Printf("Error al leer la caché del análisis de ítems: %v", err)
+	}
+	if found {
+		report.Cached = true
+		return report, nil
+	}
+
+	submissions, err := loadAssessmentSubmissions(ctx, db, query)
+	if err != nil {
+		return nil, err
+	}
+	finalResults, err := loadFinalResults(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	m.loggers.InfoLogger.Printf("Analizando %d entregas de evaluaciones", len(submissions))
+
+	report = itemAnalysisReport(submissions, finalResults, version)
+	if err := writeAnalyticsCache(ctx, db, cacheKey, version, report); err != nil {
+		m.loggers.ErrorLogger.Printf("Error al guardar la caché del análisis de ítems: %v", err)
+	}
+	return report, nil
+}
+
+func itemAnalysisCacheKey(query entity.ItemAnalysisQuery) string {
+	return "item_analysis:" + strings.Join([]string{query.CodeModule, query.CodePresentation, query.AssessmentType}, ",")
+}
+
+// itemAnalysisReport agrupa las entregas por evaluación y analiza cada una
+func itemAnalysisReport(submissions []assessmentSubmission, finalResults map[string]string, version int) *entity.ItemAnalysisReport {
+	byAssessment := make(map[int][]assessmentSubmission)
+	for _, s := range submissions {
+		byAssessment[s.AssessmentID] = append(byAssessment[s.AssessmentID], s)
+	}
+	ids := make([]int, 0, len(byAssessment))
+	for id := range byAssessment {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	report := &entity.ItemAnalysisReport{
+		PassMark:       defaultPassMark,
+		DatasetVersion: version,
+		ComputedAt:     time.Now(),
+		Assessments:    make([]entity.AssessmentItemAnalysis, 0, len(ids)),
+	}
+	for _, id := range ids {
+		report.Assessments = append(report.Assessments, itemAnalysis(byAssessment[id], finalResults))
+	}
+	return report
+}
+
+// assessmentSubmission une una fila de studentAssessment con su evaluación
+type assessmentSubmission struct {
+	AssessmentID     int     `bson:"idassessment"`
+	StudentID        int     `bson:"idstudent"`
+	DateSubmitted    int     `bson:"datesubmitted"`
+	IsBanked         int     `bson:"isbanked"`
+	Score            float64 `bson:"score"`
+	CodeModule       string  `bson:"codemodule"`
+	CodePresentation string  `bson:"codepresentation"`
+	AssessmentType   string  `bson:"assessmenttype"`
+	Date             int     `bson:"date"`
+	Weight           float64 `bson:"weight"`
+}
+
+// loadAssessmentSubmissions devuelve las entregas de studentAssessment con los
+// datos de su evaluación, filtradas por módulo, presentación o tipo
+func loadAssessmentSubmissions(ctx context.Context, db *mongo.Database, query entity.ItemAnalysisQuery) ([]assessmentSubmission, error) {
+	filter := bson.M{}
+	if query.CodeModule != "" {
+		filter["assessment.codemodule"] = query.CodeModule
+	}
+	if query.CodePresentation != "" {
+		filter["assessment.codepresentation"] = query.CodePresentation
+	}
+	if query.AssessmentType != "" {
+		filter["assessment.assessmenttype"] = query.AssessmentType
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "assessments",
+			"localField":   "idassessment",
+			"foreignField": "idassessment",
+			"as":           "assessment",
+		}}},
+		{{Key: "$unwind", Value: "$assessment"}},
+		{{Key: "$match", Value: filter}},
+		{{Key: "$project", Value: bson.M{
+			"_id":              0,
+			"idassessment":     1,
+			"idstudent":        1,
+			"datesubmitted":    1,
+			"isbanked":         1,
+			"score":            1,
+			"codemodule":       "$assessment.codemodule",
+			"codepresentation": "$assessment.codepresentation",
+			"assessmenttype":   "$assessment.assessmenttype",
+			"date":             "$assessment.date",
+			"weight":           "$assessment.weight",
+		}}},
+	}
+	cursor, err := db.Collection("studentAssessment").Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true).SetBatchSize(5000))
+	if err != nil {
+		return nil, fmt.Errorf("error al unir studentAssessment con assessments: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var submissions []assessmentSubmission
+	if err := cursor.All(ctx, &submissions); err != nil {
+		return nil, fmt.Errorf("error al decodificar las entregas: %w", err)
+	}
+	return submissions, nil
+}
+
+// loadFinalResults devuelve studentInfo.final_result por (estudiante, módulo, presentación)
+func loadFinalResults(ctx context.Context, db *mongo.Database) (map[string]string, error) {
+	projection := bson.M{"_id": 0, "idstudent": 1, "codemodule": 1, "codepresentation": 1, "finalresult": 1}
+	cursor, err := db.Collection("studentInfo").Find(ctx, bson.M{}, options.Find().SetProjection(projection))
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener studentInfo: %w", err)
+	}
+	var rows []struct {
+		StudentID        int    `bson:"idstudent"`
+		CodeModule       string `bson:"codemodule"`
+		CodePresentation string `bson:"codepresentation"`
+		FinalResult      string `bson:"finalresult"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("error al decodificar studentInfo: %w", err)
+	}
+	results := make(map[string]string, len(rows))
+	for _, r := range rows {
+		results[studentKey(r.StudentID, r.CodeModule, r.CodePresentation)] = r.FinalResult
+	}
+	return results, nil
+}
+
+// passedCourse indica si el resultado final cuenta como aprobado
+func passedCourse(finalResult string) bool {
+	return finalResult == "Pass" || finalResult == "Distinction"
+}
+
+// itemAnalysis resume la dificultad, discriminación y puntualidad de una evaluación.
+// La discriminación es la correlación biserial puntual entre la puntuación y haber
+// aprobado el curso; las entregas convalidadas (is_banked) no cuentan para el retraso
+func itemAnalysis(submissions []assessmentSubmission, finalResults map[string]string) entity.AssessmentItemAnalysis {
+	first := submissions[0]
+	analysis := entity.AssessmentItemAnalysis{
+		AssessmentID:     first.AssessmentID,
+		CodeModule:       first.CodeModule,
+		CodePresentation: first.CodePresentation,
+		AssessmentType:   first.AssessmentType,
+		Weight:           first.Weight,
+		Submissions:      len(submissions),
+		// Los exámenes sin fecha se cargan con date = 0
+		HasDueDate: !(first.AssessmentType == examAssessment && first.Date == 0),
+		Lateness:   make([]entity.LatenessBucket, len(latenessRanges)),
+		Flags:      []string{},
+	}
+	for i, r := range latenessRanges {
+		analysis.Lateness[i].Range = r.Label
+	}
+
+	scores := make([]float64, 0, len(submissions))
+	var discriminationScores, outcomes, lateness []float64
+	var passes, banked, late int
+	var scoreSum float64
+	for _, s := range submissions {
+		scores = append(scores, s.Score)
+		scoreSum += s.Score
+		if s.Score >= defaultPassMark {
+			passes++
+		}
+		if result, ok := finalResults[studentKey(s.StudentID, s.CodeModule, s.CodePresentation)]; ok {
+			discriminationScores = append(discriminationScores, s.Score)
+			outcome := 0.0
+			if passedCourse(result) {
+				outcome = 1
+			}
+			outcomes = append(outcomes, outcome)
+		}
+		if s.IsBanked == 1 {
+			banked++
+			continue
+		}
+		if !analysis.HasDueDate {
+			continue
+		}
+		days := s.DateSubmitted - s.Date
+		lateness = append(lateness, float64(days))
+		if days > 0 {
+			late++
+		}
+		for i, r := range latenessRanges {
+			if days >= r.From && days <= r.To {
+				analysis.Lateness[i].Count++
+				break
+			}
+		}
+	}
+
+	n := float64(len(submissions))
+	analysis.MeanScore = scoreSum / n
+	analysis.MedianScore = analytics.Median(scores)
+	analysis.PassRate = float64(passes) / n
+	analysis.BankedShare = float64(banked) / n
+	analysis.Discrimination = analytics.Pearson(discriminationScores, outcomes)
+	if len(lateness) > 0 {
+		var sum float64
+		for _, d := range lateness {
+			sum += d
+		}
+		analysis.MeanLateness = sum / float64(len(lateness))
+		analysis.MedianLateness = analytics.Median(lateness)
+		analysis.LateRate = float64(late) / float64(len(lateness))
+	}
+
+	if analysis.Discrimination < 0 {
+		analysis.Flags = append(analysis.Flags, "negative_discrimination")
+	} else if analysis.Discrimination < lowDiscrimination {
+		analysis.Flags = append(analysis.Flags, "low_discrimination")
+	}
+	if analysis.PassRate > maxPassRate {
+		analysis.Flags = append(analysis.Flags, "too_easy")
+	}
+	if analysis.PassRate < minPassRate {
+		analysis.Flags = append(analysis.Flags, "too_hard")
+	}
+	if analysis.LateRate > maxLateRate {
+		analysis.Flags = append(analysis.Flags, "frequently_late")
+	}
+	return analysis
+}
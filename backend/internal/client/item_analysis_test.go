@@ -0,0 +1,125 @@
+package client
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// submissions entregas de una evaluación de AAA 2013J con fecha límite el día 10
+func submissions(assessmentType string, date int, rows ...[4]int) []assessmentSubmission {
+	result := make([]assessmentSubmission, len(rows))
+	for i, r := range rows {
+		result[i] = assessmentSubmission{
+			AssessmentID:     1752,
+			StudentID:        r[0],
+			Score:            float64(r[1]),
+			DateSubmitted:    r[2],
+			IsBanked:         r[3],
+			CodeModule:       "AAA",
+			CodePresentation: "2013J",
+			AssessmentType:   assessmentType,
+			Date:             date,
+			Weight:           10,
+		}
+	}
+	return result
+}
+
+func TestItemAnalysis(t *testing.T) {
+	finalResults := map[string]string{
+		studentKey(1, "AAA", "2013J"): "Pass",
+		studentKey(2, "AAA", "2013J"): "Distinction",
+		studentKey(3, "AAA", "2013J"): "Fail",
+		studentKey(4, "AAA", "2013J"): "Withdrawn",
+	}
+	tests := []struct {
+		name        string
+		submissions []assessmentSubmission
+		flags       []string
+		passRate    float64
+		lateRate    float64
+		hasDueDate  bool
+	}{
+		{
+			name:        "entregas tardías",
+			submissions: submissions("TMA", 10, [4]int{1, 90, 8, 0}, [4]int{2, 80, 10, 0}, [4]int{3, 30, 12, 0}, [4]int{4, 20, 30, 0}),
+			flags:       []string{"frequently_late"},
+			passRate:    0.5,
+			lateRate:    0.5,
+			hasDueDate:  true,
+		},
+		{
+			name:        "discriminación negativa y demasiado fácil",
+			submissions: submissions("TMA", 10, [4]int{1, 50, 10, 0}, [4]int{3, 90, 10, 0}),
+			flags:       []string{"negative_discrimination", "too_easy"},
+			passRate:    1,
+			hasDueDate:  true,
+		},
+		{
+			name:        "sin resultados finales",
+			submissions: submissions("CMA", 10, [4]int{8, 10, 10, 0}, [4]int{9, 60, 10, 0}),
+			flags:       []string{"low_discrimination"},
+			passRate:    0.5,
+			hasDueDate:  true,
+		},
+		{
+			name:        "examen sin fecha",
+			submissions: submissions(examAssessment, 0, [4]int{1, 30, 250, 0}, [4]int{3, 20, 250, 1}),
+			flags:       []string{"too_hard"},
+			passRate:    0,
+			hasDueDate:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis := itemAnalysis(tt.submissions, finalResults)
+			if !reflect.DeepEqual(analysis.Flags, tt.flags) {
+				t.Errorf("Flags = %v, se esperaba %v", analysis.Flags, tt.flags)
+			}
+			if analysis.PassRate != tt.passRate || analysis.LateRate != tt.lateRate || analysis.HasDueDate != tt.hasDueDate {
+				t.Errorf("PassRate = %v, LateRate = %v, HasDueDate = %v", analysis.PassRate, analysis.LateRate, analysis.HasDueDate)
+			}
+			if analysis.Submissions != len(tt.submissions) || len(analysis.Lateness) != len(latenessRanges) {
+				t.Errorf("Submissions = %d, tramos de retraso = %d", analysis.Submissions, len(analysis.Lateness))
+			}
+		})
+	}
+}
+
+func TestItemAnalysisLateness(t *testing.T) {
+	analysis := itemAnalysis(submissions("TMA", 10,
+		[4]int{1, 90, 8, 0}, [4]int{2, 80, 10, 0}, [4]int{3, 30, 12, 0}, [4]int{4, 20, 30, 0}, [4]int{5, 70, 99, 1},
+	), nil)
+
+	counts := make([]int, len(analysis.Lateness))
+	for i, bucket := range analysis.Lateness {
+		counts[i] = bucket.Count
+	}
+	// La entrega convalidada no cuenta en los tramos de retraso
+	if want := []int{1, 1, 1, 0, 1}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("tramos de retraso = %v, se esperaba %v", counts, want)
+	}
+	if analysis.MeanLateness != 5 || analysis.MedianLateness != 1 {
+		t.Errorf("MeanLateness = %v, MedianLateness = %v", analysis.MeanLateness, analysis.MedianLateness)
+	}
+	if analysis.MeanScore != 58 || analysis.MedianScore != 70 || analysis.BankedShare != 0.2 {
+		t.Errorf("MeanScore = %v, MedianScore = %v, BankedShare = %v", analysis.MeanScore, analysis.MedianScore, analysis.BankedShare)
+	}
+	if math.Abs(analysis.Discrimination) > 0 {
+		t.Errorf("sin resultados finales la discriminación debería ser 0: %v", analysis.Discrimination)
+	}
+}
+
+func TestItemAnalysisReport(t *testing.T) {
+	rows := append(submissions("TMA", 10, [4]int{1, 50, 10, 0}), submissions("TMA", 10, [4]int{2, 60, 10, 0})...)
+	rows[0].AssessmentID = 20
+	rows[1].AssessmentID = 5
+	report := itemAnalysisReport(rows, nil, 3)
+	if report.DatasetVersion != 3 || report.PassMark != defaultPassMark || len(report.Assessments) != 2 {
+		t.Fatalf("informe = %+v", report)
+	}
+	if report.Assessments[0].AssessmentID != 5 || report.Assessments[1].AssessmentID != 20 {
+		t.Errorf("las evaluaciones deberían ordenarse por id: %d, %d", report.Assessments[0].AssessmentID, report.Assessments[1].AssessmentID)
+	}
+}
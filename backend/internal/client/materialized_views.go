@@ -0,0 +1,320 @@
+package client
+
+import (
+	"backend/internal/config"
+	"backend/internal/entity"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	materializedViewsCollection = "materialized_views"
+	viewStatusReady             = "ready"
+	viewStatusFailed            = "failed"
+)
+
+// materializedView describe un pipeline con nombre cuyo resultado se escribe con
+// $merge en una colección de resumen
+type materializedView struct {
+	Name   string
+	Source string
+	Target string
+	// RunKind indica el tipo de ejecución de predicción del que depende la vista
+	// ("" si solo depende de los datos cargados)
+	RunKind  string
+	Pipeline func(runID string) mongo.Pipeline
+	// Query es el INSERT ... SELECT equivalente para el backend SQL; recibe el
+	// identificador de la ejecución como parámetro cuando RunKind no está vacío
+	Query func(d sqlDialect) string
+}
+
+// Vistas registradas, en orden de actualización
+var materializedViews = []materializedView{
+	{
+		Name:     config.ViewAvgScoreByAssessmentType,
+		Source:   "prediction_assessments",
+		Target:   "mv_avg_score_by_assessment_type",
+		RunKind:  config.PredictionKindAssessments,
+		Pipeline: avgScoreByAssessmentTypePipeline,
+		Query:    avgScoreByAssessmentTypeQuery,
+	},
+	{
+		Name:     config.ViewStudentCountByAssessment,
+		Source:   "prediction_assessments",
+		Target:   "mv_student_count_by_assessment",
+		RunKind:  config.PredictionKindAssessments,
+		Pipeline: studentCountByAssessmentPipeline,
+		Query:    studentCountByAssessmentQuery,
+	},
+	{
+		Name:     config.ViewScoreHistogram,
+		Source:   "prediction_assessments",
+		Target:   "mv_score_histogram",
+		RunKind:  config.PredictionKindAssessments,
+		Pipeline: scoreHistogramPipeline,
+		Query:    scoreHistogramQuery,
+	},
+	{
+		Name:     config.ViewEngagementWeekly,
+		Source:   "studentVle",
+		Target:   engagementWeeklyCollection,
+		Pipeline: engagementWeeklyPipeline,
+		Query:    engagementWeeklyQuery,
+	},
+}
+
+func findMaterializedView(name string) (materializedView, error) {
+	for _, view := range materializedViews {
+		if view.Name == name {
+			return view, nil
+		}
+	}
+	return materializedView{}, fmt.Errorf("vista materializada no registrada: %s", name)
+}
+
+// RefreshMaterializedViews actualiza la vista indicada o todas si name está vacío
+func (m *mongoDBClient) RefreshMaterializedViews(ctx context.Context, database, name string) ([]entity.ViewMetadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+
+	views := materializedViews
+	if name != "" {
+		view, err := findMaterializedView(name)
+		if err != nil {
+			return nil, err
+		}
+		views = []materializedView{view}
+	}
+
+	db := m.client.Database(database)
+	var results []entity.ViewMetadata
+	for _, view := range views {
+		metadata, err := m.refreshView(ctx, db, view)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, *metadata)
+	}
+	return results, nil
+}
+
+func (m *mongoDBClient) GetMaterializedViews(ctx context.Context, database string) ([]entity.ViewMetadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	db := m.client.Database(database)
+	results := make([]entity.ViewMetadata, 0, len(materializedViews))
+	for _, view := range materializedViews {
+		metadata, err := viewMetadata(ctx, db, view)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *metadata)
+	}
+	return results, nil
+}
+
+func (m *mongoDBClient) GetViewMetadata(ctx context.Context, database, name string) (*entity.ViewMetadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	view, err := findMaterializedView(name)
+	if err != nil {
+		return nil, err
+	}
+	return viewMetadata(ctx, m.client.Database(database), view)
+}
+
+// refreshViewsForRun actualiza las vistas que dependen de la ejecución activa del tipo indicado
+func (m *mongoDBClient) refreshViewsForRun(ctx context.Context, db *mongo.Database, kind string) error {
+	for _, view := range materializedViews {
+		if view.RunKind != kind {
+			continue
+		}
+		if _, err := m.refreshView(ctx, db, view); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshView escribe el resultado del pipeline con $merge en la colección de
+// resumen: los documentos se sustituyen por _id (la clave de agrupación) y
+// después se eliminan los que esta actualización ya no produce. La colección
+// nunca queda vacía y conserva sus índices; si dos actualizaciones coinciden,
+// prevalecen los documentos de la más reciente
+func (m *mongoDBClient) refreshView(ctx context.Context, db *mongo.Database, view materializedView) (*entity.ViewMetadata, error) {
+	start := time.Now().Truncate(time.Millisecond)
+	metadata := &entity.ViewMetadata{Name: view.Name, Collection: view.Target, Status: viewStatusReady, RefreshedAt: start}
+
+	err := func() error {
+		if view.RunKind != "" {
+			runID, err := getActiveRunID(ctx, db, view.RunKind)
+			if err != nil {
+				return err
+			}
+			metadata.SourceRunID = runID
+		}
+		version, err := getDatasetVersion(ctx, db)
+		if err != nil {
+			return err
+		}
+		metadata.DatasetVersion = version
+
+		pipeline := append(view.Pipeline(metadata.SourceRunID),
+			bson.D{{Key: "$addFields", Value: bson.M{"refreshed_at": start}}},
+			bson.D{{Key: "$merge", Value: bson.M{
+				"into": view.Target,
+				"on":   "_id",
+				"whenMatched": mongo.Pipeline{
+					{{Key: "$replaceWith", Value: bson.M{"$cond": bson.A{
+						bson.M{"$gt": bson.A{"$refreshed_at", "$$new.refreshed_at"}}, "$$ROOT", "$$new",
+					}}}},
+				},
+				"whenNotMatched": "insert",
+			}}},
+		)
+		cursor, err := db.Collection(view.Source).Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+		if err != nil {
+			return fmt.Errorf("error al actualizar la vista %s: %w", view.Name, err)
+		}
+		cursor.Close(ctx)
+
+		// Grupos de la ejecución o versión de los datos anteriores que ya no existen
+		if _, err := db.Collection(view.Target).DeleteMany(ctx, bson.M{"refreshed_at": bson.M{"$lt": start}}); err != nil {
+			return fmt.Errorf("error al limpiar la vista %s: %w", view.Name, err)
+		}
+		metadata.Documents, err = db.Collection(view.Target).CountDocuments(ctx, bson.M{"refreshed_at": start})
+		if err != nil {
+			return fmt.Errorf("error al contar la vista %s: %w", view.Name, err)
+		}
+		return nil
+	}()
+	if err != nil {
+		metadata.Status = viewStatusFailed
+		metadata.Error = err.Error()
+	}
+	metadata.DurationMs = time.Since(start).Milliseconds()
+
+	if _, saveErr := db.Collection(materializedViewsCollection).ReplaceOne(ctx,
+		bson.M{"_id": view.Name}, metadata, options.Replace().SetUpsert(true)); saveErr != nil && err == nil {
+		err = fmt.Errorf("error al guardar los metadatos de la vista %s: %w", view.Name, saveErr)
+	}
+	if err != nil {
+		m.loggers.ErrorLogger.Printf("Error al actualizar la vista %s: %v", view.Name, err)
+		return nil, err
+	}
+	m.loggers.InfoLogger.Printf("Vista %s actualizada: %d documentos en %dms", view.Name, metadata.Documents, metadata.DurationMs)
+	return metadata, nil
+}
+
+// viewMetadata devuelve los metadatos guardados de la vista y marca si está
+// desactualizada respecto a la ejecución activa o a la versión de los datos
+func viewMetadata(ctx context.Context, db *mongo.Database, view materializedView) (*entity.ViewMetadata, error) {
+	var metadata entity.ViewMetadata
+	err := db.Collection(materializedViewsCollection).FindOne(ctx, bson.M{"_id": view.Name}).Decode(&metadata)
+	if err == mongo.ErrNoDocuments {
+		return &entity.ViewMetadata{Name: view.Name, Collection: view.Target, Stale: true}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los metadatos de la vista %s: %w", view.Name, err)
+	}
+
+	version, err := getDatasetVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	metadata.Stale = metadata.Status != viewStatusReady || metadata.DatasetVersion != version
+	if view.RunKind != "" {
+		runID, err := getActiveRunID(ctx, db, view.RunKind)
+		if err != nil {
+			return nil, err
+		}
+		metadata.Stale = metadata.Stale || metadata.SourceRunID != runID
+	}
+	return &metadata, nil
+}
+
+// ensureView actualiza la vista si nunca se ha calculado, para que la primera
+// lectura tras desplegar no devuelva una colección vacía
+func (m *mongoDBClient) ensureView(ctx context.Context, db *mongo.Database, name string) (materializedView, error) {
+	view, err := findMaterializedView(name)
+	if err != nil {
+		return view, err
+	}
+	count, err := db.Collection(materializedViewsCollection).CountDocuments(ctx, bson.M{"_id": name})
+	if err != nil {
+		return view, fmt.Errorf("error al obtener los metadatos de la vista %s: %w", name, err)
+	}
+	if count == 0 {
+		if _, err := m.refreshView(ctx, db, view); err != nil {
+			return view, err
+		}
+	}
+	return view, nil
+}
+
+// assessmentLookupStages une cada predicción con su evaluación, conservando las
+// predicciones sin evaluación asociada
+func assessmentLookupStages(runID string) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"run_id": runID}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "assessments",
+			"localField":   "assessment_id",
+			"foreignField": "idassessment",
+			"as":           "assessment",
+		}}},
+		{{Key: "$unwind", Value: bson.M{"path": "$assessment", "preserveNullAndEmptyArrays": true}}},
+	}
+}
+
+func avgScoreByAssessmentTypePipeline(runID string) mongo.Pipeline {
+	return append(assessmentLookupStages(runID),
+		bson.D{{Key: "$match", Value: bson.M{"assessment": bson.M{"$exists": true}}}},
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":           "$assessment.assessmenttype",
+			"average_score": bson.M{"$avg": "$predicted_score"},
+			"count":         bson.M{"$sum": 1},
+		}}},
+		bson.D{{Key: "$addFields", Value: bson.M{"assessment_type": "$_id"}}},
+	)
+}
+
+func studentCountByAssessmentPipeline(runID string) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"run_id": runID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$assessment_id",
+			"students": bson.M{"$addToSet": "$student_id"},
+		}}},
+		{{Key: "$project", Value: bson.M{"student_count": bson.M{"$size": "$students"}}}},
+	}
+}
+
+// scoreHistogramPipeline cuenta predicciones en tramos de un punto por módulo,
+// presentación y tipo de evaluación; la distribución se calcula a partir de él
+func scoreHistogramPipeline(runID string) mongo.Pipeline {
+	return append(assessmentLookupStages(runID),
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"code_module":       "$assessment.codemodule",
+				"code_presentation": "$assessment.codepresentation",
+				"assessment_type":   "$assessment.assessmenttype",
+				"bin":               bson.M{"$floor": "$predicted_score"},
+			},
+			"count": bson.M{"$sum": 1},
+		}}},
+		bson.D{{Key: "$addFields", Value: bson.M{
+			"code_module":       "$_id.code_module",
+			"code_presentation": "$_id.code_presentation",
+			"assessment_type":   "$_id.assessment_type",
+			"bin":               "$_id.bin",
+		}}},
+	)
+}
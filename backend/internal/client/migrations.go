@@ -0,0 +1,452 @@
+package client
+
+import (
+	"backend/internal/config"
+	"backend/internal/entity"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const schemaMigrationsCollection = "schema_migrations"
+
+// Códigos de error de MongoDB. Las migraciones toleran los dos primeros para
+// poder repetirse sin fallar; el último es el de los documentos que rechaza un
+// validador $jsonSchema
+const (
+	mongoNamespaceNotFound         = 26
+	mongoIndexNotFound             = 27
+	mongoDocumentValidationFailure = 121
+)
+
+// migrationStep es una operación de esquema reversible. Las operaciones deben
+// ser idempotentes: una migración interrumpida se vuelve a ejecutar completa
+type migrationStep interface {
+	describe(up bool) string
+	up(ctx context.Context, db *mongo.Database) error
+	down(ctx context.Context, db *mongo.Database) error
+}
+
+// migration agrupa las operaciones de una versión del esquema
+type migration struct {
+	Version int
+	Name    string
+	Steps   []migrationStep
+}
+
+// createCollectionStep crea la colección con su validador o, si ya existe, le
+// aplica el validador con collMod. Al revertir se quita el validador pero nunca
+// se elimina la colección
+type createCollectionStep struct {
+	Collection string
+	Validator  bson.M
+}
+
+func (s createCollectionStep) describe(up bool) string {
+	if !up {
+		return fmt.Sprintf("quitar validador de %s", s.Collection)
+	}
+	if s.Validator == nil {
+		return fmt.Sprintf("crear colección %s", s.Collection)
+	}
+	return fmt.Sprintf("crear colección %s con validador", s.Collection)
+}
+
+func (s createCollectionStep) up(ctx context.Context, db *mongo.Database) error {
+	names, err := db.ListCollectionNames(ctx, bson.M{"name": s.Collection})
+	if err != nil {
+		return fmt.Errorf("error al listar colecciones: %w", err)
+	}
+	if len(names) == 0 {
+		opts := options.CreateCollection()
+		if s.Validator != nil {
+			opts.SetValidator(s.Validator)
+		}
+		if err := db.CreateCollection(ctx, s.Collection, opts); err != nil {
+			return fmt.Errorf("error al crear la colección %s: %w", s.Collection, err)
+		}
+		return nil
+	}
+	if s.Validator == nil {
+		return nil
+	}
+	return setValidator(ctx, db, s.Collection, s.Validator)
+}
+
+func (s createCollectionStep) down(ctx context.Context, db *mongo.Database) error {
+	if s.Validator == nil {
+		return nil
+	}
+	return setValidator(ctx, db, s.Collection, bson.M{})
+}
+
+func setValidator(ctx context.Context, db *mongo.Database, collection string, validator bson.M) error {
+	err := db.RunCommand(ctx, bson.D{{Key: "collMod", Value: collection}, {Key: "validator", Value: validator}}).Err()
+	if err != nil && !hasMongoErrorCode(err, mongoNamespaceNotFound) {
+		return fmt.Errorf("error al modificar el validador de %s: %w", collection, err)
+	}
+	return nil
+}
+
+// createIndexStep crea un índice con nombre; al revertir lo elimina
+type createIndexStep struct {
+	Collection string
+	Name       string
+	Keys       bson.D
+	Unique     bool
+	// TTL elimina cada documento cuando llega la fecha del campo indexado
+	TTL bool
+}
+
+func (s createIndexStep) describe(up bool) string {
+	if !up {
+		return fmt.Sprintf("eliminar índice %s.%s", s.Collection, s.Name)
+	}
+	suffix := uniqueSuffix(s.Unique)
+	if s.TTL {
+		suffix += " TTL"
+	}
+	return fmt.Sprintf("crear índice %s.%s (%s)%s", s.Collection, s.Name, indexKeys(s.Keys), suffix)
+}
+
+func (s createIndexStep) up(ctx context.Context, db *mongo.Database) error {
+	return createIndex(ctx, db, s.Collection, s.Name, s.Keys, s.Unique, s.TTL)
+}
+
+func (s createIndexStep) down(ctx context.Context, db *mongo.Database) error {
+	return dropIndex(ctx, db, s.Collection, s.Name)
+}
+
+// dropIndexStep elimina un índice obsoleto; conserva su definición para poder
+// recrearlo al revertir
+type dropIndexStep struct {
+	Collection string
+	Name       string
+	Keys       bson.D
+	Unique     bool
+}
+
+func (s dropIndexStep) describe(up bool) string {
+	if !up {
+		return fmt.Sprintf("recrear índice %s.%s (%s)%s", s.Collection, s.Name, indexKeys(s.Keys), uniqueSuffix(s.Unique))
+	}
+	return fmt.Sprintf("eliminar índice %s.%s", s.Collection, s.Name)
+}
+
+func (s dropIndexStep) up(ctx context.Context, db *mongo.Database) error {
+	return dropIndex(ctx, db, s.Collection, s.Name)
+}
+
+func (s dropIndexStep) down(ctx context.Context, db *mongo.Database) error {
+	return createIndex(ctx, db, s.Collection, s.Name, s.Keys, s.Unique, false)
+}
+
+func createIndex(ctx context.Context, db *mongo.Database, collection, name string, keys bson.D, unique, ttl bool) error {
+	opts := options.Index().SetName(name)
+	if unique {
+		opts.SetUnique(true)
+	}
+	if ttl {
+		opts.SetExpireAfterSeconds(0)
+	}
+	_, err := db.Collection(collection).Indexes().CreateOne(ctx, mongo.IndexModel{Keys: keys, Options: opts})
+	if err != nil {
+		return fmt.Errorf("error al crear índice %s en %s: %w", name, collection, err)
+	}
+	return nil
+}
+
+func dropIndex(ctx context.Context, db *mongo.Database, collection, name string) error {
+	_, err := db.Collection(collection).Indexes().DropOne(ctx, name)
+	if err != nil && !hasMongoErrorCode(err, mongoIndexNotFound, mongoNamespaceNotFound) {
+		return fmt.Errorf("error al eliminar índice %s en %s: %w", name, collection, err)
+	}
+	return nil
+}
+
+// renameFieldStep renombra un campo en todos los documentos que lo tienen
+type renameFieldStep struct {
+	Collection string
+	From       string
+	To         string
+}
+
+func (s renameFieldStep) describe(up bool) string {
+	if !up {
+		return fmt.Sprintf("renombrar %s.%s a %s", s.Collection, s.To, s.From)
+	}
+	return fmt.Sprintf("renombrar %s.%s a %s", s.Collection, s.From, s.To)
+}
+
+func (s renameFieldStep) up(ctx context.Context, db *mongo.Database) error {
+	return renameField(ctx, db, s.Collection, s.From, s.To)
+}
+
+func (s renameFieldStep) down(ctx context.Context, db *mongo.Database) error {
+	return renameField(ctx, db, s.Collection, s.To, s.From)
+}
+
+func renameField(ctx context.Context, db *mongo.Database, collection, from, to string) error {
+	_, err := db.Collection(collection).UpdateMany(ctx,
+		bson.M{from: bson.M{"$exists": true}},
+		bson.M{"$rename": bson.M{from: to}})
+	if err != nil {
+		return fmt.Errorf("error al renombrar %s en %s: %w", from, collection, err)
+	}
+	return nil
+}
+
+// backfillStep actualiza con Update los documentos que cumplen Filter, o los
+// elimina si Delete es true. Al revertir, Revert se aplica a toda la colección;
+// sin Revert, o con Delete, la operación no se puede revertir
+type backfillStep struct {
+	Collection  string
+	Description string
+	Filter      bson.M
+	Update      bson.M
+	Revert      bson.M
+	Delete      bool
+}
+
+func (s backfillStep) describe(up bool) string {
+	if !up {
+		if s.Delete || s.Revert == nil {
+			return fmt.Sprintf("%s: %s (irreversible, sin cambios)", s.Collection, s.Description)
+		}
+		return fmt.Sprintf("revertir en %s: %s", s.Collection, s.Description)
+	}
+	return fmt.Sprintf("%s: %s", s.Collection, s.Description)
+}
+
+func (s backfillStep) up(ctx context.Context, db *mongo.Database) error {
+	collection := db.Collection(s.Collection)
+	if s.Delete {
+		if _, err := collection.DeleteMany(ctx, s.Filter); err != nil {
+			return fmt.Errorf("error al eliminar documentos en %s: %w", s.Collection, err)
+		}
+		return nil
+	}
+	if _, err := collection.UpdateMany(ctx, s.Filter, s.Update); err != nil {
+		return fmt.Errorf("error al actualizar documentos en %s: %w", s.Collection, err)
+	}
+	return nil
+}
+
+func (s backfillStep) down(ctx context.Context, db *mongo.Database) error {
+	if s.Delete || s.Revert == nil {
+		return nil
+	}
+	if _, err := db.Collection(s.Collection).UpdateMany(ctx, bson.M{}, s.Revert); err != nil {
+		return fmt.Errorf("error al revertir documentos en %s: %w", s.Collection, err)
+	}
+	return nil
+}
+
+func hasMongoErrorCode(err error, codes ...int32) bool {
+	var commandErr mongo.CommandError
+	if !errors.As(err, &commandErr) {
+		return false
+	}
+	for _, code := range codes {
+		if commandErr.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func indexKeys(keys bson.D) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s: %v", key.Key, key.Value)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func uniqueSuffix(unique bool) string {
+	if unique {
+		return " único"
+	}
+	return ""
+}
+
+// GetMigrationStatus devuelve las migraciones registradas indicando cuáles están aplicadas
+func (m *mongoDBClient) GetMigrationStatus(ctx context.Context, database string) ([]entity.MigrationStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	applied, err := loadAppliedMigrations(ctx, m.client.Database(database))
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]entity.MigrationStatus, len(schemaMigrations))
+	for i, migration := range schemaMigrations {
+		status, ok := applied[migration.Version]
+		if !ok {
+			status = entity.MigrationStatus{Version: migration.Version, Name: migration.Name}
+		}
+		status.Applied = ok
+		statuses[i] = status
+	}
+	return statuses, nil
+}
+
+// Migrate aplica o revierte las migraciones hasta la versión pedida. Con DryRun
+// solo devuelve el plan sin modificar la base de datos
+func (m *mongoDBClient) Migrate(ctx context.Context, database string, request entity.MigrationRequest) (*entity.MigrationPlan, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+
+	db := m.client.Database(database)
+	applied, err := loadAppliedMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	plan, pending, err := planMigrations(applied, request)
+	if err != nil {
+		return nil, err
+	}
+	if request.DryRun {
+		return plan, nil
+	}
+
+	up := plan.Direction == config.MigrationUp
+	for _, migration := range pending {
+		start := time.Now()
+		if err := runMigration(ctx, db, migration, up); err != nil {
+			return plan, fmt.Errorf("error en la migración %03d %s: %w", migration.Version, migration.Name, err)
+		}
+		if up {
+			record := entity.MigrationStatus{
+				Version:    migration.Version,
+				Name:       migration.Name,
+				AppliedAt:  time.Now(),
+				DurationMs: time.Since(start).Milliseconds(),
+			}
+			_, err = db.Collection(schemaMigrationsCollection).ReplaceOne(ctx, bson.M{"_id": migration.Version}, record, options.Replace().SetUpsert(true))
+		} else {
+			_, err = db.Collection(schemaMigrationsCollection).DeleteOne(ctx, bson.M{"_id": migration.Version})
+		}
+		if err != nil {
+			return plan, fmt.Errorf("error al registrar la migración %03d: %w", migration.Version, err)
+		}
+		m.loggers.InfoLogger.Printf("Migración %03d %s (%s) completada en %s", migration.Version, migration.Name, plan.Direction, time.Since(start))
+	}
+	return plan, nil
+}
+
+func runMigration(ctx context.Context, db *mongo.Database, migration migration, up bool) error {
+	if up {
+		for _, step := range migration.Steps {
+			if err := step.up(ctx, db); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for i := len(migration.Steps) - 1; i >= 0; i-- {
+		if err := migration.Steps[i].down(ctx, db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadAppliedMigrations(ctx context.Context, db *mongo.Database) (map[int]entity.MigrationStatus, error) {
+	cursor, err := db.Collection(schemaMigrationsCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener las migraciones aplicadas: %w", err)
+	}
+	var records []entity.MigrationStatus
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("error al decodificar las migraciones aplicadas: %w", err)
+	}
+	applied := make(map[int]entity.MigrationStatus, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// planMigrations elige las migraciones a ejecutar, en orden, según las aplicadas
+// y la petición
+func planMigrations(applied map[int]entity.MigrationStatus, request entity.MigrationRequest) (*entity.MigrationPlan, []migration, error) {
+	if !sort.SliceIsSorted(schemaMigrations, func(i, j int) bool {
+		return schemaMigrations[i].Version < schemaMigrations[j].Version
+	}) {
+		return nil, nil, fmt.Errorf("las migraciones registradas no están ordenadas por versión")
+	}
+	direction := request.Direction
+	if direction == "" {
+		direction = config.MigrationUp
+	}
+
+	current := 0
+	for version := range applied {
+		if version > current {
+			current = version
+		}
+	}
+	latest := 0
+	if len(schemaMigrations) > 0 {
+		latest = schemaMigrations[len(schemaMigrations)-1].Version
+	}
+
+	var pending []migration
+	target := request.Target
+	switch direction {
+	case config.MigrationUp:
+		if target <= 0 || target > latest {
+			target = latest
+		}
+		for _, migration := range schemaMigrations {
+			if _, ok := applied[migration.Version]; !ok && migration.Version <= target {
+				pending = append(pending, migration)
+			}
+		}
+	case config.MigrationDown:
+		if target < 0 {
+			target = 0
+			for version := range applied {
+				if version < current && version > target {
+					target = version
+				}
+			}
+		}
+		for i := len(schemaMigrations) - 1; i >= 0; i-- {
+			migration := schemaMigrations[i]
+			if _, ok := applied[migration.Version]; ok && migration.Version > target {
+				pending = append(pending, migration)
+			}
+		}
+	default:
+		return nil, nil, fmt.Errorf("dirección de migración no válida: %s", request.Direction)
+	}
+
+	plan := &entity.MigrationPlan{
+		Direction:      direction,
+		DryRun:         request.DryRun,
+		CurrentVersion: current,
+		TargetVersion:  target,
+		Migrations:     []entity.MigrationPlanEntry{},
+	}
+	for _, migration := range pending {
+		entry := entity.MigrationPlanEntry{Version: migration.Version, Name: migration.Name}
+		for i := range migration.Steps {
+			step := migration.Steps[i]
+			if direction == config.MigrationDown {
+				step = migration.Steps[len(migration.Steps)-1-i]
+			}
+			entry.Operations = append(entry.Operations, step.describe(direction == config.MigrationUp))
+		}
+		plan.Migrations = append(plan.Migrations, entry)
+	}
+	return plan, pending, nil
+}
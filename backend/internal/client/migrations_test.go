@@ -0,0 +1,65 @@
+package client
+
+import (
+	"backend/internal/config"
+	"backend/internal/entity"
+	"reflect"
+	"testing"
+)
+
+// appliedVersions simula las migraciones registradas en schema_migrations
+func appliedVersions(versions ...int) map[int]entity.MigrationStatus {
+	applied := make(map[int]entity.MigrationStatus, len(versions))
+	for _, v := range versions {
+		applied[v] = entity.MigrationStatus{Version: v, Applied: true}
+	}
+	return applied
+}
+
+func TestPlanMigrations(t *testing.T) {
+	latest := schemaMigrations[len(schemaMigrations)-1].Version
+	all := make([]int, 0, len(schemaMigrations))
+	for _, migration := range schemaMigrations {
+		all = append(all, migration.Version)
+	}
+
+	tests := []struct {
+		name     string
+		applied  map[int]entity.MigrationStatus
+		request  entity.MigrationRequest
+		current  int
+		target   int
+		versions []int
+	}{
+		{"todas pendientes", appliedVersions(), entity.MigrationRequest{}, 0, latest, all},
+		{"hasta una versión", appliedVersions(1, 2, 3), entity.MigrationRequest{Direction: config.MigrationUp, Target: 5}, 3, 5, []int{4, 5}},
+		{"al día", appliedVersions(all...), entity.MigrationRequest{}, latest, latest, nil},
+		{"revertir la última", appliedVersions(1, 2, 3), entity.MigrationRequest{Direction: config.MigrationDown, Target: -1}, 3, 2, []int{3}},
+		{"revertir todo", appliedVersions(1, 2), entity.MigrationRequest{Direction: config.MigrationDown}, 2, 0, []int{2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, pending, err := planMigrations(tt.applied, tt.request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if plan.CurrentVersion != tt.current || plan.TargetVersion != tt.target {
+				t.Errorf("versión actual %d y objetivo %d, se esperaba %d y %d", plan.CurrentVersion, plan.TargetVersion, tt.current, tt.target)
+			}
+			var versions []int
+			for i, migration := range pending {
+				versions = append(versions, migration.Version)
+				if entry := plan.Migrations[i]; entry.Version != migration.Version || len(entry.Operations) != len(migration.Steps) {
+					t.Errorf("entrada del plan %+v no corresponde a la migración %d", entry, migration.Version)
+				}
+			}
+			if !reflect.DeepEqual(versions, tt.versions) {
+				t.Errorf("migraciones = %v, se esperaba %v", versions, tt.versions)
+			}
+		})
+	}
+
+	if _, _, err := planMigrations(appliedVersions(), entity.MigrationRequest{Direction: "sideways"}); err == nil {
+		t.Error("una dirección no válida debería devolver error")
+	}
+}
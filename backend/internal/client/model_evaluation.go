@@ -0,0 +1,562 @@
+package client
+
+import (
+	"backend/internal/analytics"
+	"backend/internal/config"
+	"backend/internal/entity"
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	evaluationStrategySplit    = "split"
+	evaluationStrategyKFold    = "kfold"
+	defaultTestSize            = 0.2
+	defaultFolds               = 5
+	defaultPassMark            = 40
+	calibrationBins            = 10
+	modelEvaluationsCollection = "model_evaluations"
+	modelsCollection           = "models"
+	// Predictor de referencia que se reajusta en cada partición de la evaluación
+	baselinePredictor            = "student_assessment_baseline"
+	evaluationTaskRegression     = "regression"
+	evaluationTaskClassification = "classification"
+)
+
+var defaultThresholds = []float64{0.3, 0.4, 0.5, 0.6, 0.7}
+
+// evaluableModel describe un modelo registrado que puede evaluarse: Kind es el
+// tipo de ejecución de predicción cuyas predicciones guardadas se puntúan
+type evaluableModel struct {
+	Task                 string
+	Kind                 string
+	PredictionCollection string
+}
+
+// Modelos disponibles para evaluación, indexados por su identificador
+var evaluableModels = map[string]evaluableModel{
+	"assessments": {Task: evaluationTaskRegression, Kind: config.PredictionKindAssessments, PredictionCollection: "prediction_assessments"},
+	"vle":         {Task: evaluationTaskClassification, Kind: config.PredictionKindVle, PredictionCollection: "prediction_vle"},
+}
+
+func (m *mongoDBClient) EvaluateModel(ctx context.Context, database, modelID string, request entity.ModelEvaluationRequest) (*entity.ModelEvaluation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	registered, ok := evaluableModels[modelID]
+	if !ok {
+		return nil, fmt.Errorf("modelo %w: %s", entity.ErrNotFound, modelID)
+	}
+	if err := normalizeEvaluationRequest(&request); err != nil {
+		return nil, err
+	}
+
+	db := m.client.Database(database)
+	runID, err := m.evaluationRunID(ctx, db, registered.Kind, request.RunID)
+	if err != nil {
+		return nil, err
+	}
+	var evaluation *entity.ModelEvaluation
+	switch registered.Kind {
+	case config.PredictionKindAssessments:
+		samples, err := m.loadPredictionSamples(ctx, db, runID, nil)
+		if err != nil {
+			return nil, err
+		}
+		evaluation, err = evaluateAssessmentModel(modelID, runID, samples, request)
+		if err != nil {
+			return nil, err
+		}
+	case config.PredictionKindVle:
+		predictions, err := loadVlePredictions(ctx, db, runID)
+		if err != nil {
+			return nil, err
+		}
+		finalResults, err := loadFinalResults(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		evaluation, err = evaluateVleModel(modelID, runID, predictions, finalResults, request)
+		if err != nil {
+			return nil, err
+		}
+	}
+	m.loggers.InfoLogger.Printf("Modelo %s evaluado con %d muestras de la ejecución %s (%s)", modelID, evaluation.SampleCount, runID, request.Strategy)
+
+	if err := m.saveModelEvaluation(ctx, db, registered, evaluation); err != nil {
+		return nil, err
+	}
+	return evaluation, nil
+}
+
+// evaluationRunID devuelve la ejecución cuyas predicciones se evalúan: la
+// indicada en la petición o, si no hay ninguna, la activa del modelo
+func (m *mongoDBClient) evaluationRunID(ctx context.Context, db *mongo.Database, kind, runID string) (string, error) {
+	if runID == "" {
+		active, err := getActiveRunID(ctx, db, kind)
+		if err != nil {
+			return "", err
+		}
+		if active == "" {
+			return "", fmt.Errorf("ejecución activa de %s %w; ejecute la predicción primero", kind, entity.ErrNotFound)
+		}
+		return active, nil
+	}
+	var run entity.PredictionRun
+	err := db.Collection(predictionRunsCollection).FindOne(ctx, bson.M{"_id": runID}).Decode(&run)
+	if err == mongo.ErrNoDocuments {
+		return "", fmt.Errorf("ejecución de predicción %w: %s", entity.ErrNotFound, runID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error al obtener la ejecución %s: %w", runID, err)
+	}
+	return runID, checkEvaluationRun(run, kind)
+}
+
+// checkEvaluationRun comprueba que la ejecución sea del modelo evaluado y haya terminado
+func checkEvaluationRun(run entity.PredictionRun, kind string) error {
+	if run.Kind != kind {
+		return fmt.Errorf("la ejecución %s es de %s, no de %s", run.RunID, run.Kind, kind)
+	}
+	if run.Status != predictionRunCompleted {
+		return fmt.Errorf("la ejecución %s no está completada (%s)", run.RunID, run.Status)
+	}
+	return nil
+}
+
+// loadVlePredictions devuelve las puntuaciones de participación de la ejecución
+func loadVlePredictions(ctx context.Context, db *mongo.Database, runID string) ([]entity.ProcessedPredictionVleResult, error) {
+	cursor, err := db.Collection("prediction_vle").Find(ctx, bson.M{"run_id": runID},
+		options.Find().SetProjection(bson.M{"activity_clicks": 0}).SetBatchSize(5000))
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener las predicciones VLE: %w", err)
+	}
+	var predictions []entity.ProcessedPredictionVleResult
+	if err := cursor.All(ctx, &predictions); err != nil {
+		return nil, fmt.Errorf("error al decodificar las predicciones VLE: %w", err)
+	}
+	return predictions, nil
+}
+
+// evaluationSample predicción guardada con su resultado real. Score es la
+// probabilidad de aprobar atribuida a la predicción y Passed la etiqueta real;
+// Predicted y Actual solo se usan en regresión
+type evaluationSample struct {
+	Stratum   string
+	Predicted float64
+	Actual    float64
+	Score     float64
+	Passed    bool
+}
+
+// assessmentEvaluationSamples trata la puntuación predicha (escalada a [0, 1])
+// como probabilidad de aprobar, y la puntuación real >= passMark como aprobado
+func assessmentEvaluationSamples(samples []entity.PredictionSample, passMark float64) []evaluationSample {
+	evaluated := make([]evaluationSample, len(samples))
+	for i, s := range samples {
+		evaluated[i] = evaluationSample{
+			Stratum:   s.CodePresentation,
+			Predicted: s.PredictedScore,
+			Actual:    s.ActualScore,
+			Score:     math.Min(math.Max(s.PredictedScore/100, 0), 1),
+			Passed:    s.ActualScore >= passMark,
+		}
+	}
+	return evaluated
+}
+
+// vleEvaluationSamples usa como probabilidad de aprobar el percentil de la
+// puntuación de participación dentro de su presentación (la escala depende de
+// la normalización configurada) y como etiqueta un resultado final Pass o
+// Distinction. Se omiten los estudiantes sin resultado final
+func vleEvaluationSamples(predictions []entity.ProcessedPredictionVleResult, finalResults map[string]string) []evaluationSample {
+	presentations := make(map[string][]int)
+	for i, p := range predictions {
+		key := p.CodeModule + "|" + p.CodePresentation
+		presentations[key] = append(presentations[key], i)
+	}
+	scores := make([]float64, len(predictions))
+	for _, indexes := range presentations {
+		values := make([]float64, len(indexes))
+		for i, idx := range indexes {
+			values[i] = predictions[idx].PredictedScore
+		}
+		for i, rank := range analytics.PercentileRanks(values) {
+			scores[indexes[i]] = rank / 100
+		}
+	}
+
+	evaluated := make([]evaluationSample, 0, len(predictions))
+	for i, p := range predictions {
+		finalResult, ok := finalResults[studentKey(p.StudentID, p.CodeModule, p.CodePresentation)]
+		if !ok || finalResult == "" {
+			continue
+		}
+		evaluated = append(evaluated, evaluationSample{
+			Stratum:   p.CodePresentation,
+			Predicted: p.PredictedScore,
+			Score:     scores[i],
+			Passed:    passedCourse(finalResult),
+		})
+	}
+	return evaluated
+}
+
+// evaluateAssessmentModel puntúa las predicciones guardadas del modelo de
+// evaluaciones y, como comparación, el predictor de referencia reajustado en
+// las mismas particiones
+func evaluateAssessmentModel(modelID, runID string, samples []entity.PredictionSample, request entity.ModelEvaluationRequest) (*entity.ModelEvaluation, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no hay predicciones con puntuación real en la ejecución %s para evaluar el modelo %s", runID, modelID)
+	}
+	evaluated := assessmentEvaluationSamples(samples, request.PassMark)
+	evaluation := evaluateSamples(modelID, runID, evaluationTaskRegression, evaluated, request)
+
+	folds, count := evaluationFolds(evaluationStrata(evaluated), request)
+	baseline := evaluatePartitions(folds, count, true, request, func(train, test []int) []evaluationSample {
+		return assessmentEvaluationSamples(fitBaseline(pickSamples(samples, train)).predict(pickSamples(samples, test)), request.PassMark)
+	})
+	evaluation.Baseline = &entity.BaselineEvaluation{
+		Predictor:      baselinePredictor,
+		SampleCount:    baseline.SampleCount,
+		Regression:     baseline.Regression,
+		Classification: baseline.Classification,
+		FoldResults:    baseline.FoldResults,
+	}
+	return evaluation, nil
+}
+
+// evaluateVleModel puntúa las puntuaciones de participación guardadas como
+// clasificador del resultado final del curso
+func evaluateVleModel(modelID, runID string, predictions []entity.ProcessedPredictionVleResult, finalResults map[string]string, request entity.ModelEvaluationRequest) (*entity.ModelEvaluation, error) {
+	evaluated := vleEvaluationSamples(predictions, finalResults)
+	if len(evaluated) == 0 {
+		return nil, fmt.Errorf("no hay predicciones con resultado final en la ejecución %s para evaluar el modelo %s", runID, modelID)
+	}
+	return evaluateSamples(modelID, runID, evaluationTaskClassification, evaluated, request), nil
+}
+
+// evaluateSamples calcula las métricas de las predicciones guardadas sobre la
+// parte retenida (split) o sobre cada pliegue (kfold). Las predicciones no se
+// reajustan: las particiones solo acotan qué muestras se puntúan
+func evaluateSamples(modelID, runID, task string, samples []evaluationSample, request entity.ModelEvaluationRequest) *entity.ModelEvaluation {
+	evaluation := &entity.ModelEvaluation{
+		ModelID:     modelID,
+		RunID:       runID,
+		Task:        task,
+		Strategy:    request.Strategy,
+		Seed:        request.Seed,
+		PassMark:    request.PassMark,
+		EvaluatedAt: time.Now(),
+	}
+	switch request.Strategy {
+	case evaluationStrategySplit:
+		evaluation.TestSize = request.TestSize
+	case evaluationStrategyKFold:
+		evaluation.Folds = request.Folds
+	}
+
+	folds, count := evaluationFolds(evaluationStrata(samples), request)
+	result := evaluatePartitions(folds, count, task == evaluationTaskRegression, request, func(train, test []int) []evaluationSample {
+		return pickSamples(samples, test)
+	})
+	evaluation.SampleCount = result.SampleCount
+	evaluation.Regression = result.Regression
+	evaluation.Classification = result.Classification
+	evaluation.FoldResults = result.FoldResults
+	return evaluation
+}
+
+func evaluationStrata(samples []evaluationSample) []string {
+	strata := make([]string, len(samples))
+	for i, s := range samples {
+		strata[i] = s.Stratum
+	}
+	return strata
+}
+
+// evaluationFolds asigna cada muestra a una partición de prueba: con split solo
+// hay una (la parte retenida; el resto queda en -1) y con kfold una por pliegue
+func evaluationFolds(strata []string, request entity.ModelEvaluationRequest) ([]int, int) {
+	if request.Strategy == evaluationStrategyKFold {
+		return analytics.StratifiedFolds(strata, request.Folds, request.Seed), request.Folds
+	}
+	folds := make([]int, len(strata))
+	for i, test := range analytics.StratifiedSplit(strata, request.TestSize, request.Seed) {
+		if !test {
+			folds[i] = -1
+		}
+	}
+	return folds, 1
+}
+
+type partitionMetrics struct {
+	SampleCount    int
+	Regression     *entity.RegressionMetrics
+	Classification entity.ClassificationMetrics
+	FoldResults    []entity.FoldEvaluation
+}
+
+// evaluatePartitions puntúa lo que predict devuelve para la parte de prueba de
+// cada partición. Con kfold las métricas globales se calculan sobre todas las
+// predicciones fuera de pliegue
+func evaluatePartitions(folds []int, count int, regression bool, request entity.ModelEvaluationRequest, predict func(train, test []int) []evaluationSample) partitionMetrics {
+	var result partitionMetrics
+	var all []evaluationSample
+	for fold := 0; fold < count; fold++ {
+		var train, test []int
+		for i, f := range folds {
+			if f == fold {
+				test = append(test, i)
+			} else {
+				train = append(train, i)
+			}
+		}
+		predicted := predict(train, test)
+		all = append(all, predicted...)
+		if request.Strategy == evaluationStrategyKFold {
+			result.FoldResults = append(result.FoldResults, entity.FoldEvaluation{
+				Fold:           fold,
+				Regression:     regressionMetrics(predicted, regression),
+				Classification: classificationMetrics(predicted, request),
+			})
+		}
+	}
+	result.SampleCount = len(all)
+	result.Regression = regressionMetrics(all, regression)
+	result.Classification = classificationMetrics(all, request)
+	return result
+}
+
+func pickSamples[T any](samples []T, indexes []int) []T {
+	picked := make([]T, len(indexes))
+	for i, idx := range indexes {
+		picked[i] = samples[idx]
+	}
+	return picked
+}
+
+// baselineModel predictor aditivo de referencia: media global más el efecto de
+// la evaluación y el efecto del estudiante, estimados solo con entrenamiento
+type baselineModel struct {
+	mean        float64
+	assessments map[int]float64
+	students    map[int]float64
+}
+
+// fitBaseline estima los efectos en dos pasadas: primero el de cada evaluación
+// sobre la media global y después el de cada estudiante sobre el residuo
+func fitBaseline(train []entity.PredictionSample) baselineModel {
+	model := baselineModel{assessments: make(map[int]float64), students: make(map[int]float64)}
+	if len(train) == 0 {
+		return model
+	}
+	for _, s := range train {
+		model.mean += s.ActualScore
+	}
+	model.mean /= float64(len(train))
+
+	counts := make(map[int]int)
+	for _, s := range train {
+		model.assessments[s.AssessmentID] += s.ActualScore - model.mean
+		counts[s.AssessmentID]++
+	}
+	for id := range model.assessments {
+		model.assessments[id] /= float64(counts[id])
+	}
+
+	counts = make(map[int]int)
+	for _, s := range train {
+		model.students[s.StudentID] += s.ActualScore - model.mean - model.assessments[s.AssessmentID]
+		counts[s.StudentID]++
+	}
+	for id := range model.students {
+		model.students[id] /= float64(counts[id])
+	}
+	return model
+}
+
+// predict devuelve una copia de las muestras con la puntuación predicha por el
+// modelo, acotada a [0, 100]; los efectos desconocidos cuentan como cero
+func (b baselineModel) predict(samples []entity.PredictionSample) []entity.PredictionSample {
+	predicted := make([]entity.PredictionSample, len(samples))
+	for i, s := range samples {
+		s.PredictedScore = math.Min(math.Max(b.mean+b.assessments[s.AssessmentID]+b.students[s.StudentID], 0), 100)
+		predicted[i] = s
+	}
+	return predicted
+}
+
+// normalizeEvaluationRequest valida la petición y completa los valores por defecto
+func normalizeEvaluationRequest(request *entity.ModelEvaluationRequest) error {
+	if request.Strategy == "" {
+		request.Strategy = evaluationStrategySplit
+	}
+	switch request.Strategy {
+	case evaluationStrategySplit:
+		if request.TestSize == 0 {
+			request.TestSize = defaultTestSize
+		}
+		if request.TestSize <= 0 || request.TestSize >= 1 {
+			return fmt.Errorf("test_size debe estar entre 0 y 1: %v", request.TestSize)
+		}
+	case evaluationStrategyKFold:
+		if request.Folds == 0 {
+			request.Folds = defaultFolds
+		}
+		if request.Folds < 2 {
+			return fmt.Errorf("folds debe ser al menos 2: %d", request.Folds)
+		}
+	default:
+		return fmt.Errorf("estrategia de evaluación no soportada: %s", request.Strategy)
+	}
+	if request.PassMark == 0 {
+		request.PassMark = defaultPassMark
+	}
+	if len(request.Thresholds) == 0 {
+		request.Thresholds = defaultThresholds
+	}
+	return nil
+}
+
+// loadPredictionSamples une las predicciones de la ejecución indicada (o de la
+// activa si runID está vacío) con studentAssessment por (estudiante, evaluación)
+// y con assessments para obtener la presentación. filter restringe opcionalmente
+// las predicciones (por ejemplo, a un estudiante)
+func (m *mongoDBClient) loadPredictionSamples(ctx context.Context, db *mongo.Database, runID string, filter bson.M) ([]entity.PredictionSample, error) {
+	runFilter := bson.M{"run_id": runID}
+	if runID == "" {
+		var err error
+		runFilter, err = activeRunMatch(ctx, db, config.PredictionKindAssessments)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for key, value := range filter {
+		runFilter[key] = value
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: runFilter}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": "studentAssessment",
+			"let":  bson.M{"student": "$student_id", "assessment": "$assessment_id"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"$expr": bson.M{"$and": bson.A{
+					bson.M{"$eq": bson.A{"$idstudent", "$$student"}},
+					bson.M{"$eq": bson.A{"$idassessment", "$$assessment"}},
+				}}}},
+				bson.M{"$project": bson.M{"_id": 0, "score": 1}},
+			},
+			"as": "actual",
+		}}},
+		{{Key: "$unwind", Value: "$actual"}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "assessments",
+			"localField":   "assessment_id",
+			"foreignField": "idassessment",
+			"as":           "assessment",
+		}}},
+		{{Key: "$unwind", Value: "$assessment"}},
+		{{Key: "$project", Value: bson.M{
+			"_id":               0,
+			"student_id":        1,
+			"assessment_id":     1,
+			"predicted_score":   1,
+			"actual_score":      "$actual.score",
+			"code_module":       "$assessment.codemodule",
+			"code_presentation": "$assessment.codepresentation",
+			"assessment_type":   "$assessment.assessmenttype",
+		}}},
+	}
+
+	opts := options.Aggregate().SetAllowDiskUse(true).SetBatchSize(5000)
+	cursor, err := db.Collection("prediction_assessments").Aggregate(ctx, pipeline, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error al unir predicciones con puntuaciones reales: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var samples []entity.PredictionSample
+	if err := cursor.All(ctx, &samples); err != nil {
+		return nil, fmt.Errorf("error al decodificar muestras de predicción: %w", err)
+	}
+	return samples, nil
+}
+
+// saveModelEvaluation guarda la evaluación en el historial y la asocia al modelo
+func (m *mongoDBClient) saveModelEvaluation(ctx context.Context, db *mongo.Database, registered evaluableModel, evaluation *entity.ModelEvaluation) error {
+	if _, err := db.Collection(modelEvaluationsCollection).InsertOne(ctx, evaluation); err != nil {
+		return fmt.Errorf("error al guardar la evaluación: %w", err)
+	}
+	_, err := db.Collection(modelsCollection).UpdateOne(ctx,
+		bson.M{"_id": evaluation.ModelID},
+		bson.M{"$set": bson.M{
+			"task":                  registered.Task,
+			"prediction_collection": registered.PredictionCollection,
+			"last_evaluation":       evaluation,
+			"updated_at":            evaluation.EvaluatedAt,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("error al actualizar el modelo %s: %w", evaluation.ModelID, err)
+	}
+	m.loggers.InfoLogger.Printf("Evaluación del modelo %s guardada", evaluation.ModelID)
+	return nil
+}
+
+// regressionMetrics devuelve nil si el modelo no es de regresión
+func regressionMetrics(samples []evaluationSample, regression bool) *entity.RegressionMetrics {
+	if !regression {
+		return nil
+	}
+	predicted := make([]float64, len(samples))
+	actual := make([]float64, len(samples))
+	for i, s := range samples {
+		predicted[i] = s.Predicted
+		actual[i] = s.Actual
+	}
+	rmse, mae, r2 := analytics.RegressionMetrics(predicted, actual)
+	return &entity.RegressionMetrics{Count: len(samples), RMSE: rmse, MAE: mae, R2: r2}
+}
+
+// classificationMetrics trata Score como probabilidad de aprobar y Passed como
+// etiqueta positiva; la matriz de confusión usa el umbral PassMark / 100
+func classificationMetrics(samples []evaluationSample, request entity.ModelEvaluationRequest) entity.ClassificationMetrics {
+	scores := make([]float64, len(samples))
+	labels := make([]bool, len(samples))
+	for i, s := range samples {
+		scores[i] = s.Score
+		labels[i] = s.Passed
+	}
+
+	metrics := entity.ClassificationMetrics{AUC: analytics.AUC(scores, labels)}
+	for _, t := range request.Thresholds {
+		precision, recall := analytics.PrecisionRecall(scores, labels, t)
+		metrics.Thresholds = append(metrics.Thresholds, entity.ThresholdMetrics{
+			Threshold: t,
+			Precision: precision,
+			Recall:    recall,
+		})
+	}
+	threshold := request.PassMark / 100
+	tp, fp, tn, fn := analytics.Confusion(scores, labels, threshold)
+	metrics.ConfusionMatrix = entity.ConfusionMatrix{
+		Threshold:     threshold,
+		TruePositive:  tp,
+		FalsePositive: fp,
+		TrueNegative:  tn,
+		FalseNegative: fn,
+	}
+	for _, b := range analytics.Calibration(scores, labels, calibrationBins) {
+		metrics.Calibration = append(metrics.Calibration, entity.CalibrationBin(b))
+	}
+	return metrics
+}
@@ -0,0 +1,178 @@
+package client
+
+import (
+	"backend/internal/entity"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeEvaluationRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		request entity.ModelEvaluationRequest
+		want    entity.ModelEvaluationRequest
+		wantErr bool
+	}{
+		{
+			name:    "valores por defecto",
+			request: entity.ModelEvaluationRequest{},
+			want:    entity.ModelEvaluationRequest{Strategy: evaluationStrategySplit, TestSize: defaultTestSize, PassMark: defaultPassMark, Thresholds: defaultThresholds},
+		},
+		{
+			name:    "kfold",
+			request: entity.ModelEvaluationRequest{Strategy: evaluationStrategyKFold, PassMark: 50, Thresholds: []float64{0.5}},
+			want:    entity.ModelEvaluationRequest{Strategy: evaluationStrategyKFold, Folds: defaultFolds, PassMark: 50, Thresholds: []float64{0.5}},
+		},
+		{name: "test_size fuera de rango", request: entity.ModelEvaluationRequest{TestSize: 1.5}, wantErr: true},
+		{name: "un solo pliegue", request: entity.ModelEvaluationRequest{Strategy: evaluationStrategyKFold, Folds: 1}, wantErr: true},
+		{name: "estrategia desconocida", request: entity.ModelEvaluationRequest{Strategy: "bootstrap"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := tt.request
+			err := normalizeEvaluationRequest(&request)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(request, tt.want) {
+				t.Errorf("petición = %+v, se esperaba %+v", request, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluationFolds(t *testing.T) {
+	strata := []string{"2013J", "2013J", "2013J", "2013J", "2013J", "2014B", "2014B", "2014B", "2014B", "2014B"}
+	tests := []struct {
+		name    string
+		request entity.ModelEvaluationRequest
+		count   int
+		test    int
+	}{
+		{"split", entity.ModelEvaluationRequest{Strategy: evaluationStrategySplit, TestSize: 0.2, Seed: 1}, 1, 2},
+		{"kfold", entity.ModelEvaluationRequest{Strategy: evaluationStrategyKFold, Folds: 5, Seed: 1}, 5, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folds, count := evaluationFolds(strata, tt.request)
+			if count != tt.count {
+				t.Errorf("count = %d, se esperaba %d", count, tt.count)
+			}
+			test := 0
+			for _, f := range folds {
+				if f >= count || f < -1 {
+					t.Fatalf("partición fuera de rango: %d", f)
+				}
+				if f >= 0 {
+					test++
+				}
+			}
+			if test != tt.test {
+				t.Errorf("%d muestras de prueba, se esperaban %d", test, tt.test)
+			}
+		})
+	}
+}
+
+func TestAssessmentEvaluationSamples(t *testing.T) {
+	samples := []entity.PredictionSample{
+		{CodePresentation: "2013J", PredictedScore: 55, ActualScore: 40},
+		{CodePresentation: "2013J", PredictedScore: 120, ActualScore: 39},
+		{CodePresentation: "2014B", PredictedScore: -5, ActualScore: 90},
+	}
+	want := []evaluationSample{
+		{Stratum: "2013J", Predicted: 55, Actual: 40, Score: 0.55, Passed: true},
+		{Stratum: "2013J", Predicted: 120, Actual: 39, Score: 1, Passed: false},
+		{Stratum: "2014B", Predicted: -5, Actual: 90, Score: 0, Passed: true},
+	}
+	if got := assessmentEvaluationSamples(samples, 40); !reflect.DeepEqual(got, want) {
+		t.Errorf("assessmentEvaluationSamples = %+v, se esperaba %+v", got, want)
+	}
+}
+
+func TestVleEvaluationSamples(t *testing.T) {
+	predictions := []entity.ProcessedPredictionVleResult{
+		{StudentID: 1, CodeModule: "AAA", CodePresentation: "2013J", PredictedScore: 10},
+		{StudentID: 2, CodeModule: "AAA", CodePresentation: "2013J", PredictedScore: 20},
+		{StudentID: 3, CodeModule: "AAA", CodePresentation: "2013J", PredictedScore: 30},
+		{StudentID: 4, CodeModule: "BBB", CodePresentation: "2014B", PredictedScore: 5},
+	}
+	finalResults := map[string]string{
+		studentKey(1, "AAA", "2013J"): "Fail",
+		studentKey(2, "AAA", "2013J"): "Pass",
+		studentKey(3, "AAA", "2013J"): "",
+		studentKey(4, "BBB", "2014B"): "Distinction",
+	}
+	// El percentil se calcula dentro de cada presentación, incluido el estudiante
+	// sin resultado final, que después se omite
+	want := []evaluationSample{
+		{Stratum: "2013J", Predicted: 10, Score: 1.0 / 6, Passed: false},
+		{Stratum: "2013J", Predicted: 20, Score: 0.5, Passed: true},
+		{Stratum: "2014B", Predicted: 5, Score: 0.5, Passed: true},
+	}
+	got := vleEvaluationSamples(predictions, finalResults)
+	if len(got) != len(want) {
+		t.Fatalf("vleEvaluationSamples = %+v, se esperaba %+v", got, want)
+	}
+	for i := range want {
+		if got[i].Stratum != want[i].Stratum || got[i].Predicted != want[i].Predicted ||
+			math.Abs(got[i].Score-want[i].Score) > 1e-9 || got[i].Passed != want[i].Passed {
+			t.Errorf("muestra %d = %+v, se esperaba %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFitBaseline(t *testing.T) {
+	model := fitBaseline([]entity.PredictionSample{
+		{StudentID: 1, AssessmentID: 10, ActualScore: 60},
+		{StudentID: 1, AssessmentID: 20, ActualScore: 80},
+		{StudentID: 2, AssessmentID: 10, ActualScore: 40},
+	})
+	predicted := model.predict([]entity.PredictionSample{
+		{StudentID: 2, AssessmentID: 20},
+		{StudentID: 3, AssessmentID: 10},
+		{StudentID: 3, AssessmentID: 99},
+	})
+	// Media 60; efecto de la evaluación 10: -10, de la 20: +20; efecto del
+	// estudiante 1: +5, del 2: -10
+	want := []float64{70, 50, 60}
+	for i, p := range predicted {
+		if math.Abs(p.PredictedScore-want[i]) > 1e-9 {
+			t.Errorf("predicción %d = %v, se esperaba %v", i, p.PredictedScore, want[i])
+		}
+	}
+	if empty := fitBaseline(nil).predict([]entity.PredictionSample{{StudentID: 1}}); empty[0].PredictedScore != 0 {
+		t.Errorf("sin entrenamiento la predicción debería ser 0: %v", empty[0].PredictedScore)
+	}
+}
+
+func TestEvaluateSamples(t *testing.T) {
+	var samples []evaluationSample
+	for i := 0; i < 20; i++ {
+		passed := i%2 == 0
+		score := 0.2
+		if passed {
+			score = 0.8
+		}
+		samples = append(samples, evaluationSample{Stratum: "2013J", Score: score, Passed: passed, Predicted: score * 100, Actual: score * 100})
+	}
+	request := entity.ModelEvaluationRequest{Strategy: evaluationStrategyKFold, Folds: 4, PassMark: 50, Thresholds: []float64{0.5}}
+
+	evaluation := evaluateSamples("vle", "run-1", evaluationTaskClassification, samples, request)
+	if evaluation.SampleCount != len(samples) || len(evaluation.FoldResults) != 4 || evaluation.Folds != 4 {
+		t.Fatalf("evaluación = %+v", evaluation)
+	}
+	if evaluation.Regression != nil {
+		t.Error("un modelo de clasificación no debería tener métricas de regresión")
+	}
+	matrix := evaluation.Classification.ConfusionMatrix
+	if evaluation.Classification.AUC != 1 || matrix.TruePositive != 10 || matrix.TrueNegative != 10 || matrix.Threshold != 0.5 {
+		t.Errorf("clasificación = %+v", evaluation.Classification)
+	}
+
+	regression := evaluateSamples("assessments", "run-1", evaluationTaskRegression, samples, request)
+	if regression.Regression == nil || regression.Regression.Count != len(samples) || regression.Regression.RMSE != 0 {
+		t.Errorf("regresión = %+v", regression.Regression)
+	}
+}
@@ -1,11 +1,15 @@
 package client
 
 import (
+	"backend/internal/analytics"
 	"backend/internal/config"
 	"backend/internal/entity"
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -16,41 +20,47 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type mongoDBClient struct {
-	client  *mongo.Client
-	URI     string
-	loggers *entity.Loggers
-}
+const (
+	outOfRangeBucket = "Fuera de rango"
+	// Anchura de los tramos del histograma materializado de puntuaciones
+	scoreHistogramWidth = 1.0
+	// Coeficiente del modelo lineal de predicción de evaluaciones
+	assessmentScoreCoefficient = 1.05
+)
+
+var (
+	// Rangos por defecto de la distribución de puntuaciones; el último queda abierto
+	defaultScoreEdges = []float64{0, 60, 70, 80, 90, math.Inf(1)}
+	scorePercentiles  = []float64{0.1, 0.25, 0.5, 0.75, 0.9}
+)
 
-type MongoDBClient interface {
-	Connect() error
-	Disconnect() error
-	InsertOne(database, collection string, document interface{}) (*mongo.InsertOneResult, error)
-	InsertMany(database, collection string, documents []interface{}) (*mongo.InsertManyResult, error)
-	BatchInsert(database, collection string, documents []interface{}, batchSize int) error
-	GetData(database, collection string) ([]interface{}, error)
-	GetAllCountData(database string, colls []string) (map[string]int64, error)
-	ProcessDataPredictionAssessments(database string) ([]entity.ProcessedPredictionAssessmentResult, error)
-	ProcessDataVlePredictions(database string) ([]entity.ProcessedPredictionVleResult, error)
-	GetScoreDistributionPredictionAssessments(database string) ([]entity.ScoreRangePredictionAssessments, error)
-	GetAveragePredictedScoreByAssessmentType(database string) ([]entity.AssessmentTypeAverage, error)
-	GetStudentCountByAssessmentID(database string) ([]entity.AssessmentStudentCount, error)
+type mongoDBClient struct {
+	client   *mongo.Client
+	URI      string
+	auditKey []byte
+	loggers  *entity.Loggers
 }
 
-func NewMongoDBClient(loggers *entity.Loggers) MongoDBClient {
+func NewMongoDBClient(loggers *entity.Loggers) Client {
 	dbcredentials, _, err := config.DBCredentials()
 	if err != nil {
 		loggers.ErrorLogger.Printf("Error al obtener las credenciales de la base de datos: %v", err)
 		return nil
 	}
+	auditKey, err := erasureAuditKey()
+	if err != nil {
+		loggers.ErrorLogger.Printf("Error en la configuración de la auditoría de supresiones: %v", err)
+		return nil
+	}
 	return &mongoDBClient{
-		URI:     dbcredentials.URI,
-		loggers: loggers,
+		URI:      dbcredentials.URI,
+		auditKey: auditKey,
+		loggers:  loggers,
 	}
 }
 
-func (m *mongoDBClient) Connect() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (m *mongoDBClient) Connect(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 	clientOptions := options.Client().
 		ApplyURI(m.URI).
@@ -75,8 +85,8 @@ func (m *mongoDBClient) Connect() error {
 	return nil
 }
 
-func (m *mongoDBClient) Disconnect() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (m *mongoDBClient) Disconnect(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	if err := m.client.Disconnect(ctx); err != nil {
@@ -88,8 +98,8 @@ func (m *mongoDBClient) Disconnect() error {
 	return nil
 }
 
-func (m *mongoDBClient) InsertOne(database, collection string, document interface{}) (*mongo.InsertOneResult, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (m *mongoDBClient) InsertOne(ctx context.Context, database, collection string, document interface{}) (*mongo.InsertOneResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	col := m.client.Database(database).Collection(collection)
@@ -103,22 +113,41 @@ func (m *mongoDBClient) InsertOne(database, collection string, document interfac
 	return result, nil
 }
 
-func (m *mongoDBClient) InsertMany(database, collection string, documents []interface{}) (*mongo.InsertManyResult, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (m *mongoDBClient) InsertMany(ctx context.Context, database, collection string, documents []interface{}) (*mongo.InsertManyResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	col := m.client.Database(database).Collection(collection)
-	result, err := col.InsertMany(ctx, documents)
+	// Sin orden, los documentos que rechaza el validador no impiden insertar el resto del lote
+	result, err := col.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
 	if err != nil {
-		m.loggers.ErrorLogger.Fatalf("Error al insertar documentos: %v", err)
-		return nil, err
+		var bulkErr mongo.BulkWriteException
+		if errors.As(err, &bulkErr) {
+			rejected := 0
+			for _, writeErr := range bulkErr.WriteErrors {
+				if writeErr.Code == mongoDocumentValidationFailure {
+					rejected++
+				}
+			}
+			if rejected > 0 {
+				m.loggers.ErrorLogger.Printf("%d documentos rechazados por el validador de %s", rejected, collection)
+			}
+		}
+		m.loggers.ErrorLogger.Printf("Error al insertar documentos: %v", err)
+		return result, err
 	}
 
 	return result, nil
 }
 
-func (m *mongoDBClient) BatchInsert(database, collection string, documents []interface{}, batchSize int) error {
+// BatchInsert inserta los documentos en lotes concurrentes y devuelve error si
+// algún lote falla; los documentos rechazados por el validador se cuentan aparte
+// para que la carga no se dé por buena con filas perdidas
+func (m *mongoDBClient) BatchInsert(ctx context.Context, database, collection string, documents []interface{}, batchSize int) error {
 	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	rejected := 0
 	docCount := len(documents)
 	batches := (docCount + batchSize - 1) / batchSize
 
@@ -139,16 +168,36 @@ func (m *mongoDBClient) BatchInsert(database, collection string, documents []int
 			defer wg.Done()
 			defer func() { <-guard }()
 
-			if _, err := m.InsertMany(database, collection, batch); err != nil {
+			if _, err := m.InsertMany(ctx, database, collection, batch); err != nil {
 				m.loggers.ErrorLogger.Printf("Error al insertar lote: %v", err)
+				mu.Lock()
+				defer mu.Unlock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				var bulkErr mongo.BulkWriteException
+				if errors.As(err, &bulkErr) {
+					for _, writeErr := range bulkErr.WriteErrors {
+						if writeErr.Code == mongoDocumentValidationFailure {
+							rejected++
+						}
+					}
+				}
 			}
 		}(documents[start:end])
 	}
 
 	wg.Wait()
+	if rejected > 0 {
+		return fmt.Errorf("%d de %d documentos rechazados por el validador de %s: %w", rejected, docCount, collection, firstErr)
+	}
+	if firstErr != nil {
+		return fmt.Errorf("error al insertar en %s: %w", collection, firstErr)
+	}
 	return nil
 }
-func (m *mongoDBClient) GetAllCountData(database string, colls []string) (map[string]int64, error) {
+
+func (m *mongoDBClient) GetAllCountData(ctx context.Context, database string, colls []string) (map[string]int64, error) {
 	data := make(map[string]int64)
 	var mu sync.Mutex // Mutex para evitar condiciones de carrera
 	var wg sync.WaitGroup
@@ -158,7 +207,7 @@ func (m *mongoDBClient) GetAllCountData(database string, colls []string) (map[st
 		wg.Add(1)
 		go func(coll string) {
 			defer wg.Done()
-			count, err := m.GetCount(database, coll)
+			count, err := m.GetCount(ctx, database, coll)
 			if err != nil {
 				m.loggers.ErrorLogger.Printf("Error al obtener los datos de la colección %v: %v", coll, err)
 				if firstErr == nil {
@@ -181,12 +230,12 @@ func (m *mongoDBClient) GetAllCountData(database string, colls []string) (map[st
 	return data, nil
 }
 
-func (m *mongoDBClient) GetCount(database, collection string) (int64, error) {
+func (m *mongoDBClient) GetCount(ctx context.Context, database, collection string) (int64, error) {
 	var count int64
 	var err error
 
 	for retries := 0; retries < 3; retries++ { // Reintenta hasta 3 veces
-		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second) // Aumenta el tiempo de espera
+		ctx, cancel := context.WithTimeout(ctx, 60*time.Second) // Aumenta el tiempo de espera
 		defer cancel()
 
 		col := m.client.Database(database).Collection(collection)
@@ -201,11 +250,11 @@ func (m *mongoDBClient) GetCount(database, collection string) (int64, error) {
 	return 0, err // Retorna el error si fallan todos los reintentos
 }
 
-func (m *mongoDBClient) GetData(database, collection string) ([]interface{}, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+func (m *mongoDBClient) GetData(ctx context.Context, database, collection string) ([]interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
-	_, err := m.collectionExists(database, collection)
+	_, err := m.collectionExists(ctx, database, collection)
 	if err != nil {
 		m.loggers.ErrorLogger.Printf("Error coleccion inexistente: %v", err)
 		return nil, err
@@ -238,8 +287,8 @@ func (m *mongoDBClient) GetData(database, collection string) ([]interface{}, err
 	return results, nil
 }
 
-func (m *mongoDBClient) collectionExists(database, collection string) (bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (m *mongoDBClient) collectionExists(ctx context.Context, database, collection string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	db := m.client.Database(database)
@@ -259,8 +308,8 @@ func (m *mongoDBClient) collectionExists(database, collection string) (bool, err
 
 // PREDICTIONS ASSESSMENTS
 
-func (m *mongoDBClient) ProcessDataPredictionAssessments(database string) ([]entity.ProcessedPredictionAssessmentResult, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+func (m *mongoDBClient) ProcessDataPredictionAssessments(ctx context.Context, database string) ([]entity.ProcessedPredictionAssessmentResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
 	defer cancel()
 
 	fmt.Println("database: ", database)
@@ -272,13 +321,20 @@ func (m *mongoDBClient) ProcessDataPredictionAssessments(database string) ([]ent
 	predictionAssessmentCollection := db.Collection("prediction_assessments")
 	batchSize := 5000
 	fmt.Println("Procesando datos de studentAssessment...")
-	
+
+	runID, err := startPredictionRun(ctx, db, config.PredictionKindAssessments)
+	if err != nil {
+		return nil, err
+	}
+	m.loggers.InfoLogger.Printf("Ejecución de predicción de evaluaciones: %s", runID)
+
 	opts := options.Find().SetProjection(bson.M{"idstudent": 1, "score": 1, "idassessment": 1})
 
 	cursor, err := studentAssessmentCollection.Find(ctx, bson.M{}, opts)
 	fmt.Println("Cursor: ", cursor)
 	if err != nil {
-		log.Fatalf("Error al obtener los datos de studentAssessment: %v", err)
+		m.finishPredictionRun(ctx, db, config.PredictionKindAssessments, runID, 0, err)
+		m.loggers.ErrorLogger.Printf("Error al obtener los datos de studentAssessment: %v", err)
 		return nil, err
 	}
 	defer cursor.Close(ctx)
@@ -287,28 +343,46 @@ func (m *mongoDBClient) ProcessDataPredictionAssessments(database string) ([]ent
 	batch := make([]bson.M, 0, batchSize)
 	var allPredictions []entity.ProcessedPredictionAssessmentResult
 
-	// Iterar sobre los documentos
-	for cursor.Next(ctx) {
+	// Iterar sobre los documentos; cualquier error marca la ejecución como fallida
+	var runErr error
+	for runErr == nil && cursor.Next(ctx) {
 		var assessment bson.M
 		if err := cursor.Decode(&assessment); err != nil {
-			log.Fatalf("Error al decodificar evaluación: %v", err)
+			runErr = fmt.Errorf("error al decodificar evaluación: %w", err)
+			break
 		}
 		log.Printf("Procesando evaluación: %v", assessment)
 		batch = append(batch, assessment)
 
 		// Procesar el batch cuando alcance el tamaño adecuado
 		if len(batch) == batchSize {
-			predictions := m.processAssessmentBatch(ctx, batch, predictionAssessmentCollection)
+			predictions, err := m.processAssessmentBatch(ctx, runID, batch, predictionAssessmentCollection)
+			if err != nil {
+				runErr = err
+				break
+			}
 			allPredictions = append(allPredictions, predictions...)
 			batch = batch[:0] // Reiniciar el batch
+			runErr = ctx.Err()
 		}
 	}
+	if runErr == nil {
+		runErr = cursor.Err()
+	}
 
 	// Procesar cualquier lote restante
-	if len(batch) > 0 {
-		predictions := m.processAssessmentBatch(ctx, batch, predictionAssessmentCollection)
+	if runErr == nil && len(batch) > 0 {
+		predictions, err := m.processAssessmentBatch(ctx, runID, batch, predictionAssessmentCollection)
+		if err != nil {
+			runErr = err
+		}
 		allPredictions = append(allPredictions, predictions...)
 	}
+	if runErr != nil {
+		m.finishPredictionRun(ctx, db, config.PredictionKindAssessments, runID, 0, runErr)
+		m.loggers.ErrorLogger.Printf("Error en la ejecución %s: %v", runID, runErr)
+		return nil, runErr
+	}
 
 	// Comprobar si se generaron predicciones
 	if len(allPredictions) == 0 {
@@ -317,52 +391,61 @@ func (m *mongoDBClient) ProcessDataPredictionAssessments(database string) ([]ent
 		log.Printf("Total de predicciones generadas: %d", len(allPredictions))
 	}
 
+	if err := m.finishPredictionRun(ctx, db, config.PredictionKindAssessments, runID, len(allPredictions), nil); err != nil {
+		return nil, err
+	}
+
 	return allPredictions, nil
 }
 
-func (m *mongoDBClient) processAssessmentBatch(ctx context.Context, assessments []bson.M, collection *mongo.Collection) []entity.ProcessedPredictionAssessmentResult {
-	batchPredictions := []interface{}{}
+func (m *mongoDBClient) processAssessmentBatch(ctx context.Context, runID string, assessments []bson.M, collection *mongo.Collection) ([]entity.ProcessedPredictionAssessmentResult, error) {
+	batchPredictions := []mongo.WriteModel{}
 	var processedResults []entity.ProcessedPredictionAssessmentResult
 
 	for _, assessment := range assessments {
 		studentIDRaw, ok := assessment["idstudent"]
 		if !ok {
-			log.Fatalf("Error: 'idstudent' no encontrado en el documento: %v", assessment)
+			return nil, fmt.Errorf("'idstudent' no encontrado en el documento: %v", assessment)
 		}
 		assessmentIDRaw, ok := assessment["idassessment"]
 		if !ok {
-			log.Fatalf("Error: 'idassessment' no encontrado en el documento: %v", assessment)
+			return nil, fmt.Errorf("'idassessment' no encontrado en el documento: %v", assessment)
 		}
 		scoreRaw, ok := assessment["score"]
 		if !ok {
-			log.Fatalf("Error: 'score' no encontrado en el documento: %v", assessment)
+			return nil, fmt.Errorf("'score' no encontrado en el documento: %v", assessment)
 		}
 
 		// Convertir los campos
 		studentID, err := m.convertStudentID(studentIDRaw)
 		if err != nil {
-			log.Fatalf("Error al convertir 'idstudent': %v", err)
+			return nil, fmt.Errorf("error al convertir 'idstudent': %w", err)
 		}
 		assessmentID, err := m.convertAssessmentID(assessmentIDRaw)
 		if err != nil {
-			log.Fatalf("Error al convertir 'idassessment': %v", err)
+			return nil, fmt.Errorf("error al convertir 'idassessment': %w", err)
 		}
 		score, err := m.convertScore(scoreRaw)
 		if err != nil {
-			log.Fatalf("Error al convertir 'score': %v", err)
+			return nil, fmt.Errorf("error al convertir 'score': %w", err)
 		}
 
 		// Crear predicción basada en el historial del estudiante
 		predictedScore := m.calculatePredictedScore(studentID, score)
 
 		prediction := &entity.PredictionAssessment{
+			RunID:          runID,
 			StudentID:      studentID,
 			AssessmentID:   assessmentID,
 			PredictedScore: predictedScore,
 			PredictionDate: time.Now(),
 		}
 
-		batchPredictions = append(batchPredictions, prediction)
+		// Reemplaza la predicción existente de la misma ejecución en lugar de duplicarla
+		batchPredictions = append(batchPredictions, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"run_id": runID, "student_id": studentID, "assessment_id": assessmentID}).
+			SetReplacement(prediction).
+			SetUpsert(true))
 
 		// Almacenar las predicciones para devolverlas al frontend
 		processedResults = append(processedResults, entity.ProcessedPredictionAssessmentResult{
@@ -372,17 +455,16 @@ func (m *mongoDBClient) processAssessmentBatch(ctx context.Context, assessments
 		})
 	}
 
-	// Inserta las predicciones en la colección
+	// Inserta o reemplaza las predicciones en la colección
 	if len(batchPredictions) > 0 {
-		result, err := collection.InsertMany(ctx, batchPredictions)
+		result, err := collection.BulkWrite(ctx, batchPredictions, options.BulkWrite().SetOrdered(false))
 		if err != nil {
-			log.Fatalf("Error al insertar predicciones: %v", err)
-		} else {
-			log.Printf("Insertados %d documentos", len(result.InsertedIDs))
+			return nil, fmt.Errorf("error al insertar predicciones: %w", err)
 		}
+		log.Printf("Insertados %d documentos, reemplazados %d", result.UpsertedCount, result.ModifiedCount)
 	}
 
-	return processedResults
+	return processedResults, nil
 }
 
 func (m *mongoDBClient) convertAssessmentID(assessmentIDRaw interface{}) (int, error) {
@@ -396,14 +478,13 @@ func (m *mongoDBClient) convertAssessmentID(assessmentIDRaw interface{}) (int, e
 	case float64:
 		return int(v), nil
 	default:
-		log.Fatalf("Tipo no reconocido para idassessment: %T", v)
 		return 0, fmt.Errorf("tipo no reconocido para idassessment: %T", v)
 	}
 }
 
 func (m *mongoDBClient) calculatePredictedScore(studentID int, currentScore float64) float64 {
     // Simulando una predicción basada en el historial de puntuaciones previas del estudiante
-    predictedScore := currentScore * 1.05 // Aumentamos el score en un 5% como ejemplo
+    predictedScore := currentScore * assessmentScoreCoefficient // Aumentamos el score en un 5% como ejemplo
 
     log.Printf("Predicción calculada para el estudiante %d: %f (score actual: %f)", studentID, predictedScore, currentScore)
     return predictedScore
@@ -416,8 +497,11 @@ func (m *mongoDBClient) convertScore(scoreRaw interface{}) (float64, error) {
 		return v, nil
 	case int:
 		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
 	default:
-		log.Fatalf("Tipo no reconocido para score: %T", v)
 		return 0.0, fmt.Errorf("tipo no reconocido para score: %T", v)
 	}
 }
@@ -435,20 +519,18 @@ func (m *mongoDBClient) convertStudentID(studentIDRaw interface{}) (int, error)
 	case string: // En caso de que el ID del estudiante sea una cadena, intenta convertirlo
 		id, err := strconv.Atoi(v)
 		if err != nil {
-			log.Fatalf("Error al convertir idstudent: %v", err)
 			return 0, err
 		}
 		return id, nil
 	default:
-		log.Fatalf("Tipo no reconocido para idstudent: %T", v)
 		return 0, fmt.Errorf("tipo no reconocido para idstudent: %T", v)
 	}
 }
 
-// Predictions VLE 
+// Predictions VLE
 
-func (m *mongoDBClient) ProcessDataVlePredictions(database string) ([]entity.ProcessedPredictionVleResult, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+func (m *mongoDBClient) ProcessDataVlePredictions(ctx context.Context, database string) ([]entity.ProcessedPredictionVleResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
 	defer cancel()
 
 	// Conexión a la base de datos y colección
@@ -457,537 +539,509 @@ func (m *mongoDBClient) ProcessDataVlePredictions(database string) ([]entity.Pro
 	predictionsCollection := db.Collection("prediction_vle")
 	batchSize := 5000 // Tamaño del batch optimizado
 
-	// Opciones de búsqueda para limitar los campos devueltos
-	opts := options.Find().SetProjection(bson.M{"id_student": 1, "resource_type": 1, "sum_click": 1})
-
-	// Cursor para procesar los documentos
-	cursor, err := studentVleCollection.Find(ctx, bson.M{}, opts)
+	runID, err := startPredictionRun(ctx, db, config.PredictionKindVle)
 	if err != nil {
-		return nil, fmt.Errorf("error al obtener los datos de studentVle: %w", err)
+		return nil, err
 	}
-	defer cursor.Close(ctx)
+	m.loggers.InfoLogger.Printf("Ejecución de predicción VLE: %s", runID)
 
-	batch := make([]bson.M, 0, batchSize)
-	var wg sync.WaitGroup
-	var totalProcessed int // Contador de documentos procesados
-	var processedResults []entity.ProcessedPredictionVleResult // Resultados procesados
-
-	for cursor.Next(ctx) {
-		var interaction bson.M
-		if err := cursor.Decode(&interaction); err != nil {
-			log.Printf("error al decodificar interacción: %v", err)
-			continue
-		}
-		batch = append(batch, interaction)
+	scoringConfigs, err := loadVleScoringConfigs(ctx, db)
+	if err != nil {
+		m.finishPredictionRun(ctx, db, config.PredictionKindVle, runID, 0, err)
+		return nil, err
+	}
+	courseWeeks, err := loadCourseWeeks(ctx, db)
+	if err != nil {
+		m.finishPredictionRun(ctx, db, config.PredictionKindVle, runID, 0, err)
+		return nil, err
+	}
 
-		if len(batch) == batchSize {
-			wg.Add(1)
-			go func(b []bson.M) {
-				defer wg.Done()
-				results, err := m.processAndStoreVleBatch(ctx, b, predictionsCollection)
-				if err != nil {
-					log.Printf("error al procesar y guardar el batch: %v", err)
-				} else {
-					totalProcessed += len(b)
-					processedResults = append(processedResults, results...)
-				}
-			}(batch)
-			batch = make([]bson.M, 0, batchSize)
-		}
+	// Pipeline: clics por estudiante, recurso y semana, unión con vle para obtener
+	// el tipo de actividad y agregación por estudiante, presentación, actividad y semana
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"student":      "$idstudent",
+				"module":       "$codemodule",
+				"presentation": "$codepresentation",
+				"site":         "$idsite",
+				"week":         bson.M{"$floor": bson.M{"$divide": bson.A{"$date", 7}}},
+			},
+			"clicks": bson.M{"$sum": "$sumclick"},
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": "vle",
+			"let": bson.M{
+				"site":         "$_id.site",
+				"module":       "$_id.module",
+				"presentation": "$_id.presentation",
+			},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"$expr": bson.M{"$and": bson.A{
+					bson.M{"$eq": bson.A{"$idsite", "$$site"}},
+					bson.M{"$eq": bson.A{"$codemodule", "$$module"}},
+					bson.M{"$eq": bson.A{"$codepresentation", "$$presentation"}},
+				}}}},
+				bson.M{"$project": bson.M{"_id": 0, "activitytype": 1}},
+			},
+			"as": "site",
+		}}},
+		{{Key: "$unwind", Value: bson.M{"path": "$site", "preserveNullAndEmptyArrays": true}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"student":      "$_id.student",
+				"module":       "$_id.module",
+				"presentation": "$_id.presentation",
+				"activity":     bson.M{"$ifNull": bson.A{"$site.activitytype", "unknown"}},
+				"week":         "$_id.week",
+			},
+			"clicks": bson.M{"$sum": "$clicks"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":               0,
+			"student_id":        "$_id.student",
+			"code_module":       "$_id.module",
+			"code_presentation": "$_id.presentation",
+			"activity_type":     "$_id.activity",
+			"week":              "$_id.week",
+			"clicks":            1,
+		}}},
 	}
 
-	// Procesar el último lote restante
-	if len(batch) > 0 {
-		wg.Add(1)
-		go func(b []bson.M) {
-			defer wg.Done()
-			results, err := m.processAndStoreVleBatch(ctx, b, predictionsCollection)
-			if err != nil {
-				log.Printf("error al procesar y guardar el lote final: %v", err)
-			} else {
-				totalProcessed += len(b)
-				processedResults = append(processedResults, results...)
-			}
-		}(batch)
+	cursor, err := studentVleCollection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true).SetBatchSize(int32(batchSize)))
+	if err != nil {
+		m.finishPredictionRun(ctx, db, config.PredictionKindVle, runID, 0, err)
+		return nil, fmt.Errorf("error al agregar los datos de studentVle: %w", err)
 	}
+	defer cursor.Close(ctx)
 
-	wg.Wait()
+	// Acumular una puntuación de participación por estudiante y presentación
+	engagement := newVleEngagement(runID, scoringConfigs, courseWeeks)
+	for cursor.Next(ctx) {
+		var row entity.PredictionVle
+		if err := cursor.Decode(&row); err != nil {
+			// Puntuar con datos parciales daría por buena una ejecución incompleta
+			m.loggers.ErrorLogger.Printf("Error al decodificar interacción: %v", err)
+			err = fmt.Errorf("error al decodificar interacción: %w", err)
+			m.finishPredictionRun(ctx, db, config.PredictionKindVle, runID, 0, err)
+			return nil, err
+		}
+		engagement.add(row)
+	}
 
-	// Verificar si se produjeron errores durante el procesamiento
 	if err := cursor.Err(); err != nil {
-		return processedResults, fmt.Errorf("errores encontrados durante el procesamiento de documentos: %w", err)
+		m.finishPredictionRun(ctx, db, config.PredictionKindVle, runID, 0, err)
+		return nil, fmt.Errorf("errores encontrados durante el procesamiento de documentos: %w", err)
 	}
 
-	return processedResults, nil
-}
-
-// Función para procesar un batch de interacciones del VLE y almacenar predicciones en MongoDB
-func (m *mongoDBClient) processAndStoreVleBatch(ctx context.Context, vleBatch []bson.M, predictionsCollection *mongo.Collection) ([]entity.ProcessedPredictionVleResult, error) {
-	var processedResults []entity.ProcessedPredictionVleResult
-
-	for _, interaction := range vleBatch {
-		studentIDRaw, ok := interaction["id_student"]
-		if !ok {
-			log.Printf("Warning: 'id_student' no encontrado en el documento: %v", interaction)
-			continue
-		}
-		resourceType, ok := interaction["resource_type"].(string)
-		if !ok {
-			log.Printf("Warning: 'resource_type' no encontrado o tiene un tipo no válido en el documento: %v", interaction)
-			continue
-		}
-		clicksRaw, ok := interaction["sum_click"]
-		if !ok {
-			log.Printf("Warning: 'sum_click' no encontrado en el documento: %v", interaction)
-			continue
-		}
+	processedResults := engagement.results()
 
-		// Convertir los campos
-		studentID, err := m.convertStudentID(studentIDRaw)
-		if err != nil {
-			log.Printf("Warning: 'id_student' tiene un tipo no reconocido: %v", studentIDRaw)
-			continue
+	// Guardar las predicciones por lotes
+	for start := 0; start < len(processedResults); start += batchSize {
+		end := start + batchSize
+		if end > len(processedResults) {
+			end = len(processedResults)
 		}
-		clicks, err := m.convertClicks(clicksRaw)
-		if err != nil {
-			log.Printf("Warning: 'sum_click' tiene un tipo no reconocido: %v", clicksRaw)
-			continue
+		if err := storeVlePredictions(ctx, predictionsCollection, processedResults[start:end]); err != nil {
+			m.finishPredictionRun(ctx, db, config.PredictionKindVle, runID, start, err)
+			return nil, err
 		}
-
-		// Calcular el puntaje predicho
-		predictedScore := m.calculatePredictedScoreStudentVle(studentID, resourceType, clicks)
-
-		// Almacenar el resultado en el array
-		processedResults = append(processedResults, entity.ProcessedPredictionVleResult{
-			StudentID:      studentID,
-			PredictedScore: predictedScore,
-		})
 	}
+	m.loggers.InfoLogger.Printf("Insertadas %d predicciones en la colección", len(processedResults))
 
-	// Insertar las predicciones procesadas en la colección `prediction_vle`
-	if len(processedResults) > 0 {
-		insertData := make([]interface{}, len(processedResults))
-		for i, result := range processedResults {
-			insertData[i] = result
-		}
-
-		_, err := predictionsCollection.InsertMany(ctx, insertData)
-		if err != nil {
-			return nil, fmt.Errorf("error al insertar las predicciones en MongoDB: %w", err)
-		}
-
-		log.Printf("Insertadas %d predicciones en la colección", len(processedResults))
+	if err := m.finishPredictionRun(ctx, db, config.PredictionKindVle, runID, len(processedResults), nil); err != nil {
+		return nil, err
 	}
 
 	return processedResults, nil
 }
 
-// Función para calcular el puntaje predicho basado en las interacciones con el VLE
-func (m *mongoDBClient) calculatePredictedScoreStudentVle(studentID int, resourceType string, clicks int) float64 {
-	// Pesos basados en el tipo de interacción
-	interactionWeights := map[string]float64{
-		"forum":       1.2, // Mayor peso para interacciones con foros
-		"quiz":        1.5, // Quiz tiene mayor relevancia para el éxito
-		"resource":    1.0, // Interacciones normales
-		"assignment":  1.8, // Asignaciones tienen gran importancia
-	}
-
-	weight, exists := interactionWeights[resourceType]
-	if !exists {
-		weight = 1.0 // Si no se encuentra el tipo de recurso, usar peso por defecto
+// Función para guardar un lote de predicciones VLE, una por (ejecución, estudiante, presentación)
+func storeVlePredictions(ctx context.Context, predictionsCollection *mongo.Collection, results []entity.ProcessedPredictionVleResult) error {
+	writes := make([]mongo.WriteModel, 0, len(results))
+	for _, result := range results {
+		writes = append(writes, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{
+				"run_id":            result.RunID,
+				"student_id":        result.StudentID,
+				"code_module":       result.CodeModule,
+				"code_presentation": result.CodePresentation,
+			}).
+			SetReplacement(result).
+			SetUpsert(true))
 	}
-
-	// Calcular el puntaje predicho (ejemplo básico)
-	predictedScore := float64(clicks) * weight
-	log.Printf("Predicción calculada para el estudiante %d: %f (tipo de recurso: %s, clics: %d)", studentID, predictedScore, resourceType, clicks)
-	return predictedScore
-}
-
-// Función para convertir el número de clics
-func (m *mongoDBClient) convertClicks(clicksRaw interface{}) (int, error) {
-	switch v := clicksRaw.(type) {
-	case int:
-		return v, nil
-	case int32:
-		return int(v), nil
-	case int64:
-		return int(v), nil
-	case float64:
-		return int(v), nil
-	default:
-		return 0, fmt.Errorf("tipo no reconocido para sum_click: %T", v)
+	if _, err := predictionsCollection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		return fmt.Errorf("error al insertar las predicciones en MongoDB: %w", err)
 	}
+	return nil
 }
 
 // Charts
 
-func (m *mongoDBClient) GetScoreDistributionPredictionAssessments(database string) ([]entity.ScoreRangePredictionAssessments, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+func (m *mongoDBClient) GetScoreDistributionPredictionAssessments(ctx context.Context, database string, query entity.ScoreDistributionQuery) (*entity.ScoreDistribution, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
 	defer cancel()
 
-	// Conexión a la base de datos y colección
+	edges, err := scoreDistributionEdges(query)
+	if err != nil {
+		return nil, err
+	}
 	db := m.client.Database(database)
-	predictionCollection := db.Collection("prediction_assessments")
+	runFilter, err := activeRunMatch(ctx, db, config.PredictionKindAssessments)
+	if err != nil {
+		return nil, err
+	}
 
-	// Definir los rangos de puntuación
-	ranges := []struct {
-		Lower float64
-		Upper float64
-		Label string
-	}{
-		{0, 59, "Menos de 60"},
-		{60, 69, "60 a 70"},
-		{70, 79, "70 a 80"},
-		{80, 89, "80 a 90"},
-		{90, 100, "Más de 90"},
+	// Filtrar por la ejecución activa y, si se solicita, por módulo, presentación o tipo
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: runFilter}}}
+	assessmentFilter := bson.M{}
+	if query.CodeModule != "" {
+		assessmentFilter["assessment.codemodule"] = query.CodeModule
+	}
+	if query.CodePresentation != "" {
+		assessmentFilter["assessment.codepresentation"] = query.CodePresentation
+	}
+	if query.AssessmentType != "" {
+		assessmentFilter["assessment.assessmenttype"] = query.AssessmentType
+	}
+	if len(assessmentFilter) > 0 {
+		pipeline = append(pipeline,
+			bson.D{{Key: "$lookup", Value: bson.M{
+				"from":         "assessments",
+				"localField":   "assessment_id",
+				"foreignField": "idassessment",
+				"as":           "assessment",
+			}}},
+			bson.D{{Key: "$unwind", Value: "$assessment"}},
+			bson.D{{Key: "$match", Value: assessmentFilter}},
+		)
 	}
 
-	// Crear un mapa para almacenar los conteos
-	scoreCounts := make(map[string]int)
-	for _, r := range ranges {
-		scoreCounts[r.Label] = 0
+	// Percentiles exactos: se numeran las predicciones por puntuación y se
+	// conservan solo las posiciones entre las que se interpola cada percentil
+	positions := bson.A{}
+	for _, p := range scorePercentiles {
+		position := bson.M{"$multiply": bson.A{p, bson.M{"$subtract": bson.A{"$total", 1}}}}
+		positions = append(positions,
+			bson.M{"$add": bson.A{bson.M{"$floor": position}, 1}},
+			bson.M{"$add": bson.A{bson.M{"$ceil": position}, 1}})
+	}
+	facets := bson.M{"percentiles": bson.A{
+		bson.M{"$setWindowFields": bson.M{
+			"sortBy": bson.M{"predicted_score": 1},
+			"output": bson.M{
+				"rank":  bson.M{"$documentNumber": bson.M{}},
+				"total": bson.M{"$count": bson.M{}, "window": bson.M{"documents": bson.A{"unbounded", "unbounded"}}},
+			},
+		}},
+		bson.M{"$match": bson.M{"$expr": bson.M{"$in": bson.A{"$rank", positions}}}},
+		bson.M{"$project": bson.M{"_id": 0, "rank": 1, "total": 1, "score": "$predicted_score"}},
+	}}
+
+	// Los tramos de la vista son de un punto: solo sirven si los límites coinciden
+	// con ellos. En otro caso se agrupa en el servidor con $bucket o $bucketAuto
+	useView := query.BinCount == 0 && alignedScoreEdges(edges)
+	if query.BinCount > 0 {
+		facets["buckets"] = bson.A{bson.M{"$bucketAuto": bson.M{
+			"groupBy": "$predicted_score",
+			"buckets": query.BinCount,
+			"output":  bson.M{"student_count": bson.M{"$sum": 1}},
+		}}}
+	} else if !useView {
+		facets["buckets"] = bson.A{bson.M{"$bucket": bson.M{
+			"groupBy":    "$predicted_score",
+			"boundaries": edges,
+			"default":    outOfRangeBucket,
+			"output":     bson.M{"student_count": bson.M{"$sum": 1}},
+		}}}
 	}
+	pipeline = append(pipeline, bson.D{{Key: "$facet", Value: facets}})
 
-	// Obtener todos los documentos de la colección
-	cursor, err := predictionCollection.Find(ctx, bson.M{})
+	cursor, err := db.Collection("prediction_assessments").Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
 	if err != nil {
-		return nil, fmt.Errorf("error al obtener los documentos: %w", err)
+		return nil, fmt.Errorf("error al ejecutar la agregación de distribución: %w", err)
 	}
 	defer cursor.Close(ctx)
 
-	// Procesar los documentos y clasificarlos en los rangos correspondientes
-	for cursor.Next(ctx) {
-		var result struct {
-			PredictedScore float64 `bson:"predicted_score"`
-		}
-		if err := cursor.Decode(&result); err != nil {
-			log.Printf("error al decodificar documento: %v", err)
-			continue
+	var results []struct {
+		Buckets []struct {
+			ID           bson.RawValue `bson:"_id"`
+			StudentCount int           `bson:"student_count"`
+		} `bson:"buckets"`
+		Percentiles []struct {
+			Rank  int     `bson:"rank"`
+			Total int     `bson:"total"`
+			Score float64 `bson:"score"`
+		} `bson:"percentiles"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("error durante la iteración del cursor: %w", err)
+	}
+
+	distribution := &entity.ScoreDistribution{Buckets: []entity.ScoreRangePredictionAssessments{}}
+	if len(results) == 0 || len(results[0].Percentiles) == 0 {
+		return distribution, nil
+	}
+	facet := results[0]
+	distribution.Total = facet.Percentiles[0].Total
+	scores := make(map[int]float64, len(facet.Percentiles))
+	for _, p := range facet.Percentiles {
+		scores[p.Rank-1] = p.Score
+	}
+	distribution.Percentiles = rankedPercentiles(distribution.Total, func(position int) float64 { return scores[position] })
+
+	if query.BinCount > 0 {
+		for _, b := range facet.Buckets {
+			bounds, _ := b.ID.DocumentOK()
+			lower, _ := rawNumber(bounds.Lookup("min"))
+			upper, _ := rawNumber(bounds.Lookup("max"))
+			distribution.Buckets = append(distribution.Buckets, autoScoreBucket(lower, upper, b.StudentCount))
 		}
+		return distribution, nil
+	}
 
-		// Clasificar el resultado en el rango correspondiente
-		for _, r := range ranges {
-			if result.PredictedScore >= r.Lower && result.PredictedScore <= r.Upper {
-				scoreCounts[r.Label]++
-				break
+	var inRange []int
+	outOfRange := 0
+	if useView {
+		if inRange, outOfRange, err = m.viewScoreBuckets(ctx, db, query, edges); err != nil {
+			return nil, err
+		}
+	} else {
+		counts := make(map[float64]int)
+		for _, b := range facet.Buckets {
+			if lower, ok := rawNumber(b.ID); ok {
+				counts[lower] = b.StudentCount
+			} else {
+				outOfRange = b.StudentCount
 			}
 		}
+		for i := 0; i < len(edges)-1; i++ {
+			inRange = append(inRange, counts[edges[i]])
+		}
 	}
+	distribution.Buckets = scoreBuckets(edges, inRange, outOfRange)
+	return distribution, nil
+}
 
-	// Verificar si se produjeron errores durante la iteración del cursor
-	if err := cursor.Err(); err != nil {
-		return nil, fmt.Errorf("error durante la iteración del cursor: %w", err)
+// viewScoreBuckets suma el histograma materializado para el módulo, presentación
+// o tipo solicitados y lo reparte entre los límites, que deben estar alineados
+func (m *mongoDBClient) viewScoreBuckets(ctx context.Context, db *mongo.Database, query entity.ScoreDistributionQuery, edges []float64) ([]int, int, error) {
+	view, err := m.ensureView(ctx, db, config.ViewScoreHistogram)
+	if err != nil {
+		return nil, 0, err
 	}
-
-	// Completar los resultados con rangos que no tengan estudiantes
-	var results []entity.ScoreRangePredictionAssessments
-	for _, r := range ranges {
-		results = append(results, entity.ScoreRangePredictionAssessments{
-			Range:        r.Label,
-			StudentCount: scoreCounts[r.Label],
-		})
+	filter := bson.M{}
+	if query.CodeModule != "" {
+		filter["code_module"] = query.CodeModule
+	}
+	if query.CodePresentation != "" {
+		filter["code_presentation"] = query.CodePresentation
+	}
+	if query.AssessmentType != "" {
+		filter["assessment_type"] = query.AssessmentType
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{"_id": "$bin", "count": bson.M{"$sum": "$count"}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+	cursor, err := db.Collection(view.Target).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error al ejecutar la agregación de distribución: %w", err)
 	}
+	defer cursor.Close(ctx)
 
-	return results, nil
+	var histogram []struct {
+		Bin   float64 `bson:"_id"`
+		Count int     `bson:"count"`
+	}
+	if err := cursor.All(ctx, &histogram); err != nil {
+		return nil, 0, fmt.Errorf("error durante la iteración del cursor: %w", err)
+	}
+	lowers := make([]float64, len(histogram))
+	counts := make([]int, len(histogram))
+	for i, h := range histogram {
+		lowers[i] = h.Bin
+		counts[i] = h.Count
+	}
+	inRange, outOfRange := histogramBuckets(lowers, counts, edges)
+	return inRange, outOfRange, nil
 }
 
-// Average by types
+// rawNumber convierte un valor BSON numérico a float64
+func rawNumber(value bson.RawValue) (float64, bool) {
+	switch value.Type {
+	case bson.TypeDouble:
+		return value.Double(), true
+	case bson.TypeInt32:
+		return float64(value.Int32()), true
+	case bson.TypeInt64:
+		return float64(value.Int64()), true
+	default:
+		return 0, false
+	}
+}
 
-// Función principal para obtener el promedio de puntajes predichos por tipo de evaluación
-func (m *mongoDBClient) GetAveragePredictedScoreByAssessmentType(database string) ([]entity.AssessmentTypeAverage, error) {
-    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
-    defer cancel()
-
-    db := m.client.Database(database)
-    predictionCollection := db.Collection("prediction_assessments")
-    assessmentCollection := db.Collection("assessments")
-
-    // Crear índices si no existen
-    err := createIndexes(ctx, predictionCollection, assessmentCollection)
-    if err != nil {
-        return nil, fmt.Errorf("error al crear índices: %w", err)
-    }
-
-    // Obtener los tipos de evaluación
-    assessmentTypes, err := getDistinctAssessmentTypes(ctx, assessmentCollection)
-    if err != nil {
-        return nil, fmt.Errorf("error al obtener tipos de evaluación: %w", err)
-    }
-
-    log.Printf("Tipos de evaluación obtenidos: %v", assessmentTypes)
-
-    // Variables para goroutines y sincronización
-    var wg sync.WaitGroup
-    var mu sync.Mutex
-    results := []entity.AssessmentTypeAverage{}
-    var aggregateErr error
-
-    // Tamaño del batch
-    const batchSize = 5000
-
-    processBatch := func(types []string) {
-        defer wg.Done()
-
-        // Obtener IDs de evaluaciones por tipo
-        assessmentIDs := getAssessmentIDsForTypes(ctx, types, assessmentCollection)
-        if len(assessmentIDs) == 0 {
-            return
-        }
-
-        // Pipeline optimizado
-        pipeline := mongo.Pipeline{
-            // Filtrar por assessment_ids específicos
-            bson.D{{"$match", bson.M{"assessment_id": bson.M{"$in": assessmentIDs}}}},
-            // Unir con la colección de assessments
-            bson.D{{"$lookup", bson.M{
-                "from":         "assessments",
-                "localField":   "assessment_id",
-                "foreignField": "idassessment",
-                "as":           "assessment_info",
-            }}},
-            // Descomponer la información del assessment
-            bson.D{{"$unwind", "$assessment_info"}},
-            // Agrupar por tipo de evaluación y calcular el promedio
-            bson.D{{"$group", bson.M{
-                "_id":           "$assessment_info.assessmenttype",
-                "average_score": bson.M{"$avg": "$predicted_score"},
-            }}},
-            // Ordenar por promedio
-            bson.D{{"$sort", bson.M{"average_score": -1}}},
-            // Proyectar los campos requeridos
-            bson.D{{"$project", bson.M{
-                "_id":            0,
-                "assessment_type": "$_id",
-                "average_score":  "$average_score",
-            }}},
-        }
-
-        cursorOpts := options.Aggregate().SetBatchSize(batchSize)
-        cursor, err := predictionCollection.Aggregate(ctx, pipeline, cursorOpts)
-        if err != nil {
-            mu.Lock()
-            if aggregateErr == nil {
-                aggregateErr = fmt.Errorf("error al ejecutar la agregación: %w", err)
-            }
-            mu.Unlock()
-            return
-        }
-        defer cursor.Close(ctx)
-
-        var localResults []entity.AssessmentTypeAverage
-        for cursor.Next(ctx) {
-            var result entity.AssessmentTypeAverage
-            if err := cursor.Decode(&result); err != nil {
-                log.Printf("Error al decodificar resultado: %v", err)
-                continue
-            }
-            localResults = append(localResults, result)
-        }
-
-        if err := cursor.Err(); err != nil {
-            mu.Lock()
-            if aggregateErr == nil {
-                aggregateErr = fmt.Errorf("error durante la iteración del cursor: %w", err)
-            }
-            mu.Unlock()
-            return
-        }
-
-        // Agregar los resultados locales a los globales
-        mu.Lock()
-        results = append(results, localResults...)
-        mu.Unlock()
-    }
-
-    // Procesar en batches
-    for i := 0; i < len(assessmentTypes); i += batchSize {
-        end := i + batchSize
-        if end > len(assessmentTypes) {
-            end = len(assessmentTypes)
-        }
-        batch := assessmentTypes[i:end]
-
-        wg.Add(1)
-        go processBatch(batch)
-    }
-
-    wg.Wait()
-
-    if aggregateErr != nil {
-        return nil, aggregateErr
-    }
-
-    return results, nil
+// scoreDistributionEdges devuelve los límites de rango de la consulta (los de
+// por defecto si no se indican); no aplica cuando se pide un número de rangos
+func scoreDistributionEdges(query entity.ScoreDistributionQuery) ([]float64, error) {
+	if query.BinCount > 0 {
+		return nil, nil
+	}
+	edges := query.Bins
+	if len(edges) == 0 {
+		edges = defaultScoreEdges
+	}
+	if err := validateScoreEdges(edges); err != nil {
+		return nil, err
+	}
+	return edges, nil
 }
 
+// alignedScoreEdges indica si todos los límites finitos coinciden con el inicio
+// de un tramo del histograma materializado, de modo que el reparto es exacto
+func alignedScoreEdges(edges []float64) bool {
+	for _, edge := range edges {
+		if !math.IsInf(edge, 0) && math.Mod(edge, scoreHistogramWidth) != 0 {
+			return false
+		}
+	}
+	return true
+}
 
-// Función para obtener IDs de evaluación para un conjunto de tipos
-func getAssessmentIDsForTypes(ctx context.Context, types []string, collection *mongo.Collection) []interface{} {
-    var ids []interface{}
-    cursor, err := collection.Find(ctx, bson.D{{"assessmenttype", bson.D{{"$in", types}}}}, options.Find().SetProjection(bson.D{{"idassessment", 1}}))
-    if err != nil {
-        log.Printf("error al obtener IDs de evaluación para tipos: %v", err)
-        return ids
-    }
-    defer cursor.Close(ctx)
-
-    for cursor.Next(ctx) {
-        var result struct{ IDAssessment interface{} `bson:"idassessment"` }
-        if err := cursor.Decode(&result); err != nil {
-            log.Printf("error al decodificar ID de evaluación: %v", err)
-            continue
-        }
-        ids = append(ids, result.IDAssessment)
-    }
-
-    if err := cursor.Err(); err != nil {
-        log.Printf("error durante la iteración del cursor para IDs: %v", err)
-    }
-
-    return ids
+// rankedPercentiles calcula los percentiles de la distribución a partir de los
+// valores ordenados que devuelve scoreAt (posición en base 0)
+func rankedPercentiles(total int, scoreAt func(position int) float64) entity.ScorePercentiles {
+	p := make([]float64, len(scorePercentiles))
+	for i, percentile := range scorePercentiles {
+		lower, upper, fraction := analytics.PercentilePosition(total, percentile)
+		p[i] = scoreAt(lower) + fraction*(scoreAt(upper)-scoreAt(lower))
+	}
+	return entity.ScorePercentiles{P10: p[0], P25: p[1], Median: p[2], P75: p[3], P90: p[4]}
 }
 
+// autoScoreBucket rango de un reparto por número de rangos
+func autoScoreBucket(lower, upper float64, count int) entity.ScoreRangePredictionAssessments {
+	return entity.ScoreRangePredictionAssessments{
+		Range:        fmt.Sprintf("%g a %g", lower, upper),
+		Lower:        &lower,
+		Upper:        &upper,
+		StudentCount: count,
+	}
+}
 
-// Función para obtener tipos de evaluación distintos
-func getDistinctAssessmentTypes(ctx context.Context, collection *mongo.Collection) ([]string, error) {
-    cursor, err := collection.Distinct(ctx, "assessmenttype", bson.D{})
-    if err != nil {
-        return nil, fmt.Errorf("error al obtener tipos de evaluación: %w", err)
-    }
-
-    // Convertir cursor a un slice de strings
-    var types []string
-    for _, item := range cursor {
-        if str, ok := item.(string); ok {
-            types = append(types, str)
-        } else {
-            return nil, fmt.Errorf("tipo de evaluación inesperado: %v", item)
-        }
-    }
-
-    return types, nil
+// scoreBuckets construye los rangos de la distribución a partir de los recuentos
+// de cada par de límites, incluidos los rangos sin estudiantes
+func scoreBuckets(edges []float64, inRange []int, outOfRange int) []entity.ScoreRangePredictionAssessments {
+	buckets := []entity.ScoreRangePredictionAssessments{}
+	for i, count := range inRange {
+		lower, upper := edges[i], edges[i+1]
+		bucket := entity.ScoreRangePredictionAssessments{
+			Range:        fmt.Sprintf("%g a %g", lower, upper),
+			Lower:        &lower,
+			StudentCount: count,
+		}
+		if math.IsInf(upper, 1) {
+			bucket.Range = fmt.Sprintf("%g o más", lower)
+		} else {
+			bucket.Upper = &upper
+		}
+		buckets = append(buckets, bucket)
+	}
+	if outOfRange > 0 {
+		buckets = append(buckets, entity.ScoreRangePredictionAssessments{
+			Range:        outOfRangeBucket,
+			StudentCount: outOfRange,
+		})
+	}
+	return buckets
 }
 
-// Función para crear índices en las colecciones
-func createIndexes(ctx context.Context, predictionCollection, assessmentCollection *mongo.Collection) error {
-    // Índice en prediction_assessments
-    _, err := predictionCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
-        Keys: bson.D{{"assessment_id", 1}},
-        Options: options.Index().SetName("index_assessment_id"),
-    })
-    if err != nil {
-        return fmt.Errorf("error al crear índice en prediction_assessments: %w", err)
-    }
-    log.Println("Índice creado en prediction_assessments para 'assessment_id'")
-
-    // Índice en assessments
-    _, err = assessmentCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
-        Keys: bson.D{{"assessmenttype", 1}},
-        Options: options.Index().SetName("index_assessmenttype"),
-    })
-    if err != nil {
-        return fmt.Errorf("error al crear índice en assessments: %w", err)
-    }
-    log.Println("Índice creado en assessments para 'assessmenttype'")
-
-    return nil
+// histogramBuckets reparte los tramos del histograma entre los límites indicados;
+// cada tramo se asigna por su límite inferior, por lo que solo es exacto con
+// límites alineados (alignedScoreEdges)
+func histogramBuckets(lowers []float64, counts []int, edges []float64) ([]int, int) {
+	if len(edges) < 2 {
+		return nil, 0
+	}
+	inRange := make([]int, len(edges)-1)
+	outOfRange := 0
+	for i, lower := range lowers {
+		// Índice del primer límite mayor que el inicio del tramo
+		bucket := sort.Search(len(edges), func(e int) bool { return edges[e] > lower })
+		if bucket == 0 || bucket == len(edges) {
+			outOfRange += counts[i]
+			continue
+		}
+		inRange[bucket-1] += counts[i]
+	}
+	return inRange, outOfRange
 }
 
+// validateScoreEdges comprueba que los límites de los rangos sean estrictamente crecientes
+func validateScoreEdges(edges []float64) error {
+	if len(edges) < 2 {
+		return fmt.Errorf("se necesitan al menos dos límites de rango")
+	}
+	for i := 1; i < len(edges); i++ {
+		if edges[i] <= edges[i-1] {
+			return fmt.Errorf("los límites de rango deben ser crecientes: %v", edges)
+		}
+	}
+	return nil
+}
 
-// Students by Assessment
+// Average by types
 
-func (m *mongoDBClient) GetStudentCountByAssessmentID(database string) ([]entity.AssessmentStudentCount, error) {
-	// Contexto con timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+// Función principal para obtener el promedio de puntajes predichos por tipo de evaluación
+func (m *mongoDBClient) GetAveragePredictedScoreByAssessmentType(ctx context.Context, database string) ([]entity.AssessmentTypeAverage, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
 	defer cancel()
 
-	numWorkers := 10
-
-	// Conexión a la base de datos y colección
-	collection := m.client.Database(database).Collection("prediction_assessments")
-
-	// Crear índices si no existen
-	err := createIndexesCounts(ctx, collection)
+	db := m.client.Database(database)
+	view, err := m.ensureView(ctx, db, config.ViewAvgScoreByAssessmentType)
 	if err != nil {
-		return nil, fmt.Errorf("error al crear índices: %w", err)
-	}
-
-	// Canal para recibir los resultados de las goroutines
-	resultChan := make(chan entity.AssessmentStudentCount, 1000)
-	var wg sync.WaitGroup
-
-	// Pipeline de agregación
-	pipeline := mongo.Pipeline{
-		{{"$group", bson.D{
-			{"_id", "$assessment_id"},
-			{"student_count", bson.D{{"$addToSet", "$student_id"}}},
-		}}},
-		{{"$project", bson.D{
-			{"_id", 1},
-			{"student_count", bson.D{{"$size", "$student_count"}}},
-		}}},
+		return nil, err
 	}
 
-	// Ejecutar la agregación con batch
-	cursor, err := collection.Aggregate(ctx, pipeline, options.Aggregate().SetBatchSize(5000))
+	// Leer los promedios precalculados, ordenados por promedio
+	opts := options.Find().SetSort(bson.M{"average_score": -1})
+	cursor, err := db.Collection(view.Target).Find(ctx, bson.M{}, opts)
 	if err != nil {
-		return nil, fmt.Errorf("error al ejecutar agregación: %w", err)
+		return nil, fmt.Errorf("error al obtener los promedios por tipo: %w", err)
 	}
 	defer cursor.Close(ctx)
 
-	// Goroutine para manejar los resultados en paralelo
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			processResults(ctx, cursor, resultChan)
-		}()
-	}
-
-	// Esperar a que todas las goroutines terminen
-	go func() {
-		wg.Wait()
-		close(resultChan)
-	}()
-
-	// Recopilar resultados
-	var results []entity.AssessmentStudentCount
-	for result := range resultChan {
-		results = append(results, result)
+	results := []entity.AssessmentTypeAverage{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("error durante la iteración del cursor: %w", err)
 	}
-
 	return results, nil
 }
 
-// Función para procesar resultados de la agregación
-func processResults(ctx context.Context, cursor *mongo.Cursor, resultChan chan<- entity.AssessmentStudentCount) {
-	for cursor.Next(ctx) {
-		var result entity.AssessmentStudentCount
-		if err := cursor.Decode(&result); err != nil {
-			log.Printf("Error al decodificar el resultado: %v", err)
-			continue
-		}
-		resultChan <- result
-	}
-	if err := cursor.Err(); err != nil {
-		log.Printf("Error en el cursor: %v", err)
-	}
-}
+// Students by Assessment
 
-// Crear índice para optimizar las consultas
-func createIndexesCounts(ctx context.Context, collection *mongo.Collection) error {
-	indexModel := mongo.IndexModel{
-		Keys:    bson.D{{"assessment_id", 1}, {"student_id", 1}},
-		Options: options.Index().SetName("index_assessment_student"),
-	}
+func (m *mongoDBClient) GetStudentCountByAssessmentID(ctx context.Context, database string) ([]entity.AssessmentStudentCount, error) {
+	// Contexto con timeout
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
 
-	_, err := collection.Indexes().CreateOne(ctx, indexModel)
+	db := m.client.Database(database)
+	view, err := m.ensureView(ctx, db, config.ViewStudentCountByAssessment)
 	if err != nil {
-		return fmt.Errorf("error al crear índice: %w", err)
+		return nil, err
 	}
-	log.Println("Índice creado en prediction_assessments para 'assessment_id' y 'student_id'")
-	return nil
-}
-
 
+	// Leer los recuentos precalculados de la ejecución activa
+	cursor, err := db.Collection(view.Target).Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los recuentos por evaluación: %w", err)
+	}
+	defer cursor.Close(ctx)
 
+	var results []entity.AssessmentStudentCount
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("error durante la iteración del cursor: %w", err)
+	}
+	return results, nil
+}
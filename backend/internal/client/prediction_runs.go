@@ -0,0 +1,220 @@
+package client
+
+import (
+	"backend/internal/config"
+	"backend/internal/entity"
+	"backend/internal/repository"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	predictionRunsCollection       = "prediction_runs"
+	activePredictionRunsCollection = "active_prediction_runs"
+	predictionRunRunning           = repository.RunStatusRunning
+	predictionRunCompleted         = repository.RunStatusCompleted
+	predictionRunFailed            = repository.RunStatusFailed
+)
+
+// Colección de predicciones asociada a cada tipo de ejecución
+var predictionCollections = map[string]string{
+	config.PredictionKindAssessments: "prediction_assessments",
+	config.PredictionKindVle:         "prediction_vle",
+}
+
+func (m *mongoDBClient) GetPredictionRuns(ctx context.Context, database, kind string) ([]entity.PredictionRun, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	db := m.client.Database(database)
+	filter := bson.M{}
+	if kind != "" {
+		if _, ok := predictionCollections[kind]; !ok {
+			return nil, fmt.Errorf("tipo de predicción no soportado: %s", kind)
+		}
+		filter["kind"] = kind
+	}
+	cursor, err := db.Collection(predictionRunsCollection).Find(ctx, filter, options.Find().SetSort(bson.M{"started_at": -1}))
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener las ejecuciones de predicción: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var runs []entity.PredictionRun
+	if err := cursor.All(ctx, &runs); err != nil {
+		return nil, fmt.Errorf("error al decodificar las ejecuciones de predicción: %w", err)
+	}
+
+	active := make(map[string]string)
+	for k := range predictionCollections {
+		runID, err := getActiveRunID(ctx, db, k)
+		if err != nil {
+			return nil, err
+		}
+		active[k] = runID
+	}
+	for i := range runs {
+		runs[i].Active = runs[i].RunID == active[runs[i].Kind]
+	}
+	return runs, nil
+}
+
+func (m *mongoDBClient) ActivatePredictionRun(ctx context.Context, database, runID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	db := m.client.Database(database)
+	var run entity.PredictionRun
+	if err := db.Collection(predictionRunsCollection).FindOne(ctx, bson.M{"_id": runID}).Decode(&run); err != nil {
+		return fmt.Errorf("ejecución de predicción no encontrada %s: %w", runID, err)
+	}
+	if run.Status != predictionRunCompleted {
+		return fmt.Errorf("la ejecución %s no está completada (%s)", runID, run.Status)
+	}
+	if err := setActiveRun(ctx, db, run.Kind, run.RunID); err != nil {
+		return err
+	}
+	m.loggers.InfoLogger.Printf("Ejecución %s activada para %s", run.RunID, run.Kind)
+	return m.refreshViewsForRun(ctx, db, run.Kind)
+}
+
+// startPredictionRun registra una nueva ejecución en curso y devuelve su identificador
+func startPredictionRun(ctx context.Context, db *mongo.Database, kind string) (string, error) {
+	run := entity.PredictionRun{
+		RunID:     primitive.NewObjectID().Hex(),
+		Kind:      kind,
+		Status:    predictionRunRunning,
+		StartedAt: time.Now(),
+	}
+	if _, err := db.Collection(predictionRunsCollection).InsertOne(ctx, run); err != nil {
+		return "", fmt.Errorf("error al registrar la ejecución de predicción: %w", err)
+	}
+	return run.RunID, nil
+}
+
+// finishPredictionRun cierra la ejecución; si terminó sin errores pasa a ser la
+// ejecución activa, se actualizan sus vistas y se aplica la política de retención
+func (m *mongoDBClient) finishPredictionRun(ctx context.Context, db *mongo.Database, kind, runID string, recordCount int, runErr error) error {
+	set := bson.M{
+		"status":       predictionRunCompleted,
+		"record_count": recordCount,
+		"finished_at":  time.Now(),
+	}
+	if runErr != nil {
+		set["status"] = predictionRunFailed
+		set["error"] = runErr.Error()
+		// Una ejecución cancelada se marca como fallida aunque el contexto ya no sea válido
+		ctx = context.WithoutCancel(ctx)
+	}
+	if _, err := db.Collection(predictionRunsCollection).UpdateByID(ctx, runID, bson.M{"$set": set}); err != nil {
+		return fmt.Errorf("error al cerrar la ejecución %s: %w", runID, err)
+	}
+	if runErr != nil {
+		return nil
+	}
+	if err := setActiveRun(ctx, db, kind, runID); err != nil {
+		return err
+	}
+	if err := m.refreshViewsForRun(ctx, db, kind); err != nil {
+		return err
+	}
+	return m.purgePredictionRuns(ctx, db, kind)
+}
+
+func setActiveRun(ctx context.Context, db *mongo.Database, kind, runID string) error {
+	_, err := db.Collection(activePredictionRunsCollection).UpdateByID(ctx, kind,
+		bson.M{"$set": bson.M{"run_id": runID, "activated_at": time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("error al activar la ejecución %s: %w", runID, err)
+	}
+	return nil
+}
+
+// getActiveRunID devuelve la ejecución activa del tipo indicado o "" si no existe
+func getActiveRunID(ctx context.Context, db *mongo.Database, kind string) (string, error) {
+	var active struct {
+		RunID string `bson:"run_id"`
+	}
+	err := db.Collection(activePredictionRunsCollection).FindOne(ctx, bson.M{"_id": kind}).Decode(&active)
+	if err == mongo.ErrNoDocuments {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error al obtener la ejecución activa de %s: %w", kind, err)
+	}
+	return active.RunID, nil
+}
+
+// activeRunMatch construye el filtro que limita una consulta a la ejecución activa
+func activeRunMatch(ctx context.Context, db *mongo.Database, kind string) (bson.M, error) {
+	runID, err := getActiveRunID(ctx, db, kind)
+	if err != nil {
+		return nil, err
+	}
+	return bson.M{"run_id": runID}, nil
+}
+
+// purgePredictionRuns conserva las últimas N ejecuciones completadas (y siempre
+// la activa) y elimina las demás junto con sus predicciones
+func (m *mongoDBClient) purgePredictionRuns(ctx context.Context, db *mongo.Database, kind string) error {
+	retention := viper.GetInt(config.PredictionRunsRetention)
+	if retention <= 0 {
+		return nil
+	}
+	activeID, err := getActiveRunID(ctx, db, kind)
+	if err != nil {
+		return err
+	}
+
+	opts := options.Find().SetSort(bson.M{"started_at": -1}).SetProjection(bson.M{"_id": 1, "status": 1})
+	cursor, err := db.Collection(predictionRunsCollection).Find(ctx, bson.M{"kind": kind}, opts)
+	if err != nil {
+		return fmt.Errorf("error al obtener ejecuciones para la retención: %w", err)
+	}
+	var runs []entity.PredictionRun
+	if err := cursor.All(ctx, &runs); err != nil {
+		return fmt.Errorf("error al decodificar ejecuciones para la retención: %w", err)
+	}
+
+	expired := expiredPredictionRuns(runs, activeID, retention)
+	if len(expired) == 0 {
+		return nil
+	}
+
+	deleted, err := db.Collection(predictionCollections[kind]).DeleteMany(ctx, bson.M{"run_id": bson.M{"$in": expired}})
+	if err != nil {
+		return fmt.Errorf("error al eliminar predicciones de ejecuciones antiguas: %w", err)
+	}
+	if _, err := db.Collection(predictionRunsCollection).DeleteMany(ctx, bson.M{"_id": bson.M{"$in": expired}}); err != nil {
+		return fmt.Errorf("error al eliminar ejecuciones antiguas: %w", err)
+	}
+	m.loggers.InfoLogger.Printf("Retención %s: %d ejecuciones y %d predicciones eliminadas", kind, len(expired), deleted.DeletedCount)
+	return nil
+}
+
+// expiredPredictionRuns selecciona, de las ejecuciones ordenadas de más reciente
+// a más antigua, las que quedan fuera de la política de retención
+func expiredPredictionRuns(runs []entity.PredictionRun, activeID string, retention int) []string {
+	var expired []string
+	kept := 0
+	for _, run := range runs {
+		if run.RunID == activeID || run.Status == predictionRunRunning {
+			continue
+		}
+		if run.Status == predictionRunCompleted && kept < retention-1 {
+			kept++
+			continue
+		}
+		expired = append(expired, run.RunID)
+	}
+	return expired
+}
@@ -0,0 +1,368 @@
+package client
+
+import (
+	"backend/internal/entity"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	dataProfilesCollection = "data_profiles"
+	profileTopValues       = 10
+	profileHistogramBins   = 10
+)
+
+// ProfileDataset calcula el perfil de cada campo de las colecciones de OULAD y
+// lo guarda para la versión actual del conjunto de datos
+func (m *mongoDBClient) ProfileDataset(ctx context.Context, database string) (*entity.DataProfile, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Hour)
+	defer cancel()
+
+	db := m.client.Database(database)
+	version, err := getDatasetVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	profile := &entity.DataProfile{
+		DatasetVersion: version,
+		ComputedAt:     time.Now(),
+		Collections:    make([]entity.CollectionProfile, 0, len(ouladCollections)),
+	}
+	for _, collection := range ouladCollections {
+		collectionProfile, err := profileCollection(ctx, db.Collection(collection.Name), collection.schemaFields())
+		if err != nil {
+			return nil, err
+		}
+		profile.Collections = append(profile.Collections, *collectionProfile)
+	}
+	profile.DurationMs = time.Since(profile.ComputedAt).Milliseconds()
+
+	_, err = db.Collection(dataProfilesCollection).ReplaceOne(ctx, bson.M{"_id": version}, profile, options.Replace().SetUpsert(true))
+	if err != nil {
+		return nil, fmt.Errorf("error al guardar el perfil de datos: %w", err)
+	}
+	m.loggers.InfoLogger.Printf("Perfil de datos de la versión %d calculado en %d ms", version, profile.DurationMs)
+	return profile, nil
+}
+
+// GetDataProfile devuelve el perfil guardado de una versión (la más reciente si version es 0)
+func (m *mongoDBClient) GetDataProfile(ctx context.Context, database string, version int) (*entity.DataProfile, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	return loadDataProfile(ctx, m.client.Database(database), version)
+}
+
+// DiffDataProfiles compara los perfiles de dos versiones del conjunto de datos
+func (m *mongoDBClient) DiffDataProfiles(ctx context.Context, database string, from, to int) (*entity.DataProfileDiff, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if from <= 0 || to <= 0 {
+		return nil, fmt.Errorf("from y to deben ser versiones del conjunto de datos")
+	}
+	db := m.client.Database(database)
+	before, err := loadDataProfile(ctx, db, from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := loadDataProfile(ctx, db, to)
+	if err != nil {
+		return nil, err
+	}
+	diff := diffDataProfiles(before, after)
+	return &diff, nil
+}
+
+func loadDataProfile(ctx context.Context, db *mongo.Database, version int) (*entity.DataProfile, error) {
+	filter, opts := bson.M{"_id": version}, options.FindOne()
+	if version == 0 {
+		filter, opts = bson.M{}, opts.SetSort(bson.M{"_id": -1})
+	}
+	var profile entity.DataProfile
+	err := db.Collection(dataProfilesCollection).FindOne(ctx, filter, opts).Decode(&profile)
+	if err == mongo.ErrNoDocuments {
+		if version == 0 {
+			return nil, fmt.Errorf("perfil de datos %w; ejecute el perfilado primero", entity.ErrNotFound)
+		}
+		return nil, fmt.Errorf("perfil de datos %w para la versión %d", entity.ErrNotFound, version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el perfil de datos: %w", err)
+	}
+	return &profile, nil
+}
+
+// profileCollection perfila los campos de la entidad y cualquier otro campo
+// presente en los documentos
+func profileCollection(ctx context.Context, collection *mongo.Collection, schema []schemaField) (*entity.CollectionProfile, error) {
+	documents, err := collection.EstimatedDocumentCount(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error al contar documentos de %s: %w", collection.Name(), err)
+	}
+	fields, err := collectionFields(ctx, collection, schema)
+	if err != nil {
+		return nil, err
+	}
+	profile := &entity.CollectionProfile{
+		Collection: collection.Name(),
+		Documents:  documents,
+		Fields:     make([]entity.FieldProfile, 0, len(fields)),
+	}
+	for _, field := range fields {
+		fieldProfile, err := profileField(ctx, collection, field)
+		if err != nil {
+			return nil, err
+		}
+		profile.Fields = append(profile.Fields, *fieldProfile)
+	}
+	return profile, nil
+}
+
+// collectionFields devuelve los campos de la entidad, en su orden, seguidos de
+// los campos inesperados encontrados en los documentos
+func collectionFields(ctx context.Context, collection *mongo.Collection, schema []schemaField) ([]string, error) {
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$project", Value: bson.M{"keys": bson.M{"$map": bson.M{
+			"input": bson.M{"$objectToArray": "$$ROOT"},
+			"in":    "$$this.k",
+		}}}}},
+		{{Key: "$unwind", Value: "$keys"}},
+		{{Key: "$group", Value: bson.M{"_id": "$keys"}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los campos de %s: %w", collection.Name(), err)
+	}
+	var found []struct {
+		Name string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, fmt.Errorf("error al decodificar los campos de %s: %w", collection.Name(), err)
+	}
+
+	fields := make([]string, 0, len(schema)+len(found))
+	known := map[string]bool{"_id": true}
+	for _, field := range schema {
+		fields = append(fields, field.Name)
+		known[field.Name] = true
+	}
+	var extra []string
+	for _, field := range found {
+		if !known[field.Name] {
+			extra = append(extra, field.Name)
+		}
+	}
+	sort.Strings(extra)
+	return append(fields, extra...), nil
+}
+
+// profileField calcula tipos, valores ausentes y nulos, valores distintos y más
+// frecuentes y, si el campo tiene valores numéricos, su rango, media e histograma
+func profileField(ctx context.Context, collection *mongo.Collection, field string) (*entity.FieldProfile, error) {
+	profile := &entity.FieldProfile{
+		Field:     field,
+		Types:     map[string]int64{},
+		Histogram: []entity.ProfileBin{},
+		TopValues: []entity.ValueCount{},
+	}
+	path := "$" + field
+
+	// Tipos BSON, incluidos "missing" y "null"
+	var types []struct {
+		Type  string `bson:"_id"`
+		Count int64  `bson:"count"`
+	}
+	if err := aggregateAll(ctx, collection, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": bson.M{"$type": path}, "count": bson.M{"$sum": 1}}}},
+	}, &types); err != nil {
+		return nil, fmt.Errorf("error al obtener los tipos de %s.%s: %w", collection.Name(), field, err)
+	}
+	var total, numeric int64
+	valueTypes := 0
+	for _, t := range types {
+		profile.Types[t.Type] = t.Count
+		total += t.Count
+		switch t.Type {
+		case "missing":
+			profile.Missing = t.Count
+		case "null":
+			profile.Nulls = t.Count
+		case "int", "long", "double", "decimal":
+			numeric += t.Count
+			valueTypes++
+		default:
+			valueTypes++
+		}
+	}
+	if total > 0 {
+		profile.MissingRate = float64(profile.Missing) / float64(total)
+		profile.NullRate = float64(profile.Nulls) / float64(total)
+	}
+	profile.TypeConsistent = valueTypes <= 1
+
+	// Valores distintos y más frecuentes
+	var values []struct {
+		Distinct []struct {
+			Count int64 `bson:"count"`
+		} `bson:"distinct"`
+		Top []entity.ValueCount `bson:"top"`
+	}
+	if err := aggregateAll(ctx, collection, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{field: bson.M{"$exists": true, "$ne": nil}}}},
+		{{Key: "$group", Value: bson.M{"_id": path, "count": bson.M{"$sum": 1}}}},
+		{{Key: "$facet", Value: bson.M{
+			"distinct": bson.A{bson.M{"$count": "count"}},
+			"top": bson.A{
+				bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+				bson.M{"$limit": profileTopValues},
+				bson.M{"$project": bson.M{"_id": 0, "value": "$_id", "count": 1}},
+			},
+		}}},
+	}, &values); err != nil {
+		return nil, fmt.Errorf("error al obtener los valores de %s.%s: %w", collection.Name(), field, err)
+	}
+	if len(values) > 0 {
+		if len(values[0].Distinct) > 0 {
+			profile.Distinct = values[0].Distinct[0].Count
+		}
+		profile.TopValues = values[0].Top
+	}
+	if numeric == 0 {
+		return profile, nil
+	}
+
+	// Rango, media e histograma de los valores numéricos
+	numericMatch := bson.D{{Key: "$match", Value: bson.M{field: bson.M{"$type": "number"}}}}
+	var stats []struct {
+		Min  float64 `bson:"min"`
+		Max  float64 `bson:"max"`
+		Mean float64 `bson:"mean"`
+	}
+	if err := aggregateAll(ctx, collection, mongo.Pipeline{
+		numericMatch,
+		{{Key: "$group", Value: bson.M{
+			"_id":  nil,
+			"min":  bson.M{"$min": path},
+			"max":  bson.M{"$max": path},
+			"mean": bson.M{"$avg": path},
+		}}},
+	}, &stats); err != nil {
+		return nil, fmt.Errorf("error al obtener el rango de %s.%s: %w", collection.Name(), field, err)
+	}
+	if len(stats) > 0 {
+		profile.Min, profile.Max, profile.Mean = &stats[0].Min, &stats[0].Max, &stats[0].Mean
+	}
+	var bins []struct {
+		Range struct {
+			Min float64 `bson:"min"`
+			Max float64 `bson:"max"`
+		} `bson:"_id"`
+		Count int64 `bson:"count"`
+	}
+	if err := aggregateAll(ctx, collection, mongo.Pipeline{
+		numericMatch,
+		{{Key: "$bucketAuto", Value: bson.M{"groupBy": path, "buckets": profileHistogramBins}}},
+	}, &bins); err != nil {
+		return nil, fmt.Errorf("error al obtener el histograma de %s.%s: %w", collection.Name(), field, err)
+	}
+	for _, bin := range bins {
+		profile.Histogram = append(profile.Histogram, entity.ProfileBin{Min: bin.Range.Min, Max: bin.Range.Max, Count: bin.Count})
+	}
+	return profile, nil
+}
+
+func aggregateAll(ctx context.Context, collection *mongo.Collection, pipeline mongo.Pipeline, results interface{}) error {
+	cursor, err := collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return err
+	}
+	return cursor.All(ctx, results)
+}
+
+// diffDataProfiles enumera las métricas que cambian entre dos perfiles, incluidos
+// los campos y colecciones que aparecen o desaparecen
+func diffDataProfiles(from, to *entity.DataProfile) entity.DataProfileDiff {
+	diff := entity.DataProfileDiff{From: from.DatasetVersion, To: to.DatasetVersion, Changes: []entity.ProfileChange{}}
+	change := func(collection, field, metric string, before, after interface{}) {
+		if before != after {
+			diff.Changes = append(diff.Changes, entity.ProfileChange{
+				Collection: collection, Field: field, Metric: metric, From: before, To: after,
+			})
+		}
+	}
+
+	previous := make(map[string]entity.CollectionProfile, len(from.Collections))
+	for _, collection := range from.Collections {
+		previous[collection.Collection] = collection
+	}
+	for _, current := range to.Collections {
+		before, ok := previous[current.Collection]
+		if !ok {
+			change(current.Collection, "", "documents", nil, current.Documents)
+			continue
+		}
+		delete(previous, current.Collection)
+		change(current.Collection, "", "documents", before.Documents, current.Documents)
+
+		fields := make(map[string]entity.FieldProfile, len(before.Fields))
+		for _, field := range before.Fields {
+			fields[field.Field] = field
+		}
+		for _, field := range current.Fields {
+			old, ok := fields[field.Field]
+			if !ok {
+				change(current.Collection, field.Field, "present", false, true)
+				continue
+			}
+			delete(fields, field.Field)
+			change(current.Collection, field.Field, "missing_rate", old.MissingRate, field.MissingRate)
+			change(current.Collection, field.Field, "null_rate", old.NullRate, field.NullRate)
+			change(current.Collection, field.Field, "distinct", old.Distinct, field.Distinct)
+			change(current.Collection, field.Field, "type_consistent", old.TypeConsistent, field.TypeConsistent)
+			change(current.Collection, field.Field, "min", floatValue(old.Min), floatValue(field.Min))
+			change(current.Collection, field.Field, "max", floatValue(old.Max), floatValue(field.Max))
+			change(current.Collection, field.Field, "mean", floatValue(old.Mean), floatValue(field.Mean))
+			for _, t := range profileTypes(old.Types, field.Types) {
+				change(current.Collection, field.Field, "types."+t, old.Types[t], field.Types[t])
+			}
+		}
+		for _, field := range before.Fields {
+			if _, removed := fields[field.Field]; removed {
+				change(current.Collection, field.Field, "present", true, false)
+			}
+		}
+	}
+	for _, collection := range from.Collections {
+		if _, removed := previous[collection.Collection]; removed {
+			change(collection.Collection, "", "documents", collection.Documents, nil)
+		}
+	}
+	return diff
+}
+
+// floatValue desreferencia una métrica opcional para compararla por valor
+func floatValue(value *float64) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+func profileTypes(a, b map[string]int64) []string {
+	types := make([]string, 0, len(a)+len(b))
+	for t := range a {
+		types = append(types, t)
+	}
+	for t := range b {
+		if _, ok := a[t]; !ok {
+			types = append(types, t)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
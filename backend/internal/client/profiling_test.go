@@ -0,0 +1,47 @@
+package client
+
+import (
+	"backend/internal/entity"
+	"reflect"
+	"testing"
+)
+
+func TestDiffDataProfiles(t *testing.T) {
+	one, sameOne := 1.0, 1.0
+	from := &entity.DataProfile{DatasetVersion: 1, Collections: []entity.CollectionProfile{
+		{Collection: "assessments", Documents: 10, Fields: []entity.FieldProfile{
+			{Field: "score", Distinct: 5, TypeConsistent: true, Min: &one, Types: map[string]int64{"int": 10}},
+			{Field: "weight", Distinct: 2},
+		}},
+		{Collection: "courses", Documents: 3},
+	}}
+	to := &entity.DataProfile{DatasetVersion: 2, Collections: []entity.CollectionProfile{
+		{Collection: "assessments", Documents: 12, Fields: []entity.FieldProfile{
+			{Field: "score", Distinct: 6, TypeConsistent: false, Min: &sameOne, Types: map[string]int64{"int": 10, "string": 2}},
+			{Field: "date", Distinct: 4},
+		}},
+		{Collection: "vle", Documents: 7},
+	}}
+
+	diff := diffDataProfiles(from, to)
+	want := []entity.ProfileChange{
+		{Collection: "assessments", Metric: "documents", From: int64(10), To: int64(12)},
+		{Collection: "assessments", Field: "score", Metric: "distinct", From: int64(5), To: int64(6)},
+		{Collection: "assessments", Field: "score", Metric: "type_consistent", From: true, To: false},
+		{Collection: "assessments", Field: "score", Metric: "types.string", From: int64(0), To: int64(2)},
+		{Collection: "assessments", Field: "date", Metric: "present", From: false, To: true},
+		{Collection: "assessments", Field: "weight", Metric: "present", From: true, To: false},
+		{Collection: "vle", Metric: "documents", From: nil, To: int64(7)},
+		{Collection: "courses", Metric: "documents", From: int64(3), To: nil},
+	}
+	if diff.From != 1 || diff.To != 2 {
+		t.Errorf("versiones %d -> %d, se esperaba 1 -> 2", diff.From, diff.To)
+	}
+	if !reflect.DeepEqual(diff.Changes, want) {
+		t.Errorf("cambios:\n%+v\nse esperaba:\n%+v", diff.Changes, want)
+	}
+
+	if same := diffDataProfiles(from, from); len(same.Changes) != 0 {
+		t.Errorf("un perfil no debería diferir de sí mismo: %+v", same.Changes)
+	}
+}
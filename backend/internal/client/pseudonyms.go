@@ -0,0 +1,57 @@
+package client
+
+import (
+	"backend/internal/entity"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// studentPseudonymsCollection correspondencia seudónimo -> id_student. Se escribe
+// al cargar studentInfo y solo la leen la reidentificación y la supresión, ambas
+// de administración; GetData no la expone (repository.DataCollections)
+const studentPseudonymsCollection = "student_pseudonyms"
+
+// SaveStudentPseudonyms guarda o reemplaza la correspondencia de cada seudónimo
+func (m *mongoDBClient) SaveStudentPseudonyms(ctx context.Context, database string, pseudonyms []entity.StudentPseudonym) error {
+	if len(pseudonyms) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	models := make([]mongo.WriteModel, len(pseudonyms))
+	for i, pseudonym := range pseudonyms {
+		models[i] = mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": pseudonym.Pseudonym}).
+			SetReplacement(pseudonym).
+			SetUpsert(true)
+	}
+	_, err := m.client.Database(database).Collection(studentPseudonymsCollection).
+		BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return fmt.Errorf("error al guardar los seudónimos: %w", err)
+	}
+	return nil
+}
+
+// GetStudentPseudonym devuelve el id_student original de un seudónimo
+func (m *mongoDBClient) GetStudentPseudonym(ctx context.Context, database string, pseudonym int) (*entity.StudentPseudonym, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var result entity.StudentPseudonym
+	err := m.client.Database(database).Collection(studentPseudonymsCollection).
+		FindOne(ctx, bson.M{"_id": pseudonym}).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("seudónimo %w: %d", entity.ErrNotFound, pseudonym)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el seudónimo: %w", err)
+	}
+	return &result, nil
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"backend/internal/entity"
+	"backend/internal/repository"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Repositories expone el cliente de MongoDB a través de los repositorios de persistencia
+func (m *mongoDBClient) Repositories() repository.Repositories {
+	return repository.Repositories{
+		Dataset:          m,
+		DatasetVersions:  m,
+		PredictionRuns:   m,
+		VleScoringConfig: m,
+		AnalyticsCache:   m,
+		Pseudonyms:       m,
+		Users:            m,
+		Predictions:      m,
+		Analytics:        m,
+		DataQuality:      m,
+		Views:            m,
+		Migrations:       m,
+		Erasure:          m,
+	}
+}
+
+func (m *mongoDBClient) CountBy(ctx context.Context, database, collection string, fields []string, filter repository.Filter) ([]repository.GroupCount, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	match := bson.M{}
+	for field, value := range filter {
+		match[field] = value
+	}
+	groupID := bson.D{}
+	for _, field := range fields {
+		groupID = append(groupID, bson.E{Key: field, Value: "$" + field})
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{"_id": groupID, "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+	cursor, err := m.client.Database(database).Collection(collection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("error al agrupar %s: %w", collection, err)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Key   map[string]interface{} `bson:"_id"`
+		Count int                    `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("error al decodificar los grupos de %s: %w", collection, err)
+	}
+	groups := make([]repository.GroupCount, 0, len(rows))
+	for _, row := range rows {
+		groups = append(groups, repository.GroupCount{Key: row.Key, Count: row.Count})
+	}
+	return groups, nil
+}
+
+func (m *mongoDBClient) GetDatasetVersion(ctx context.Context, database string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	return getDatasetVersion(ctx, m.client.Database(database))
+}
+
+func (m *mongoDBClient) ReadAnalyticsCache(ctx context.Context, database, key string, version int, out interface{}) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	return readAnalyticsCache(ctx, m.client.Database(database), key, version, out)
+}
+
+func (m *mongoDBClient) WriteAnalyticsCache(ctx context.Context, database, key string, version int, data interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	return writeAnalyticsCache(ctx, m.client.Database(database), key, version, data)
+}
+
+func (m *mongoDBClient) SavePredictionRun(ctx context.Context, database string, run entity.PredictionRun) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	_, err := m.client.Database(database).Collection(predictionRunsCollection).ReplaceOne(ctx,
+		bson.M{"_id": run.RunID},
+		run,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("error al guardar la ejecución de predicción %s: %w", run.RunID, err)
+	}
+	return nil
+}
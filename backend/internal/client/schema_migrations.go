@@ -0,0 +1,174 @@
+package client
+
+import (
+	"backend/internal/config"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// schemaMigrations es el historial del esquema de MongoDB, ordenado por versión.
+// Una migración aplicada no se modifica: los cambios se añaden como una versión nueva
+var schemaMigrations = []migration{
+	{
+		Version: 1,
+		Name:    "drop_legacy_indexes",
+		Steps: []migrationStep{
+			// Índice de los recuentos por evaluación anteriores a las ejecuciones de predicción
+			dropIndexStep{
+				Collection: "prediction_assessments",
+				Name:       "index_assessment_student",
+				Keys:       bson.D{{Key: "assessment_id", Value: 1}, {Key: "student_id", Value: 1}},
+			},
+		},
+	},
+	{
+		Version: 2,
+		Name:    "remove_legacy_predictions",
+		Steps: []migrationStep{
+			// Las predicciones sin run_id son anteriores a las ejecuciones: ningún
+			// gráfico las lee y pueden repetir (estudiante, evaluación), lo que
+			// impide crear los índices únicos
+			backfillStep{
+				Collection:  "prediction_assessments",
+				Description: "eliminar predicciones sin run_id",
+				Filter:      bson.M{"run_id": bson.M{"$exists": false}},
+				Delete:      true,
+			},
+			backfillStep{
+				Collection:  "prediction_vle",
+				Description: "eliminar predicciones sin run_id",
+				Filter:      bson.M{"run_id": bson.M{"$exists": false}},
+				Delete:      true,
+			},
+		},
+	},
+	{
+		Version: 3,
+		Name:    "initial_indexes",
+		Steps: []migrationStep{
+			createIndexStep{
+				Collection: "prediction_assessments",
+				Name:       "index_run_student_assessment",
+				Keys:       bson.D{{Key: "run_id", Value: 1}, {Key: "student_id", Value: 1}, {Key: "assessment_id", Value: 1}},
+				Unique:     true,
+			},
+			createIndexStep{
+				Collection: "prediction_assessments",
+				Name:       "index_assessment_id",
+				Keys:       bson.D{{Key: "assessment_id", Value: 1}},
+			},
+			createIndexStep{
+				Collection: "prediction_vle",
+				Name:       "index_run_student_presentation",
+				Keys: bson.D{
+					{Key: "run_id", Value: 1},
+					{Key: "student_id", Value: 1},
+					{Key: "code_module", Value: 1},
+					{Key: "code_presentation", Value: 1},
+				},
+				Unique: true,
+			},
+			createIndexStep{
+				Collection: "assessments",
+				Name:       "index_assessmenttype",
+				Keys:       bson.D{{Key: "assessmenttype", Value: 1}},
+			},
+			createIndexStep{
+				Collection: "assessments",
+				Name:       "index_idassessment",
+				Keys:       bson.D{{Key: "idassessment", Value: 1}},
+			},
+			createIndexStep{
+				Collection: "studentAssessment",
+				Name:       "index_student_assessment",
+				Keys:       bson.D{{Key: "idstudent", Value: 1}, {Key: "idassessment", Value: 1}},
+			},
+			createIndexStep{
+				Collection: "vle",
+				Name:       "index_site_module_presentation",
+				Keys:       bson.D{{Key: "idsite", Value: 1}, {Key: "codemodule", Value: 1}, {Key: "codepresentation", Value: 1}},
+			},
+			createIndexStep{
+				Collection: "studentVle",
+				Name:       "index_presentation_student",
+				Keys:       bson.D{{Key: "codemodule", Value: 1}, {Key: "codepresentation", Value: 1}, {Key: "idstudent", Value: 1}},
+			},
+			createIndexStep{
+				Collection: engagementWeeklyCollection,
+				Name:       "index_presentation_student_week",
+				Keys: bson.D{
+					{Key: "code_module", Value: 1},
+					{Key: "code_presentation", Value: 1},
+					{Key: "student_id", Value: 1},
+					{Key: "week", Value: 1},
+				},
+			},
+			createIndexStep{
+				Collection: predictionRunsCollection,
+				Name:       "index_kind_started_at",
+				Keys:       bson.D{{Key: "kind", Value: 1}, {Key: "started_at", Value: -1}},
+			},
+		},
+	},
+	{
+		Version: 4,
+		Name:    "prediction_runs_validator",
+		Steps: []migrationStep{
+			createCollectionStep{
+				Collection: predictionRunsCollection,
+				Validator: bson.M{"$jsonSchema": bson.M{
+					"bsonType": "object",
+					"required": []string{"_id", "kind", "status", "started_at"},
+					"properties": bson.M{
+						"_id":          bson.M{"bsonType": "string"},
+						"kind":         bson.M{"enum": []string{config.PredictionKindAssessments, config.PredictionKindVle}},
+						"status":       bson.M{"enum": []string{predictionRunRunning, predictionRunCompleted, predictionRunFailed}},
+						"record_count": bson.M{"bsonType": []string{"int", "long"}},
+						"started_at":   bson.M{"bsonType": "date"},
+						"finished_at":  bson.M{"bsonType": "date"},
+					},
+				}},
+			},
+		},
+	},
+	{
+		Version: 5,
+		Name:    "oulad_validators",
+		Steps:   ouladValidatorSteps(),
+	},
+	{
+		Version: 6,
+		Name:    "integrity_indexes",
+		Steps: []migrationStep{
+			// Búsquedas de las comprobaciones de integridad en las colecciones de referencia
+			createIndexStep{
+				Collection: "studentInfo",
+				Name:       "index_presentation_student",
+				Keys:       bson.D{{Key: "codemodule", Value: 1}, {Key: "codepresentation", Value: 1}, {Key: "idstudent", Value: 1}},
+			},
+			createIndexStep{
+				Collection: "courses",
+				Name:       "index_module_presentation",
+				Keys:       bson.D{{Key: "codemodule", Value: 1}, {Key: "codepresentation", Value: 1}},
+			},
+			createIndexStep{
+				Collection: integrityReportsCollection,
+				Name:       "index_started_at",
+				Keys:       bson.D{{Key: "started_at", Value: -1}},
+			},
+		},
+	},
+	{
+		Version: 7,
+		Name:    "users",
+		Steps: []migrationStep{
+			loginUsersStep{},
+			createIndexStep{
+				Collection: refreshTokensCollection,
+				Name:       "index_expires_at",
+				Keys:       bson.D{{Key: "expires_at", Value: 1}},
+				TTL:        true,
+			},
+		},
+	},
+}
@@ -0,0 +1,209 @@
+package client
+
+import (
+	"backend/internal/entity"
+	"backend/internal/repository"
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultViolationSamples = 10
+	maxViolationSamples     = 100
+)
+
+// ouladCollection relaciona una colección de OULAD con la entidad con la que se carga
+type ouladCollection struct {
+	Name   string
+	Entity interface{}
+}
+
+var ouladCollections = []ouladCollection{
+	{"courses", entity.Courses{}},
+	{"assessments", entity.Assessments{}},
+	{"vle", entity.Vle{}},
+	{"studentInfo", entity.StudentInfo{}},
+	{"studentRegistration", entity.StudentRegistration{}},
+	{"studentAssessment", entity.StudentAssessment{}},
+	{"studentVle", entity.StudentVle{}},
+}
+
+// Valores admitidos en los campos categóricos, por colección y campo
+var ouladEnums = map[string]map[string][]string{
+	"assessments": {"assessmenttype": repository.AssessmentTypes},
+	"vle":         {"activitytype": repository.ActivityTypes},
+	"studentInfo": {"finalresult": repository.FinalResults},
+}
+
+// schemaField tipos BSON y valores admitidos de un campo
+type schemaField struct {
+	Name      string
+	BsonTypes []string
+	Enum      []string
+}
+
+// schemaFields deriva los campos de la colección de su entidad. Sin etiquetas
+// bson el driver guarda cada campo con su nombre en minúsculas, los enteros como
+// int o long según su tamaño y los float64 como double
+func (c ouladCollection) schemaFields() []schemaField {
+	t := reflect.TypeOf(c.Entity)
+	fields := make([]schemaField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.ToLower(t.Field(i).Name)
+		field := schemaField{Name: name, Enum: ouladEnums[c.Name][name]}
+		switch t.Field(i).Type.Kind() {
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			field.BsonTypes = []string{"int", "long"}
+		case reflect.Float32, reflect.Float64:
+			field.BsonTypes = []string{"double"}
+		case reflect.Bool:
+			field.BsonTypes = []string{"bool"}
+		default:
+			field.BsonTypes = []string{"string"}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// validator genera el $jsonSchema de la colección; todos los campos son obligatorios
+// porque processBatch los escribe siempre
+func (c ouladCollection) validator() bson.M {
+	fields := c.schemaFields()
+	required := make([]string, len(fields))
+	properties := bson.M{}
+	for i, field := range fields {
+		required[i] = field.Name
+		property := bson.M{"bsonType": field.BsonTypes}
+		if len(field.Enum) > 0 {
+			property["enum"] = field.Enum
+		}
+		properties[field.Name] = property
+	}
+	return bson.M{"$jsonSchema": bson.M{
+		"bsonType":   "object",
+		"required":   required,
+		"properties": properties,
+	}}
+}
+
+// ouladValidatorSteps crea cada colección de OULAD con su validador
+func ouladValidatorSteps() []migrationStep {
+	steps := make([]migrationStep, len(ouladCollections))
+	for i, collection := range ouladCollections {
+		steps[i] = createCollectionStep{Collection: collection.Name, Validator: collection.validator()}
+	}
+	return steps
+}
+
+// schemaErrors describe los campos del documento que no cumplen el esquema
+func schemaErrors(document bson.M, fields []schemaField) []string {
+	errors := []string{}
+	for _, field := range fields {
+		value, ok := document[field.Name]
+		if !ok {
+			errors = append(errors, fmt.Sprintf("%s: campo obligatorio ausente", field.Name))
+			continue
+		}
+		if bsonType := bsonTypeName(value); !slices.Contains(field.BsonTypes, bsonType) {
+			errors = append(errors, fmt.Sprintf("%s: se esperaba %s y es %s", field.Name, strings.Join(field.BsonTypes, " o "), bsonType))
+			continue
+		}
+		if len(field.Enum) > 0 && !slices.Contains(field.Enum, value.(string)) {
+			errors = append(errors, fmt.Sprintf("%s: valor no admitido %q", field.Name, value))
+		}
+	}
+	return errors
+}
+
+// bsonTypeName devuelve el alias de $type del valor decodificado
+func bsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case int32:
+		return "int"
+	case int64:
+		return "long"
+	case float64:
+		return "double"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case bson.M, bson.D:
+		return "object"
+	case bson.A:
+		return "array"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// GetSchemaViolations busca los documentos ya almacenados que no cumplen el
+// validador de su colección y devuelve una muestra con los campos que fallan
+func (m *mongoDBClient) GetSchemaViolations(ctx context.Context, database string, query entity.SchemaViolationQuery) (*entity.SchemaViolationReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultViolationSamples
+	}
+	if limit > maxViolationSamples {
+		return nil, fmt.Errorf("limit no puede superar %d", maxViolationSamples)
+	}
+
+	db := m.client.Database(database)
+	report := &entity.SchemaViolationReport{CheckedAt: time.Now(), Collections: []entity.CollectionViolations{}}
+	for _, collection := range ouladCollections {
+		if query.Collection != "" && query.Collection != collection.Name {
+			continue
+		}
+		coll := db.Collection(collection.Name)
+		documents, err := coll.EstimatedDocumentCount(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error al contar documentos de %s: %w", collection.Name, err)
+		}
+		filter := bson.M{"$nor": bson.A{collection.validator()}}
+		violations, err := coll.CountDocuments(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("error al contar documentos no válidos de %s: %w", collection.Name, err)
+		}
+
+		result := entity.CollectionViolations{
+			Collection: collection.Name,
+			Documents:  documents,
+			Violations: violations,
+			Samples:    []entity.SchemaViolation{},
+		}
+		if violations > 0 {
+			cursor, err := coll.Find(ctx, filter, options.Find().SetLimit(int64(limit)))
+			if err != nil {
+				return nil, fmt.Errorf("error al obtener documentos no válidos de %s: %w", collection.Name, err)
+			}
+			var samples []bson.M
+			if err := cursor.All(ctx, &samples); err != nil {
+				return nil, fmt.Errorf("error al decodificar documentos no válidos de %s: %w", collection.Name, err)
+			}
+			fields := collection.schemaFields()
+			for _, sample := range samples {
+				result.Samples = append(result.Samples, entity.SchemaViolation{
+					Document: sample,
+					Errors:   schemaErrors(sample, fields),
+				})
+			}
+		}
+		report.Collections = append(report.Collections, result)
+	}
+	if len(report.Collections) == 0 {
+		return nil, fmt.Errorf("colección no válida: %s", query.Collection)
+	}
+	return report, nil
+}
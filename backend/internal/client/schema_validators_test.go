@@ -0,0 +1,56 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestSchemaFields(t *testing.T) {
+	collection := ouladCollection{"studentInfo", struct {
+		IdStudent   int
+		Score       float64
+		FinalResult string
+	}{}}
+	want := []schemaField{
+		{Name: "idstudent", BsonTypes: []string{"int", "long"}},
+		{Name: "score", BsonTypes: []string{"double"}},
+		{Name: "finalresult", BsonTypes: []string{"string"}, Enum: ouladEnums["studentInfo"]["finalresult"]},
+	}
+	if got := collection.schemaFields(); !reflect.DeepEqual(got, want) {
+		t.Errorf("schemaFields = %+v, se esperaba %+v", got, want)
+	}
+}
+
+func TestSchemaErrors(t *testing.T) {
+	fields := []schemaField{
+		{Name: "idstudent", BsonTypes: []string{"int", "long"}},
+		{Name: "finalresult", BsonTypes: []string{"string"}, Enum: []string{"Pass", "Fail"}},
+		{Name: "score", BsonTypes: []string{"double"}},
+	}
+	tests := []struct {
+		name     string
+		document bson.M
+		want     []string
+	}{
+		{"válido", bson.M{"idstudent": int32(1), "finalresult": "Pass", "score": 1.5}, []string{}},
+		{"entero largo", bson.M{"idstudent": int64(1), "finalresult": "Fail", "score": 0.0}, []string{}},
+		{"campo ausente", bson.M{"finalresult": "Pass", "score": 1.5}, []string{"idstudent: campo obligatorio ausente"}},
+		{"tipo incorrecto", bson.M{"idstudent": "1", "finalresult": "Pass", "score": 1.5}, []string{"idstudent: se esperaba int o long y es string"}},
+		{"valor no admitido", bson.M{"idstudent": int32(1), "finalresult": "Maybe", "score": 1.5}, []string{`finalresult: valor no admitido "Maybe"`}},
+		{"nulo", bson.M{"idstudent": int32(1), "finalresult": "Pass", "score": nil}, []string{"score: se esperaba double y es null"}},
+		{"varios errores", bson.M{"finalresult": int32(3)}, []string{
+			"idstudent: campo obligatorio ausente",
+			"finalresult: se esperaba string y es int",
+			"score: campo obligatorio ausente",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := schemaErrors(tt.document, fields); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("schemaErrors = %q, se esperaba %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,815 @@
+package client
+
+import (
+	"backend/internal/config"
+	"backend/internal/entity"
+	"backend/internal/repository"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Las analíticas del backend SQL cargan las mismas filas que las agregaciones de
+// MongoDB y reutilizan los mismos cálculos en Go
+
+func (s *sqlClient) EvaluateModel(ctx context.Context, database, modelID string, request entity.ModelEvaluationRequest) (*entity.ModelEvaluation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	registered, ok := evaluableModels[modelID]
+	if !ok {
+		return nil, fmt.Errorf("modelo %w: %s", entity.ErrNotFound, modelID)
+	}
+	if err := normalizeEvaluationRequest(&request); err != nil {
+		return nil, err
+	}
+	runID, err := s.evaluationRunID(ctx, registered.Kind, request.RunID)
+	if err != nil {
+		return nil, err
+	}
+	var evaluation *entity.ModelEvaluation
+	switch registered.Kind {
+	case config.PredictionKindAssessments:
+		samples, err := s.loadPredictionSamples(ctx, runID, 0)
+		if err != nil {
+			return nil, err
+		}
+		evaluation, err = evaluateAssessmentModel(modelID, runID, samples, request)
+		if err != nil {
+			return nil, err
+		}
+	case config.PredictionKindVle:
+		predictions, err := s.loadVlePredictions(ctx, runID)
+		if err != nil {
+			return nil, err
+		}
+		finalResults, err := s.loadFinalResults(ctx)
+		if err != nil {
+			return nil, err
+		}
+		evaluation, err = evaluateVleModel(modelID, runID, predictions, finalResults, request)
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.loggers.InfoLogger.Printf("Modelo %s evaluado con %d muestras de la ejecución %s (%s)", modelID, evaluation.SampleCount, runID, request.Strategy)
+
+	data, err := bson.Marshal(evaluation)
+	if err != nil {
+		return nil, fmt.Errorf("error al guardar la evaluación: %w", err)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error al iniciar la transacción: %w", err)
+	}
+	defer tx.Rollback()
+	row := []interface{}{evaluation.ModelID, evaluation.EvaluatedAt, data}
+	if err := s.insertRows(ctx, tx, modelEvaluationsCollection, []string{"model_id", "evaluated_at", "data"}, [][]interface{}{row}, nil); err != nil {
+		return nil, fmt.Errorf("error al guardar la evaluación: %w", err)
+	}
+	row = []interface{}{evaluation.ModelID, registered.Task, registered.PredictionCollection, data, evaluation.EvaluatedAt}
+	if err := s.insertRows(ctx, tx, modelsCollection, []string{"model_id", "task", "prediction_collection", "last_evaluation", "updated_at"}, [][]interface{}{row}, []string{"model_id"}); err != nil {
+		return nil, fmt.Errorf("error al actualizar el modelo %s: %w", evaluation.ModelID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.loggers.InfoLogger.Printf("Evaluación del modelo %s guardada", evaluation.ModelID)
+	return evaluation, nil
+}
+
+// evaluationRunID devuelve la ejecución cuyas predicciones se evalúan: la
+// indicada en la petición o, si no hay ninguna, la activa del modelo
+func (s *sqlClient) evaluationRunID(ctx context.Context, kind, runID string) (string, error) {
+	if runID == "" {
+		active, err := s.activeRunID(ctx, kind)
+		if err != nil {
+			return "", err
+		}
+		if active == "" {
+			return "", fmt.Errorf("ejecución activa de %s %w; ejecute la predicción primero", kind, entity.ErrNotFound)
+		}
+		return active, nil
+	}
+	run := entity.PredictionRun{RunID: runID}
+	err := s.queryRow(ctx, "SELECT kind, status FROM "+predictionRunsCollection+" WHERE run_id = ?", runID).Scan(&run.Kind, &run.Status)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("ejecución de predicción %w: %s", entity.ErrNotFound, runID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error al obtener la ejecución %s: %w", runID, err)
+	}
+	return runID, checkEvaluationRun(run, kind)
+}
+
+// loadVlePredictions devuelve las puntuaciones de participación de la ejecución
+func (s *sqlClient) loadVlePredictions(ctx context.Context, runID string) ([]entity.ProcessedPredictionVleResult, error) {
+	rows, err := s.query(ctx, "SELECT student_id, code_module, code_presentation, predicted_score FROM prediction_vle WHERE run_id = ?", runID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener las predicciones VLE: %w", err)
+	}
+	defer rows.Close()
+
+	var predictions []entity.ProcessedPredictionVleResult
+	for rows.Next() {
+		p := entity.ProcessedPredictionVleResult{RunID: runID}
+		if err := rows.Scan(&p.StudentID, &p.CodeModule, &p.CodePresentation, &p.PredictedScore); err != nil {
+			return nil, fmt.Errorf("error al decodificar las predicciones VLE: %w", err)
+		}
+		predictions = append(predictions, p)
+	}
+	return predictions, rows.Err()
+}
+
+func (s *sqlClient) GetActualVsPredicted(ctx context.Context, database string, query entity.ActualVsPredictedQuery) (*entity.ActualVsPredictedReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	samples, err := s.loadPredictionSamples(ctx, "", 0)
+	if err != nil {
+		return nil, err
+	}
+	s.loggers.InfoLogger.Printf("Comparando %d predicciones con puntuaciones reales", len(samples))
+	return actualVsPredictedReport(samples, query), nil
+}
+
+func (s *sqlClient) GetFairnessReport(ctx context.Context, database string, query entity.FairnessQuery) (*entity.FairnessReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	if err := normalizeFairnessQuery(&query); err != nil {
+		return nil, err
+	}
+	if query.RunID == "" {
+		runID, err := s.activeRunID(ctx, config.PredictionKindAssessments)
+		if err != nil {
+			return nil, err
+		}
+		query.RunID = runID
+	}
+	samples, err := s.loadPredictionSamples(ctx, query.RunID, 0)
+	if err != nil {
+		return nil, err
+	}
+	students, err := s.loadStudentAttributes(ctx, query.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	s.loggers.InfoLogger.Printf("Calculando equidad de la ejecución %s con %d muestras", query.RunID, len(samples))
+	return fairnessReport(query, samples, students), nil
+}
+
+func (s *sqlClient) GetItemAnalysis(ctx context.Context, database string, query entity.ItemAnalysisQuery) (*entity.ItemAnalysisReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	version, err := s.datasetVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := itemAnalysisCacheKey(query)
+	report := &entity.ItemAnalysisReport{}
+	found, err := s.ReadAnalyticsCache(ctx, database, cacheKey, version, report)
+	if err != nil {
+		s.loggers.ErrorLogger.Printf("Error al leer la caché del análisis de ítems: %v", err)
+	}
+	if found {
+		report.Cached = true
+		return report, nil
+	}
+
+	submissions, err := s.loadAssessmentSubmissions(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	finalResults, err := s.loadFinalResults(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.loggers.InfoLogger.Printf("Analizando %d entregas de evaluaciones", len(submissions))
+
+	report = itemAnalysisReport(submissions, finalResults, version)
+	if err := s.WriteAnalyticsCache(ctx, database, cacheKey, version, report); err != nil {
+		s.loggers.ErrorLogger.Printf("Error al guardar la caché del análisis de ítems: %v", err)
+	}
+	return report, nil
+}
+
+func (s *sqlClient) GetWithdrawalSurvival(ctx context.Context, database string, query entity.SurvivalQuery) (*entity.SurvivalReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	attributes, err := survivalAttributes(query)
+	if err != nil {
+		return nil, err
+	}
+	version, err := s.datasetVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := survivalCacheKey(query, attributes)
+	report := &entity.SurvivalReport{}
+	found, err := s.ReadAnalyticsCache(ctx, database, cacheKey, version, report)
+	if err != nil {
+		s.loggers.ErrorLogger.Printf("Error al leer la caché de supervivencia: %v", err)
+	}
+	if found {
+		report.Cached = true
+		return report, nil
+	}
+
+	registrations, err := s.loadRegistrations(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	courseWeeks, err := s.loadCourseWeeks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	finalResults, err := s.loadFinalResults(ctx)
+	if err != nil {
+		return nil, err
+	}
+	students, err := s.loadStudentAttributes(ctx, attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	report, skipped := survivalReport(attributes, registrations, courseWeeks, finalResults, students, version)
+	if skipped > 0 {
+		s.loggers.InfoLogger.Printf("%d matrículas sin duración de curso omitidas", skipped)
+	}
+	if err := s.WriteAnalyticsCache(ctx, database, cacheKey, version, report); err != nil {
+		s.loggers.ErrorLogger.Printf("Error al guardar la caché de supervivencia: %v", err)
+	}
+	return report, nil
+}
+
+func (s *sqlClient) GetFeatureCorrelations(ctx context.Context, database string, query entity.CorrelationQuery) (*entity.CorrelationReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	if err := normalizeCorrelationQuery(&query); err != nil {
+		return nil, err
+	}
+	set, err := s.buildStudentFeatures(ctx, query.CodeModule, query.CodePresentation)
+	if err != nil {
+		return nil, err
+	}
+	return correlationReport(query, set), nil
+}
+
+func (s *sqlClient) ExplainStudentPredictions(ctx context.Context, database string, query entity.ExplanationQuery) ([]entity.PredictionExplanation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	return explainPredictions(query, func(modelID string, query entity.ExplanationQuery) ([]entity.PredictionExplanation, error) {
+		if modelID == config.PredictionKindVle {
+			return s.explainVlePredictions(ctx, query)
+		}
+		return s.explainAssessmentPredictions(ctx, query)
+	})
+}
+
+func (s *sqlClient) explainAssessmentPredictions(ctx context.Context, query entity.ExplanationQuery) ([]entity.PredictionExplanation, error) {
+	runID, err := s.activeRunID(ctx, config.PredictionKindAssessments)
+	if err != nil {
+		return nil, err
+	}
+	samples, err := s.loadPredictionSamples(ctx, runID, query.StudentID)
+	if err != nil {
+		return nil, err
+	}
+	averages, err := s.averageAssessmentScores(ctx, runID, query.StudentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var explanations []entity.PredictionExplanation
+	for _, sample := range samples {
+		if matchesPresentation(query, sample.CodeModule, sample.CodePresentation) {
+			explanations = append(explanations, assessmentExplanation(runID, sample, averages[sample.AssessmentID], query.Top))
+		}
+	}
+	return explanations, nil
+}
+
+func (s *sqlClient) explainVlePredictions(ctx context.Context, query entity.ExplanationQuery) ([]entity.PredictionExplanation, error) {
+	runID, err := s.activeRunID(ctx, config.PredictionKindVle)
+	if err != nil {
+		return nil, err
+	}
+	statement := "SELECT run_id, student_id, code_module, code_presentation, total_clicks, activity_clicks, raw_score, predicted_score " +
+		"FROM prediction_vle WHERE run_id = ? AND student_id = ?"
+	args := []interface{}{runID, query.StudentID}
+	if query.CodeModule != "" {
+		statement += " AND code_module = ?"
+		args = append(args, query.CodeModule)
+	}
+	if query.CodePresentation != "" {
+		statement += " AND code_presentation = ?"
+		args = append(args, query.CodePresentation)
+	}
+	rows, err := s.query(ctx, statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener las predicciones VLE: %w", err)
+	}
+	defer rows.Close()
+	var predictions []entity.ProcessedPredictionVleResult
+	for rows.Next() {
+		var p entity.ProcessedPredictionVleResult
+		var activityClicks string
+		if err := rows.Scan(&p.RunID, &p.StudentID, &p.CodeModule, &p.CodePresentation, &p.TotalClicks, &activityClicks, &p.RawScore, &p.PredictedScore); err != nil {
+			return nil, fmt.Errorf("error al decodificar las predicciones VLE: %w", err)
+		}
+		if err := json.Unmarshal([]byte(activityClicks), &p.ActivityClicks); err != nil {
+			return nil, fmt.Errorf("error al decodificar los clics por actividad: %w", err)
+		}
+		predictions = append(predictions, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al decodificar las predicciones VLE: %w", err)
+	}
+	if len(predictions) == 0 {
+		return nil, nil
+	}
+
+	scoringConfigs, err := s.loadVleScoringConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	courseWeeks, err := s.loadCourseWeeks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	view, err := s.ensureView(ctx, config.ViewEngagementWeekly)
+	if err != nil {
+		return nil, err
+	}
+
+	var explanations []entity.PredictionExplanation
+	for _, p := range predictions {
+		scoringConfig := vleScoringConfigFor(scoringConfigs, p.CodeModule)
+		weekly, err := s.loadWeeklyActivityClicks(ctx, view.Target, p.CodeModule, p.CodePresentation)
+		if err != nil {
+			return nil, err
+		}
+		decayed := decayClicks(weekly, scoringConfig, courseWeeks[p.CodeModule+"|"+p.CodePresentation])
+		explanations = append(explanations, vleExplanation(p, scoringConfig, decayed, query.Top))
+	}
+	return explanations, nil
+}
+
+func (s *sqlClient) ClusterStudents(ctx context.Context, database string, request entity.ClusteringRequest) (*entity.ClusteringResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	if err := normalizeClusteringRequest(&request); err != nil {
+		return nil, err
+	}
+	features, students, vectors, err := s.loadClusteringVectors(ctx, request.CodeModule, request.CodePresentation)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) < request.K {
+		return nil, fmt.Errorf("no hay suficientes estudiantes (%d) para %d clústeres", len(vectors), request.K)
+	}
+	studentOutcomes, err := s.loadFinalResults(ctx)
+	if err != nil {
+		return nil, err
+	}
+	version, err := s.datasetVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.loggers.InfoLogger.Printf("Agrupando %d estudiantes de %s %s en %d clústeres", len(vectors), request.CodeModule, request.CodePresentation, request.K)
+
+	result, assignments := clusterStudents(request, features, students, vectors, studentOutcomes, version)
+	data, err := bson.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("error al guardar los clústeres: %w", err)
+	}
+	rows := make([][]interface{}, 0, len(students))
+	for i, studentID := range students {
+		rows = append(rows, []interface{}{result.ID, studentID, assignments[i]})
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error al iniciar la transacción: %w", err)
+	}
+	defer tx.Rollback()
+	if err := s.insertRows(ctx, tx, studentClustersCollection, []string{"id", "data"}, [][]interface{}{{result.ID, data}}, []string{"id"}); err != nil {
+		return nil, fmt.Errorf("error al guardar los clústeres: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, s.dialect.rebind("DELETE FROM "+clusterAssignmentsCollection+" WHERE clustering_id = ?"), result.ID); err != nil {
+		return nil, fmt.Errorf("error al limpiar las asignaciones anteriores: %w", err)
+	}
+	if err := s.insertRows(ctx, tx, clusterAssignmentsCollection, []string{"clustering_id", "student_id", "cluster"}, rows, nil); err != nil {
+		return nil, fmt.Errorf("error al guardar las asignaciones: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *sqlClient) GetStudentClusters(ctx context.Context, database, codeModule, codePresentation string) (*entity.ClusteringResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var data []byte
+	err := s.queryRow(ctx, "SELECT data FROM "+studentClustersCollection+" WHERE id = ?", codeModule+"|"+codePresentation).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("agrupamiento %w para %s %s; ejecute el agrupamiento primero", entity.ErrNotFound, codeModule, codePresentation)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los clústeres: %w", err)
+	}
+	var result entity.ClusteringResult
+	if err := bson.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("error al decodificar los clústeres: %w", err)
+	}
+	return &result, nil
+}
+
+// loadPredictionSamples une las predicciones de la ejecución (la activa si runID
+// está vacío) con la puntuación real y los datos de la evaluación; con studentID
+// distinto de 0 solo devuelve las de ese estudiante
+func (s *sqlClient) loadPredictionSamples(ctx context.Context, runID string, studentID int) ([]entity.PredictionSample, error) {
+	if runID == "" {
+		var err error
+		if runID, err = s.activeRunID(ctx, config.PredictionKindAssessments); err != nil {
+			return nil, err
+		}
+	}
+	statement := "SELECT p.student_id, p.assessment_id, p.predicted_score, COALESCE(sa.score, 0), " +
+		"a.codemodule, a.codepresentation, a.assessmenttype FROM prediction_assessments p " +
+		"JOIN studentAssessment sa ON sa.idstudent = p.student_id AND sa.idassessment = p.assessment_id " +
+		"JOIN assessments a ON a.idassessment = p.assessment_id WHERE p.run_id = ?"
+	args := []interface{}{runID}
+	if studentID != 0 {
+		statement += " AND p.student_id = ?"
+		args = append(args, studentID)
+	}
+	rows, err := s.query(ctx, statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error al unir predicciones con puntuaciones reales: %w", err)
+	}
+	defer rows.Close()
+
+	var samples []entity.PredictionSample
+	for rows.Next() {
+		var sample entity.PredictionSample
+		if err := rows.Scan(&sample.StudentID, &sample.AssessmentID, &sample.PredictedScore, &sample.ActualScore,
+			&sample.CodeModule, &sample.CodePresentation, &sample.AssessmentType); err != nil {
+			return nil, fmt.Errorf("error al decodificar muestras de predicción: %w", err)
+		}
+		samples = append(samples, sample)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al decodificar muestras de predicción: %w", err)
+	}
+	return samples, nil
+}
+
+// averageAssessmentScores devuelve la puntuación media de las evaluaciones que
+// el estudiante tiene en la ejecución
+func (s *sqlClient) averageAssessmentScores(ctx context.Context, runID string, studentID int) (map[int]float64, error) {
+	rows, err := s.query(ctx, "SELECT idassessment, AVG(score) FROM studentAssessment WHERE score IS NOT NULL AND idassessment IN "+
+		"(SELECT assessment_id FROM prediction_assessments WHERE run_id = ? AND student_id = ?) GROUP BY idassessment", runID, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("error al calcular las puntuaciones medias: %w", err)
+	}
+	defer rows.Close()
+
+	averages := make(map[int]float64)
+	for rows.Next() {
+		var assessmentID int
+		var score float64
+		if err := rows.Scan(&assessmentID, &score); err != nil {
+			return nil, fmt.Errorf("error al decodificar las puntuaciones medias: %w", err)
+		}
+		averages[assessmentID] = score
+	}
+	return averages, rows.Err()
+}
+
+// loadStudentAttributes devuelve los atributos solicitados de studentInfo por
+// (estudiante, módulo, presentación); los valores nulos se devuelven vacíos
+func (s *sqlClient) loadStudentAttributes(ctx context.Context, attributes []string) (map[string]map[string]string, error) {
+	columns := []string{"idstudent", "codemodule", "codepresentation"}
+	for _, attribute := range attributes {
+		columns = append(columns, "COALESCE(CAST("+repository.StudentInfoFields[attribute]+" AS TEXT), '')")
+	}
+	rows, err := s.query(ctx, "SELECT "+strings.Join(columns, ", ")+" FROM studentInfo")
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener studentInfo: %w", err)
+	}
+	defer rows.Close()
+
+	students := make(map[string]map[string]string)
+	for rows.Next() {
+		var studentID int
+		var module, presentation string
+		values := make([]string, len(attributes))
+		dest := []interface{}{&studentID, &module, &presentation}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("error al decodificar studentInfo: %w", err)
+		}
+		student := make(map[string]string, len(attributes))
+		for i, attribute := range attributes {
+			student[attribute] = values[i]
+		}
+		students[studentKey(studentID, module, presentation)] = student
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error durante la iteración de studentInfo: %w", err)
+	}
+	return students, nil
+}
+
+// loadFinalResults devuelve studentInfo.finalresult por (estudiante, módulo, presentación)
+func (s *sqlClient) loadFinalResults(ctx context.Context) (map[string]string, error) {
+	rows, err := s.query(ctx, "SELECT idstudent, codemodule, codepresentation, COALESCE(finalresult, '') FROM studentInfo")
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener studentInfo: %w", err)
+	}
+	defer rows.Close()
+
+	results := make(map[string]string)
+	for rows.Next() {
+		var studentID int
+		var module, presentation, finalResult string
+		if err := rows.Scan(&studentID, &module, &presentation, &finalResult); err != nil {
+			return nil, fmt.Errorf("error al decodificar studentInfo: %w", err)
+		}
+		results[studentKey(studentID, module, presentation)] = finalResult
+	}
+	return results, rows.Err()
+}
+
+// loadAssessmentSubmissions devuelve las entregas de studentAssessment con los
+// datos de su evaluación, filtradas por módulo, presentación o tipo
+func (s *sqlClient) loadAssessmentSubmissions(ctx context.Context, query entity.ItemAnalysisQuery) ([]assessmentSubmission, error) {
+	statement := "SELECT sa.idassessment, sa.idstudent, COALESCE(sa.datesubmitted, 0), COALESCE(sa.isbanked, 0), COALESCE(sa.score, 0), " +
+		"a.codemodule, a.codepresentation, a.assessmenttype, COALESCE(a.date, 0), COALESCE(a.weight, 0) " +
+		"FROM studentAssessment sa JOIN assessments a ON a.idassessment = sa.idassessment WHERE 1 = 1"
+	var args []interface{}
+	if query.CodeModule != "" {
+		statement += " AND a.codemodule = ?"
+		args = append(args, query.CodeModule)
+	}
+	if query.CodePresentation != "" {
+		statement += " AND a.codepresentation = ?"
+		args = append(args, query.CodePresentation)
+	}
+	if query.AssessmentType != "" {
+		statement += " AND a.assessmenttype = ?"
+		args = append(args, query.AssessmentType)
+	}
+	rows, err := s.query(ctx, statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error al unir studentAssessment con assessments: %w", err)
+	}
+	defer rows.Close()
+
+	var submissions []assessmentSubmission
+	for rows.Next() {
+		var sub assessmentSubmission
+		if err := rows.Scan(&sub.AssessmentID, &sub.StudentID, &sub.DateSubmitted, &sub.IsBanked, &sub.Score,
+			&sub.CodeModule, &sub.CodePresentation, &sub.AssessmentType, &sub.Date, &sub.Weight); err != nil {
+			return nil, fmt.Errorf("error al decodificar las entregas: %w", err)
+		}
+		submissions = append(submissions, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al decodificar las entregas: %w", err)
+	}
+	return submissions, nil
+}
+
+// loadRegistrations devuelve las matrículas filtradas por módulo o presentación;
+// una fecha de baja vacía se carga como 0, igual que en MongoDB
+func (s *sqlClient) loadRegistrations(ctx context.Context, query entity.SurvivalQuery) ([]registration, error) {
+	statement := "SELECT idstudent, codemodule, codepresentation, COALESCE(dateunregistration, 0) FROM studentRegistration WHERE 1 = 1"
+	var args []interface{}
+	if query.CodeModule != "" {
+		statement += " AND codemodule = ?"
+		args = append(args, query.CodeModule)
+	}
+	if query.CodePresentation != "" {
+		statement += " AND codepresentation = ?"
+		args = append(args, query.CodePresentation)
+	}
+	rows, err := s.query(ctx, statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener studentRegistration: %w", err)
+	}
+	defer rows.Close()
+
+	var registrations []registration
+	for rows.Next() {
+		var r registration
+		if err := rows.Scan(&r.StudentID, &r.CodeModule, &r.CodePresentation, &r.DateUnregistration); err != nil {
+			return nil, fmt.Errorf("error al decodificar studentRegistration: %w", err)
+		}
+		registrations = append(registrations, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al decodificar studentRegistration: %w", err)
+	}
+	return registrations, nil
+}
+
+// buildStudentFeatures calcula las características de los estudiantes
+// matriculados en una presentación, como la versión de MongoDB
+func (s *sqlClient) buildStudentFeatures(ctx context.Context, codeModule, codePresentation string) (*studentFeatureSet, error) {
+	if codeModule == "" || codePresentation == "" {
+		return nil, fmt.Errorf("se requiere code_module y code_presentation")
+	}
+
+	rows, err := s.query(ctx, "SELECT idstudent, COALESCE(studiedcredits, 0), COALESCE(numofprevattempts, 0), COALESCE(finalresult, '') "+
+		"FROM studentInfo WHERE codemodule = ? AND codepresentation = ?", codeModule, codePresentation)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener studentInfo: %w", err)
+	}
+	var infos []featureInfo
+	for rows.Next() {
+		var info featureInfo
+		if err := rows.Scan(&info.StudentID, &info.StudiedCredits, &info.NumOfPrevAttempts, &info.FinalResult); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error al decodificar studentInfo: %w", err)
+		}
+		infos = append(infos, info)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al decodificar studentInfo: %w", err)
+	}
+
+	view, err := s.ensureView(ctx, config.ViewEngagementWeekly)
+	if err != nil {
+		return nil, err
+	}
+	rows, err = s.query(ctx, "SELECT student_id, activity_type, SUM(clicks), MIN(first_day) FROM "+view.Target+
+		" WHERE code_module = ? AND code_presentation = ? GROUP BY student_id, activity_type", codeModule, codePresentation)
+	if err != nil {
+		return nil, fmt.Errorf("error al agregar la participación por actividad: %w", err)
+	}
+	var activities []activityClicks
+	for rows.Next() {
+		var row activityClicks
+		if err := rows.Scan(&row.StudentID, &row.Activity, &row.Clicks, &row.FirstDay); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error al decodificar la participación por actividad: %w", err)
+		}
+		activities = append(activities, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al decodificar la participación por actividad: %w", err)
+	}
+
+	days, err := s.studentValues(ctx, "SELECT idstudent, COUNT(DISTINCT date) FROM studentVle "+
+		"WHERE codemodule = ? AND codepresentation = ? GROUP BY idstudent", codeModule, codePresentation)
+	if err != nil {
+		return nil, fmt.Errorf("error al agregar los días activos: %w", err)
+	}
+	scores, err := s.studentValues(ctx, "SELECT idstudent, AVG(score) FROM studentAssessment WHERE score IS NOT NULL AND idassessment IN "+
+		"(SELECT idassessment FROM assessments WHERE codemodule = ? AND codepresentation = ?) GROUP BY idstudent", codeModule, codePresentation)
+	if err != nil {
+		return nil, fmt.Errorf("error al agregar las puntuaciones medias: %w", err)
+	}
+	return newStudentFeatureSet(infos, activities, days, scores), nil
+}
+
+// studentValues ejecuta una consulta que devuelve (estudiante, valor)
+func (s *sqlClient) studentValues(ctx context.Context, statement string, args ...interface{}) ([]studentValue, error) {
+	rows, err := s.query(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []studentValue
+	for rows.Next() {
+		var value studentValue
+		if err := rows.Scan(&value.StudentID, &value.Value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
+
+// loadWeeklyActivityClicks devuelve las filas de la vista semanal de una presentación
+func (s *sqlClient) loadWeeklyActivityClicks(ctx context.Context, table, codeModule, codePresentation string) ([]weeklyActivityClicks, error) {
+	rows, err := s.query(ctx, "SELECT student_id, week, activity_type, clicks FROM "+table+
+		" WHERE code_module = ? AND code_presentation = ?", codeModule, codePresentation)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener la participación semanal: %w", err)
+	}
+	defer rows.Close()
+
+	var weekly []weeklyActivityClicks
+	for rows.Next() {
+		var row weeklyActivityClicks
+		if err := rows.Scan(&row.StudentID, &row.Week, &row.ActivityType, &row.Clicks); err != nil {
+			return nil, fmt.Errorf("error al decodificar la participación semanal: %w", err)
+		}
+		weekly = append(weekly, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al decodificar la participación semanal: %w", err)
+	}
+	return weekly, nil
+}
+
+// loadClusteringVectors construye los vectores de clics semanales y puntuaciones
+// de los estudiantes matriculados en la presentación
+func (s *sqlClient) loadClusteringVectors(ctx context.Context, codeModule, codePresentation string) ([]string, []int, [][]float64, error) {
+	rows, err := s.query(ctx, "SELECT DISTINCT idstudent FROM studentInfo WHERE codemodule = ? AND codepresentation = ?", codeModule, codePresentation)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error al obtener los estudiantes de la presentación: %w", err)
+	}
+	var students []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, nil, nil, fmt.Errorf("error al obtener los estudiantes de la presentación: %w", err)
+		}
+		students = append(students, id)
+	}
+	rows.Close()
+
+	view, err := s.ensureView(ctx, config.ViewEngagementWeekly)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	rows, err = s.query(ctx, "SELECT student_id, week, SUM(clicks) FROM "+view.Target+
+		" WHERE code_module = ? AND code_presentation = ? GROUP BY student_id, week", codeModule, codePresentation)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error al agregar los clics semanales: %w", err)
+	}
+	var weekly []weeklyClicks
+	for rows.Next() {
+		var row weeklyClicks
+		if err := rows.Scan(&row.StudentID, &row.Week, &row.Clicks); err != nil {
+			rows.Close()
+			return nil, nil, nil, fmt.Errorf("error al decodificar los clics semanales: %w", err)
+		}
+		weekly = append(weekly, row)
+	}
+	rows.Close()
+	courseWeeks, err := s.loadCourseWeeks(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	rows, err = s.query(ctx, "SELECT idassessment FROM assessments WHERE codemodule = ? AND codepresentation = ? ORDER BY date, idassessment",
+		codeModule, codePresentation)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error al obtener las evaluaciones: %w", err)
+	}
+	var assessmentIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, nil, nil, fmt.Errorf("error al decodificar las evaluaciones: %w", err)
+		}
+		assessmentIDs = append(assessmentIDs, id)
+	}
+	rows.Close()
+
+	rows, err = s.query(ctx, "SELECT sa.idstudent, sa.idassessment, COALESCE(sa.score, 0) FROM studentAssessment sa "+
+		"JOIN assessments a ON a.idassessment = sa.idassessment WHERE a.codemodule = ? AND a.codepresentation = ?", codeModule, codePresentation)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error al obtener las puntuaciones: %w", err)
+	}
+	defer rows.Close()
+	var scores []assessmentScore
+	for rows.Next() {
+		var score assessmentScore
+		if err := rows.Scan(&score.StudentID, &score.AssessmentID, &score.Score); err != nil {
+			return nil, nil, nil, fmt.Errorf("error al decodificar las puntuaciones: %w", err)
+		}
+		scores = append(scores, score)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, nil, fmt.Errorf("error al decodificar las puntuaciones: %w", err)
+	}
+
+	features, vectors := clusteringVectors(students, weekly, courseWeeks[codeModule+"|"+codePresentation], assessmentIDs, scores)
+	return features, students, vectors, nil
+}
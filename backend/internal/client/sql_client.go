@@ -0,0 +1,228 @@
+package client
+
+import (
+	"backend/internal/config"
+	"backend/internal/entity"
+	"backend/internal/repository"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/spf13/viper"
+	_ "modernc.org/sqlite"
+)
+
+// Número máximo de parámetros por sentencia; SQLite admite 32766 y PostgreSQL 65535
+const sqlMaxParams = 32000
+
+// sqlClient implementa el cliente sobre database/sql con SQLite o PostgreSQL.
+// El nombre de base de datos que reciben los métodos se ignora: la base de datos
+// la fija la cadena de conexión. La validación de esquema, la integridad, el
+// perfilado y las migraciones solo existen en MongoDB y devuelven ErrUnsupported
+type sqlClient struct {
+	db       *sql.DB
+	dialect  sqlDialect
+	dsn      string
+	auditKey []byte
+	loggers  *entity.Loggers
+}
+
+func NewSQLClient(driver string, loggers *entity.Loggers) Client {
+	dsn := viper.GetString(config.SqlDSN)
+	if dsn == "" {
+		_, dbCredentials, err := config.DBCredentials()
+		if err != nil {
+			loggers.ErrorLogger.Printf("Error al obtener las credenciales de la base de datos: %v", err)
+			return nil
+		}
+		if driver == config.StoragePostgres {
+			dsn = fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
+				dbCredentials.Host, dbCredentials.Port, dbCredentials.User, dbCredentials.Password, dbCredentials.Dbname)
+		} else {
+			dsn = "file:" + dbCredentials.Dbname + ".db"
+		}
+	}
+	auditKey, err := erasureAuditKey()
+	if err != nil {
+		loggers.ErrorLogger.Printf("Error en la configuración de la auditoría de supresiones: %v", err)
+		return nil
+	}
+	return &sqlClient{
+		dialect:  sqlDialect(driver),
+		dsn:      dsn,
+		auditKey: auditKey,
+		loggers:  loggers,
+	}
+}
+
+func (s *sqlClient) Connect(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	db, err := sql.Open(string(s.dialect), s.dsn)
+	if err != nil {
+		s.loggers.ErrorLogger.Printf("Error al abrir la base de datos %s: %v", s.dialect, err)
+		return err
+	}
+	// SQLite admite un único escritor; una sola conexión evita errores de bloqueo
+	if !s.dialect.postgres() {
+		db.SetMaxOpenConns(1)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		s.loggers.ErrorLogger.Printf("Error al verificar la conexión con %s: %v", s.dialect, err)
+		return err
+	}
+	s.db = db
+	if err := s.createSQLSchema(ctx); err != nil {
+		return err
+	}
+	s.loggers.InfoLogger.Printf("Conectado a %s", s.dialect)
+	return nil
+}
+
+func (s *sqlClient) Disconnect(ctx context.Context) error {
+	if err := s.db.Close(); err != nil {
+		s.loggers.ErrorLogger.Printf("Error al desconectar de %s: %v", s.dialect, err)
+		return err
+	}
+	s.loggers.InfoLogger.Printf("Desconectado de %s", s.dialect)
+	return nil
+}
+
+func (s *sqlClient) Repositories() repository.Repositories {
+	return repository.Repositories{
+		Dataset:          s,
+		DatasetVersions:  s,
+		PredictionRuns:   s,
+		VleScoringConfig: s,
+		AnalyticsCache:   s,
+		Pseudonyms:       s,
+		Users:            s,
+		Predictions:      s,
+		Analytics:        s,
+		DataQuality:      s,
+		Views:            s,
+		Migrations:       s,
+		Erasure:          s,
+	}
+}
+
+func (s *sqlClient) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return s.db.ExecContext(ctx, s.dialect.rebind(query), args...)
+}
+
+func (s *sqlClient) query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return s.db.QueryContext(ctx, s.dialect.rebind(query), args...)
+}
+
+func (s *sqlClient) queryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return s.db.QueryRowContext(ctx, s.dialect.rebind(query), args...)
+}
+
+// insertRows inserta las filas en lotes dentro de la transacción; con conflict
+// no vacío las filas con la misma clave se reemplazan. En PostgreSQL las
+// inserciones sin clave usan COPY
+func (s *sqlClient) insertRows(ctx context.Context, tx *sql.Tx, table string, columns []string, rows [][]interface{}, conflict []string) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	if s.dialect.postgres() && len(conflict) == 0 {
+		stmt, err := tx.PrepareContext(ctx, pq.CopyIn(strings.ToLower(table), columns...))
+		if err != nil {
+			return fmt.Errorf("error al preparar la copia en %s: %w", table, err)
+		}
+		defer stmt.Close()
+		for _, row := range rows {
+			if _, err := stmt.ExecContext(ctx, row...); err != nil {
+				return fmt.Errorf("error al copiar en %s: %w", table, err)
+			}
+		}
+		if _, err := stmt.ExecContext(ctx); err != nil {
+			return fmt.Errorf("error al copiar en %s: %w", table, err)
+		}
+		return nil
+	}
+
+	placeholder := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	suffix := ""
+	if len(conflict) > 0 {
+		var updates []string
+		for _, column := range columns {
+			updates = append(updates, column+" = excluded."+column)
+		}
+		suffix = " ON CONFLICT (" + strings.Join(conflict, ", ") + ") DO UPDATE SET " + strings.Join(updates, ", ")
+	}
+	batchSize := sqlMaxParams / len(columns)
+	for start := 0; start < len(rows); start += batchSize {
+		end := start + batchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		values := make([]string, 0, end-start)
+		args := make([]interface{}, 0, (end-start)*len(columns))
+		for _, row := range rows[start:end] {
+			values = append(values, placeholder)
+			args = append(args, row...)
+		}
+		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s%s", table, strings.Join(columns, ", "), strings.Join(values, ", "), suffix)
+		if _, err := tx.ExecContext(ctx, s.dialect.rebind(query), args...); err != nil {
+			return fmt.Errorf("error al insertar en %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// upsert inserta o reemplaza una fila según la clave primaria de la tabla
+func (s *sqlClient) upsert(ctx context.Context, table string, values map[string]interface{}) error {
+	definition, ok := findSQLTable(table)
+	if !ok {
+		return fmt.Errorf("tabla no registrada: %s", table)
+	}
+	row := make([]interface{}, len(definition.Columns))
+	for i, column := range definition.Columns {
+		row[i] = values[column.Name]
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error al iniciar la transacción: %w", err)
+	}
+	defer tx.Rollback()
+	if err := s.insertRows(ctx, tx, table, definition.columnNames(), [][]interface{}{row}, definition.Key); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// nullTime guarda las fechas vacías como NULL
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// errSQLUnsupported indica una operación que solo está disponible con MongoDB
+func errSQLUnsupported(operation string) error {
+	return fmt.Errorf("%s %w; use STORAGE_DRIVER=%s", operation, entity.ErrUnsupported, config.StorageMongoDB)
+}
+
+// La validación de esquema ($jsonSchema) depende de los validadores de
+// MongoDB; en SQL las restricciones de tipo las aplica el propio esquema de sqlTables
+
+func (s *sqlClient) GetSchemaViolations(ctx context.Context, database string, query entity.SchemaViolationQuery) (*entity.SchemaViolationReport, error) {
+	return nil, errSQLUnsupported("la validación de esquema")
+}
+
+// GetMigrationStatus no tiene migraciones que informar: el esquema SQL se crea
+// completo al conectar a partir de sqlTables
+func (s *sqlClient) GetMigrationStatus(ctx context.Context, database string) ([]entity.MigrationStatus, error) {
+	return []entity.MigrationStatus{}, nil
+}
+
+// Migrate no aplica nada: las migraciones versionadas son de MongoDB
+func (s *sqlClient) Migrate(ctx context.Context, database string, request entity.MigrationRequest) (*entity.MigrationPlan, error) {
+	return nil, errSQLUnsupported("las migraciones de esquema")
+}
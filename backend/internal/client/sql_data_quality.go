@@ -0,0 +1,442 @@
+package client
+
+import (
+	"backend/internal/analytics"
+	"backend/internal/entity"
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// sqlSource devuelve la tabla, o la subconsulta de SQLSource, que se comprueba
+func (c integrityCheck) sqlSource() string {
+	if c.SQLSource != "" {
+		return "(" + c.SQLSource + ")"
+	}
+	return c.Collection
+}
+
+// sqlGroups consulta los grupos de clave que incumplen la comprobación con las
+// mismas reglas que pipeline: claves repetidas (GROUP BY ... HAVING) o sin fila
+// en la tabla de referencia (LEFT JOIN)
+func (c integrityCheck) sqlGroups() string {
+	columns := make([]string, len(c.Keys))
+	for i, field := range c.Keys {
+		columns[i] = "src." + field
+	}
+	selected := make([]string, len(c.Keys))
+	for i, field := range c.Keys {
+		selected[i] = columns[i] + " AS " + field
+	}
+	query := fmt.Sprintf("SELECT %s, COUNT(*) AS documents FROM %s src", strings.Join(selected, ", "), c.sqlSource())
+	if c.Kind == integrityDuplicate {
+		return query + " GROUP BY " + strings.Join(columns, ", ") + " HAVING COUNT(*) > 1"
+	}
+	conditions := make([]string, len(c.Keys))
+	for i, field := range c.RefKeys {
+		conditions[i] = "ref." + field + " = " + columns[i]
+	}
+	return query + fmt.Sprintf(" LEFT JOIN (SELECT DISTINCT %s FROM %s) ref ON %s WHERE ref.%s IS NULL GROUP BY %s",
+		strings.Join(c.RefKeys, ", "), c.Reference, strings.Join(conditions, " AND "), c.RefKeys[0], strings.Join(columns, ", "))
+}
+
+// runIntegrityCheck ejecuta la comprobación y toma como muestra la primera fila
+// de los grupos con más filas, como $first en MongoDB
+func (s *sqlClient) runIntegrityCheck(ctx context.Context, c integrityCheck) (entity.IntegrityCheck, error) {
+	check := entity.IntegrityCheck{
+		Name:       c.Name,
+		Kind:       c.Kind,
+		Collection: c.Collection,
+		Reference:  c.Reference,
+		Keys:       c.Keys,
+		Samples:    []entity.IntegritySample{},
+	}
+	groups := c.sqlGroups()
+	err := s.queryRow(ctx, "SELECT COUNT(*), COALESCE(SUM(documents), 0) FROM ("+groups+") g").Scan(&check.Groups, &check.Documents)
+	if err != nil {
+		return check, fmt.Errorf("error en la comprobación %s: %w", c.Name, err)
+	}
+	if check.Groups == 0 {
+		return check, nil
+	}
+
+	keys := strings.Join(c.Keys, ", ")
+	rows, err := s.query(ctx, fmt.Sprintf("SELECT %s, documents FROM (%s) g ORDER BY documents DESC, %s LIMIT %d",
+		keys, groups, keys, integritySampleSize))
+	if err != nil {
+		return check, fmt.Errorf("error en la comprobación %s: %w", c.Name, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		values, err := scanValues(rows, len(c.Keys)+1)
+		if err != nil {
+			return check, fmt.Errorf("error al decodificar la comprobación %s: %w", c.Name, err)
+		}
+		sample := entity.IntegritySample{Key: make(map[string]interface{}, len(c.Keys))}
+		for i, field := range c.Keys {
+			sample.Key[field] = values[i]
+		}
+		if sample.Count, err = toInt(values[len(c.Keys)]); err != nil {
+			return check, err
+		}
+		check.Samples = append(check.Samples, sample)
+	}
+	if err := rows.Err(); err != nil {
+		return check, err
+	}
+	rows.Close()
+
+	for i := range check.Samples {
+		if check.Samples[i].Document, err = s.integritySampleDocument(ctx, c, check.Samples[i].Key); err != nil {
+			return check, err
+		}
+	}
+	return check, nil
+}
+
+// integritySampleDocument devuelve una fila del grupo con la clave indicada
+func (s *sqlClient) integritySampleDocument(ctx context.Context, c integrityCheck, key map[string]interface{}) (map[string]interface{}, error) {
+	conditions := make([]string, 0, len(c.Keys))
+	args := make([]interface{}, 0, len(c.Keys))
+	for _, field := range c.Keys {
+		if key[field] == nil {
+			conditions = append(conditions, "src."+field+" IS NULL")
+			continue
+		}
+		conditions = append(conditions, "src."+field+" = ?")
+		args = append(args, key[field])
+	}
+	rows, err := s.query(ctx, fmt.Sprintf("SELECT * FROM %s src WHERE %s LIMIT 1", c.sqlSource(), strings.Join(conditions, " AND ")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener la muestra de %s: %w", c.Name, err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	document := make(map[string]interface{}, len(columns))
+	if rows.Next() {
+		values, err := scanValues(rows, len(columns))
+		if err != nil {
+			return nil, fmt.Errorf("error al decodificar la muestra de %s: %w", c.Name, err)
+		}
+		for i, column := range columns {
+			document[column] = values[i]
+		}
+	}
+	return document, rows.Err()
+}
+
+// RunIntegrityCheck comprueba las claves foráneas implícitas y las claves
+// naturales de OULAD y guarda el informe
+func (s *sqlClient) RunIntegrityCheck(ctx context.Context, database string) (*entity.IntegrityReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+
+	version, err := s.datasetVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &entity.IntegrityReport{
+		ID:             primitive.NewObjectID().Hex(),
+		DatasetVersion: version,
+		StartedAt:      time.Now(),
+		Checks:         make([]entity.IntegrityCheck, 0, len(integrityChecks)),
+	}
+	for _, definition := range integrityChecks {
+		check, err := s.runIntegrityCheck(ctx, definition)
+		if err != nil {
+			return nil, err
+		}
+		if check.Groups > 0 {
+			report.Issues++
+		}
+		report.Checks = append(report.Checks, check)
+	}
+	report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+
+	data, err := bson.Marshal(report)
+	if err != nil {
+		return nil, fmt.Errorf("error al guardar el informe de integridad: %w", err)
+	}
+	err = s.upsert(ctx, integrityReportsCollection, map[string]interface{}{
+		"id":              report.ID,
+		"dataset_version": report.DatasetVersion,
+		"started_at":      report.StartedAt,
+		"data":            data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error al guardar el informe de integridad: %w", err)
+	}
+	s.loggers.InfoLogger.Printf("Comprobación de integridad %s: %d de %d comprobaciones con incidencias en %d ms",
+		report.ID, report.Issues, len(report.Checks), report.DurationMs)
+	return report, nil
+}
+
+// GetIntegrityReport devuelve el informe de integridad más reciente
+func (s *sqlClient) GetIntegrityReport(ctx context.Context, database string) (*entity.IntegrityReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var data []byte
+	err := s.queryRow(ctx, "SELECT data FROM "+integrityReportsCollection+" ORDER BY started_at DESC LIMIT 1").Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("informe de integridad %w; ejecute la comprobación primero", entity.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el informe de integridad: %w", err)
+	}
+	var report entity.IntegrityReport
+	if err := bson.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("error al decodificar el informe de integridad: %w", err)
+	}
+	return &report, nil
+}
+
+// ProfileDataset calcula el perfil de cada columna de las tablas de OULAD y lo
+// guarda para la versión actual del conjunto de datos
+func (s *sqlClient) ProfileDataset(ctx context.Context, database string) (*entity.DataProfile, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Hour)
+	defer cancel()
+
+	version, err := s.datasetVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	profile := &entity.DataProfile{
+		DatasetVersion: version,
+		ComputedAt:     time.Now(),
+		Collections:    make([]entity.CollectionProfile, 0, len(ouladCollections)),
+	}
+	for _, collection := range ouladCollections {
+		collectionProfile, err := s.profileTable(ctx, collection)
+		if err != nil {
+			return nil, err
+		}
+		profile.Collections = append(profile.Collections, *collectionProfile)
+	}
+	profile.DurationMs = time.Since(profile.ComputedAt).Milliseconds()
+
+	data, err := bson.Marshal(profile)
+	if err != nil {
+		return nil, fmt.Errorf("error al guardar el perfil de datos: %w", err)
+	}
+	err = s.upsert(ctx, dataProfilesCollection, map[string]interface{}{
+		"dataset_version": profile.DatasetVersion,
+		"computed_at":     profile.ComputedAt,
+		"data":            data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error al guardar el perfil de datos: %w", err)
+	}
+	s.loggers.InfoLogger.Printf("Perfil de datos de la versión %d calculado en %d ms", version, profile.DurationMs)
+	return profile, nil
+}
+
+// GetDataProfile devuelve el perfil guardado de una versión (la más reciente si version es 0)
+func (s *sqlClient) GetDataProfile(ctx context.Context, database string, version int) (*entity.DataProfile, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	return s.loadDataProfile(ctx, version)
+}
+
+// DiffDataProfiles compara los perfiles de dos versiones del conjunto de datos
+func (s *sqlClient) DiffDataProfiles(ctx context.Context, database string, from, to int) (*entity.DataProfileDiff, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if from <= 0 || to <= 0 {
+		return nil, fmt.Errorf("from y to deben ser versiones del conjunto de datos")
+	}
+	before, err := s.loadDataProfile(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := s.loadDataProfile(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+	diff := diffDataProfiles(before, after)
+	return &diff, nil
+}
+
+func (s *sqlClient) loadDataProfile(ctx context.Context, version int) (*entity.DataProfile, error) {
+	var data []byte
+	var err error
+	if version == 0 {
+		err = s.queryRow(ctx, "SELECT data FROM "+dataProfilesCollection+" ORDER BY dataset_version DESC LIMIT 1").Scan(&data)
+	} else {
+		err = s.queryRow(ctx, "SELECT data FROM "+dataProfilesCollection+" WHERE dataset_version = ?", version).Scan(&data)
+	}
+	if err == sql.ErrNoRows {
+		if version == 0 {
+			return nil, fmt.Errorf("perfil de datos %w; ejecute el perfilado primero", entity.ErrNotFound)
+		}
+		return nil, fmt.Errorf("perfil de datos %w para la versión %d", entity.ErrNotFound, version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el perfil de datos: %w", err)
+	}
+	var profile entity.DataProfile
+	if err := bson.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("error al decodificar el perfil de datos: %w", err)
+	}
+	return &profile, nil
+}
+
+// profileTable perfila las columnas de la tabla en el orden de los campos de la
+// entidad, seguidas de las columnas que la entidad no declara
+func (s *sqlClient) profileTable(ctx context.Context, collection ouladCollection) (*entity.CollectionProfile, error) {
+	table, ok := findSQLTable(collection.Name)
+	if !ok {
+		return nil, fmt.Errorf("tabla no registrada: %s", collection.Name)
+	}
+	profile := &entity.CollectionProfile{Collection: collection.Name, Fields: []entity.FieldProfile{}}
+	if err := s.queryRow(ctx, "SELECT COUNT(*) FROM "+table.Name).Scan(&profile.Documents); err != nil {
+		return nil, fmt.Errorf("error al contar documentos de %s: %w", table.Name, err)
+	}
+
+	columns := make([]sqlColumn, 0, len(table.Columns))
+	known := make(map[string]bool, len(table.Columns))
+	for _, field := range collection.schemaFields() {
+		for _, column := range table.Columns {
+			if column.Name == field.Name {
+				columns = append(columns, column)
+				known[column.Name] = true
+			}
+		}
+	}
+	var extra []sqlColumn
+	for _, column := range table.Columns {
+		if !known[column.Name] {
+			extra = append(extra, column)
+		}
+	}
+	sort.Slice(extra, func(i, j int) bool { return extra[i].Name < extra[j].Name })
+
+	for _, column := range append(columns, extra...) {
+		fieldProfile, err := s.profileColumn(ctx, table.Name, column)
+		if err != nil {
+			return nil, err
+		}
+		profile.Fields = append(profile.Fields, *fieldProfile)
+	}
+	return profile, nil
+}
+
+// profileColumn calcula las mismas métricas que profileField. Los tipos son los
+// que tendría el valor en MongoDB: int o long según su tamaño, double o string
+func (s *sqlClient) profileColumn(ctx context.Context, table string, column sqlColumn) (*entity.FieldProfile, error) {
+	profile := &entity.FieldProfile{
+		Field:     column.Name,
+		Types:     map[string]int64{},
+		Histogram: []entity.ProfileBin{},
+		TopValues: []entity.ValueCount{},
+	}
+	name := column.Name
+	numeric := column.Type == sqlInteger || column.Type == sqlReal
+
+	// small cuenta los enteros que caben en 32 bits, que MongoDB guarda como int
+	small := "0"
+	if column.Type == sqlInteger {
+		small = fmt.Sprintf("COALESCE(SUM(CASE WHEN %s BETWEEN %d AND %d THEN 1 ELSE 0 END), 0)", name, math.MinInt32, math.MaxInt32)
+	}
+	var total, values, smallValues int64
+	err := s.queryRow(ctx, fmt.Sprintf("SELECT COUNT(*), COUNT(%[1]s), COUNT(DISTINCT %[1]s), %[2]s FROM %[3]s", name, small, table)).
+		Scan(&total, &values, &profile.Distinct, &smallValues)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los tipos de %s.%s: %w", table, name, err)
+	}
+	profile.Nulls = total - values
+	if profile.Nulls > 0 {
+		profile.Types["null"] = profile.Nulls
+	}
+	switch column.Type {
+	case sqlInteger:
+		if smallValues > 0 {
+			profile.Types["int"] = smallValues
+		}
+		if values > smallValues {
+			profile.Types["long"] = values - smallValues
+		}
+	case sqlReal:
+		if values > 0 {
+			profile.Types["double"] = values
+		}
+	default:
+		if values > 0 {
+			profile.Types["string"] = values
+		}
+	}
+	if total > 0 {
+		profile.NullRate = float64(profile.Nulls) / float64(total)
+	}
+	valueTypes := len(profile.Types)
+	if profile.Nulls > 0 {
+		valueTypes--
+	}
+	profile.TypeConsistent = valueTypes <= 1
+
+	// Valores más frecuentes
+	rows, err := s.query(ctx, fmt.Sprintf("SELECT %[1]s, COUNT(*) AS n FROM %[2]s WHERE %[1]s IS NOT NULL GROUP BY %[1]s ORDER BY n DESC, %[1]s LIMIT %[3]d",
+		name, table, profileTopValues))
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los valores de %s.%s: %w", table, name, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		row, err := scanValues(rows, 2)
+		if err != nil {
+			return nil, fmt.Errorf("error al decodificar los valores de %s.%s: %w", table, name, err)
+		}
+		count, err := toInt(row[1])
+		if err != nil {
+			return nil, err
+		}
+		profile.TopValues = append(profile.TopValues, entity.ValueCount{Value: row[0], Count: int64(count)})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	if !numeric || values == 0 {
+		return profile, nil
+	}
+
+	// Rango, media e histograma de los valores numéricos
+	var minimum, maximum, mean float64
+	err = s.queryRow(ctx, fmt.Sprintf("SELECT MIN(%[1]s), MAX(%[1]s), AVG(%[1]s) FROM %[2]s", name, table)).Scan(&minimum, &maximum, &mean)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el rango de %s.%s: %w", table, name, err)
+	}
+	profile.Min, profile.Max, profile.Mean = &minimum, &maximum, &mean
+
+	rows, err = s.query(ctx, fmt.Sprintf("SELECT %[1]s FROM %[2]s WHERE %[1]s IS NOT NULL ORDER BY %[1]s", name, table))
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el histograma de %s.%s: %w", table, name, err)
+	}
+	defer rows.Close()
+	sorted := make([]float64, 0, values)
+	for rows.Next() {
+		var value float64
+		if err := rows.Scan(&value); err != nil {
+			return nil, fmt.Errorf("error al decodificar el histograma de %s.%s: %w", table, name, err)
+		}
+		sorted = append(sorted, value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, bin := range analytics.AutoBuckets(sorted, profileHistogramBins) {
+		profile.Histogram = append(profile.Histogram, entity.ProfileBin{Min: bin.Min, Max: bin.Max, Count: int64(bin.Count)})
+	}
+	return profile, nil
+}
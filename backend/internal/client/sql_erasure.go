@@ -0,0 +1,266 @@
+package client
+
+import (
+	"backend/internal/entity"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// EraseStudent elimina los documentos del estudiante en una sola transacción junto
+// con la entrada de auditoría; las tablas que el backend SQL no crea se omiten
+func (s *sqlClient) EraseStudent(ctx context.Context, database string, request entity.ErasureRequest) (*entity.ErasureAuditEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error al iniciar la transacción: %w", err)
+	}
+	defer tx.Rollback()
+	deleteRows := func(table, where string, args ...interface{}) (int64, error) {
+		result, err := tx.ExecContext(ctx, s.dialect.rebind("DELETE FROM "+table+where), args...)
+		if err != nil {
+			return 0, fmt.Errorf("error al suprimir el estudiante de %s: %w", table, err)
+		}
+		return result.RowsAffected()
+	}
+
+	collections := make([]entity.ErasureCollection, 0, len(erasureTargets)+2)
+	for _, target := range erasureTargets {
+		if _, ok := findSQLTable(target.Collection); !ok {
+			continue
+		}
+		deleted, err := deleteRows(target.Collection, " WHERE "+target.Field+" = ?", request.StudentID)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, entity.ErasureCollection{Collection: target.Collection, Deleted: deleted})
+	}
+	if erasedDocuments(collections) == 0 {
+		return nil, fmt.Errorf("estudiante %w: %d", entity.ErrNotFound, request.StudentID)
+	}
+
+	pseudonyms := entity.ErasureCollection{Collection: studentPseudonymsCollection}
+	if request.Pseudonym > 0 {
+		if pseudonyms.Deleted, err = deleteRows(studentPseudonymsCollection, " WHERE pseudonym = ?", request.Pseudonym); err != nil {
+			return nil, err
+		}
+	}
+	collections = append(collections, pseudonyms)
+	// La caché puede contener muestras por estudiante; se invalida entera
+	cache, err := deleteRows(analyticsCacheCollection, "")
+	if err != nil {
+		return nil, err
+	}
+	collections = append(collections, entity.ErasureCollection{Collection: analyticsCacheCollection, Deleted: cache})
+	samples, err := s.eraseStoredSamples(ctx, tx, integrityReportsCollection, "id", pullIntegritySamples(request.StudentID))
+	if err != nil {
+		return nil, err
+	}
+	collections = append(collections, entity.ErasureCollection{Collection: integrityReportsCollection, Updated: samples})
+	values, err := s.eraseStoredSamples(ctx, tx, dataProfilesCollection, "dataset_version", pullProfileValues(request.StudentID))
+	if err != nil {
+		return nil, err
+	}
+	collections = append(collections, entity.ErasureCollection{Collection: dataProfilesCollection, Updated: values})
+
+	var last *entity.ErasureAuditEntry
+	var previous entity.ErasureAuditEntry
+	err = tx.QueryRowContext(ctx, "SELECT sequence, hash FROM "+erasureAuditCollection+" ORDER BY sequence DESC LIMIT 1").
+		Scan(&previous.Sequence, &previous.Hash)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("error al obtener la auditoría de supresiones: %w", err)
+	}
+	if err == nil {
+		last = &previous
+	}
+	entry := newErasureEntry(s.auditKey, last, request.Subject, collections)
+	encoded, err := json.Marshal(entry.Collections)
+	if err != nil {
+		return nil, fmt.Errorf("error al codificar la auditoría de supresión: %w", err)
+	}
+	table, _ := findSQLTable(erasureAuditCollection)
+	row := []interface{}{entry.Sequence, entry.Subject, entry.ErasedAt, string(encoded), entry.PreviousHash, entry.Hash}
+	if err := s.insertRows(ctx, tx, table.Name, table.columnNames(), [][]interface{}{row}, nil); err != nil {
+		return nil, fmt.Errorf("error al registrar la auditoría de supresión: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.loggers.InfoLogger.Printf("Supresión %d registrada para %s", entry.Sequence, entry.Subject)
+	return &entry, nil
+}
+
+// GetErasureAudit devuelve la auditoría de supresiones y el resultado de verificar la cadena
+func (s *sqlClient) GetErasureAudit(ctx context.Context, database string) (*entity.ErasureAuditLog, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	rows, err := s.query(ctx, "SELECT sequence, subject, erased_at, collections, previous_hash, hash FROM "+erasureAuditCollection+" ORDER BY sequence")
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener la auditoría de supresiones: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []entity.ErasureAuditEntry{}
+	for rows.Next() {
+		var entry entity.ErasureAuditEntry
+		var collections string
+		if err := rows.Scan(&entry.Sequence, &entry.Subject, &entry.ErasedAt, &collections, &entry.PreviousHash, &entry.Hash); err != nil {
+			return nil, fmt.Errorf("error al decodificar la auditoría de supresiones: %w", err)
+		}
+		if err := json.Unmarshal([]byte(collections), &entry.Collections); err != nil {
+			return nil, fmt.Errorf("error al decodificar la supresión %d: %w", entry.Sequence, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	log := verifyErasureAudit(s.auditKey, entries)
+	return &log, nil
+}
+
+func (s *sqlClient) GetErasedSubjects(ctx context.Context, database string) (map[string]bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	rows, err := s.query(ctx, "SELECT DISTINCT subject FROM "+erasureAuditCollection)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los estudiantes suprimidos: %w", err)
+	}
+	defer rows.Close()
+
+	subjects := make(map[string]bool)
+	for rows.Next() {
+		var subject string
+		if err := rows.Scan(&subject); err != nil {
+			return nil, fmt.Errorf("error al decodificar los estudiantes suprimidos: %w", err)
+		}
+		subjects[subject] = true
+	}
+	return subjects, rows.Err()
+}
+
+// eraseStoredSamples reescribe los informes guardados en table (clave key) de
+// los que pull quita al estudiante, como los $pull de erasureOperations;
+// devuelve los informes modificados
+func (s *sqlClient) eraseStoredSamples(ctx context.Context, tx *sql.Tx, table, key string, pull func(data []byte) ([]byte, bool, error)) (int64, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT "+key+", data FROM "+table)
+	if err != nil {
+		return 0, fmt.Errorf("error al obtener %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	type storedReport struct {
+		key  interface{}
+		data []byte
+	}
+	var updated []storedReport
+	for rows.Next() {
+		var report storedReport
+		if err := rows.Scan(&report.key, &report.data); err != nil {
+			return 0, fmt.Errorf("error al decodificar %s: %w", table, err)
+		}
+		data, changed, err := pull(report.data)
+		if err != nil {
+			return 0, fmt.Errorf("error al suprimir el estudiante de %s %v: %w", table, report.key, err)
+		}
+		if changed {
+			updated = append(updated, storedReport{key: report.key, data: data})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	rows.Close()
+
+	statement := s.dialect.rebind("UPDATE " + table + " SET data = ? WHERE " + key + " = ?")
+	for _, report := range updated {
+		if _, err := tx.ExecContext(ctx, statement, report.data, report.key); err != nil {
+			return 0, fmt.Errorf("error al suprimir el estudiante de %s %v: %w", table, report.key, err)
+		}
+	}
+	return int64(len(updated)), nil
+}
+
+// pullIntegritySamples quita al estudiante de las muestras de un informe de integridad
+func pullIntegritySamples(studentID int) func(data []byte) ([]byte, bool, error) {
+	return func(data []byte) ([]byte, bool, error) {
+		var report entity.IntegrityReport
+		if err := bson.Unmarshal(data, &report); err != nil {
+			return nil, false, err
+		}
+		if !pullStudentSamples(&report, studentID) {
+			return nil, false, nil
+		}
+		data, err := bson.Marshal(report)
+		return data, true, err
+	}
+}
+
+// pullProfileValues quita al estudiante de los valores frecuentes de un perfil de datos
+func pullProfileValues(studentID int) func(data []byte) ([]byte, bool, error) {
+	return func(data []byte) ([]byte, bool, error) {
+		var profile entity.DataProfile
+		if err := bson.Unmarshal(data, &profile); err != nil {
+			return nil, false, err
+		}
+		if !pullStudentValues(&profile, studentID) {
+			return nil, false, nil
+		}
+		data, err := bson.Marshal(profile)
+		return data, true, err
+	}
+}
+
+// pullStudentSamples quita las muestras cuya clave o documento son del estudiante
+func pullStudentSamples(report *entity.IntegrityReport, studentID int) bool {
+	changed := false
+	for i, check := range report.Checks {
+		samples := make([]entity.IntegritySample, 0, len(check.Samples))
+		for _, sample := range check.Samples {
+			if sampleStudent(sample.Key, studentID) || sampleStudent(sample.Document, studentID) {
+				changed = true
+				continue
+			}
+			samples = append(samples, sample)
+		}
+		report.Checks[i].Samples = samples
+	}
+	return changed
+}
+
+func sampleStudent(values map[string]interface{}, studentID int) bool {
+	id, err := toInt(values["idstudent"])
+	return err == nil && id == studentID
+}
+
+// pullStudentValues quita el id del estudiante de los valores más frecuentes de
+// los campos idstudent y student_id
+func pullStudentValues(profile *entity.DataProfile, studentID int) bool {
+	changed := false
+	for i := range profile.Collections {
+		fields := profile.Collections[i].Fields
+		for j, field := range fields {
+			if field.Field != "idstudent" && field.Field != "student_id" {
+				continue
+			}
+			values := make([]entity.ValueCount, 0, len(field.TopValues))
+			for _, value := range field.TopValues {
+				if id, err := toInt(value.Value); err == nil && id == studentID {
+					changed = true
+					continue
+				}
+				values = append(values, value)
+			}
+			fields[j].TopValues = values
+		}
+	}
+	return changed
+}
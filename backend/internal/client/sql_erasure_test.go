@@ -0,0 +1,99 @@
+package client
+
+import (
+	"backend/internal/entity"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestPullStudentSamples(t *testing.T) {
+	report := func() *entity.IntegrityReport {
+		return &entity.IntegrityReport{Checks: []entity.IntegrityCheck{
+			{Name: "student_vle_student_info", Samples: []entity.IntegritySample{
+				{Key: map[string]interface{}{"idstudent": int32(5)}},
+				{Key: map[string]interface{}{"idstudent": int64(6)}},
+			}},
+			{Name: "assessment_duplicates", Samples: []entity.IntegritySample{
+				{Key: map[string]interface{}{"idassessment": int32(1)}, Document: map[string]interface{}{"idstudent": 5.0}},
+			}},
+		}}
+	}
+	tests := []struct {
+		name      string
+		studentID int
+		changed   bool
+		remaining []int
+	}{
+		{"en clave y documento", 5, true, []int{1, 0}},
+		{"otro estudiante", 6, true, []int{1, 1}},
+		{"sin muestras", 7, false, []int{2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := report()
+			if changed := pullStudentSamples(r, tt.studentID); changed != tt.changed {
+				t.Errorf("changed = %v, se esperaba %v", changed, tt.changed)
+			}
+			for i, check := range r.Checks {
+				if len(check.Samples) != tt.remaining[i] {
+					t.Errorf("%s: %d muestras, se esperaban %d", check.Name, len(check.Samples), tt.remaining[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPullStudentValues(t *testing.T) {
+	profile := &entity.DataProfile{Collections: []entity.CollectionProfile{
+		{Collection: "studentInfo", Fields: []entity.FieldProfile{
+			{Field: "idstudent", TopValues: []entity.ValueCount{{Value: int32(5), Count: 3}, {Value: int32(6), Count: 1}}},
+			{Field: "score", TopValues: []entity.ValueCount{{Value: int32(5), Count: 9}}},
+		}},
+		{Collection: "prediction_vle", Fields: []entity.FieldProfile{
+			{Field: "student_id", TopValues: []entity.ValueCount{{Value: int64(5), Count: 2}}},
+		}},
+	}}
+	if !pullStudentValues(profile, 5) {
+		t.Fatal("se esperaba que el perfil cambiara")
+	}
+	want := map[string]int{"idstudent": 1, "score": 1, "student_id": 0}
+	for _, collection := range profile.Collections {
+		for _, field := range collection.Fields {
+			if len(field.TopValues) != want[field.Field] {
+				t.Errorf("%s.%s: %d valores, se esperaban %d", collection.Collection, field.Field, len(field.TopValues), want[field.Field])
+			}
+		}
+	}
+	if pullStudentValues(profile, 5) {
+		t.Error("una segunda supresión no debería cambiar el perfil")
+	}
+}
+
+func TestPullIntegritySamples(t *testing.T) {
+	data, err := bson.Marshal(entity.IntegrityReport{ID: "r1", Checks: []entity.IntegrityCheck{
+		{Name: "student_vle_student_info", Samples: []entity.IntegritySample{
+			{Key: map[string]interface{}{"idstudent": 5}, Count: 2},
+			{Key: map[string]interface{}{"idstudent": 6}, Count: 1},
+		}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, changed, err := pullIntegritySamples(7)(data); err != nil || changed {
+		t.Fatalf("estudiante sin muestras: changed = %v, err = %v", changed, err)
+	}
+	updated, changed, err := pullIntegritySamples(5)(data)
+	if err != nil || !changed {
+		t.Fatalf("changed = %v, err = %v", changed, err)
+	}
+	var report entity.IntegrityReport
+	if err := bson.Unmarshal(updated, &report); err != nil {
+		t.Fatal(err)
+	}
+	samples := report.Checks[0].Samples
+	if report.ID != "r1" || len(samples) != 1 || samples[0].Count != 1 {
+		t.Errorf("informe tras la supresión = %+v", report)
+	}
+}
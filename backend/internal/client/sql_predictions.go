@@ -0,0 +1,238 @@
+package client
+
+import (
+	"backend/internal/config"
+	"backend/internal/entity"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *sqlClient) ProcessDataPredictionAssessments(ctx context.Context, database string) ([]entity.ProcessedPredictionAssessmentResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	runID, err := s.startPredictionRun(ctx, config.PredictionKindAssessments)
+	if err != nil {
+		return nil, err
+	}
+	s.loggers.InfoLogger.Printf("Ejecución de predicción de evaluaciones: %s", runID)
+
+	results, err := s.predictAssessments(ctx)
+	if err == nil {
+		now := time.Now()
+		rows := make([][]interface{}, len(results))
+		for i, result := range results {
+			rows[i] = []interface{}{runID, result.StudentID, result.AssessmentID, result.PredictedScore, now}
+		}
+		err = s.storePredictions(ctx, "prediction_assessments", rows)
+	}
+	if err != nil {
+		s.finishPredictionRun(ctx, config.PredictionKindAssessments, runID, 0, err)
+		return nil, err
+	}
+	s.loggers.InfoLogger.Printf("Total de predicciones generadas: %d", len(results))
+
+	if err := s.finishPredictionRun(ctx, config.PredictionKindAssessments, runID, len(results), nil); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// predictAssessments calcula la puntuación predicha de cada entrega de studentAssessment
+func (s *sqlClient) predictAssessments(ctx context.Context) ([]entity.ProcessedPredictionAssessmentResult, error) {
+	rows, err := s.query(ctx, "SELECT idstudent, idassessment, score FROM studentAssessment")
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los datos de studentAssessment: %w", err)
+	}
+	defer rows.Close()
+
+	var results []entity.ProcessedPredictionAssessmentResult
+	for rows.Next() {
+		var result entity.ProcessedPredictionAssessmentResult
+		var score float64
+		if err := rows.Scan(&result.StudentID, &result.AssessmentID, &score); err != nil {
+			return nil, fmt.Errorf("error al decodificar evaluación: %w", err)
+		}
+		result.PredictedScore = score * assessmentScoreCoefficient
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+func (s *sqlClient) ProcessDataVlePredictions(ctx context.Context, database string) ([]entity.ProcessedPredictionVleResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+
+	runID, err := s.startPredictionRun(ctx, config.PredictionKindVle)
+	if err != nil {
+		return nil, err
+	}
+	s.loggers.InfoLogger.Printf("Ejecución de predicción VLE: %s", runID)
+
+	results, err := s.predictVle(ctx, runID)
+	if err == nil {
+		rows := make([][]interface{}, len(results))
+		for i, result := range results {
+			activityClicks, marshalErr := json.Marshal(result.ActivityClicks)
+			if marshalErr != nil {
+				err = fmt.Errorf("error al codificar los clics por actividad: %w", marshalErr)
+				break
+			}
+			rows[i] = []interface{}{runID, result.StudentID, result.CodeModule, result.CodePresentation,
+				result.TotalClicks, string(activityClicks), result.RawScore, result.PredictedScore}
+		}
+		if err == nil {
+			err = s.storePredictions(ctx, "prediction_vle", rows)
+		}
+	}
+	if err != nil {
+		s.finishPredictionRun(ctx, config.PredictionKindVle, runID, 0, err)
+		return nil, err
+	}
+	s.loggers.InfoLogger.Printf("Insertadas %d predicciones en la tabla", len(results))
+
+	if err := s.finishPredictionRun(ctx, config.PredictionKindVle, runID, len(results), nil); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// predictVle agrega los clics por estudiante, presentación, tipo de actividad y
+// semana y calcula la puntuación de participación igual que con MongoDB
+func (s *sqlClient) predictVle(ctx context.Context, runID string) ([]entity.ProcessedPredictionVleResult, error) {
+	scoringConfigs, err := s.loadVleScoringConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	courseWeeks, err := s.loadCourseWeeks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.query(ctx, fmt.Sprintf(`SELECT sv.idstudent, sv.codemodule, sv.codepresentation,
+		COALESCE(v.activitytype, 'unknown'), %s, SUM(sv.sumclick)
+		FROM studentVle sv
+		LEFT JOIN vle v ON v.idsite = sv.idsite AND v.codemodule = sv.codemodule AND v.codepresentation = sv.codepresentation
+		GROUP BY 1, 2, 3, 4, 5
+		ORDER BY 1, 2, 3`, s.dialect.week("sv.date")))
+	if err != nil {
+		return nil, fmt.Errorf("error al agregar los datos de studentVle: %w", err)
+	}
+	defer rows.Close()
+
+	engagement := newVleEngagement(runID, scoringConfigs, courseWeeks)
+	for rows.Next() {
+		var row entity.PredictionVle
+		if err := rows.Scan(&row.StudentID, &row.CodeModule, &row.CodePresentation, &row.ActivityType, &row.Week, &row.Clicks); err != nil {
+			return nil, fmt.Errorf("error al decodificar interacción: %w", err)
+		}
+		engagement.add(row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("errores encontrados durante el procesamiento de filas: %w", err)
+	}
+	return engagement.results(), nil
+}
+
+// storePredictions inserta o reemplaza las predicciones de la ejecución
+func (s *sqlClient) storePredictions(ctx context.Context, table string, rows [][]interface{}) error {
+	definition, _ := findSQLTable(table)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error al iniciar la transacción: %w", err)
+	}
+	defer tx.Rollback()
+	if err := s.insertRows(ctx, tx, table, definition.columnNames(), rows, definition.Key); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// startPredictionRun registra una nueva ejecución en curso y devuelve su identificador
+func (s *sqlClient) startPredictionRun(ctx context.Context, kind string) (string, error) {
+	run := entity.PredictionRun{
+		RunID:     primitive.NewObjectID().Hex(),
+		Kind:      kind,
+		Status:    predictionRunRunning,
+		StartedAt: time.Now(),
+	}
+	if err := s.savePredictionRun(ctx, run); err != nil {
+		return "", fmt.Errorf("error al registrar la ejecución de predicción: %w", err)
+	}
+	return run.RunID, nil
+}
+
+// finishPredictionRun cierra la ejecución; si terminó sin errores pasa a ser la
+// ejecución activa, se actualizan sus vistas y se aplica la política de retención
+func (s *sqlClient) finishPredictionRun(ctx context.Context, kind, runID string, recordCount int, runErr error) error {
+	status, errorMessage := predictionRunCompleted, ""
+	if runErr != nil {
+		status, errorMessage = predictionRunFailed, runErr.Error()
+		// Una ejecución cancelada se marca como fallida aunque el contexto ya no sea válido
+		ctx = context.WithoutCancel(ctx)
+	}
+	_, err := s.exec(ctx, "UPDATE "+predictionRunsCollection+" SET status = ?, record_count = ?, error = ?, finished_at = ? WHERE run_id = ?",
+		status, recordCount, errorMessage, time.Now(), runID)
+	if err != nil {
+		return fmt.Errorf("error al cerrar la ejecución %s: %w", runID, err)
+	}
+	if runErr != nil {
+		return nil
+	}
+	if err := s.setActiveRun(ctx, kind, runID); err != nil {
+		return err
+	}
+	if err := s.refreshViewsForRun(ctx, kind); err != nil {
+		return err
+	}
+	return s.purgePredictionRuns(ctx, kind)
+}
+
+// purgePredictionRuns conserva las últimas N ejecuciones completadas (y siempre
+// la activa) y elimina las demás junto con sus predicciones
+func (s *sqlClient) purgePredictionRuns(ctx context.Context, kind string) error {
+	retention := viper.GetInt(config.PredictionRunsRetention)
+	if retention <= 0 {
+		return nil
+	}
+	activeID, err := s.activeRunID(ctx, kind)
+	if err != nil {
+		return err
+	}
+	runs, err := s.loadPredictionRuns(ctx, kind)
+	if err != nil {
+		return fmt.Errorf("error al obtener ejecuciones para la retención: %w", err)
+	}
+	expired := expiredPredictionRuns(runs, activeID, retention)
+	if len(expired) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error al iniciar la transacción: %w", err)
+	}
+	defer tx.Rollback()
+	var deleted int64
+	for _, runID := range expired {
+		result, err := tx.ExecContext(ctx, s.dialect.rebind("DELETE FROM "+predictionCollections[kind]+" WHERE run_id = ?"), runID)
+		if err != nil {
+			return fmt.Errorf("error al eliminar predicciones de ejecuciones antiguas: %w", err)
+		}
+		count, _ := result.RowsAffected()
+		deleted += count
+		if _, err := tx.ExecContext(ctx, s.dialect.rebind("DELETE FROM "+predictionRunsCollection+" WHERE run_id = ?"), runID); err != nil {
+			return fmt.Errorf("error al eliminar ejecuciones antiguas: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error al eliminar ejecuciones antiguas: %w", err)
+	}
+	s.loggers.InfoLogger.Printf("Retención %s: %d ejecuciones y %d predicciones eliminadas", kind, len(expired), deleted)
+	return nil
+}
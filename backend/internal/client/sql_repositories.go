@@ -0,0 +1,587 @@
+package client
+
+import (
+	"backend/internal/entity"
+	"backend/internal/repository"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// BatchInsert inserta los documentos en la tabla de la colección en una sola transacción
+func (s *sqlClient) BatchInsert(ctx context.Context, database, collection string, documents []interface{}, batchSize int) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+
+	table, ok := findSQLTable(collection)
+	if !ok {
+		return fmt.Errorf("tabla no registrada: %s", collection)
+	}
+	columns := table.columnNames()
+	rows := make([][]interface{}, 0, len(documents))
+	for _, document := range documents {
+		// Mismos nombres de campo que al guardar el documento en MongoDB
+		raw, err := bson.Marshal(document)
+		if err != nil {
+			return fmt.Errorf("error al codificar el documento: %w", err)
+		}
+		var doc bson.M
+		if err := bson.Unmarshal(raw, &doc); err != nil {
+			return fmt.Errorf("error al decodificar el documento: %w", err)
+		}
+		row := make([]interface{}, len(columns))
+		for i, column := range columns {
+			row[i] = doc[column]
+		}
+		rows = append(rows, row)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error al iniciar la transacción: %w", err)
+	}
+	defer tx.Rollback()
+	if err := s.insertRows(ctx, tx, table.Name, columns, rows, nil); err != nil {
+		s.loggers.ErrorLogger.Printf("Error al insertar lote: %v", err)
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *sqlClient) GetData(ctx context.Context, database, collection string) ([]interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	table, ok := findSQLTable(collection)
+	if !ok {
+		return nil, nil
+	}
+	columns := table.columnNames()
+	rows, err := s.query(ctx, fmt.Sprintf("SELECT %s FROM %s", strings.Join(columns, ", "), table.Name))
+	if err != nil {
+		s.loggers.ErrorLogger.Printf("Error al obtener los datos: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []interface{}
+	for rows.Next() {
+		values, err := scanValues(rows, len(columns))
+		if err != nil {
+			s.loggers.ErrorLogger.Printf("Error al decodificar el documento: %v", err)
+			return nil, err
+		}
+		document := make(bson.D, len(columns))
+		for i, column := range columns {
+			document[i] = bson.E{Key: column, Value: values[i]}
+		}
+		results = append(results, document)
+	}
+	return results, rows.Err()
+}
+
+func (s *sqlClient) GetAllCountData(ctx context.Context, database string, colls []string) (map[string]int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	data := make(map[string]int64, len(colls))
+	for _, coll := range colls {
+		// Igual que en MongoDB, una colección inexistente tiene cero documentos
+		if _, ok := findSQLTable(coll); !ok {
+			data[coll] = 0
+			continue
+		}
+		var count int64
+		if err := s.queryRow(ctx, "SELECT COUNT(*) FROM "+coll).Scan(&count); err != nil {
+			s.loggers.ErrorLogger.Printf("Error al obtener los datos de la colección %v: %v", coll, err)
+			return nil, err
+		}
+		data[coll] = count
+	}
+	return data, nil
+}
+
+func (s *sqlClient) CountBy(ctx context.Context, database, collection string, fields []string, filter repository.Filter) ([]repository.GroupCount, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	table, ok := findSQLTable(collection)
+	if !ok {
+		return nil, fmt.Errorf("tabla no registrada: %s", collection)
+	}
+	for _, field := range fields {
+		if !table.hasColumn(field) {
+			return nil, fmt.Errorf("campo no soportado en %s: %s", collection, field)
+		}
+	}
+	var conditions []string
+	var args []interface{}
+	for field, value := range filter {
+		if !table.hasColumn(field) {
+			return nil, fmt.Errorf("campo no soportado en %s: %s", collection, field)
+		}
+		conditions = append(conditions, field+" = ?")
+		args = append(args, value)
+	}
+	query := fmt.Sprintf("SELECT %s, COUNT(*) FROM %s", strings.Join(fields, ", "), table.Name)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" GROUP BY %[1]s ORDER BY %[1]s", strings.Join(fields, ", "))
+
+	rows, err := s.query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error al agrupar %s: %w", collection, err)
+	}
+	defer rows.Close()
+
+	var groups []repository.GroupCount
+	for rows.Next() {
+		values, err := scanValues(rows, len(fields)+1)
+		if err != nil {
+			return nil, fmt.Errorf("error al decodificar los grupos de %s: %w", collection, err)
+		}
+		// Igual que $group, los campos nulos no forman parte de la clave
+		key := make(map[string]interface{}, len(fields))
+		for i, field := range fields {
+			if values[i] != nil {
+				key[field] = values[i]
+			}
+		}
+		count, err := toInt(values[len(fields)])
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, repository.GroupCount{Key: key, Count: count})
+	}
+	return groups, rows.Err()
+}
+
+// scanValues lee una fila sin tipos fijos, devolviendo el texto como string
+func scanValues(rows *sql.Rows, n int) ([]interface{}, error) {
+	values := make([]interface{}, n)
+	pointers := make([]interface{}, n)
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	if err := rows.Scan(pointers...); err != nil {
+		return nil, err
+	}
+	for i, value := range values {
+		if b, ok := value.([]byte); ok {
+			values[i] = string(b)
+		}
+	}
+	return values, nil
+}
+
+func (s *sqlClient) RegisterDatasetLoad(ctx context.Context, database string) (*entity.DatasetVersion, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	version := entity.DatasetVersion{LoadedAt: time.Now()}
+	_, err := s.exec(ctx, fmt.Sprintf(`INSERT INTO %[1]s (id, version, loaded_at) VALUES (?, 1, ?)
+		ON CONFLICT (id) DO UPDATE SET version = %[1]s.version + 1, loaded_at = excluded.loaded_at`, datasetMetadataCollection),
+		datasetMetadataID, version.LoadedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error al registrar la versión del conjunto de datos: %w", err)
+	}
+	if version.Version, err = s.GetDatasetVersion(ctx, database); err != nil {
+		return nil, err
+	}
+	// Los resultados en caché de versiones anteriores ya no son válidos
+	if _, err := s.exec(ctx, "DELETE FROM "+analyticsCacheCollection+" WHERE dataset_version < ?", version.Version); err != nil {
+		return nil, fmt.Errorf("error al limpiar la caché de analítica: %w", err)
+	}
+	s.loggers.InfoLogger.Printf("Versión del conjunto de datos: %d", version.Version)
+	return &version, nil
+}
+
+func (s *sqlClient) GetDatasetVersion(ctx context.Context, database string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	return s.datasetVersion(ctx)
+}
+
+// datasetVersion devuelve la versión actual del conjunto de datos (0 si nunca se registró una carga)
+func (s *sqlClient) datasetVersion(ctx context.Context) (int, error) {
+	var version int
+	err := s.queryRow(ctx, "SELECT version FROM "+datasetMetadataCollection+" WHERE id = ?", datasetMetadataID).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error al obtener la versión del conjunto de datos: %w", err)
+	}
+	return version, nil
+}
+
+func (s *sqlClient) ReadAnalyticsCache(ctx context.Context, database, key string, version int, out interface{}) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var data []byte
+	err := s.queryRow(ctx, "SELECT data FROM "+analyticsCacheCollection+" WHERE cache_key = ? AND dataset_version = ?", key, version).Scan(&data)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error al leer la caché %s: %w", key, err)
+	}
+	if err := bson.Unmarshal(data, out); err != nil {
+		return false, fmt.Errorf("error al decodificar la caché %s: %w", key, err)
+	}
+	return true, nil
+}
+
+func (s *sqlClient) WriteAnalyticsCache(ctx context.Context, database, key string, version int, data interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	raw, err := bson.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("error al guardar la caché %s: %w", key, err)
+	}
+	err = s.upsert(ctx, analyticsCacheCollection, map[string]interface{}{
+		"cache_key":       key,
+		"dataset_version": version,
+		"computed_at":     time.Now(),
+		"data":            raw,
+	})
+	if err != nil {
+		return fmt.Errorf("error al guardar la caché %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *sqlClient) SavePredictionRun(ctx context.Context, database string, run entity.PredictionRun) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if err := s.savePredictionRun(ctx, run); err != nil {
+		return fmt.Errorf("error al guardar la ejecución de predicción %s: %w", run.RunID, err)
+	}
+	return nil
+}
+
+func (s *sqlClient) savePredictionRun(ctx context.Context, run entity.PredictionRun) error {
+	return s.upsert(ctx, predictionRunsCollection, map[string]interface{}{
+		"run_id":       run.RunID,
+		"kind":         run.Kind,
+		"status":       run.Status,
+		"record_count": run.RecordCount,
+		"error":        run.Error,
+		"started_at":   run.StartedAt,
+		"finished_at":  nullTime(run.FinishedAt),
+	})
+}
+
+func (s *sqlClient) GetPredictionRuns(ctx context.Context, database, kind string) ([]entity.PredictionRun, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if kind != "" {
+		if _, ok := predictionCollections[kind]; !ok {
+			return nil, fmt.Errorf("tipo de predicción no soportado: %s", kind)
+		}
+	}
+	runs, err := s.loadPredictionRuns(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+	active := make(map[string]string)
+	for k := range predictionCollections {
+		if active[k], err = s.activeRunID(ctx, k); err != nil {
+			return nil, err
+		}
+	}
+	for i := range runs {
+		runs[i].Active = runs[i].RunID == active[runs[i].Kind]
+	}
+	return runs, nil
+}
+
+// loadPredictionRuns devuelve las ejecuciones del tipo indicado (todas si está
+// vacío) de más reciente a más antigua
+func (s *sqlClient) loadPredictionRuns(ctx context.Context, kind string) ([]entity.PredictionRun, error) {
+	query := "SELECT run_id, kind, status, record_count, error, started_at, finished_at FROM " + predictionRunsCollection
+	var args []interface{}
+	if kind != "" {
+		query += " WHERE kind = ?"
+		args = append(args, kind)
+	}
+	rows, err := s.query(ctx, query+" ORDER BY started_at DESC", args...)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener las ejecuciones de predicción: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []entity.PredictionRun
+	for rows.Next() {
+		var run entity.PredictionRun
+		var runErr sql.NullString
+		var finishedAt sql.NullTime
+		if err := rows.Scan(&run.RunID, &run.Kind, &run.Status, &run.RecordCount, &runErr, &run.StartedAt, &finishedAt); err != nil {
+			return nil, fmt.Errorf("error al decodificar las ejecuciones de predicción: %w", err)
+		}
+		run.Error = runErr.String
+		run.FinishedAt = finishedAt.Time
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+func (s *sqlClient) ActivatePredictionRun(ctx context.Context, database, runID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	var kind, status string
+	err := s.queryRow(ctx, "SELECT kind, status FROM "+predictionRunsCollection+" WHERE run_id = ?", runID).Scan(&kind, &status)
+	if err != nil {
+		return fmt.Errorf("ejecución de predicción no encontrada %s: %w", runID, err)
+	}
+	if status != predictionRunCompleted {
+		return fmt.Errorf("la ejecución %s no está completada (%s)", runID, status)
+	}
+	if err := s.setActiveRun(ctx, kind, runID); err != nil {
+		return err
+	}
+	s.loggers.InfoLogger.Printf("Ejecución %s activada para %s", runID, kind)
+	return s.refreshViewsForRun(ctx, kind)
+}
+
+func (s *sqlClient) setActiveRun(ctx context.Context, kind, runID string) error {
+	err := s.upsert(ctx, activePredictionRunsCollection, map[string]interface{}{
+		"kind":         kind,
+		"run_id":       runID,
+		"activated_at": time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("error al activar la ejecución %s: %w", runID, err)
+	}
+	return nil
+}
+
+// activeRunID devuelve la ejecución activa del tipo indicado o "" si no existe
+func (s *sqlClient) activeRunID(ctx context.Context, kind string) (string, error) {
+	var runID string
+	err := s.queryRow(ctx, "SELECT run_id FROM "+activePredictionRunsCollection+" WHERE kind = ?", kind).Scan(&runID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error al obtener la ejecución activa de %s: %w", kind, err)
+	}
+	return runID, nil
+}
+
+func (s *sqlClient) GetVleScoringConfigs(ctx context.Context, database string) ([]entity.VleScoringConfig, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	configs, err := s.loadVleScoringConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var results []entity.VleScoringConfig
+	for _, cfg := range configs {
+		results = append(results, cfg)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].CodeModule < results[j].CodeModule })
+	return results, nil
+}
+
+func (s *sqlClient) SaveVleScoringConfig(ctx context.Context, database string, scoringConfig entity.VleScoringConfig) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if err := repository.ValidateVleScoringConfig(&scoringConfig); err != nil {
+		return err
+	}
+	scoringConfig.UpdatedAt = time.Now()
+	weights, err := json.Marshal(scoringConfig.Weights)
+	if err != nil {
+		return fmt.Errorf("error al codificar los pesos: %w", err)
+	}
+	err = s.upsert(ctx, vleScoringConfigsCollection, map[string]interface{}{
+		"code_module":    scoringConfig.CodeModule,
+		"weights":        string(weights),
+		"default_weight": scoringConfig.DefaultWeight,
+		"normalization":  scoringConfig.Normalization,
+		"week_decay":     scoringConfig.WeekDecay,
+		"updated_at":     scoringConfig.UpdatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("error al guardar la configuración de puntuación VLE: %w", err)
+	}
+	s.loggers.InfoLogger.Printf("Configuración de puntuación VLE guardada para %s", scoringConfig.CodeModule)
+	return nil
+}
+
+// loadVleScoringConfigs devuelve las configuraciones guardadas indexadas por
+// módulo, incluyendo siempre una configuración "default"
+func (s *sqlClient) loadVleScoringConfigs(ctx context.Context) (map[string]entity.VleScoringConfig, error) {
+	rows, err := s.query(ctx, "SELECT code_module, weights, default_weight, normalization, week_decay, updated_at FROM "+vleScoringConfigsCollection)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener las configuraciones de puntuación VLE: %w", err)
+	}
+	defer rows.Close()
+
+	configs := map[string]entity.VleScoringConfig{repository.DefaultVleScoringModule: repository.DefaultVleScoringConfig()}
+	for rows.Next() {
+		var cfg entity.VleScoringConfig
+		var weights string
+		if err := rows.Scan(&cfg.CodeModule, &weights, &cfg.DefaultWeight, &cfg.Normalization, &cfg.WeekDecay, &cfg.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error al decodificar las configuraciones de puntuación VLE: %w", err)
+		}
+		if err := json.Unmarshal([]byte(weights), &cfg.Weights); err != nil {
+			return nil, fmt.Errorf("error al decodificar los pesos de %s: %w", cfg.CodeModule, err)
+		}
+		configs[cfg.CodeModule] = cfg
+	}
+	return configs, rows.Err()
+}
+
+// loadCourseWeeks devuelve la última semana de cada presentación según courses.length
+func (s *sqlClient) loadCourseWeeks(ctx context.Context) (map[string]int, error) {
+	rows, err := s.query(ctx, "SELECT codemodule, codepresentation, length FROM courses")
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los cursos: %w", err)
+	}
+	defer rows.Close()
+
+	weeks := make(map[string]int)
+	for rows.Next() {
+		var codeModule, codePresentation string
+		var length int
+		if err := rows.Scan(&codeModule, &codePresentation, &length); err != nil {
+			return nil, fmt.Errorf("error al decodificar los cursos: %w", err)
+		}
+		weeks[codeModule+"|"+codePresentation] = length / 7
+	}
+	return weeks, rows.Err()
+}
+
+// SaveStudentPseudonyms guarda o reemplaza la correspondencia de cada seudónimo
+func (s *sqlClient) SaveStudentPseudonyms(ctx context.Context, database string, pseudonyms []entity.StudentPseudonym) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	table, _ := findSQLTable(studentPseudonymsCollection)
+	rows := make([][]interface{}, len(pseudonyms))
+	for i, pseudonym := range pseudonyms {
+		rows[i] = []interface{}{pseudonym.Pseudonym, pseudonym.IdStudent, pseudonym.CreatedAt}
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error al iniciar la transacción: %w", err)
+	}
+	defer tx.Rollback()
+	if err := s.insertRows(ctx, tx, table.Name, table.columnNames(), rows, table.Key); err != nil {
+		return fmt.Errorf("error al guardar los seudónimos: %w", err)
+	}
+	return tx.Commit()
+}
+
+// GetStudentPseudonym devuelve el id_student original de un seudónimo
+func (s *sqlClient) GetStudentPseudonym(ctx context.Context, database string, pseudonym int) (*entity.StudentPseudonym, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	result := entity.StudentPseudonym{Pseudonym: pseudonym}
+	err := s.queryRow(ctx, "SELECT id_student, created_at FROM "+studentPseudonymsCollection+" WHERE pseudonym = ?", pseudonym).
+		Scan(&result.IdStudent, &result.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("seudónimo %w: %d", entity.ErrNotFound, pseudonym)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el seudónimo: %w", err)
+	}
+	return &result, nil
+}
+
+func (s *sqlClient) GetUser(ctx context.Context, database, username string) (*entity.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	user := entity.User{Username: username}
+	err := s.queryRow(ctx, "SELECT password_hash, role, created_at FROM "+usersCollection+" WHERE username = ?", username).
+		Scan(&user.PasswordHash, &user.Role, &user.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el usuario: %w", err)
+	}
+	return &user, nil
+}
+
+func (s *sqlClient) SaveUser(ctx context.Context, database string, user entity.User) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	err := s.upsert(ctx, usersCollection, map[string]interface{}{
+		"username":      user.Username,
+		"password_hash": user.PasswordHash,
+		"role":          user.Role,
+		"created_at":    user.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("error al guardar el usuario: %w", err)
+	}
+	return nil
+}
+
+func (s *sqlClient) SaveRefreshToken(ctx context.Context, database string, token entity.RefreshToken) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// Los tokens caducados se purgan al emitir otros nuevos; en MongoDB lo hace el índice TTL
+	if _, err := s.exec(ctx, "DELETE FROM "+refreshTokensCollection+" WHERE expires_at < ?", time.Now()); err != nil {
+		return fmt.Errorf("error al purgar los tokens de refresco: %w", err)
+	}
+	_, err := s.exec(ctx, "INSERT INTO "+refreshTokensCollection+" (token_hash, username, expires_at, created_at) VALUES (?, ?, ?, ?)",
+		token.Hash, token.Username, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error al guardar el token de refresco: %w", err)
+	}
+	return nil
+}
+
+// ConsumeRefreshToken elimina el token y lo devuelve: cada token de refresco solo
+// puede usarse una vez
+func (s *sqlClient) ConsumeRefreshToken(ctx context.Context, database, hash string) (*entity.RefreshToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error al iniciar la transacción: %w", err)
+	}
+	defer tx.Rollback()
+	token := entity.RefreshToken{Hash: hash}
+	err = tx.QueryRowContext(ctx, s.dialect.rebind("SELECT username, expires_at, created_at FROM "+refreshTokensCollection+" WHERE token_hash = ?"), hash).
+		Scan(&token.Username, &token.ExpiresAt, &token.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el token de refresco: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, s.dialect.rebind("DELETE FROM "+refreshTokensCollection+" WHERE token_hash = ?"), hash)
+	if err != nil {
+		return nil, fmt.Errorf("error al eliminar el token de refresco: %w", err)
+	}
+	// Otra petición consumió el token entre la lectura y el borrado
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return nil, nil
+	}
+	return &token, tx.Commit()
+}
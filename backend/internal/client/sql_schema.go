@@ -0,0 +1,367 @@
+package client
+
+import (
+	"backend/internal/config"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Tipos de columna lógicos; cada dialecto los traduce a su tipo nativo
+const (
+	sqlInteger   = "integer"
+	sqlReal      = "real"
+	sqlText      = "text"
+	sqlTimestamp = "timestamp"
+	sqlBlob      = "blob"
+)
+
+type sqlColumn struct {
+	Name string
+	Type string
+}
+
+// sqlTable describe una tabla con los mismos nombres de campo que la colección
+// equivalente de MongoDB
+type sqlTable struct {
+	Name    string
+	Columns []sqlColumn
+	Key     []string
+	Indexes [][]string
+}
+
+func (t sqlTable) columnNames() []string {
+	names := make([]string, len(t.Columns))
+	for i, column := range t.Columns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+func (t sqlTable) hasColumn(name string) bool {
+	for _, column := range t.Columns {
+		if column.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Esquema relacional de OULAD y de las tablas internas del backend
+var sqlTables = []sqlTable{
+	{
+		Name: "courses",
+		Columns: []sqlColumn{
+			{"codemodule", sqlText}, {"codepresentation", sqlText}, {"length", sqlInteger},
+		},
+	},
+	{
+		Name: "assessments",
+		Columns: []sqlColumn{
+			{"idassessment", sqlInteger}, {"codemodule", sqlText}, {"codepresentation", sqlText},
+			{"assessmenttype", sqlText}, {"date", sqlInteger}, {"weight", sqlInteger},
+		},
+		Indexes: [][]string{{"idassessment"}, {"assessmenttype"}},
+	},
+	{
+		Name: "vle",
+		Columns: []sqlColumn{
+			{"idsite", sqlInteger}, {"codemodule", sqlText}, {"codepresentation", sqlText},
+			{"activitytype", sqlText}, {"weekfrom", sqlInteger}, {"weekto", sqlInteger},
+		},
+		Indexes: [][]string{{"idsite", "codemodule", "codepresentation"}},
+	},
+	{
+		Name: "studentInfo",
+		Columns: []sqlColumn{
+			{"idstudent", sqlInteger}, {"codemodule", sqlText}, {"codepresentation", sqlText},
+			{"gender", sqlText}, {"region", sqlText}, {"highesteducation", sqlText},
+			{"imdband", sqlText}, {"ageband", sqlText}, {"numofprevattempts", sqlInteger},
+			{"studiedcredits", sqlInteger}, {"disability", sqlText}, {"finalresult", sqlText},
+		},
+		Indexes: [][]string{{"codemodule", "codepresentation", "idstudent"}},
+	},
+	{
+		Name: "studentRegistration",
+		Columns: []sqlColumn{
+			{"codemodule", sqlText}, {"codepresentation", sqlText}, {"idstudent", sqlInteger},
+			{"dateregistration", sqlInteger}, {"dateunregistration", sqlInteger},
+		},
+	},
+	{
+		Name: "studentAssessment",
+		Columns: []sqlColumn{
+			{"idassessment", sqlInteger}, {"idstudent", sqlInteger}, {"datesubmitted", sqlInteger},
+			{"isbanked", sqlInteger}, {"score", sqlReal},
+		},
+		Indexes: [][]string{{"idassessment"}},
+	},
+	{
+		Name: "studentVle",
+		Columns: []sqlColumn{
+			{"codemodule", sqlText}, {"codepresentation", sqlText}, {"idstudent", sqlInteger},
+			{"idsite", sqlInteger}, {"date", sqlInteger}, {"sumclick", sqlInteger},
+		},
+		Indexes: [][]string{{"codemodule", "codepresentation", "idstudent"}},
+	},
+	{
+		Name: "prediction_assessments",
+		Columns: []sqlColumn{
+			{"run_id", sqlText}, {"student_id", sqlInteger}, {"assessment_id", sqlInteger},
+			{"predicted_score", sqlReal}, {"prediction_date", sqlTimestamp},
+		},
+		Key:     []string{"run_id", "student_id", "assessment_id"},
+		Indexes: [][]string{{"assessment_id"}},
+	},
+	{
+		Name: "prediction_vle",
+		Columns: []sqlColumn{
+			{"run_id", sqlText}, {"student_id", sqlInteger}, {"code_module", sqlText},
+			{"code_presentation", sqlText}, {"total_clicks", sqlInteger}, {"activity_clicks", sqlText},
+			{"raw_score", sqlReal}, {"predicted_score", sqlReal},
+		},
+		Key: []string{"run_id", "student_id", "code_module", "code_presentation"},
+	},
+	{
+		Name: predictionRunsCollection,
+		Columns: []sqlColumn{
+			{"run_id", sqlText}, {"kind", sqlText}, {"status", sqlText}, {"record_count", sqlInteger},
+			{"error", sqlText}, {"started_at", sqlTimestamp}, {"finished_at", sqlTimestamp},
+		},
+		Key:     []string{"run_id"},
+		Indexes: [][]string{{"kind", "started_at"}},
+	},
+	{
+		Name: activePredictionRunsCollection,
+		Columns: []sqlColumn{
+			{"kind", sqlText}, {"run_id", sqlText}, {"activated_at", sqlTimestamp},
+		},
+		Key: []string{"kind"},
+	},
+	{
+		Name: vleScoringConfigsCollection,
+		Columns: []sqlColumn{
+			{"code_module", sqlText}, {"weights", sqlText}, {"default_weight", sqlReal},
+			{"normalization", sqlText}, {"week_decay", sqlReal}, {"updated_at", sqlTimestamp},
+		},
+		Key: []string{"code_module"},
+	},
+	{
+		Name: datasetMetadataCollection,
+		Columns: []sqlColumn{
+			{"id", sqlText}, {"version", sqlInteger}, {"loaded_at", sqlTimestamp},
+		},
+		Key: []string{"id"},
+	},
+	{
+		Name: analyticsCacheCollection,
+		Columns: []sqlColumn{
+			{"cache_key", sqlText}, {"dataset_version", sqlInteger}, {"computed_at", sqlTimestamp}, {"data", sqlBlob},
+		},
+		Key: []string{"cache_key"},
+	},
+	{
+		Name: studentPseudonymsCollection,
+		Columns: []sqlColumn{
+			{"pseudonym", sqlInteger}, {"id_student", sqlInteger}, {"created_at", sqlTimestamp},
+		},
+		Key: []string{"pseudonym"},
+	},
+	{
+		Name: erasureAuditCollection,
+		Columns: []sqlColumn{
+			{"sequence", sqlInteger}, {"subject", sqlText}, {"erased_at", sqlTimestamp},
+			{"collections", sqlText}, {"previous_hash", sqlText}, {"hash", sqlText},
+		},
+		Key: []string{"sequence"},
+	},
+	{
+		Name: usersCollection,
+		Columns: []sqlColumn{
+			{"username", sqlText}, {"password_hash", sqlText}, {"role", sqlText}, {"created_at", sqlTimestamp},
+		},
+		Key: []string{"username"},
+	},
+	{
+		Name: refreshTokensCollection,
+		Columns: []sqlColumn{
+			{"token_hash", sqlText}, {"username", sqlText}, {"expires_at", sqlTimestamp}, {"created_at", sqlTimestamp},
+		},
+		Key:     []string{"token_hash"},
+		Indexes: [][]string{{"expires_at"}},
+	},
+	{
+		Name: modelEvaluationsCollection,
+		Columns: []sqlColumn{
+			{"model_id", sqlText}, {"evaluated_at", sqlTimestamp}, {"data", sqlBlob},
+		},
+		Indexes: [][]string{{"model_id", "evaluated_at"}},
+	},
+	{
+		Name: modelsCollection,
+		Columns: []sqlColumn{
+			{"model_id", sqlText}, {"task", sqlText}, {"prediction_collection", sqlText},
+			{"last_evaluation", sqlBlob}, {"updated_at", sqlTimestamp},
+		},
+		Key: []string{"model_id"},
+	},
+	{
+		Name: studentClustersCollection,
+		Columns: []sqlColumn{
+			{"id", sqlText}, {"data", sqlBlob},
+		},
+		Key: []string{"id"},
+	},
+	{
+		Name: clusterAssignmentsCollection,
+		Columns: []sqlColumn{
+			{"clustering_id", sqlText}, {"student_id", sqlInteger}, {"cluster", sqlInteger},
+		},
+		Indexes: [][]string{{"clustering_id"}, {"student_id"}},
+	},
+	{
+		Name: integrityReportsCollection,
+		Columns: []sqlColumn{
+			{"id", sqlText}, {"dataset_version", sqlInteger}, {"started_at", sqlTimestamp}, {"data", sqlBlob},
+		},
+		Key:     []string{"id"},
+		Indexes: [][]string{{"started_at"}},
+	},
+	{
+		Name: dataProfilesCollection,
+		Columns: []sqlColumn{
+			{"dataset_version", sqlInteger}, {"computed_at", sqlTimestamp}, {"data", sqlBlob},
+		},
+		Key: []string{"dataset_version"},
+	},
+	{
+		Name: materializedViewsCollection,
+		Columns: []sqlColumn{
+			{"name", sqlText}, {"collection", sqlText}, {"status", sqlText}, {"error", sqlText},
+			{"refreshed_at", sqlTimestamp}, {"duration_ms", sqlInteger}, {"documents", sqlInteger},
+			{"source_run_id", sqlText}, {"dataset_version", sqlInteger},
+		},
+		Key: []string{"name"},
+	},
+	{
+		Name: "mv_avg_score_by_assessment_type",
+		Columns: []sqlColumn{
+			{"assessment_type", sqlText}, {"average_score", sqlReal}, {"predictions", sqlInteger},
+		},
+	},
+	{
+		Name: "mv_student_count_by_assessment",
+		Columns: []sqlColumn{
+			{"assessment_id", sqlInteger}, {"student_count", sqlInteger},
+		},
+	},
+	{
+		Name: "mv_score_histogram",
+		Columns: []sqlColumn{
+			{"code_module", sqlText}, {"code_presentation", sqlText}, {"assessment_type", sqlText},
+			{"bin", sqlReal}, {"predictions", sqlInteger},
+		},
+	},
+	{
+		Name: engagementWeeklyCollection,
+		Columns: []sqlColumn{
+			{"student_id", sqlInteger}, {"code_module", sqlText}, {"code_presentation", sqlText},
+			{"week", sqlInteger}, {"activity_type", sqlText}, {"clicks", sqlInteger},
+			{"days_active", sqlInteger}, {"first_day", sqlInteger}, {"last_day", sqlInteger},
+		},
+		Indexes: [][]string{{"code_module", "code_presentation", "student_id", "week"}},
+	},
+}
+
+func findSQLTable(name string) (sqlTable, bool) {
+	for _, table := range sqlTables {
+		if table.Name == name {
+			return table, true
+		}
+	}
+	return sqlTable{}, false
+}
+
+// sqlDialect adapta los tipos y las expresiones que difieren entre SQLite y PostgreSQL
+type sqlDialect string
+
+func (d sqlDialect) postgres() bool {
+	return string(d) == config.StoragePostgres
+}
+
+func (d sqlDialect) columnType(logical string) string {
+	if d.postgres() {
+		switch logical {
+		case sqlInteger:
+			return "BIGINT"
+		case sqlReal:
+			return "DOUBLE PRECISION"
+		case sqlTimestamp:
+			return "TIMESTAMPTZ"
+		case sqlBlob:
+			return "BYTEA"
+		}
+		return "TEXT"
+	}
+	return strings.ToUpper(logical)
+}
+
+// floor redondea hacia abajo; SQLite no incluye FLOOR en todas sus compilaciones
+func (d sqlDialect) floor(expr string) string {
+	if d.postgres() {
+		return fmt.Sprintf("FLOOR(%s)", expr)
+	}
+	return fmt.Sprintf("(CAST(%[1]s AS INTEGER) - (%[1]s < CAST(%[1]s AS INTEGER)))", expr)
+}
+
+// week devuelve la semana de un día del curso, como $floor(date / 7) en MongoDB
+func (d sqlDialect) week(expr string) string {
+	return fmt.Sprintf("((%[1]s - ((%[1]s %% 7) + 7) %% 7) / 7)", expr)
+}
+
+// rebind convierte los marcadores ? en los $n que usa PostgreSQL
+func (d sqlDialect) rebind(query string) string {
+	if !d.postgres() {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// createTableSQL genera el DDL de la tabla y de sus índices
+func (d sqlDialect) createTableSQL(table sqlTable) []string {
+	columns := make([]string, 0, len(table.Columns)+1)
+	for _, column := range table.Columns {
+		columns = append(columns, column.Name+" "+d.columnType(column.Type))
+	}
+	if len(table.Key) > 0 {
+		columns = append(columns, "PRIMARY KEY ("+strings.Join(table.Key, ", ")+")")
+	}
+	statements := []string{fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", table.Name, strings.Join(columns, ", "))}
+	for _, index := range table.Indexes {
+		name := strings.ToLower("index_" + table.Name + "_" + strings.Join(index, "_"))
+		statements = append(statements, fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s)", name, table.Name, strings.Join(index, ", ")))
+	}
+	return statements
+}
+
+// createSQLSchema crea las tablas e índices que aún no existen
+func (s *sqlClient) createSQLSchema(ctx context.Context) error {
+	for _, table := range sqlTables {
+		for _, statement := range s.dialect.createTableSQL(table) {
+			if _, err := s.db.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("error al crear la tabla %s: %w", table.Name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,524 @@
+package client
+
+import (
+	"backend/internal/analytics"
+	"backend/internal/config"
+	"backend/internal/entity"
+	"backend/internal/repository"
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const assessmentJoinSQL = `FROM prediction_assessments p
+	%s JOIN assessments a ON a.idassessment = p.assessment_id
+	WHERE p.run_id = ?`
+
+func avgScoreByAssessmentTypeQuery(sqlDialect) string {
+	return `INSERT INTO mv_avg_score_by_assessment_type (assessment_type, average_score, predictions)
+	SELECT a.assessmenttype, AVG(p.predicted_score), COUNT(*) ` + fmt.Sprintf(assessmentJoinSQL, "INNER") + `
+	GROUP BY a.assessmenttype`
+}
+
+func studentCountByAssessmentQuery(sqlDialect) string {
+	return `INSERT INTO mv_student_count_by_assessment (assessment_id, student_count)
+	SELECT assessment_id, COUNT(DISTINCT student_id) FROM prediction_assessments
+	WHERE run_id = ?
+	GROUP BY assessment_id`
+}
+
+func scoreHistogramQuery(d sqlDialect) string {
+	return `INSERT INTO mv_score_histogram (code_module, code_presentation, assessment_type, bin, predictions)
+	SELECT a.codemodule, a.codepresentation, a.assessmenttype, ` + d.floor("p.predicted_score") + `, COUNT(*) ` +
+		fmt.Sprintf(assessmentJoinSQL, "LEFT") + `
+	GROUP BY 1, 2, 3, 4`
+}
+
+func engagementWeeklyQuery(d sqlDialect) string {
+	return `INSERT INTO engagement_weekly (student_id, code_module, code_presentation, week, activity_type, clicks, days_active, first_day, last_day)
+	SELECT sv.idstudent, sv.codemodule, sv.codepresentation, ` + d.week("sv.date") + `, COALESCE(v.activitytype, 'unknown'),
+		SUM(sv.sumclick), COUNT(DISTINCT sv.date), MIN(sv.date), MAX(sv.date)
+	FROM studentVle sv
+	LEFT JOIN vle v ON v.idsite = sv.idsite AND v.codemodule = sv.codemodule AND v.codepresentation = sv.codepresentation
+	GROUP BY 1, 2, 3, 4, 5`
+}
+
+// RefreshMaterializedViews actualiza la vista indicada o todas si name está vacío
+func (s *sqlClient) RefreshMaterializedViews(ctx context.Context, database, name string) ([]entity.ViewMetadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+
+	views := materializedViews
+	if name != "" {
+		view, err := findMaterializedView(name)
+		if err != nil {
+			return nil, err
+		}
+		views = []materializedView{view}
+	}
+
+	var results []entity.ViewMetadata
+	for _, view := range views {
+		metadata, err := s.refreshView(ctx, view)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, *metadata)
+	}
+	return results, nil
+}
+
+func (s *sqlClient) GetMaterializedViews(ctx context.Context, database string) ([]entity.ViewMetadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	results := make([]entity.ViewMetadata, 0, len(materializedViews))
+	for _, view := range materializedViews {
+		metadata, err := s.viewMetadata(ctx, view)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *metadata)
+	}
+	return results, nil
+}
+
+func (s *sqlClient) GetViewMetadata(ctx context.Context, database, name string) (*entity.ViewMetadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	view, err := findMaterializedView(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.viewMetadata(ctx, view)
+}
+
+// refreshViewsForRun actualiza las vistas que dependen de la ejecución activa del tipo indicado
+func (s *sqlClient) refreshViewsForRun(ctx context.Context, kind string) error {
+	for _, view := range materializedViews {
+		if view.RunKind != kind {
+			continue
+		}
+		if _, err := s.refreshView(ctx, view); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshView recalcula la tabla de resumen de la vista en una transacción, de
+// modo que las lecturas nunca ven la tabla vacía mientras se recalcula. En
+// PostgreSQL la tabla se bloquea para que dos actualizaciones simultáneas no
+// dupliquen filas; SQLite ya serializa las escrituras
+func (s *sqlClient) refreshView(ctx context.Context, view materializedView) (*entity.ViewMetadata, error) {
+	start := time.Now().Truncate(time.Millisecond)
+	metadata := &entity.ViewMetadata{Name: view.Name, Collection: view.Target, Status: viewStatusReady, RefreshedAt: start}
+
+	err := func() error {
+		var args []interface{}
+		if view.RunKind != "" {
+			runID, err := s.activeRunID(ctx, view.RunKind)
+			if err != nil {
+				return err
+			}
+			metadata.SourceRunID = runID
+			args = append(args, runID)
+		}
+		version, err := s.datasetVersion(ctx)
+		if err != nil {
+			return err
+		}
+		metadata.DatasetVersion = version
+
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("error al iniciar la transacción: %w", err)
+		}
+		defer tx.Rollback()
+		if s.dialect.postgres() {
+			if _, err := tx.ExecContext(ctx, "LOCK TABLE "+view.Target+" IN EXCLUSIVE MODE"); err != nil {
+				return fmt.Errorf("error al bloquear la vista %s: %w", view.Name, err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+view.Target); err != nil {
+			return fmt.Errorf("error al limpiar la vista %s: %w", view.Name, err)
+		}
+		result, err := tx.ExecContext(ctx, s.dialect.rebind(view.Query(s.dialect)), args...)
+		if err != nil {
+			return fmt.Errorf("error al actualizar la vista %s: %w", view.Name, err)
+		}
+		if metadata.Documents, err = result.RowsAffected(); err != nil {
+			return fmt.Errorf("error al contar la vista %s: %w", view.Name, err)
+		}
+		return tx.Commit()
+	}()
+	if err != nil {
+		metadata.Status = viewStatusFailed
+		metadata.Error = err.Error()
+	}
+	metadata.DurationMs = time.Since(start).Milliseconds()
+
+	saveErr := s.upsert(ctx, materializedViewsCollection, map[string]interface{}{
+		"name":            metadata.Name,
+		"collection":      metadata.Collection,
+		"status":          metadata.Status,
+		"error":           metadata.Error,
+		"refreshed_at":    metadata.RefreshedAt,
+		"duration_ms":     metadata.DurationMs,
+		"documents":       metadata.Documents,
+		"source_run_id":   metadata.SourceRunID,
+		"dataset_version": metadata.DatasetVersion,
+	})
+	if saveErr != nil && err == nil {
+		err = fmt.Errorf("error al guardar los metadatos de la vista %s: %w", view.Name, saveErr)
+	}
+	if err != nil {
+		s.loggers.ErrorLogger.Printf("Error al actualizar la vista %s: %v", view.Name, err)
+		return nil, err
+	}
+	s.loggers.InfoLogger.Printf("Vista %s actualizada: %d filas en %dms", view.Name, metadata.Documents, metadata.DurationMs)
+	return metadata, nil
+}
+
+// viewMetadata devuelve los metadatos guardados de la vista y marca si está
+// desactualizada respecto a la ejecución activa o a la versión de los datos
+func (s *sqlClient) viewMetadata(ctx context.Context, view materializedView) (*entity.ViewMetadata, error) {
+	var metadata entity.ViewMetadata
+	err := s.queryRow(ctx, `SELECT name, collection, status, error, refreshed_at, duration_ms, documents, source_run_id, dataset_version
+		FROM `+materializedViewsCollection+` WHERE name = ?`, view.Name).Scan(
+		&metadata.Name, &metadata.Collection, &metadata.Status, &metadata.Error, &metadata.RefreshedAt,
+		&metadata.DurationMs, &metadata.Documents, &metadata.SourceRunID, &metadata.DatasetVersion)
+	if err == sql.ErrNoRows {
+		return &entity.ViewMetadata{Name: view.Name, Collection: view.Target, Stale: true}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los metadatos de la vista %s: %w", view.Name, err)
+	}
+
+	version, err := s.datasetVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	metadata.Stale = metadata.Status != viewStatusReady || metadata.DatasetVersion != version
+	if view.RunKind != "" {
+		runID, err := s.activeRunID(ctx, view.RunKind)
+		if err != nil {
+			return nil, err
+		}
+		metadata.Stale = metadata.Stale || metadata.SourceRunID != runID
+	}
+	return &metadata, nil
+}
+
+// ensureView actualiza la vista si nunca se ha calculado, para que la primera
+// lectura tras desplegar no devuelva una tabla vacía
+func (s *sqlClient) ensureView(ctx context.Context, name string) (materializedView, error) {
+	view, err := findMaterializedView(name)
+	if err != nil {
+		return view, err
+	}
+	var count int
+	if err := s.queryRow(ctx, "SELECT COUNT(*) FROM "+materializedViewsCollection+" WHERE name = ?", name).Scan(&count); err != nil {
+		return view, fmt.Errorf("error al obtener los metadatos de la vista %s: %w", name, err)
+	}
+	if count == 0 {
+		if _, err := s.refreshView(ctx, view); err != nil {
+			return view, err
+		}
+	}
+	return view, nil
+}
+
+// Charts
+
+func (s *sqlClient) GetScoreDistributionPredictionAssessments(ctx context.Context, database string, query entity.ScoreDistributionQuery) (*entity.ScoreDistribution, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	edges, err := scoreDistributionEdges(query)
+	if err != nil {
+		return nil, err
+	}
+	runID, err := s.activeRunID(ctx, config.PredictionKindAssessments)
+	if err != nil {
+		return nil, err
+	}
+
+	// Puntuaciones ordenadas de la ejecución activa para el módulo, presentación o tipo solicitados
+	conditions, args := scoreDistributionConditions(query, "a.codemodule", "a.codepresentation", "a.assessmenttype")
+	statement := "SELECT p.predicted_score " + fmt.Sprintf(assessmentJoinSQL, "LEFT")
+	for _, condition := range conditions {
+		statement += " AND " + condition
+	}
+	rows, err := s.query(ctx, statement+" ORDER BY p.predicted_score", append([]interface{}{runID}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("error al ejecutar la agregación de distribución: %w", err)
+	}
+	defer rows.Close()
+	var scores []float64
+	for rows.Next() {
+		var score float64
+		if err := rows.Scan(&score); err != nil {
+			return nil, fmt.Errorf("error durante la iteración de filas: %w", err)
+		}
+		scores = append(scores, score)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error durante la iteración de filas: %w", err)
+	}
+
+	distribution := &entity.ScoreDistribution{Buckets: []entity.ScoreRangePredictionAssessments{}}
+	if len(scores) == 0 {
+		return distribution, nil
+	}
+	distribution.Total = len(scores)
+	distribution.Percentiles = rankedPercentiles(len(scores), func(position int) float64 { return scores[position] })
+
+	if query.BinCount > 0 {
+		for _, b := range analytics.AutoBuckets(scores, query.BinCount) {
+			distribution.Buckets = append(distribution.Buckets, autoScoreBucket(b.Min, b.Max, b.Count))
+		}
+		return distribution, nil
+	}
+
+	var inRange []int
+	outOfRange := 0
+	if alignedScoreEdges(edges) {
+		if inRange, outOfRange, err = s.viewScoreBuckets(ctx, query, edges); err != nil {
+			return nil, err
+		}
+	} else {
+		inRange = make([]int, len(edges)-1)
+		for _, score := range scores {
+			bucket := sort.Search(len(edges), func(e int) bool { return edges[e] > score })
+			if bucket == 0 || bucket == len(edges) {
+				outOfRange++
+			} else {
+				inRange[bucket-1]++
+			}
+		}
+	}
+	distribution.Buckets = scoreBuckets(edges, inRange, outOfRange)
+	return distribution, nil
+}
+
+// scoreDistributionConditions condiciones de filtro por módulo, presentación y
+// tipo de evaluación sobre las columnas indicadas
+func scoreDistributionConditions(query entity.ScoreDistributionQuery, module, presentation, assessmentType string) ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	for _, filter := range []struct{ column, value string }{
+		{module, query.CodeModule},
+		{presentation, query.CodePresentation},
+		{assessmentType, query.AssessmentType},
+	} {
+		if filter.value != "" {
+			conditions = append(conditions, filter.column+" = ?")
+			args = append(args, filter.value)
+		}
+	}
+	return conditions, args
+}
+
+// viewScoreBuckets suma el histograma materializado y lo reparte entre los
+// límites, que deben estar alineados con sus tramos
+func (s *sqlClient) viewScoreBuckets(ctx context.Context, query entity.ScoreDistributionQuery, edges []float64) ([]int, int, error) {
+	view, err := s.ensureView(ctx, config.ViewScoreHistogram)
+	if err != nil {
+		return nil, 0, err
+	}
+	conditions, args := scoreDistributionConditions(query, "code_module", "code_presentation", "assessment_type")
+	statement := "SELECT bin, SUM(predictions) FROM " + view.Target
+	if len(conditions) > 0 {
+		statement += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	rows, err := s.query(ctx, statement+" GROUP BY bin ORDER BY bin", args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error al ejecutar la agregación de distribución: %w", err)
+	}
+	defer rows.Close()
+
+	var lowers []float64
+	var counts []int
+	for rows.Next() {
+		var lower float64
+		var count int
+		if err := rows.Scan(&lower, &count); err != nil {
+			return nil, 0, fmt.Errorf("error durante la iteración de filas: %w", err)
+		}
+		lowers = append(lowers, lower)
+		counts = append(counts, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error durante la iteración de filas: %w", err)
+	}
+	inRange, outOfRange := histogramBuckets(lowers, counts, edges)
+	return inRange, outOfRange, nil
+}
+
+func (s *sqlClient) GetAveragePredictedScoreByAssessmentType(ctx context.Context, database string) ([]entity.AssessmentTypeAverage, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	view, err := s.ensureView(ctx, config.ViewAvgScoreByAssessmentType)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.query(ctx, "SELECT assessment_type, average_score FROM "+view.Target+" ORDER BY average_score DESC")
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los promedios por tipo: %w", err)
+	}
+	defer rows.Close()
+
+	results := []entity.AssessmentTypeAverage{}
+	for rows.Next() {
+		var result entity.AssessmentTypeAverage
+		if err := rows.Scan(&result.AssessmentType, &result.AverageScore); err != nil {
+			return nil, fmt.Errorf("error durante la iteración de filas: %w", err)
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+func (s *sqlClient) GetStudentCountByAssessmentID(ctx context.Context, database string) ([]entity.AssessmentStudentCount, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	view, err := s.ensureView(ctx, config.ViewStudentCountByAssessment)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.query(ctx, "SELECT assessment_id, student_count FROM "+view.Target+" ORDER BY assessment_id")
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los recuentos por evaluación: %w", err)
+	}
+	defer rows.Close()
+
+	var results []entity.AssessmentStudentCount
+	for rows.Next() {
+		var result entity.AssessmentStudentCount
+		if err := rows.Scan(&result.AssessmentID, &result.StudentCount); err != nil {
+			return nil, fmt.Errorf("error durante la iteración de filas: %w", err)
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+func (s *sqlClient) GetEngagementSeries(ctx context.Context, database string, query entity.EngagementQuery) (*entity.EngagementSeries, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	if query.Granularity == "" {
+		query.Granularity = granularityWeek
+	}
+	if query.StudentID == 0 && (query.CodeModule == "" || query.CodePresentation == "") {
+		return nil, fmt.Errorf("se requiere code_module y code_presentation o id_student")
+	}
+	empty := &entity.EngagementSeries{Granularity: query.Granularity, Points: []entity.EngagementPoint{}}
+
+	// Subconsulta de los estudiantes de studentInfo que cumplen el filtro de cohorte
+	var cohort string
+	var cohortArgs []interface{}
+	if len(query.Cohort) > 0 {
+		conditions := []string{"1 = 1"}
+		if query.CodeModule != "" {
+			conditions = append(conditions, "codemodule = ?")
+			cohortArgs = append(cohortArgs, query.CodeModule)
+		}
+		if query.CodePresentation != "" {
+			conditions = append(conditions, "codepresentation = ?")
+			cohortArgs = append(cohortArgs, query.CodePresentation)
+		}
+		for attribute, value := range query.Cohort {
+			field, ok := repository.StudentInfoFields[attribute]
+			if !ok {
+				return nil, fmt.Errorf("atributo de cohorte no soportado: %s", attribute)
+			}
+			conditions = append(conditions, field+" = ?")
+			cohortArgs = append(cohortArgs, value)
+		}
+		cohort = "SELECT idstudent FROM studentInfo WHERE " + strings.Join(conditions, " AND ")
+		var exists int
+		err := s.queryRow(ctx, "SELECT COUNT(*) FROM ("+cohort+") c", cohortArgs...).Scan(&exists)
+		if err != nil {
+			return nil, fmt.Errorf("error al obtener los estudiantes de la cohorte: %w", err)
+		}
+		if exists == 0 {
+			return empty, nil
+		}
+	}
+
+	var statement string
+	var conditions []string
+	var args []interface{}
+	switch query.Granularity {
+	case granularityWeek:
+		// Semanal: se lee de la tabla materializada
+		if _, err := s.ensureView(ctx, config.ViewEngagementWeekly); err != nil {
+			return nil, err
+		}
+		statement = "SELECT week, activity_type, SUM(clicks), COUNT(DISTINCT student_id) FROM " + engagementWeeklyCollection
+		if query.CodeModule != "" {
+			conditions = append(conditions, "code_module = ?")
+			args = append(args, query.CodeModule)
+		}
+		if query.CodePresentation != "" {
+			conditions = append(conditions, "code_presentation = ?")
+			args = append(args, query.CodePresentation)
+		}
+		if query.StudentID != 0 {
+			conditions = append(conditions, "student_id = ?")
+			args = append(args, query.StudentID)
+		} else if cohort != "" {
+			conditions = append(conditions, "student_id IN ("+cohort+")")
+			args = append(args, cohortArgs...)
+		}
+	case granularityDay:
+		// Diaria: se agrega directamente desde studentVle con el filtro aplicado
+		statement = `SELECT sv.date, COALESCE(v.activitytype, 'unknown'), SUM(sv.sumclick), COUNT(DISTINCT sv.idstudent)
+		FROM studentVle sv
+		LEFT JOIN vle v ON v.idsite = sv.idsite AND v.codemodule = sv.codemodule AND v.codepresentation = sv.codepresentation`
+		if query.CodeModule != "" {
+			conditions = append(conditions, "sv.codemodule = ?")
+			args = append(args, query.CodeModule)
+		}
+		if query.CodePresentation != "" {
+			conditions = append(conditions, "sv.codepresentation = ?")
+			args = append(args, query.CodePresentation)
+		}
+		if query.StudentID != 0 {
+			conditions = append(conditions, "sv.idstudent = ?")
+			args = append(args, query.StudentID)
+		} else if cohort != "" {
+			conditions = append(conditions, "sv.idstudent IN ("+cohort+")")
+			args = append(args, cohortArgs...)
+		}
+	default:
+		return nil, fmt.Errorf("granularidad no soportada: %s", query.Granularity)
+	}
+	if len(conditions) > 0 {
+		statement += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := s.query(ctx, statement+" GROUP BY 1, 2 ORDER BY 1, 2", args...)
+	if err != nil {
+		return nil, fmt.Errorf("error al agregar la participación: %w", err)
+	}
+	defer rows.Close()
+
+	series := empty
+	for rows.Next() {
+		var point entity.EngagementPoint
+		if err := rows.Scan(&point.Period, &point.ActivityType, &point.Clicks, &point.Students); err != nil {
+			return nil, fmt.Errorf("error al decodificar la participación: %w", err)
+		}
+		series.Points = append(series.Points, point)
+	}
+	return series, rows.Err()
+}
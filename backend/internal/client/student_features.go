@@ -0,0 +1,215 @@
+package client
+
+import (
+	"backend/internal/config"
+	"context"
+	"fmt"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Características por estudiante y presentación
+const (
+	featureTotalClicks    = "total_clicks"
+	featureDaysActive     = "days_active"
+	featureFirstAccessDay = "first_access_day"
+	featureStudiedCredits = "studied_credits"
+	featurePrevAttempts   = "num_of_prev_attempts"
+	featureAvgScore       = "avg_assessment_score"
+	activityFeaturePrefix = "clicks_"
+)
+
+// studentFeatures contiene las características de un estudiante; las que no se
+// pueden calcular (sin accesos al VLE o sin evaluaciones) no aparecen en Values
+type studentFeatures struct {
+	StudentID   int
+	FinalResult string
+	Values      map[string]float64
+}
+
+type studentFeatureSet struct {
+	Names    []string
+	Students []studentFeatures
+}
+
+// featureInfo datos de matrícula de studentInfo
+type featureInfo struct {
+	StudentID         int    `bson:"idstudent"`
+	StudiedCredits    int    `bson:"studiedcredits"`
+	NumOfPrevAttempts int    `bson:"numofprevattempts"`
+	FinalResult       string `bson:"finalresult"`
+}
+
+// activityClicks clics de un estudiante en un tipo de actividad y su primer acceso
+type activityClicks struct {
+	StudentID int
+	Activity  string
+	Clicks    int
+	FirstDay  int
+}
+
+// studentValue valor agregado por estudiante (días activos, puntuación media)
+type studentValue struct {
+	StudentID int     `bson:"_id"`
+	Value     float64 `bson:"value"`
+}
+
+// buildStudentFeatures calcula las características de todos los estudiantes
+// matriculados en una presentación a partir de studentInfo, engagement_weekly,
+// studentVle y studentAssessment
+func (m *mongoDBClient) buildStudentFeatures(ctx context.Context, db *mongo.Database, codeModule, codePresentation string) (*studentFeatureSet, error) {
+	if codeModule == "" || codePresentation == "" {
+		return nil, fmt.Errorf("se requiere code_module y code_presentation")
+	}
+	presentation := bson.M{"codemodule": codeModule, "codepresentation": codePresentation}
+
+	// Datos de matrícula
+	projection := bson.M{"_id": 0, "idstudent": 1, "studiedcredits": 1, "numofprevattempts": 1, "finalresult": 1}
+	cursor, err := db.Collection("studentInfo").Find(ctx, presentation, options.Find().SetProjection(projection))
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener studentInfo: %w", err)
+	}
+	var infos []featureInfo
+	if err := cursor.All(ctx, &infos); err != nil {
+		return nil, fmt.Errorf("error al decodificar studentInfo: %w", err)
+	}
+
+	// Clics por tipo de actividad y primer acceso desde la vista semanal
+	view, err := m.ensureView(ctx, db, config.ViewEngagementWeekly)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err = db.Collection(view.Target).Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"code_module": codeModule, "code_presentation": codePresentation}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       bson.M{"student": "$student_id", "activity": "$activity_type"},
+			"clicks":    bson.M{"$sum": "$clicks"},
+			"first_day": bson.M{"$min": "$first_day"},
+		}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error al agregar la participación por actividad: %w", err)
+	}
+	var activityRows []struct {
+		ID struct {
+			Student  int    `bson:"student"`
+			Activity string `bson:"activity"`
+		} `bson:"_id"`
+		Clicks   int `bson:"clicks"`
+		FirstDay int `bson:"first_day"`
+	}
+	if err := cursor.All(ctx, &activityRows); err != nil {
+		return nil, fmt.Errorf("error al decodificar la participación por actividad: %w", err)
+	}
+	activities := make([]activityClicks, len(activityRows))
+	for i, row := range activityRows {
+		activities[i] = activityClicks{StudentID: row.ID.Student, Activity: row.ID.Activity, Clicks: row.Clicks, FirstDay: row.FirstDay}
+	}
+
+	// Días distintos con actividad
+	cursor, err = db.Collection("studentVle").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: presentation}},
+		{{Key: "$group", Value: bson.M{"_id": bson.M{"student": "$idstudent", "date": "$date"}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$_id.student", "value": bson.M{"$sum": 1}}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, fmt.Errorf("error al agregar los días activos: %w", err)
+	}
+	var days []studentValue
+	if err := cursor.All(ctx, &days); err != nil {
+		return nil, fmt.Errorf("error al decodificar los días activos: %w", err)
+	}
+
+	// Puntuación media en las evaluaciones de la presentación
+	assessmentIDs, err := db.Collection("assessments").Distinct(ctx, "idassessment", presentation)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener las evaluaciones de la presentación: %w", err)
+	}
+	cursor, err = db.Collection("studentAssessment").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"idassessment": bson.M{"$in": assessmentIDs}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$idstudent", "value": bson.M{"$avg": "$score"}}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error al agregar las puntuaciones medias: %w", err)
+	}
+	var scores []studentValue
+	if err := cursor.All(ctx, &scores); err != nil {
+		return nil, fmt.Errorf("error al decodificar las puntuaciones medias: %w", err)
+	}
+
+	return newStudentFeatureSet(infos, activities, days, scores), nil
+}
+
+// newStudentFeatureSet reúne las características de los estudiantes de infos, en
+// su mismo orden; los datos de estudiantes no matriculados se descartan
+func newStudentFeatureSet(infos []featureInfo, activities []activityClicks, days, scores []studentValue) *studentFeatureSet {
+	students := make(map[int]*studentFeatures, len(infos))
+	for _, info := range infos {
+		students[info.StudentID] = &studentFeatures{
+			StudentID:   info.StudentID,
+			FinalResult: info.FinalResult,
+			Values: map[string]float64{
+				featureTotalClicks:    0,
+				featureDaysActive:     0,
+				featureStudiedCredits: float64(info.StudiedCredits),
+				featurePrevAttempts:   float64(info.NumOfPrevAttempts),
+			},
+		}
+	}
+
+	activityTypes := make(map[string]bool)
+	for _, row := range activities {
+		activityTypes[row.Activity] = true
+	}
+	activityNames := make([]string, 0, len(activityTypes))
+	for activity := range activityTypes {
+		activityNames = append(activityNames, activityFeaturePrefix+activity)
+	}
+	sort.Strings(activityNames)
+	for _, s := range students {
+		for _, name := range activityNames {
+			s.Values[name] = 0
+		}
+	}
+	for _, row := range activities {
+		s, ok := students[row.StudentID]
+		if !ok {
+			continue
+		}
+		s.Values[activityFeaturePrefix+row.Activity] += float64(row.Clicks)
+		s.Values[featureTotalClicks] += float64(row.Clicks)
+		first := float64(row.FirstDay)
+		if current, ok := s.Values[featureFirstAccessDay]; !ok || first < current {
+			s.Values[featureFirstAccessDay] = first
+		}
+	}
+	for _, row := range days {
+		if s, ok := students[row.StudentID]; ok {
+			s.Values[featureDaysActive] = row.Value
+		}
+	}
+	for _, row := range scores {
+		if s, ok := students[row.StudentID]; ok {
+			s.Values[featureAvgScore] = row.Value
+		}
+	}
+
+	set := &studentFeatureSet{
+		Names: append([]string{
+			featureTotalClicks,
+			featureDaysActive,
+			featureFirstAccessDay,
+			featureStudiedCredits,
+			featurePrevAttempts,
+			featureAvgScore,
+		}, activityNames...),
+		Students: make([]studentFeatures, 0, len(infos)),
+	}
+	for _, info := range infos {
+		set.Students = append(set.Students, *students[info.StudentID])
+	}
+	return set
+}
@@ -0,0 +1,212 @@
+package client
+
+import (
+	"backend/internal/analytics"
+	"backend/internal/entity"
+	"backend/internal/repository"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const withdrawnResult = "Withdrawn"
+
+// GetWithdrawalSurvival estima curvas de Kaplan-Meier de permanencia en semanas por
+// presentación y, opcionalmente, por atributos demográficos. Se considera baja a
+// los estudiantes con final_result "Withdrawn", en la semana de date_unregistration
+// (una fecha vacía se carga como 0); el resto queda censurado al final del curso
+func (m *mongoDBClient) GetWithdrawalSurvival(ctx context.Context, database string, query entity.SurvivalQuery) (*entity.SurvivalReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	attributes, err := survivalAttributes(query)
+	if err != nil {
+		return nil, err
+	}
+
+	db := m.client.Database(database)
+	version, err := getDatasetVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := survivalCacheKey(query, attributes)
+	report := &entity.SurvivalReport{}
+	found, err := readAnalyticsCache(ctx, db, cacheKey, version, report)
+	if err != nil {
+		m.loggers.ErrorLogger.Printf("Error al leer la caché de supervivencia: %v", err)
+	}
+	if found {
+		report.Cached = true
+		return report, nil
+	}
+
+	registrations, err := loadRegistrations(ctx, db, query)
+	if err != nil {
+		return nil, err
+	}
+	courseWeeks, err := loadCourseWeeks(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	finalResults, err := loadFinalResults(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	students, err := loadStudentAttributes(ctx, db, attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	report, skipped := survivalReport(attributes, registrations, courseWeeks, finalResults, students, version)
+	if skipped > 0 {
+		m.loggers.InfoLogger.Printf("%d matrículas sin duración de curso omitidas", skipped)
+	}
+	if err := writeAnalyticsCache(ctx, db, cacheKey, version, report); err != nil {
+		m.loggers.ErrorLogger.Printf("Error al guardar la caché de supervivencia: %v", err)
+	}
+	return report, nil
+}
+
+// survivalAttributes valida la agrupación y devuelve los atributos demográficos;
+// la presentación siempre forma parte del grupo
+func survivalAttributes(query entity.SurvivalQuery) ([]string, error) {
+	var attributes []string
+	for _, attribute := range query.GroupBy {
+		if _, ok := repository.StudentInfoFields[attribute]; !ok {
+			return nil, fmt.Errorf("campo de agrupación no soportado: %s", attribute)
+		}
+		if attribute != "code_module" && attribute != "code_presentation" {
+			attributes = append(attributes, attribute)
+		}
+	}
+	return attributes, nil
+}
+
+func survivalCacheKey(query entity.SurvivalQuery, attributes []string) string {
+	return "survival:" + strings.Join([]string{query.CodeModule, query.CodePresentation, strings.Join(attributes, "+")}, ",")
+}
+
+// survivalReport calcula una curva por grupo; devuelve también las matrículas
+// omitidas por no conocerse la duración del curso
+func survivalReport(attributes []string, registrations []registration, courseWeeks map[string]int, finalResults map[string]string, students map[string]map[string]string, version int) (*entity.SurvivalReport, int) {
+	type cohort struct {
+		group  map[string]string
+		times  []int
+		events []bool
+	}
+	cohorts := make(map[string]*cohort)
+	skipped := 0
+	for _, r := range registrations {
+		endWeek, ok := courseWeeks[r.CodeModule+"|"+r.CodePresentation]
+		if !ok {
+			skipped++
+			continue
+		}
+		key := studentKey(r.StudentID, r.CodeModule, r.CodePresentation)
+		group := map[string]string{"code_module": r.CodeModule, "code_presentation": r.CodePresentation}
+		for _, attribute := range attributes {
+			group[attribute] = students[key][attribute]
+		}
+		groupKey := survivalGroupKey(group, attributes)
+		c, ok := cohorts[groupKey]
+		if !ok {
+			c = &cohort{group: group}
+			cohorts[groupKey] = c
+		}
+
+		week, withdrawn := endWeek, finalResults[key] == withdrawnResult
+		if withdrawn {
+			week = r.DateUnregistration / 7
+			if week < 0 {
+				week = 0
+			}
+			if week > endWeek {
+				week = endWeek
+			}
+		}
+		c.times = append(c.times, week)
+		c.events = append(c.events, withdrawn)
+	}
+
+	keys := make([]string, 0, len(cohorts))
+	for k := range cohorts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	report := &entity.SurvivalReport{
+		GroupBy:        append([]string{"code_module", "code_presentation"}, attributes...),
+		DatasetVersion: version,
+		ComputedAt:     time.Now(),
+		Curves:         make([]entity.SurvivalCurve, 0, len(keys)),
+	}
+	for _, k := range keys {
+		c := cohorts[k]
+		curve := entity.SurvivalCurve{Group: c.group, Students: len(c.times)}
+		for _, withdrawn := range c.events {
+			if withdrawn {
+				curve.Withdrawals++
+			}
+		}
+		points := analytics.KaplanMeier(c.times, c.events)
+		curve.Points = make([]entity.SurvivalPoint, 0, len(points))
+		for _, p := range points {
+			curve.Points = append(curve.Points, entity.SurvivalPoint{
+				Week:     p.Time,
+				AtRisk:   p.AtRisk,
+				Events:   p.Events,
+				Censored: p.Censored,
+				Survival: p.Survival,
+				Lower:    p.Lower,
+				Upper:    p.Upper,
+			})
+		}
+		if median, ok := analytics.MedianSurvival(points); ok {
+			curve.MedianWeek = &median
+		}
+		report.Curves = append(report.Curves, curve)
+	}
+	return report, skipped
+}
+
+func survivalGroupKey(group map[string]string, attributes []string) string {
+	parts := []string{group["code_module"], group["code_presentation"]}
+	for _, attribute := range attributes {
+		parts = append(parts, group[attribute])
+	}
+	return strings.Join(parts, "|")
+}
+
+type registration struct {
+	StudentID          int    `bson:"idstudent"`
+	CodeModule         string `bson:"codemodule"`
+	CodePresentation   string `bson:"codepresentation"`
+	DateUnregistration int    `bson:"dateunregistration"`
+}
+
+// loadRegistrations devuelve las matrículas de studentRegistration filtradas por módulo o presentación
+func loadRegistrations(ctx context.Context, db *mongo.Database, query entity.SurvivalQuery) ([]registration, error) {
+	filter := bson.M{}
+	if query.CodeModule != "" {
+		filter["codemodule"] = query.CodeModule
+	}
+	if query.CodePresentation != "" {
+		filter["codepresentation"] = query.CodePresentation
+	}
+	projection := bson.M{"_id": 0, "idstudent": 1, "codemodule": 1, "codepresentation": 1, "dateunregistration": 1}
+	cursor, err := db.Collection("studentRegistration").Find(ctx, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener studentRegistration: %w", err)
+	}
+	var registrations []registration
+	if err := cursor.All(ctx, &registrations); err != nil {
+		return nil, fmt.Errorf("error al decodificar studentRegistration: %w", err)
+	}
+	return registrations, nil
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSurvivalReport(t *testing.T) {
+	registrations := []registration{
+		{StudentID: 1, CodeModule: "AAA", CodePresentation: "2013J"},
+		{StudentID: 2, CodeModule: "AAA", CodePresentation: "2013J", DateUnregistration: 20},
+		{StudentID: 3, CodeModule: "AAA", CodePresentation: "2013J", DateUnregistration: -14},
+		{StudentID: 5, CodeModule: "AAA", CodePresentation: "2013J", DateUnregistration: 100},
+		{StudentID: 4, CodeModule: "BBB", CodePresentation: "2014B"},
+	}
+	finalResults := map[string]string{
+		studentKey(1, "AAA", "2013J"): "Pass",
+		studentKey(2, "AAA", "2013J"): withdrawnResult,
+		studentKey(3, "AAA", "2013J"): withdrawnResult,
+		studentKey(5, "AAA", "2013J"): withdrawnResult,
+	}
+	students := map[string]map[string]string{
+		studentKey(1, "AAA", "2013J"): {"gender": "M"},
+		studentKey(2, "AAA", "2013J"): {"gender": "M"},
+		studentKey(3, "AAA", "2013J"): {"gender": "F"},
+		studentKey(5, "AAA", "2013J"): {"gender": "F"},
+	}
+	courseWeeks := map[string]int{"AAA|2013J": 4}
+
+	report, skipped := survivalReport([]string{"gender"}, registrations, courseWeeks, finalResults, students, 2)
+	if skipped != 1 {
+		t.Errorf("skipped = %d, se esperaba 1", skipped)
+	}
+	if want := []string{"code_module", "code_presentation", "gender"}; !reflect.DeepEqual(report.GroupBy, want) {
+		t.Errorf("GroupBy = %v, se esperaba %v", report.GroupBy, want)
+	}
+	if report.DatasetVersion != 2 || len(report.Curves) != 2 {
+		t.Fatalf("informe = %+v", report)
+	}
+
+	tests := []struct {
+		gender      string
+		students    int
+		withdrawals int
+		points      int
+		medianWeek  int
+	}{
+		// Las bajas antes del inicio cuentan en la semana 0 y las posteriores al
+		// final del curso en la última semana
+		{"F", 2, 2, 5, 0},
+		{"M", 2, 1, 5, 2},
+	}
+	for i, tt := range tests {
+		curve := report.Curves[i]
+		if curve.Group["gender"] != tt.gender || curve.Group["code_module"] != "AAA" {
+			t.Errorf("curva %d: grupo %v, se esperaba %s", i, curve.Group, tt.gender)
+			continue
+		}
+		if curve.Students != tt.students || curve.Withdrawals != tt.withdrawals || len(curve.Points) != tt.points {
+			t.Errorf("grupo %s: %d estudiantes, %d bajas, %d puntos", tt.gender, curve.Students, curve.Withdrawals, len(curve.Points))
+		}
+		if curve.MedianWeek == nil || *curve.MedianWeek != tt.medianWeek {
+			t.Errorf("grupo %s: MedianWeek = %v, se esperaba %d", tt.gender, curve.MedianWeek, tt.medianWeek)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"backend/internal/auth"
+	"backend/internal/config"
+	"backend/internal/entity"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	usersCollection         = "users"
+	refreshTokensCollection = "refresh_tokens"
+)
+
+func (m *mongoDBClient) GetUser(ctx context.Context, database, username string) (*entity.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var user entity.User
+	err := m.client.Database(database).Collection(usersCollection).FindOne(ctx, bson.M{"_id": username}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el usuario: %w", err)
+	}
+	return &user, nil
+}
+
+func (m *mongoDBClient) SaveUser(ctx context.Context, database string, user entity.User) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	_, err := m.client.Database(database).Collection(usersCollection).
+		ReplaceOne(ctx, bson.M{"_id": user.Username}, user, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("error al guardar el usuario: %w", err)
+	}
+	return nil
+}
+
+func (m *mongoDBClient) SaveRefreshToken(ctx context.Context, database string, token entity.RefreshToken) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if _, err := m.client.Database(database).Collection(refreshTokensCollection).InsertOne(ctx, token); err != nil {
+		return fmt.Errorf("error al guardar el token de refresco: %w", err)
+	}
+	return nil
+}
+
+// ConsumeRefreshToken elimina el token y lo devuelve: cada token de refresco solo
+// puede usarse una vez
+func (m *mongoDBClient) ConsumeRefreshToken(ctx context.Context, database, hash string) (*entity.RefreshToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var token entity.RefreshToken
+	err := m.client.Database(database).Collection(refreshTokensCollection).FindOneAndDelete(ctx, bson.M{"_id": hash}).Decode(&token)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el token de refresco: %w", err)
+	}
+	return &token, nil
+}
+
+// loginUsersStep convierte los documentos de login que creaba init-mongo.js, con
+// la contraseña en claro, en analistas con hash bcrypt y elimina la colección.
+// Ninguna credencial heredada obtiene el rol de administración; los usuarios
+// que ya existen no se modifican
+type loginUsersStep struct{}
+
+func (s loginUsersStep) describe(up bool) string {
+	if !up {
+		return "login -> users (irreversible, sin cambios)"
+	}
+	return "login -> users: analistas con hash bcrypt de las contraseñas y eliminar login"
+}
+
+func (s loginUsersStep) up(ctx context.Context, db *mongo.Database) error {
+	cursor, err := db.Collection("login").Find(ctx, bson.M{})
+	if err != nil {
+		return fmt.Errorf("error al leer login: %w", err)
+	}
+	var logins []struct {
+		User     string `bson:"user"`
+		Password string `bson:"password"`
+	}
+	if err := cursor.All(ctx, &logins); err != nil {
+		return fmt.Errorf("error al decodificar login: %w", err)
+	}
+	for _, login := range logins {
+		if login.User == "" || login.Password == "" {
+			continue
+		}
+		hash, err := auth.HashPassword(login.Password)
+		if err != nil {
+			return err
+		}
+		_, err = db.Collection(usersCollection).UpdateOne(ctx, bson.M{"_id": login.User},
+			bson.M{"$setOnInsert": bson.M{"password_hash": hash, "role": config.RoleAnalyst, "created_at": time.Now()}},
+			options.Update().SetUpsert(true))
+		if err != nil {
+			return fmt.Errorf("error al crear el usuario %s: %w", login.User, err)
+		}
+	}
+	if err := db.Collection("login").Drop(ctx); err != nil {
+		return fmt.Errorf("error al eliminar login: %w", err)
+	}
+	return nil
+}
+
+func (s loginUsersStep) down(ctx context.Context, db *mongo.Database) error {
+	return nil
+}
@@ -0,0 +1,197 @@
+package client
+
+import (
+	"backend/internal/analytics"
+	"backend/internal/entity"
+	"backend/internal/repository"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const vleScoringConfigsCollection = "vle_scoring_configs"
+
+func (m *mongoDBClient) GetVleScoringConfigs(ctx context.Context, database string) ([]entity.VleScoringConfig, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	configs, err := loadVleScoringConfigs(ctx, m.client.Database(database))
+	if err != nil {
+		return nil, err
+	}
+	var results []entity.VleScoringConfig
+	for _, cfg := range configs {
+		results = append(results, cfg)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].CodeModule < results[j].CodeModule })
+	return results, nil
+}
+
+func (m *mongoDBClient) SaveVleScoringConfig(ctx context.Context, database string, scoringConfig entity.VleScoringConfig) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if err := repository.ValidateVleScoringConfig(&scoringConfig); err != nil {
+		return err
+	}
+	scoringConfig.UpdatedAt = time.Now()
+	_, err := m.client.Database(database).Collection(vleScoringConfigsCollection).ReplaceOne(ctx,
+		bson.M{"_id": scoringConfig.CodeModule},
+		scoringConfig,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("error al guardar la configuración de puntuación VLE: %w", err)
+	}
+	m.loggers.InfoLogger.Printf("Configuración de puntuación VLE guardada para %s", scoringConfig.CodeModule)
+	return nil
+}
+
+// loadVleScoringConfigs devuelve las configuraciones guardadas indexadas por
+// módulo, incluyendo siempre una configuración "default"
+func loadVleScoringConfigs(ctx context.Context, db *mongo.Database) (map[string]entity.VleScoringConfig, error) {
+	cursor, err := db.Collection(vleScoringConfigsCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener las configuraciones de puntuación VLE: %w", err)
+	}
+	var stored []entity.VleScoringConfig
+	if err := cursor.All(ctx, &stored); err != nil {
+		return nil, fmt.Errorf("error al decodificar las configuraciones de puntuación VLE: %w", err)
+	}
+	configs := map[string]entity.VleScoringConfig{repository.DefaultVleScoringModule: repository.DefaultVleScoringConfig()}
+	for _, cfg := range stored {
+		configs[cfg.CodeModule] = cfg
+	}
+	return configs, nil
+}
+
+// vleScoringConfigFor resuelve la configuración aplicable a un módulo
+func vleScoringConfigFor(configs map[string]entity.VleScoringConfig, codeModule string) entity.VleScoringConfig {
+	if cfg, ok := configs[codeModule]; ok {
+		return cfg
+	}
+	return configs[repository.DefaultVleScoringModule]
+}
+
+// vleActivityScore pondera los clics de una actividad y aplica el decaimiento
+// semanal según las semanas que faltan hasta el final de la presentación
+func vleActivityScore(scoringConfig entity.VleScoringConfig, activityType string, clicks int, weeksToEnd int) float64 {
+	weight := vleActivityWeight(scoringConfig, activityType)
+	if weeksToEnd < 0 {
+		weeksToEnd = 0
+	}
+	return float64(clicks) * weight * math.Pow(1-scoringConfig.WeekDecay, float64(weeksToEnd))
+}
+
+// vleActivityWeight devuelve el peso del tipo de actividad o el peso por defecto
+func vleActivityWeight(scoringConfig entity.VleScoringConfig, activityType string) float64 {
+	if weight, ok := scoringConfig.Weights[activityType]; ok {
+		return weight
+	}
+	return scoringConfig.DefaultWeight
+}
+
+// vleEngagement acumula la puntuación de participación por estudiante y
+// presentación a partir de los clics semanales por tipo de actividad
+type vleEngagement struct {
+	runID       string
+	configs     map[string]entity.VleScoringConfig
+	courseWeeks map[string]int
+	byStudent   map[string]*entity.ProcessedPredictionVleResult
+	keys        []string
+}
+
+func newVleEngagement(runID string, configs map[string]entity.VleScoringConfig, courseWeeks map[string]int) *vleEngagement {
+	return &vleEngagement{
+		runID:       runID,
+		configs:     configs,
+		courseWeeks: courseWeeks,
+		byStudent:   make(map[string]*entity.ProcessedPredictionVleResult),
+	}
+}
+
+func (e *vleEngagement) add(row entity.PredictionVle) {
+	key := fmt.Sprintf("%d|%s|%s", row.StudentID, row.CodeModule, row.CodePresentation)
+	result, ok := e.byStudent[key]
+	if !ok {
+		result = &entity.ProcessedPredictionVleResult{
+			RunID:            e.runID,
+			StudentID:        row.StudentID,
+			CodeModule:       row.CodeModule,
+			CodePresentation: row.CodePresentation,
+			ActivityClicks:   make(map[string]int),
+		}
+		e.byStudent[key] = result
+		e.keys = append(e.keys, key)
+	}
+	result.ActivityClicks[row.ActivityType] += row.Clicks
+	result.TotalClicks += row.Clicks
+	weeksToEnd := e.courseWeeks[row.CodeModule+"|"+row.CodePresentation] - row.Week
+	result.RawScore += vleActivityScore(vleScoringConfigFor(e.configs, row.CodeModule), row.ActivityType, row.Clicks, weeksToEnd)
+}
+
+// results devuelve las puntuaciones en orden de aparición, ya normalizadas
+func (e *vleEngagement) results() []entity.ProcessedPredictionVleResult {
+	results := make([]entity.ProcessedPredictionVleResult, 0, len(e.keys))
+	for _, key := range e.keys {
+		results = append(results, *e.byStudent[key])
+	}
+	normalizeVleScores(results, e.configs)
+	return results
+}
+
+// normalizeVleScores normaliza las puntuaciones dentro de cada presentación
+// según la configuración de su módulo
+func normalizeVleScores(results []entity.ProcessedPredictionVleResult, configs map[string]entity.VleScoringConfig) {
+	byPresentation := make(map[string][]int)
+	for i, r := range results {
+		key := r.CodeModule + "|" + r.CodePresentation
+		byPresentation[key] = append(byPresentation[key], i)
+	}
+	for _, members := range byPresentation {
+		cfg := vleScoringConfigFor(configs, results[members[0]].CodeModule)
+		values := make([]float64, len(members))
+		for i, idx := range members {
+			values[i] = results[idx].RawScore
+		}
+		var normalized []float64
+		switch cfg.Normalization {
+		case repository.NormalizationZScore:
+			normalized = analytics.ZScores(values)
+		case repository.NormalizationPercentile:
+			normalized = analytics.PercentileRanks(values)
+		default:
+			normalized = values
+		}
+		for i, idx := range members {
+			results[idx].PredictedScore = normalized[i]
+		}
+	}
+}
+
+// loadCourseWeeks devuelve la última semana de cada presentación según courses.length
+func loadCourseWeeks(ctx context.Context, db *mongo.Database) (map[string]int, error) {
+	cursor, err := db.Collection("courses").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los cursos: %w", err)
+	}
+	var courses []struct {
+		CodeModule       string `bson:"codemodule"`
+		CodePresentation string `bson:"codepresentation"`
+		Length           int    `bson:"length"`
+	}
+	if err := cursor.All(ctx, &courses); err != nil {
+		return nil, fmt.Errorf("error al decodificar los cursos: %w", err)
+	}
+	weeks := make(map[string]int, len(courses))
+	for _, c := range courses {
+		weeks[c.CodeModule+"|"+c.CodePresentation] = c.Length / 7
+	}
+	return weeks, nil
+}
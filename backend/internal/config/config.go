@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strconv"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -21,6 +22,12 @@ func ConfigEnv() (error, bool) {
 	return nil, true
 }
 
+// PlaceholderSecret indica si la clave es uno de los valores de ejemplo
+// (change-me...) que no deben usarse fuera de la documentación
+func PlaceholderSecret(secret string) bool {
+	return strings.Contains(strings.ToLower(secret), "change-me")
+}
+
 func RootDir() string {
 	_, b, _, _ := runtime.Caller(0)
 	return filepath.Join(filepath.Dir(b), "../..")
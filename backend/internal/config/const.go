@@ -26,6 +26,40 @@ const (
 	FilePathReadDev     string = "FILE_PATH_READ_DEV"
 	FilePathReadQa      string = "FILE_PATH_READ_QA"
 	Envirornment        string = "ENVIRONMENT"
+	//Almacenamiento
+	StorageDriver   string = "STORAGE_DRIVER"
+	SqlDSN          string = "SQL_DSN"
+	StorageMongoDB  string = "mongodb"
+	StorageSQLite   string = "sqlite"
+	StoragePostgres string = "postgres"
 	//MongoDB
-	BatchSize string = "BATCH_SIZE"
+	BatchSize        string = "BATCH_SIZE"
+	MigrateOnStartup string = "MIGRATE_ON_STARTUP"
+	MigrationUp      string = "up"
+	MigrationDown    string = "down"
+	//Predicciones
+	PredictionRunsRetention   string = "PREDICTION_RUNS_RETENTION"
+	PredictionKindAssessments string = "assessments"
+	PredictionKindVle         string = "vle"
+	//Seudonimización
+	PseudonymizationMode   string = "PSEUDONYMIZATION_MODE"
+	PseudonymizationKey    string = "PSEUDONYMIZATION_KEY"
+	PseudonymizationOff    string = "off"
+	PseudonymizationLoad   string = "load"
+	PseudonymizationExport string = "export"
+	//Autenticación
+	JwtSecret             string = "JWT_SECRET"
+	AccessTokenTTLMinutes string = "ACCESS_TOKEN_TTL_MINUTES"
+	RefreshTokenTTLHours  string = "REFRESH_TOKEN_TTL_HOURS"
+	RoleAdmin             string = "admin"
+	RoleAnalyst           string = "analyst"
+	//Auditoría de supresiones
+	ErasureAuditKey string = "ERASURE_AUDIT_KEY"
+	//Integridad
+	IntegrityCheckAfterLoad string = "INTEGRITY_CHECK_AFTER_LOAD"
+	//Vistas materializadas
+	ViewAvgScoreByAssessmentType string = "avg_score_by_assessment_type"
+	ViewStudentCountByAssessment string = "student_count_by_assessment"
+	ViewScoreHistogram           string = "score_histogram"
+	ViewEngagementWeekly         string = "engagement_weekly"
 )
@@ -1,10 +1,19 @@
 package entity
 
 import (
+	"errors"
 	"log"
 	"time"
 )
 
+// ErrNotFound recurso inexistente; las capas inferiores lo envuelven para que
+// la API responda 404
+var ErrNotFound = errors.New("no encontrado")
+
+// ErrUnsupported operación que el backend de almacenamiento configurado no
+// implementa; la API responde 501
+var ErrUnsupported = errors.New("no está disponible con este backend de almacenamiento")
+
 // Courses estructura para el archivo courses.csv
 type Courses struct {
 	CodeModule       string `json:"code_module"`
@@ -102,6 +111,7 @@ type CollectionsRequest struct {
 	Collections []string `json:"collections"`
 }
 type PredictionAssessment struct {
+	RunID          string    `bson:"run_id"`
 	StudentID      int       `bson:"student_id"`
 	AssessmentID   int       `bson:"assessment_id"`
 	PredictedScore float64   `bson:"predicted_score"`
@@ -113,21 +123,55 @@ type ProcessedPredictionAssessmentResult struct {
 	PredictedScore float64 `bson:"predicted_score"`
 }
 
+// PredictionVle clics agregados por estudiante, presentación, tipo de actividad y semana
 type PredictionVle struct {
-	StudentID    int    `bson:"id_student"`
-	ResourceType string `bson:"resource_type"`
-	Clicks       int    `bson:"sum_click"`
+	StudentID        int    `bson:"student_id"`
+	CodeModule       string `bson:"code_module"`
+	CodePresentation string `bson:"code_presentation"`
+	ActivityType     string `bson:"activity_type"`
+	Week             int    `bson:"week"`
+	Clicks           int    `bson:"clicks"`
 }
 
 // Estructura para almacenar el resultado del procesamiento
 type ProcessedPredictionVleResult struct {
-	StudentID      int     `bson:"student_id"`
-	PredictedScore float64 `bson:"predicted_score"`
+	RunID            string         `bson:"run_id"`
+	StudentID        int            `bson:"student_id"`
+	CodeModule       string         `bson:"code_module"`
+	CodePresentation string         `bson:"code_presentation"`
+	TotalClicks      int            `bson:"total_clicks"`
+	ActivityClicks   map[string]int `bson:"activity_clicks"`
+	RawScore         float64        `bson:"raw_score"`
+	PredictedScore   float64        `bson:"predicted_score"`
 }
 
 type ScoreRangePredictionAssessments struct {
-	Range        string `json:"range"`
-	StudentCount int    `json:"student_count"`
+	Range        string   `json:"range"`
+	Lower        *float64 `json:"lower,omitempty"`
+	Upper        *float64 `json:"upper,omitempty"`
+	StudentCount int      `json:"student_count"`
+}
+
+type ScoreDistributionQuery struct {
+	Bins             []float64
+	BinCount         int
+	CodeModule       string
+	CodePresentation string
+	AssessmentType   string
+}
+
+type ScorePercentiles struct {
+	P10    float64 `json:"p10"`
+	P25    float64 `json:"p25"`
+	Median float64 `json:"median"`
+	P75    float64 `json:"p75"`
+	P90    float64 `json:"p90"`
+}
+
+type ScoreDistribution struct {
+	Buckets     []ScoreRangePredictionAssessments `json:"buckets"`
+	Percentiles ScorePercentiles                  `json:"percentiles"`
+	Total       int                               `json:"total"`
 }
 
 type AssessmentTypeAverage struct {
@@ -139,3 +183,643 @@ type AssessmentStudentCount struct {
 	AssessmentID int `bson:"_id"`
 	StudentCount int    `bson:"student_count"`
 }
+
+// Evaluación de modelos
+
+type ModelEvaluationRequest struct {
+	RunID      string    `json:"run_id"`
+	Strategy   string    `json:"strategy"`
+	TestSize   float64   `json:"test_size"`
+	Folds      int       `json:"folds"`
+	Seed       int64     `json:"seed"`
+	PassMark   float64   `json:"pass_mark"`
+	Thresholds []float64 `json:"thresholds"`
+}
+
+type RegressionMetrics struct {
+	Count int     `json:"count" bson:"count"`
+	RMSE  float64 `json:"rmse" bson:"rmse"`
+	MAE   float64 `json:"mae" bson:"mae"`
+	R2    float64 `json:"r2" bson:"r2"`
+}
+
+type ThresholdMetrics struct {
+	Threshold float64 `json:"threshold" bson:"threshold"`
+	Precision float64 `json:"precision" bson:"precision"`
+	Recall    float64 `json:"recall" bson:"recall"`
+}
+
+type ConfusionMatrix struct {
+	Threshold     float64 `json:"threshold" bson:"threshold"`
+	TruePositive  int     `json:"true_positive" bson:"true_positive"`
+	FalsePositive int     `json:"false_positive" bson:"false_positive"`
+	TrueNegative  int     `json:"true_negative" bson:"true_negative"`
+	FalseNegative int     `json:"false_negative" bson:"false_negative"`
+}
+
+type CalibrationBin struct {
+	Lower         float64 `json:"lower" bson:"lower"`
+	Upper         float64 `json:"upper" bson:"upper"`
+	MeanPredicted float64 `json:"mean_predicted" bson:"mean_predicted"`
+	ObservedRate  float64 `json:"observed_rate" bson:"observed_rate"`
+	Count         int     `json:"count" bson:"count"`
+}
+
+type ClassificationMetrics struct {
+	AUC             float64            `json:"auc" bson:"auc"`
+	Thresholds      []ThresholdMetrics `json:"thresholds" bson:"thresholds"`
+	ConfusionMatrix ConfusionMatrix    `json:"confusion_matrix" bson:"confusion_matrix"`
+	Calibration     []CalibrationBin   `json:"calibration" bson:"calibration"`
+}
+
+type FoldEvaluation struct {
+	Fold           int                   `json:"fold" bson:"fold"`
+	Regression     *RegressionMetrics    `json:"regression,omitempty" bson:"regression,omitempty"`
+	Classification ClassificationMetrics `json:"classification" bson:"classification"`
+}
+
+// BaselineEvaluation métricas de un predictor de referencia reajustado en las
+// mismas particiones, para comparar con el modelo evaluado
+type BaselineEvaluation struct {
+	Predictor      string                `json:"predictor" bson:"predictor"`
+	SampleCount    int                   `json:"sample_count" bson:"sample_count"`
+	Regression     *RegressionMetrics    `json:"regression,omitempty" bson:"regression,omitempty"`
+	Classification ClassificationMetrics `json:"classification" bson:"classification"`
+	FoldResults    []FoldEvaluation      `json:"fold_results,omitempty" bson:"fold_results,omitempty"`
+}
+
+type ModelEvaluation struct {
+	ModelID        string                `json:"model_id" bson:"model_id"`
+	RunID          string                `json:"run_id" bson:"run_id"`
+	Task           string                `json:"task" bson:"task"`
+	Strategy       string                `json:"strategy" bson:"strategy"`
+	TestSize       float64               `json:"test_size,omitempty" bson:"test_size,omitempty"`
+	Folds          int                   `json:"folds,omitempty" bson:"folds,omitempty"`
+	Seed           int64                 `json:"seed" bson:"seed"`
+	PassMark       float64               `json:"pass_mark" bson:"pass_mark"`
+	SampleCount    int                   `json:"sample_count" bson:"sample_count"`
+	Regression     *RegressionMetrics    `json:"regression,omitempty" bson:"regression,omitempty"`
+	Classification ClassificationMetrics `json:"classification" bson:"classification"`
+	FoldResults    []FoldEvaluation      `json:"fold_results,omitempty" bson:"fold_results,omitempty"`
+	Baseline       *BaselineEvaluation   `json:"baseline,omitempty" bson:"baseline,omitempty"`
+	EvaluatedAt    time.Time             `json:"evaluated_at" bson:"evaluated_at"`
+}
+
+// PredictionSample une una predicción con la puntuación real obtenida
+type PredictionSample struct {
+	StudentID        int     `bson:"student_id"`
+	AssessmentID     int     `bson:"assessment_id"`
+	PredictedScore   float64 `bson:"predicted_score"`
+	ActualScore      float64 `bson:"actual_score"`
+	CodeModule       string  `bson:"code_module"`
+	CodePresentation string  `bson:"code_presentation"`
+	AssessmentType   string  `bson:"assessment_type"`
+}
+
+// PredictionRun registra cada ejecución de un proceso de predicción
+type PredictionRun struct {
+	RunID       string    `json:"run_id" bson:"_id"`
+	Kind        string    `json:"kind" bson:"kind"`
+	Status      string    `json:"status" bson:"status"`
+	RecordCount int       `json:"record_count" bson:"record_count"`
+	Error       string    `json:"error,omitempty" bson:"error,omitempty"`
+	StartedAt   time.Time `json:"started_at" bson:"started_at"`
+	FinishedAt  time.Time `json:"finished_at,omitempty" bson:"finished_at,omitempty"`
+	Active      bool      `json:"active" bson:"-"`
+}
+
+// VleScoringConfig parámetros de puntuación de participación VLE por módulo
+type VleScoringConfig struct {
+	CodeModule    string             `json:"code_module" bson:"_id"`
+	Weights       map[string]float64 `json:"weights" bson:"weights"`
+	DefaultWeight float64            `json:"default_weight" bson:"default_weight"`
+	Normalization string             `json:"normalization" bson:"normalization"`
+	WeekDecay     float64            `json:"week_decay" bson:"week_decay"`
+	UpdatedAt     time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// Comparación entre puntuaciones reales y predichas
+
+type ResidualStats struct {
+	Key           string  `json:"key"`
+	Count         int     `json:"count"`
+	MeanPredicted float64 `json:"mean_predicted"`
+	MeanActual    float64 `json:"mean_actual"`
+	MeanResidual  float64 `json:"mean_residual"`
+	StdResidual   float64 `json:"std_residual"`
+	MAE           float64 `json:"mae"`
+	RMSE          float64 `json:"rmse"`
+}
+
+type ScatterPoint struct {
+	StudentID      int     `json:"student_id"`
+	AssessmentID   int     `json:"assessment_id"`
+	PredictedScore float64 `json:"predicted_score"`
+	ActualScore    float64 `json:"actual_score"`
+}
+
+type CalibrationPoint struct {
+	Lower         float64 `json:"lower"`
+	Upper         float64 `json:"upper"`
+	MeanPredicted float64 `json:"mean_predicted"`
+	MeanActual    float64 `json:"mean_actual"`
+	Count         int     `json:"count"`
+}
+
+type ActualVsPredictedQuery struct {
+	SampleSize       int
+	WorstCount       int
+	CalibrationWidth float64
+}
+
+type ActualVsPredictedReport struct {
+	Overall          ResidualStats      `json:"overall"`
+	ByAssessment     []ResidualStats    `json:"by_assessment"`
+	ByAssessmentType []ResidualStats    `json:"by_assessment_type"`
+	ByPresentation   []ResidualStats    `json:"by_presentation"`
+	Calibration      []CalibrationPoint `json:"calibration"`
+	Sample           []ScatterPoint     `json:"sample"`
+	WorstAssessments []ResidualStats    `json:"worst_assessments"`
+}
+
+// Informe de equidad por atributos demográficos
+
+type FairnessQuery struct {
+	ModelID    string
+	RunID      string
+	Attributes []string
+	Tolerance  float64
+	PassMark   float64
+}
+
+type FairnessGroupMetrics struct {
+	Attribute         string   `json:"attribute"`
+	Group             string   `json:"group"`
+	Count             int      `json:"count"`
+	MeanPredicted     float64  `json:"mean_predicted"`
+	MeanActual        float64  `json:"mean_actual"`
+	MeanResidual      float64  `json:"mean_residual"`
+	MAE               float64  `json:"mae"`
+	SelectionRate     float64  `json:"selection_rate"`
+	PassRate          float64  `json:"pass_rate"`
+	ErrorRate         float64  `json:"error_rate"`
+	FalsePositiveRate float64  `json:"false_positive_rate"`
+	FalseNegativeRate float64  `json:"false_negative_rate"`
+	DisparateImpact   float64  `json:"disparate_impact"`
+	Flags             []string `json:"flags,omitempty"`
+}
+
+type FairnessReport struct {
+	ModelID   string                 `json:"model_id"`
+	RunID     string                 `json:"run_id"`
+	PassMark  float64                `json:"pass_mark"`
+	Tolerance float64                `json:"tolerance"`
+	Overall   FairnessGroupMetrics   `json:"overall"`
+	Groups    []FairnessGroupMetrics `json:"groups"`
+	Flagged   int                    `json:"flagged"`
+}
+
+// DatasetVersion versión del conjunto de datos cargado, incrementada en cada carga
+type DatasetVersion struct {
+	Version  int       `json:"version" bson:"version"`
+	LoadedAt time.Time `json:"loaded_at" bson:"loaded_at"`
+}
+
+// Resultados finales por cohorte
+
+type OutcomeGroup struct {
+	Group       map[string]string  `json:"group" bson:"group"`
+	Total       int                `json:"total" bson:"total"`
+	Counts      map[string]int     `json:"counts" bson:"counts"`
+	Percentages map[string]float64 `json:"percentages" bson:"percentages"`
+}
+
+type OutcomeReport struct {
+	GroupBy        []string       `json:"group_by" bson:"group_by"`
+	DatasetVersion int            `json:"dataset_version" bson:"dataset_version"`
+	ComputedAt     time.Time      `json:"computed_at" bson:"computed_at"`
+	Cached         bool           `json:"cached" bson:"-"`
+	Groups         []OutcomeGroup `json:"groups" bson:"groups"`
+}
+
+// Series temporales de participación VLE
+
+type EngagementQuery struct {
+	CodeModule       string
+	CodePresentation string
+	StudentID        int
+	Granularity      string
+	Cohort           map[string]string
+}
+
+type EngagementPoint struct {
+	Period       int    `json:"period" bson:"period"`
+	ActivityType string `json:"activity_type" bson:"activity_type"`
+	Clicks       int    `json:"clicks" bson:"clicks"`
+	Students     int    `json:"students" bson:"students"`
+}
+
+type EngagementSeries struct {
+	Granularity string            `json:"granularity"`
+	Points      []EngagementPoint `json:"points"`
+}
+
+// Vistas materializadas
+
+type ViewMetadata struct {
+	Name           string    `json:"name" bson:"_id"`
+	Collection     string    `json:"collection" bson:"collection"`
+	Status         string    `json:"status" bson:"status"`
+	Error          string    `json:"error,omitempty" bson:"error,omitempty"`
+	RefreshedAt    time.Time `json:"refreshed_at" bson:"refreshed_at"`
+	DurationMs     int64     `json:"duration_ms" bson:"duration_ms"`
+	Documents      int64     `json:"documents" bson:"documents"`
+	SourceRunID    string    `json:"source_run_id,omitempty" bson:"source_run_id,omitempty"`
+	DatasetVersion int       `json:"dataset_version" bson:"dataset_version"`
+	Stale          bool      `json:"stale" bson:"-"`
+}
+
+// Análisis de ítems de evaluaciones
+
+type ItemAnalysisQuery struct {
+	CodeModule       string
+	CodePresentation string
+	AssessmentType   string
+}
+
+type LatenessBucket struct {
+	Range string `json:"range" bson:"range"`
+	Count int    `json:"count" bson:"count"`
+}
+
+type AssessmentItemAnalysis struct {
+	AssessmentID     int              `json:"id_assessment" bson:"id_assessment"`
+	CodeModule       string           `json:"code_module" bson:"code_module"`
+	CodePresentation string           `json:"code_presentation" bson:"code_presentation"`
+	AssessmentType   string           `json:"assessment_type" bson:"assessment_type"`
+	Weight           float64          `json:"weight" bson:"weight"`
+	Submissions      int              `json:"submissions" bson:"submissions"`
+	MeanScore        float64          `json:"mean_score" bson:"mean_score"`
+	MedianScore      float64          `json:"median_score" bson:"median_score"`
+	PassRate         float64          `json:"pass_rate" bson:"pass_rate"`
+	Discrimination   float64          `json:"discrimination" bson:"discrimination"`
+	BankedShare      float64          `json:"banked_share" bson:"banked_share"`
+	HasDueDate       bool             `json:"has_due_date" bson:"has_due_date"`
+	MeanLateness     float64          `json:"mean_lateness_days" bson:"mean_lateness_days"`
+	MedianLateness   float64          `json:"median_lateness_days" bson:"median_lateness_days"`
+	LateRate         float64          `json:"late_rate" bson:"late_rate"`
+	Lateness         []LatenessBucket `json:"lateness" bson:"lateness"`
+	Flags            []string         `json:"flags" bson:"flags"`
+}
+
+type ItemAnalysisReport struct {
+	PassMark       float64                  `json:"pass_mark" bson:"pass_mark"`
+	DatasetVersion int                      `json:"dataset_version" bson:"dataset_version"`
+	ComputedAt     time.Time                `json:"computed_at" bson:"computed_at"`
+	Cached         bool                     `json:"cached" bson:"-"`
+	Assessments    []AssessmentItemAnalysis `json:"assessments" bson:"assessments"`
+}
+
+// Análisis de supervivencia de las bajas
+
+type SurvivalQuery struct {
+	CodeModule       string
+	CodePresentation string
+	GroupBy          []string
+}
+
+type SurvivalPoint struct {
+	Week     int     `json:"week" bson:"week"`
+	AtRisk   int     `json:"at_risk" bson:"at_risk"`
+	Events   int     `json:"withdrawals" bson:"withdrawals"`
+	Censored int     `json:"censored" bson:"censored"`
+	Survival float64 `json:"survival" bson:"survival"`
+	Lower    float64 `json:"lower" bson:"lower"`
+	Upper    float64 `json:"upper" bson:"upper"`
+}
+
+type SurvivalCurve struct {
+	Group       map[string]string `json:"group" bson:"group"`
+	Students    int               `json:"students" bson:"students"`
+	Withdrawals int               `json:"withdrawals" bson:"withdrawals"`
+	MedianWeek  *int              `json:"median_week" bson:"median_week"`
+	Points      []SurvivalPoint   `json:"points" bson:"points"`
+}
+
+type SurvivalReport struct {
+	GroupBy        []string        `json:"group_by" bson:"group_by"`
+	DatasetVersion int             `json:"dataset_version" bson:"dataset_version"`
+	ComputedAt     time.Time       `json:"computed_at" bson:"computed_at"`
+	Cached         bool            `json:"cached" bson:"-"`
+	Curves         []SurvivalCurve `json:"curves" bson:"curves"`
+}
+
+// Correlaciones entre características de estudiantes y resultados
+
+type CorrelationQuery struct {
+	CodeModule       string
+	CodePresentation string
+	Target           string
+}
+
+type FeatureImportance struct {
+	Feature  string  `json:"feature"`
+	Pearson  float64 `json:"pearson"`
+	Spearman float64 `json:"spearman"`
+	Count    int     `json:"count"`
+}
+
+type CorrelationReport struct {
+	CodeModule       string              `json:"code_module"`
+	CodePresentation string              `json:"code_presentation"`
+	Target           string              `json:"target"`
+	Students         int                 `json:"students"`
+	Variables        []string            `json:"variables"`
+	Pearson          [][]float64         `json:"pearson"`
+	Spearman         [][]float64         `json:"spearman"`
+	Importances      []FeatureImportance `json:"importances"`
+}
+
+// Explicación de predicciones por estudiante
+
+type ExplanationQuery struct {
+	StudentID        int
+	ModelID          string
+	CodeModule       string
+	CodePresentation string
+	Top              int
+}
+
+type FeatureContribution struct {
+	Feature      string  `json:"feature"`
+	Value        float64 `json:"value"`
+	Average      float64 `json:"average"`
+	Coefficient  float64 `json:"coefficient"`
+	Contribution float64 `json:"contribution"`
+}
+
+type PredictionExplanation struct {
+	ModelID          string                `json:"model"`
+	ModelType        string                `json:"model_type"`
+	RunID            string                `json:"run_id"`
+	StudentID        int                   `json:"student_id"`
+	AssessmentID     int                   `json:"assessment_id,omitempty"`
+	CodeModule       string                `json:"code_module"`
+	CodePresentation string                `json:"code_presentation"`
+	PredictedScore   float64               `json:"predicted_score"`
+	Baseline         float64               `json:"baseline"`
+	Approximate      bool                  `json:"approximate"`
+	TopPositive      []FeatureContribution `json:"top_positive"`
+	TopNegative      []FeatureContribution `json:"top_negative"`
+	Summary          string                `json:"summary"`
+}
+
+// Segmentación de estudiantes por patrones de participación
+
+type ClusteringRequest struct {
+	CodeModule       string `json:"code_module"`
+	CodePresentation string `json:"code_presentation"`
+	K                int    `json:"k"`
+	Seed             int64  `json:"seed"`
+}
+
+type StudentCluster struct {
+	Cluster            int                `json:"cluster" bson:"cluster"`
+	Size               int                `json:"size" bson:"size"`
+	Centroid           []float64          `json:"centroid" bson:"centroid"`
+	Outcomes           map[string]int     `json:"outcomes" bson:"outcomes"`
+	OutcomePercentages map[string]float64 `json:"outcome_percentages" bson:"outcome_percentages"`
+}
+
+type ClusteringResult struct {
+	ID               string           `json:"id" bson:"_id"`
+	CodeModule       string           `json:"code_module" bson:"code_module"`
+	CodePresentation string           `json:"code_presentation" bson:"code_presentation"`
+	K                int              `json:"k" bson:"k"`
+	Seed             int64            `json:"seed" bson:"seed"`
+	Features         []string         `json:"features" bson:"features"`
+	Students         int              `json:"students" bson:"students"`
+	Inertia          float64          `json:"inertia" bson:"inertia"`
+	DatasetVersion   int              `json:"dataset_version" bson:"dataset_version"`
+	ComputedAt       time.Time        `json:"computed_at" bson:"computed_at"`
+	Clusters         []StudentCluster `json:"clusters" bson:"clusters"`
+}
+
+// Migraciones de esquema
+
+// MigrationRequest indica el sentido y la versión final de una migración. Con
+// "up" y Target <= 0 se aplican todas las pendientes; con "down" y Target < 0
+// solo se revierte la última aplicada
+type MigrationRequest struct {
+	Direction string `json:"direction"`
+	Target    int    `json:"target"`
+	DryRun    bool   `json:"dry_run"`
+}
+
+type MigrationStatus struct {
+	Version    int       `json:"version" bson:"_id"`
+	Name       string    `json:"name" bson:"name"`
+	Applied    bool      `json:"applied" bson:"-"`
+	AppliedAt  time.Time `json:"applied_at,omitempty" bson:"applied_at"`
+	DurationMs int64     `json:"duration_ms" bson:"duration_ms"`
+}
+
+type MigrationPlanEntry struct {
+	Version    int      `json:"version"`
+	Name       string   `json:"name"`
+	Operations []string `json:"operations"`
+}
+
+type MigrationPlan struct {
+	Direction      string               `json:"direction"`
+	DryRun         bool                 `json:"dry_run"`
+	CurrentVersion int                  `json:"current_version"`
+	TargetVersion  int                  `json:"target_version"`
+	Migrations     []MigrationPlanEntry `json:"migrations"`
+}
+
+// Validación de esquema de las colecciones de OULAD
+
+type SchemaViolationQuery struct {
+	Collection string
+	Limit      int
+}
+
+type SchemaViolation struct {
+	Document map[string]interface{} `json:"document"`
+	Errors   []string               `json:"errors"`
+}
+
+type CollectionViolations struct {
+	Collection string            `json:"collection"`
+	Documents  int64             `json:"documents"`
+	Violations int64             `json:"violations"`
+	Samples    []SchemaViolation `json:"samples"`
+}
+
+type SchemaViolationReport struct {
+	CheckedAt   time.Time              `json:"checked_at"`
+	Collections []CollectionViolations `json:"collections"`
+}
+
+// Integridad referencial entre colecciones de OULAD
+
+type IntegritySample struct {
+	Key      map[string]interface{} `json:"key" bson:"key"`
+	Count    int                    `json:"count" bson:"count"`
+	Document map[string]interface{} `json:"document" bson:"document"`
+}
+
+type IntegrityCheck struct {
+	Name       string            `json:"name" bson:"name"`
+	Kind       string            `json:"kind" bson:"kind"`
+	Collection string            `json:"collection" bson:"collection"`
+	Reference  string            `json:"reference,omitempty" bson:"reference,omitempty"`
+	Keys       []string          `json:"keys" bson:"keys"`
+	Groups     int64             `json:"groups" bson:"groups"`
+	Documents  int64             `json:"documents" bson:"documents"`
+	Samples    []IntegritySample `json:"samples" bson:"samples"`
+}
+
+type IntegrityReport struct {
+	ID             string           `json:"id" bson:"_id"`
+	DatasetVersion int              `json:"dataset_version" bson:"dataset_version"`
+	StartedAt      time.Time        `json:"started_at" bson:"started_at"`
+	DurationMs     int64            `json:"duration_ms" bson:"duration_ms"`
+	Issues         int              `json:"issues" bson:"issues"`
+	Checks         []IntegrityCheck `json:"checks" bson:"checks"`
+}
+
+// Perfil de calidad de datos por colección y campo
+
+type ValueCount struct {
+	Value interface{} `json:"value" bson:"value"`
+	Count int64       `json:"count" bson:"count"`
+}
+
+type ProfileBin struct {
+	Min   float64 `json:"min" bson:"min"`
+	Max   float64 `json:"max" bson:"max"`
+	Count int64   `json:"count" bson:"count"`
+}
+
+type FieldProfile struct {
+	Field          string           `json:"field" bson:"field"`
+	Missing        int64            `json:"missing" bson:"missing"`
+	MissingRate    float64          `json:"missing_rate" bson:"missing_rate"`
+	Nulls          int64            `json:"nulls" bson:"nulls"`
+	NullRate       float64          `json:"null_rate" bson:"null_rate"`
+	Types          map[string]int64 `json:"types" bson:"types"`
+	TypeConsistent bool             `json:"type_consistent" bson:"type_consistent"`
+	Distinct       int64            `json:"distinct" bson:"distinct"`
+	Min            *float64         `json:"min,omitempty" bson:"min,omitempty"`
+	Max            *float64         `json:"max,omitempty" bson:"max,omitempty"`
+	Mean           *float64         `json:"mean,omitempty" bson:"mean,omitempty"`
+	Histogram      []ProfileBin     `json:"histogram" bson:"histogram"`
+	TopValues      []ValueCount     `json:"top_values" bson:"top_values"`
+}
+
+type CollectionProfile struct {
+	Collection string         `json:"collection" bson:"collection"`
+	Documents  int64          `json:"documents" bson:"documents"`
+	Fields     []FieldProfile `json:"fields" bson:"fields"`
+}
+
+type DataProfile struct {
+	DatasetVersion int                 `json:"dataset_version" bson:"_id"`
+	ComputedAt     time.Time           `json:"computed_at" bson:"computed_at"`
+	DurationMs     int64               `json:"duration_ms" bson:"duration_ms"`
+	Collections    []CollectionProfile `json:"collections" bson:"collections"`
+}
+
+type ProfileChange struct {
+	Collection string      `json:"collection"`
+	Field      string      `json:"field,omitempty"`
+	Metric     string      `json:"metric"`
+	From       interface{} `json:"from"`
+	To         interface{} `json:"to"`
+}
+
+type DataProfileDiff struct {
+	From    int             `json:"from"`
+	To      int             `json:"to"`
+	Changes []ProfileChange `json:"changes"`
+}
+
+// Seudonimización de estudiantes
+
+// StudentPseudonym relaciona el seudónimo de un estudiante con su id_student original
+type StudentPseudonym struct {
+	Pseudonym int       `json:"pseudonym" bson:"_id"`
+	IdStudent int       `json:"id_student" bson:"id_student"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// Derecho de supresión
+
+// ErasureRequest estudiante a suprimir: StudentID es el id almacenado, Pseudonym
+// su entrada en la tabla de reidentificación (0 sin seudonimización) y Subject
+// la referencia que queda en la auditoría
+type ErasureRequest struct {
+	StudentID int
+	Pseudonym int
+	Subject   string
+}
+
+type ErasureCollection struct {
+	Collection string `json:"collection" bson:"collection"`
+	Deleted    int64  `json:"deleted" bson:"deleted"`
+	Updated    int64  `json:"updated" bson:"updated"`
+}
+
+// ErasureAuditEntry entrada de auditoría encadenada con el hash de la anterior
+type ErasureAuditEntry struct {
+	Sequence     int                 `json:"sequence" bson:"_id"`
+	Subject      string              `json:"subject" bson:"subject"`
+	ErasedAt     time.Time           `json:"erased_at" bson:"erased_at"`
+	Collections  []ErasureCollection `json:"collections" bson:"collections"`
+	PreviousHash string              `json:"previous_hash" bson:"previous_hash"`
+	Hash         string              `json:"hash" bson:"hash"`
+	// Pending la entrada se registró pero el borrado no terminó; no forma parte del hash
+	Pending bool `json:"pending,omitempty" bson:"pending,omitempty"`
+}
+
+type ErasureAuditLog struct {
+	Entries  []ErasureAuditEntry `json:"entries"`
+	Valid    bool                `json:"valid"`
+	BrokenAt int                 `json:"broken_at,omitempty"`
+}
+
+// Autenticación
+
+type User struct {
+	Username     string    `json:"username" bson:"_id"`
+	PasswordHash string    `json:"-" bson:"password_hash"`
+	Role         string    `json:"role" bson:"role"`
+	CreatedAt    time.Time `json:"created_at" bson:"created_at"`
+}
+
+// RefreshToken token de refresco, guardado solo por su hash
+type RefreshToken struct {
+	Hash      string    `bson:"_id"`
+	Username  string    `bson:"username"`
+	ExpiresAt time.Time `bson:"expires_at"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type AuthTokens struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int    `json:"refresh_expires_in"`
+}
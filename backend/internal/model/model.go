@@ -2,50 +2,90 @@ package model
 
 import (
 	"archive/zip"
-	"backend/internal/client"
 	"backend/internal/config"
 	"backend/internal/entity"
+	"backend/internal/privacy"
+	"backend/internal/repository"
+	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type model struct {
-	client        client.MongoDBClient
+	repos         repository.Repositories
 	dbCredentials *entity.DBCredentials
 	loggers       *entity.Loggers
+	pseudonymizer *privacy.Pseudonymizer
 }
 type Model interface {
-	LoadBatchData() error
-	DownloadData() error
-	GetFiles() ([]*entity.FileInfo, error)
-	GetData(collection string) ([]interface{}, error)
-	GetAllCountData(collections []string) (map[string]int64, error)
-	ProcessDataPredictionAssessments() ([]entity.ProcessedPredictionAssessmentResult, error)
-	ProcessDataVlePredictions() ([]entity.ProcessedPredictionVleResult, error)
-	GetScoreDistributionPredictionAssessments() ([]entity.ScoreRangePredictionAssessments, error)
-	GetAveragePredictedScoreByAssessmentType() ([]entity.AssessmentTypeAverage, error)
-	GetStudentCountByAssessmentID() ([]entity.AssessmentStudentCount, error)
-}
-
-func NewModel(client client.MongoDBClient, loggers *entity.Loggers) Model {
+	LoadBatchData(ctx context.Context) error
+	DownloadData(ctx context.Context) error
+	GetFiles(ctx context.Context) ([]*entity.FileInfo, error)
+	GetData(ctx context.Context, collection string) ([]interface{}, error)
+	GetAllCountData(ctx context.Context, collections []string) (map[string]int64, error)
+	ProcessDataPredictionAssessments(ctx context.Context) ([]entity.ProcessedPredictionAssessmentResult, error)
+	ProcessDataVlePredictions(ctx context.Context) ([]entity.ProcessedPredictionVleResult, error)
+	GetScoreDistributionPredictionAssessments(ctx context.Context, query entity.ScoreDistributionQuery) (*entity.ScoreDistribution, error)
+	GetAveragePredictedScoreByAssessmentType(ctx context.Context) ([]entity.AssessmentTypeAverage, error)
+	GetStudentCountByAssessmentID(ctx context.Context) ([]entity.AssessmentStudentCount, error)
+	EvaluateModel(ctx context.Context, modelID string, request entity.ModelEvaluationRequest) (*entity.ModelEvaluation, error)
+	GetPredictionRuns(ctx context.Context, kind string) ([]entity.PredictionRun, error)
+	ActivatePredictionRun(ctx context.Context, runID string) error
+	GetVleScoringConfigs(ctx context.Context) ([]entity.VleScoringConfig, error)
+	SaveVleScoringConfig(ctx context.Context, scoringConfig entity.VleScoringConfig) error
+	GetActualVsPredicted(ctx context.Context, query entity.ActualVsPredictedQuery) (*entity.ActualVsPredictedReport, error)
+	GetFairnessReport(ctx context.Context, query entity.FairnessQuery) (*entity.FairnessReport, error)
+	GetOutcomes(ctx context.Context, groupBy []string) (*entity.OutcomeReport, error)
+	GetEngagementSeries(ctx context.Context, query entity.EngagementQuery) (*entity.EngagementSeries, error)
+	RefreshMaterializedViews(ctx context.Context, name string) ([]entity.ViewMetadata, error)
+	GetMaterializedViews(ctx context.Context) ([]entity.ViewMetadata, error)
+	GetViewMetadata(ctx context.Context, name string) (*entity.ViewMetadata, error)
+	GetItemAnalysis(ctx context.Context, query entity.ItemAnalysisQuery) (*entity.ItemAnalysisReport, error)
+	GetWithdrawalSurvival(ctx context.Context, query entity.SurvivalQuery) (*entity.SurvivalReport, error)
+	GetFeatureCorrelations(ctx context.Context, query entity.CorrelationQuery) (*entity.CorrelationReport, error)
+	ExplainStudentPredictions(ctx context.Context, query entity.ExplanationQuery) ([]entity.PredictionExplanation, error)
+	ClusterStudents(ctx context.Context, request entity.ClusteringRequest) (*entity.ClusteringResult, error)
+	GetStudentClusters(ctx context.Context, codeModule, codePresentation string) (*entity.ClusteringResult, error)
+	GetMigrationStatus(ctx context.Context) ([]entity.MigrationStatus, error)
+	Migrate(ctx context.Context, request entity.MigrationRequest) (*entity.MigrationPlan, error)
+	GetSchemaViolations(ctx context.Context, query entity.SchemaViolationQuery) (*entity.SchemaViolationReport, error)
+	RunIntegrityCheck(ctx context.Context) (*entity.IntegrityReport, error)
+	GetIntegrityReport(ctx context.Context) (*entity.IntegrityReport, error)
+	ProfileDataset(ctx context.Context) (*entity.DataProfile, error)
+	GetDataProfile(ctx context.Context, version int) (*entity.DataProfile, error)
+	DiffDataProfiles(ctx context.Context, from, to int) (*entity.DataProfileDiff, error)
+	GetStudentPseudonym(ctx context.Context, pseudonym int) (*entity.StudentPseudonym, error)
+	EraseStudent(ctx context.Context, studentID int) (*entity.ErasureAuditEntry, error)
+	GetErasureAudit(ctx context.Context) (*entity.ErasureAuditLog, error)
+	GetUser(ctx context.Context, username string) (*entity.User, error)
+	SaveUser(ctx context.Context, user entity.User) error
+	SaveRefreshToken(ctx context.Context, token entity.RefreshToken) error
+	ConsumeRefreshToken(ctx context.Context, hash string) (*entity.RefreshToken, error)
+}
+
+func NewModel(repos repository.Repositories, loggers *entity.Loggers, pseudonymizer *privacy.Pseudonymizer) Model {
 	_, dbCredentials, _ := config.DBCredentials()
 	return &model{
-		client:        client,
+		repos:         repos,
 		dbCredentials: &dbCredentials,
 		loggers:       loggers,
+		pseudonymizer: pseudonymizer,
 	}
 }
 
-func (m *model) LoadBatchData() error {
+func (m *model) LoadBatchData(ctx context.Context) error {
 	enviroment := viper.GetString(config.Envirornment)
 	var filePathRead string
 	if enviroment == "DEV" {
@@ -60,7 +100,7 @@ func (m *model) LoadBatchData() error {
 	files := []struct {
 		Path         string
 		Collection   string
-		ProcessBatch func(string, [][]string) error
+		ProcessBatch func(context.Context, string, [][]string, *erasedStudents) error
 	}{
 		{filePathRead + "/courses.csv", "courses", m.processBatch},
 		{filePathRead + "/assessments.csv", "assessments", m.processBatch},
@@ -70,23 +110,53 @@ func (m *model) LoadBatchData() error {
 		{filePathRead + "/studentVle.csv", "studentVle", m.processBatch},
 		{filePathRead + "/studentRegistration.csv", "studentRegistration", m.processBatch},
 	}
+	// Los estudiantes suprimidos siguen en los CSV de origen; no se vuelven a importar
+	subjects, err := m.repos.Erasure.GetErasedSubjects(ctx, m.dbCredentials.Dbname)
+	if err != nil {
+		m.loggers.ErrorLogger.Printf("Error al obtener los estudiantes suprimidos: %v", err)
+		return err
+	}
+	erased := newErasedStudents(subjects)
 	for _, file := range files {
 		m.loggers.InfoLogger.Printf("Procesando archivo: %s", file.Path)
 		batchSize := viper.GetInt(config.BatchSize)
-		if err := m.processCSVInBatches(file.Path, batchSize, func(batch [][]string) error {
-			return file.ProcessBatch(file.Collection, batch)
+		if err := m.processCSVInBatches(ctx, file.Path, batchSize, func(batch [][]string) error {
+			return file.ProcessBatch(ctx, file.Collection, batch, erased)
 		}); err != nil {
+			// Una carga cancelada o incompleta no se registra como nueva versión del conjunto de datos
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return fmt.Errorf("carga cancelada: %w", ctxErr)
+			}
 			m.loggers.ErrorLogger.Printf("Error al procesar archivo %s: %v", file.Path, err)
+			return fmt.Errorf("error al procesar %s: %w", file.Path, err)
+		}
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("carga cancelada: %w", err)
 		}
 	}
 
 	m.loggers.InfoLogger.Println("Procesamiento completado.")
+	if _, err := m.repos.DatasetVersions.RegisterDatasetLoad(ctx, m.dbCredentials.Dbname); err != nil {
+		m.loggers.ErrorLogger.Printf("Error al registrar la carga: %v", err)
+		return err
+	}
+	if _, err := m.repos.Views.RefreshMaterializedViews(ctx, m.dbCredentials.Dbname, ""); err != nil {
+		m.loggers.ErrorLogger.Printf("Error al actualizar las vistas materializadas: %v", err)
+		return err
+	}
+	// Las incidencias de integridad quedan en el informe; no invalidan la carga
+	if viper.GetBool(config.IntegrityCheckAfterLoad) {
+		_, err := m.repos.DataQuality.RunIntegrityCheck(ctx, m.dbCredentials.Dbname)
+		if err != nil && !errors.Is(err, entity.ErrUnsupported) {
+			m.loggers.ErrorLogger.Printf("Error en la comprobación de integridad: %v", err)
+		}
+	}
 	return nil
 
 }
 
-func (m *model) DownloadData() error {
-	files, err := m.GetFiles()
+func (m *model) DownloadData(ctx context.Context) error {
+	files, err := m.GetFiles(ctx)
 	if err != nil {
 		m.loggers.ErrorLogger.Printf("Error al obtener archivos: %v", err)
 		return err
@@ -111,7 +181,7 @@ func (m *model) DownloadData() error {
 		}
 
 		log.Println("Descargando archivo...")
-		err := m.downloadZip(url, zipPath)
+		err := m.downloadZip(ctx, url, zipPath)
 		if err != nil {
 			m.loggers.ErrorLogger.Printf("Error al descargar archivo: %v", err)
 			return err
@@ -131,7 +201,7 @@ func (m *model) DownloadData() error {
 	return nil
 }
 
-func (m *model) processCSVInBatches(filePath string, batchSize int, processBatch func([][]string) error) error {
+func (m *model) processCSVInBatches(ctx context.Context, filePath string, batchSize int, processBatch func([][]string) error) error {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return err
@@ -156,6 +226,9 @@ func (m *model) processCSVInBatches(filePath string, batchSize int, processBatch
 		}
 		batch = append(batch, record)
 		if len(batch) >= batchSize {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			if err := processBatch(batch); err != nil {
 				return err
 			}
@@ -164,9 +237,12 @@ func (m *model) processCSVInBatches(filePath string, batchSize int, processBatch
 	}
 	return nil
 }
-func (m *model) processBatch(collectionName string, batch [][]string) error {
+func (m *model) processBatch(ctx context.Context, collectionName string, batch [][]string, erased *erasedStudents) error {
 	var data []interface{}
 	m.loggers.InfoLogger.Printf("Procesando lote de %d registros para la colección %s", len(batch)-1, collectionName)
+	// studentInfo contiene a todos los estudiantes: de ahí sale la tabla de reidentificación
+	pseudonyms := make(map[int]entity.StudentPseudonym)
+	skipped := 0
 
 	for _, record := range batch[1:] {
 		switch collectionName {
@@ -206,10 +282,18 @@ func (m *model) processBatch(collectionName string, batch [][]string) error {
 			data = append(data, value)
 		case "studentInfo":
 			idStudent, _ := strconv.Atoi(record[2])
+			if erased.contains(m.loadStudentID(idStudent)) {
+				skipped++
+				continue
+			}
 			numOfPrevAttempts, _ := strconv.Atoi(record[8])
 			studiedCredits, _ := strconv.Atoi(record[9])
+			if m.pseudonymizer.Enabled() {
+				pseudonym := m.pseudonymizer.StudentID(idStudent)
+				pseudonyms[pseudonym] = entity.StudentPseudonym{Pseudonym: pseudonym, IdStudent: idStudent, CreatedAt: time.Now()}
+			}
 			value := entity.StudentInfo{
-				IdStudent:         idStudent,
+				IdStudent:         m.loadStudentID(idStudent),
 				CodeModule:        record[0],
 				CodePresentation:  record[1],
 				Gender:            record[3],
@@ -225,12 +309,16 @@ func (m *model) processBatch(collectionName string, batch [][]string) error {
 			data = append(data, value)
 		case "studentRegistration":
 			idStudent, _ := strconv.Atoi(record[2])
+			if erased.contains(m.loadStudentID(idStudent)) {
+				skipped++
+				continue
+			}
 			dateRegistration, _ := strconv.Atoi(record[3])
 			dateUnregistration, _ := strconv.Atoi(record[4])
 			value := entity.StudentRegistration{
 				CodeModule:         record[0],
 				CodePresentation:   record[1],
-				IdStudent:          idStudent,
+				IdStudent:          m.loadStudentID(idStudent),
 				DateRegistration:   dateRegistration,
 				DateUnregistration: dateUnregistration,
 			}
@@ -238,12 +326,16 @@ func (m *model) processBatch(collectionName string, batch [][]string) error {
 		case "studentAssessment":
 			idAssessment, _ := strconv.Atoi(record[0])
 			idStudent, _ := strconv.Atoi(record[1])
+			if erased.contains(m.loadStudentID(idStudent)) {
+				skipped++
+				continue
+			}
 			dateSubmitted, _ := strconv.Atoi(record[2])
 			isBanked, _ := strconv.Atoi(record[3])
 			score, _ := strconv.ParseFloat(record[4], 64)
 			value := entity.StudentAssessment{
 				IdAssessment:  idAssessment,
-				IdStudent:     idStudent,
+				IdStudent:     m.loadStudentID(idStudent),
 				DateSubmitted: dateSubmitted,
 				IsBanked:      isBanked,
 				Score:         score,
@@ -251,13 +343,17 @@ func (m *model) processBatch(collectionName string, batch [][]string) error {
 			data = append(data, value)
 		case "studentVle":
 			idStudent, _ := strconv.Atoi(record[2])
+			if erased.contains(m.loadStudentID(idStudent)) {
+				skipped++
+				continue
+			}
 			idSite, _ := strconv.Atoi(record[3])
 			date, _ := strconv.Atoi(record[4])
 			sumClick, _ := strconv.Atoi(record[5])
 			value := entity.StudentVle{
 				CodeModule:       record[0],
 				CodePresentation: record[1],
-				IdStudent:        idStudent,
+				IdStudent:        m.loadStudentID(idStudent),
 				IdSite:           idSite,
 				Date:             date,
 				SumClick:         sumClick,
@@ -265,19 +361,72 @@ func (m *model) processBatch(collectionName string, batch [][]string) error {
 			data = append(data, value)
 		}
 	}
+	if skipped > 0 {
+		m.loggers.InfoLogger.Printf("Omitidos %d registros de estudiantes suprimidos en %s", skipped, collectionName)
+	}
 	batchSize := viper.GetInt(config.BatchSize)
-	err := m.client.BatchInsert(m.dbCredentials.Dbname, collectionName, data, batchSize)
-	return err
+	if err := m.repos.Dataset.BatchInsert(ctx, m.dbCredentials.Dbname, collectionName, data, batchSize); err != nil {
+		return err
+	}
+	if len(pseudonyms) > 0 {
+		records := make([]entity.StudentPseudonym, 0, len(pseudonyms))
+		for _, pseudonym := range pseudonyms {
+			records = append(records, pseudonym)
+		}
+		return m.repos.Pseudonyms.SaveStudentPseudonyms(ctx, m.dbCredentials.Dbname, records)
+	}
+	return nil
+}
+
+// loadStudentID devuelve el id_student que se guarda: el seudónimo si se
+// seudonimiza durante la carga
+func (m *model) loadStudentID(idStudent int) int {
+	if m.pseudonymizer.AtLoad() {
+		return m.pseudonymizer.StudentID(idStudent)
+	}
+	return idStudent
+}
+
+// erasedStudents reconoce a los estudiantes suprimidos comparando el HMAC de su
+// id almacenado con los sujetos de la auditoría; recuerda el resultado de cada id
+type erasedStudents struct {
+	key      []byte
+	subjects map[string]bool
+	checked  map[int]bool
+}
+
+func newErasedStudents(subjects map[string]bool) *erasedStudents {
+	return &erasedStudents{
+		key:      []byte(viper.GetString(config.ErasureAuditKey)),
+		subjects: subjects,
+		checked:  make(map[int]bool),
+	}
+}
+
+func (e *erasedStudents) contains(studentID int) bool {
+	if len(e.subjects) == 0 {
+		return false
+	}
+	erased, ok := e.checked[studentID]
+	if !ok {
+		erased = e.subjects[privacy.ErasureSubject(e.key, studentID)]
+		e.checked[studentID] = erased
+	}
+	return erased
 }
 
-func (m *model) downloadZip(url, filepath string) error {
+func (m *model) downloadZip(ctx context.Context, url, filepath string) error {
 	out, err := os.Create(filepath)
 	if err != nil {
 		return err
 	}
 	defer out.Close()
 
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -335,7 +484,7 @@ func (m *model) unzipFile(src, dest string) error {
 	return nil
 }
 
-func (m *model) GetFiles() ([]*entity.FileInfo, error) {
+func (m *model) GetFiles(ctx context.Context) ([]*entity.FileInfo, error) {
 	var dirPath string
 	environment := viper.GetString(config.Envirornment)
 	if environment == "DEV" {
@@ -367,32 +516,275 @@ func (m *model) GetFiles() ([]*entity.FileInfo, error) {
 	}
 	return files, nil
 }
-func (m *model) GetAllCountData(collections []string) (map[string]int64, error) {
-	return m.client.GetAllCountData(m.dbCredentials.Dbname, collections)
+func (m *model) GetAllCountData(ctx context.Context, collections []string) (map[string]int64, error) {
+	return m.repos.Dataset.GetAllCountData(ctx, m.dbCredentials.Dbname, collections)
+}
+
+func (m *model) GetData(ctx context.Context, collection string) ([]interface{}, error) {
+	if !repository.DataCollections[collection] {
+		return nil, fmt.Errorf("colección %w: %s", entity.ErrNotFound, collection)
+	}
+	return m.repos.Dataset.GetData(ctx, m.dbCredentials.Dbname, collection)
+}
+
+func (m *model) ProcessDataPredictionAssessments(ctx context.Context) ([]entity.ProcessedPredictionAssessmentResult, error) {
+	return m.repos.Predictions.ProcessDataPredictionAssessments(ctx, m.dbCredentials.Dbname)
+}
+
+func (m *model) ProcessDataVlePredictions(ctx context.Context) ([]entity.ProcessedPredictionVleResult, error) {
+	return m.repos.Predictions.ProcessDataVlePredictions(ctx, m.dbCredentials.Dbname)
+}
+
+func (m *model) GetScoreDistributionPredictionAssessments(ctx context.Context, query entity.ScoreDistributionQuery) (*entity.ScoreDistribution, error) {
+	return m.repos.Analytics.GetScoreDistributionPredictionAssessments(ctx, m.dbCredentials.Dbname, query)
+}
+
+func (m *model) GetAveragePredictedScoreByAssessmentType(ctx context.Context) ([]entity.AssessmentTypeAverage, error) {
+	return m.repos.Analytics.GetAveragePredictedScoreByAssessmentType(ctx, m.dbCredentials.Dbname)
+}
+
+func (m *model) GetStudentCountByAssessmentID(ctx context.Context) ([]entity.AssessmentStudentCount, error) {
+	return m.repos.Analytics.GetStudentCountByAssessmentID(ctx, m.dbCredentials.Dbname)
+}
+
+func (m *model) EvaluateModel(ctx context.Context, modelID string, request entity.ModelEvaluationRequest) (*entity.ModelEvaluation, error) {
+	return m.repos.Predictions.EvaluateModel(ctx, m.dbCredentials.Dbname, modelID, request)
+}
+
+func (m *model) GetPredictionRuns(ctx context.Context, kind string) ([]entity.PredictionRun, error) {
+	return m.repos.PredictionRuns.GetPredictionRuns(ctx, m.dbCredentials.Dbname, kind)
+}
+
+func (m *model) ActivatePredictionRun(ctx context.Context, runID string) error {
+	return m.repos.PredictionRuns.ActivatePredictionRun(ctx, m.dbCredentials.Dbname, runID)
+}
+
+func (m *model) GetVleScoringConfigs(ctx context.Context) ([]entity.VleScoringConfig, error) {
+	return m.repos.VleScoringConfig.GetVleScoringConfigs(ctx, m.dbCredentials.Dbname)
+}
+
+func (m *model) SaveVleScoringConfig(ctx context.Context, scoringConfig entity.VleScoringConfig) error {
+	return m.repos.VleScoringConfig.SaveVleScoringConfig(ctx, m.dbCredentials.Dbname, scoringConfig)
+}
+
+func (m *model) GetActualVsPredicted(ctx context.Context, query entity.ActualVsPredictedQuery) (*entity.ActualVsPredictedReport, error) {
+	return m.repos.Analytics.GetActualVsPredicted(ctx, m.dbCredentials.Dbname, query)
+}
+
+func (m *model) GetFairnessReport(ctx context.Context, query entity.FairnessQuery) (*entity.FairnessReport, error) {
+	return m.repos.Analytics.GetFairnessReport(ctx, m.dbCredentials.Dbname, query)
+}
+
+func (m *model) GetOutcomes(ctx context.Context, groupBy []string) (*entity.OutcomeReport, error) {
+	database := m.dbCredentials.Dbname
+	fields := make([]string, 0, len(groupBy)+1)
+	for _, field := range groupBy {
+		dbField, ok := repository.StudentInfoFields[field]
+		if !ok {
+			return nil, fmt.Errorf("campo de agrupación no soportado: %s", field)
+		}
+		fields = append(fields, dbField)
+	}
+	fields = append(fields, "finalresult")
+
+	version, err := m.repos.DatasetVersions.GetDatasetVersion(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := "outcomes:" + strings.Join(groupBy, ",")
+	report := &entity.OutcomeReport{}
+	found, err := m.repos.AnalyticsCache.ReadAnalyticsCache(ctx, database, cacheKey, version, report)
+	if err != nil {
+		m.loggers.ErrorLogger.Printf("Error al leer la caché de resultados: %v", err)
+	}
+	if found {
+		report.Cached = true
+		return report, nil
+	}
+
+	counts, err := m.repos.Dataset.CountBy(ctx, database, "studentInfo", fields, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error al agregar resultados finales: %w", err)
+	}
+
+	// Reunir los recuentos por resultado final de cada grupo
+	report = &entity.OutcomeReport{
+		GroupBy:        groupBy,
+		DatasetVersion: version,
+		ComputedAt:     time.Now(),
+		Groups:         []entity.OutcomeGroup{},
+	}
+	groups := make(map[string]int)
+	for _, count := range counts {
+		key := make(map[string]string, len(groupBy))
+		keyParts := make([]string, len(groupBy))
+		for i, field := range groupBy {
+			key[field], _ = count.Key[fields[i]].(string)
+			keyParts[i] = key[field]
+		}
+		groupKey := strings.Join(keyParts, "\x00")
+		index, ok := groups[groupKey]
+		if !ok {
+			index = len(report.Groups)
+			groups[groupKey] = index
+			group := entity.OutcomeGroup{
+				Group:       key,
+				Counts:      make(map[string]int, len(repository.FinalResults)),
+				Percentages: make(map[string]float64, len(repository.FinalResults)),
+			}
+			for _, result := range repository.FinalResults {
+				group.Counts[result] = 0
+			}
+			report.Groups = append(report.Groups, group)
+		}
+		result, _ := count.Key["finalresult"].(string)
+		report.Groups[index].Counts[result] += count.Count
+		report.Groups[index].Total += count.Count
+	}
+	for i := range report.Groups {
+		group := &report.Groups[i]
+		for result, count := range group.Counts {
+			if group.Total > 0 {
+				group.Percentages[result] = float64(count) / float64(group.Total) * 100
+			}
+		}
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		for _, field := range groupBy {
+			if report.Groups[i].Group[field] != report.Groups[j].Group[field] {
+				return report.Groups[i].Group[field] < report.Groups[j].Group[field]
+			}
+		}
+		return false
+	})
+
+	if err := m.repos.AnalyticsCache.WriteAnalyticsCache(ctx, database, cacheKey, version, report); err != nil {
+		m.loggers.ErrorLogger.Printf("Error al guardar la caché de resultados: %v", err)
+	}
+	return report, nil
+}
+
+func (m *model) GetEngagementSeries(ctx context.Context, query entity.EngagementQuery) (*entity.EngagementSeries, error) {
+	return m.repos.Analytics.GetEngagementSeries(ctx, m.dbCredentials.Dbname, query)
+}
+
+func (m *model) RefreshMaterializedViews(ctx context.Context, name string) ([]entity.ViewMetadata, error) {
+	return m.repos.Views.RefreshMaterializedViews(ctx, m.dbCredentials.Dbname, name)
+}
+
+func (m *model) GetMaterializedViews(ctx context.Context) ([]entity.ViewMetadata, error) {
+	return m.repos.Views.GetMaterializedViews(ctx, m.dbCredentials.Dbname)
+}
+
+func (m *model) GetViewMetadata(ctx context.Context, name string) (*entity.ViewMetadata, error) {
+	return m.repos.Views.GetViewMetadata(ctx, m.dbCredentials.Dbname, name)
+}
+
+func (m *model) GetItemAnalysis(ctx context.Context, query entity.ItemAnalysisQuery) (*entity.ItemAnalysisReport, error) {
+	return m.repos.Analytics.GetItemAnalysis(ctx, m.dbCredentials.Dbname, query)
+}
+
+func (m *model) GetWithdrawalSurvival(ctx context.Context, query entity.SurvivalQuery) (*entity.SurvivalReport, error) {
+	return m.repos.Analytics.GetWithdrawalSurvival(ctx, m.dbCredentials.Dbname, query)
+}
+
+func (m *model) GetFeatureCorrelations(ctx context.Context, query entity.CorrelationQuery) (*entity.CorrelationReport, error) {
+	return m.repos.Analytics.GetFeatureCorrelations(ctx, m.dbCredentials.Dbname, query)
+}
+
+func (m *model) ExplainStudentPredictions(ctx context.Context, query entity.ExplanationQuery) ([]entity.PredictionExplanation, error) {
+	return m.repos.Predictions.ExplainStudentPredictions(ctx, m.dbCredentials.Dbname, query)
+}
+
+func (m *model) ClusterStudents(ctx context.Context, request entity.ClusteringRequest) (*entity.ClusteringResult, error) {
+	return m.repos.Analytics.ClusterStudents(ctx, m.dbCredentials.Dbname, request)
+}
+
+func (m *model) GetStudentClusters(ctx context.Context, codeModule, codePresentation string) (*entity.ClusteringResult, error) {
+	return m.repos.Analytics.GetStudentClusters(ctx, m.dbCredentials.Dbname, codeModule, codePresentation)
+}
+
+func (m *model) GetSchemaViolations(ctx context.Context, query entity.SchemaViolationQuery) (*entity.SchemaViolationReport, error) {
+	return m.repos.DataQuality.GetSchemaViolations(ctx, m.dbCredentials.Dbname, query)
+}
+
+func (m *model) RunIntegrityCheck(ctx context.Context) (*entity.IntegrityReport, error) {
+	return m.repos.DataQuality.RunIntegrityCheck(ctx, m.dbCredentials.Dbname)
+}
+
+func (m *model) GetIntegrityReport(ctx context.Context) (*entity.IntegrityReport, error) {
+	return m.repos.DataQuality.GetIntegrityReport(ctx, m.dbCredentials.Dbname)
+}
+
+func (m *model) ProfileDataset(ctx context.Context) (*entity.DataProfile, error) {
+	return m.repos.DataQuality.ProfileDataset(ctx, m.dbCredentials.Dbname)
+}
+
+func (m *model) GetDataProfile(ctx context.Context, version int) (*entity.DataProfile, error) {
+	return m.repos.DataQuality.GetDataProfile(ctx, m.dbCredentials.Dbname, version)
+}
+
+func (m *model) DiffDataProfiles(ctx context.Context, from, to int) (*entity.DataProfileDiff, error) {
+	return m.repos.DataQuality.DiffDataProfiles(ctx, m.dbCredentials.Dbname, from, to)
+}
+
+func (m *model) GetStudentPseudonym(ctx context.Context, pseudonym int) (*entity.StudentPseudonym, error) {
+	return m.repos.Pseudonyms.GetStudentPseudonym(ctx, m.dbCredentials.Dbname, pseudonym)
+}
+
+// EraseStudent suprime al estudiante (studentID es el id almacenado) y recalcula
+// las vistas materializadas para que los agregados dejen de incluirlo
+func (m *model) EraseStudent(ctx context.Context, studentID int) (*entity.ErasureAuditEntry, error) {
+	request := entity.ErasureRequest{StudentID: studentID, Subject: erasureSubject(studentID)}
+	if m.pseudonymizer.Enabled() {
+		request.Pseudonym = studentID
+		if !m.pseudonymizer.AtLoad() {
+			request.Pseudonym = m.pseudonymizer.StudentID(studentID)
+		}
+	}
+	entry, err := m.repos.Erasure.EraseStudent(ctx, m.dbCredentials.Dbname, request)
+	if err != nil {
+		return nil, err
+	}
+	// La supresión ya consta en la auditoría; un fallo aquí solo deja vistas por refrescar
+	if _, err := m.repos.Views.RefreshMaterializedViews(ctx, m.dbCredentials.Dbname, ""); err != nil {
+		m.loggers.ErrorLogger.Printf("Error al actualizar las vistas materializadas tras la supresión: %v", err)
+	}
+	return entry, nil
+}
+
+// erasureSubject referencia al estudiante (id almacenado) en la auditoría de
+// supresiones, con la clave ERASURE_AUDIT_KEY que el cliente valida al arrancar
+func erasureSubject(studentID int) string {
+	return privacy.ErasureSubject([]byte(viper.GetString(config.ErasureAuditKey)), studentID)
+}
+
+func (m *model) GetErasureAudit(ctx context.Context) (*entity.ErasureAuditLog, error) {
+	return m.repos.Erasure.GetErasureAudit(ctx, m.dbCredentials.Dbname)
 }
 
-func (m *model) GetData(collection string) ([]interface{}, error) {
-	return m.client.GetData(m.dbCredentials.Dbname, collection)
+func (m *model) GetUser(ctx context.Context, username string) (*entity.User, error) {
+	return m.repos.Users.GetUser(ctx, m.dbCredentials.Dbname, username)
 }
 
-func (m *model) ProcessDataPredictionAssessments() ([]entity.ProcessedPredictionAssessmentResult, error) {
-	return m.client.ProcessDataPredictionAssessments(m.dbCredentials.Dbname)
+func (m *model) SaveUser(ctx context.Context, user entity.User) error {
+	return m.repos.Users.SaveUser(ctx, m.dbCredentials.Dbname, user)
 }
 
-func (m *model) ProcessDataVlePredictions() ([]entity.ProcessedPredictionVleResult, error) {
-	return m.client.ProcessDataVlePredictions(m.dbCredentials.Dbname)
+func (m *model) SaveRefreshToken(ctx context.Context, token entity.RefreshToken) error {
+	return m.repos.Users.SaveRefreshToken(ctx, m.dbCredentials.Dbname, token)
 }
 
-func (m *model) GetScoreDistributionPredictionAssessments() ([]entity.ScoreRangePredictionAssessments, error) {
-	return m.client.GetScoreDistributionPredictionAssessments(m.dbCredentials.Dbname)
+func (m *model) ConsumeRefreshToken(ctx context.Context, hash string) (*entity.RefreshToken, error) {
+	return m.repos.Users.ConsumeRefreshToken(ctx, m.dbCredentials.Dbname, hash)
 }
 
-func (m *model) GetAveragePredictedScoreByAssessmentType() ([]entity.AssessmentTypeAverage, error) {
-	return m.client.GetAveragePredictedScoreByAssessmentType(m.dbCredentials.Dbname)
+func (m *model) GetMigrationStatus(ctx context.Context) ([]entity.MigrationStatus, error) {
+	return m.repos.Migrations.GetMigrationStatus(ctx, m.dbCredentials.Dbname)
 }
 
-func (m *model) GetStudentCountByAssessmentID() ([]entity.AssessmentStudentCount, error) {
-	return m.client.GetStudentCountByAssessmentID(m.dbCredentials.Dbname)
+func (m *model) Migrate(ctx context.Context, request entity.MigrationRequest) (*entity.MigrationPlan, error) {
+	return m.repos.Migrations.Migrate(ctx, m.dbCredentials.Dbname, request)
 }
 
 func (m *model) formatFileSize(size int64) string {
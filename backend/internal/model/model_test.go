@@ -0,0 +1,279 @@
+package model
+
+import (
+	"backend/internal/config"
+	"backend/internal/entity"
+	"backend/internal/privacy"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const testKey = "test-pseudonymization-key-0123456789"
+
+// testFiles CSV mínimos de OULAD: dos estudiantes en una presentación
+var testFiles = map[string]string{
+	"courses.csv": `"code_module","code_presentation","module_presentation_length"
+"AAA","2013J","268"
+`,
+	"assessments.csv": `"code_module","code_presentation","id_assessment","assessment_type","date","weight"
+"AAA","2013J","1752","TMA","19","10"
+`,
+	"vle.csv": `"id_site","code_module","code_presentation","activity_type","week_from","week_to"
+"546943","AAA","2013J","resource","",""
+`,
+	"studentInfo.csv": `"code_module","code_presentation","id_student","gender","region","highest_education","imd_band","age_band","num_of_prev_attempts","studied_credits","disability","final_result"
+"AAA","2013J","11391","M","East Anglian Region","HE Qualification","90-100%","55<=","0","240","N","Pass"
+"AAA","2013J","28400","F","Scotland","HE Qualification","20-30%","35-55","0","60","N","Withdrawn"
+"AAA","2013J","30268","F","Scotland","A Level or Equivalent","30-40%","35-55","0","60","Y","Pass"
+`,
+	"studentRegistration.csv": `"code_module","code_presentation","id_student","date_registration","date_unregistration"
+"AAA","2013J","11391","-159",""
+`,
+	"studentAssessment.csv": `"id_assessment","id_student","date_submitted","is_banked","score"
+"1752","11391","18","0","78"
+`,
+	"studentVle.csv": `"code_module","code_presentation","id_student","id_site","date","sum_click"
+"AAA","2013J","11391","546943","-10","4"
+`,
+}
+
+func newTestModel(t *testing.T, mode string) (*model, repository.Repositories) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range testFiles {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	viper.Reset()
+	viper.Set(config.FilePathReadQa, dir)
+	viper.Set(config.BatchSize, 100)
+	viper.Set(config.DbnameQa, "test")
+	t.Cleanup(viper.Reset)
+
+	key := testKey
+	if mode == config.PseudonymizationOff {
+		key = ""
+	}
+	pseudonymizer, err := privacy.NewPseudonymizer(key, mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loggers := &entity.Loggers{
+		InfoLogger:  log.New(io.Discard, "", 0),
+		ErrorLogger: log.New(io.Discard, "", 0),
+	}
+	repos := repository.NewMemoryRepositories()
+	return NewModel(repos, loggers, pseudonymizer).(*model), repos
+}
+
+func studentIDs(t *testing.T, data []interface{}) []int {
+	t.Helper()
+	ids := make([]int, 0, len(data))
+	for _, document := range data {
+		switch id := document.(bson.M)["idstudent"].(type) {
+		case int32:
+			ids = append(ids, int(id))
+		case int64:
+			ids = append(ids, int(id))
+		default:
+			t.Fatalf("idstudent con tipo inesperado %T", id)
+		}
+	}
+	return ids
+}
+
+func TestLoadBatchDataRegistersVersion(t *testing.T) {
+	m, repos := newTestModel(t, config.PseudonymizationOff)
+	ctx := context.Background()
+
+	if err := m.LoadBatchData(ctx); err != nil {
+		t.Fatalf("LoadBatchData: %v", err)
+	}
+	counts, err := m.GetAllCountData(ctx, []string{"courses", "studentInfo", "studentVle"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for collection, want := range map[string]int64{"courses": 1, "studentInfo": 3, "studentVle": 1} {
+		if counts[collection] != want {
+			t.Errorf("%s: %d documentos, se esperaban %d", collection, counts[collection], want)
+		}
+	}
+	version, err := repos.DatasetVersions.GetDatasetVersion(ctx, "test")
+	if err != nil || version != 1 {
+		t.Fatalf("versión del conjunto de datos = %d, %v; se esperaba 1", version, err)
+	}
+}
+
+func TestLoadBatchDataPseudonymizesAtLoad(t *testing.T) {
+	m, _ := newTestModel(t, config.PseudonymizationLoad)
+	ctx := context.Background()
+
+	if err := m.LoadBatchData(ctx); err != nil {
+		t.Fatalf("LoadBatchData: %v", err)
+	}
+	data, err := m.GetData(ctx, "studentInfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range studentIDs(t, data) {
+		if id == 11391 || id == 28400 || id == 30268 {
+			t.Fatalf("se guardó el id_student original %d", id)
+		}
+		pseudonym, err := m.GetStudentPseudonym(ctx, id)
+		if err != nil {
+			t.Fatalf("GetStudentPseudonym(%d): %v", id, err)
+		}
+		if m.pseudonymizer.StudentID(pseudonym.IdStudent) != id {
+			t.Errorf("el seudónimo %d no corresponde a %d", id, pseudonym.IdStudent)
+		}
+	}
+	if _, err := m.GetStudentPseudonym(ctx, 1); !errors.Is(err, entity.ErrNotFound) {
+		t.Errorf("seudónimo inexistente: %v, se esperaba ErrNotFound", err)
+	}
+}
+
+func TestLoadBatchDataCancelled(t *testing.T) {
+	m, repos := newTestModel(t, config.PseudonymizationOff)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := m.LoadBatchData(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("LoadBatchData = %v, se esperaba context.Canceled", err)
+	}
+	if version, _ := repos.DatasetVersions.GetDatasetVersion(context.Background(), "test"); version != 0 {
+		t.Errorf("una carga cancelada registró la versión %d", version)
+	}
+}
+
+func TestLoadBatchDataIntegrityCheckUnsupported(t *testing.T) {
+	m, _ := newTestModel(t, config.PseudonymizationOff)
+	viper.Set(config.IntegrityCheckAfterLoad, true)
+	ctx := context.Background()
+
+	if err := m.LoadBatchData(ctx); err != nil {
+		t.Fatalf("LoadBatchData: %v", err)
+	}
+	if _, err := m.GetIntegrityReport(ctx); !errors.Is(err, entity.ErrUnsupported) {
+		t.Errorf("GetIntegrityReport = %v, se esperaba ErrUnsupported", err)
+	}
+	if _, err := m.EraseStudent(ctx, 11391); !errors.Is(err, entity.ErrUnsupported) {
+		t.Errorf("EraseStudent = %v, se esperaba ErrUnsupported", err)
+	}
+}
+
+// erasedSubjects simula la auditoría de supresiones con los sujetos dados
+type erasedSubjects struct {
+	repository.ErasureRepository
+	subjects map[string]bool
+}
+
+func (e erasedSubjects) GetErasedSubjects(ctx context.Context, database string) (map[string]bool, error) {
+	return e.subjects, nil
+}
+
+func TestLoadBatchDataSkipsErasedStudents(t *testing.T) {
+	const auditKey = "test-erasure-audit-key-0123456789abcdef"
+	for _, mode := range []string{config.PseudonymizationOff, config.PseudonymizationLoad} {
+		t.Run(mode, func(t *testing.T) {
+			m, repos := newTestModel(t, mode)
+			viper.Set(config.ErasureAuditKey, auditKey)
+			erasedID := m.loadStudentID(11391)
+			subject := privacy.ErasureSubject([]byte(auditKey), erasedID)
+			m.repos.Erasure = erasedSubjects{ErasureRepository: repos.Erasure, subjects: map[string]bool{subject: true}}
+			ctx := context.Background()
+
+			if err := m.LoadBatchData(ctx); err != nil {
+				t.Fatalf("LoadBatchData: %v", err)
+			}
+			for _, collection := range []string{"studentInfo", "studentRegistration", "studentAssessment", "studentVle"} {
+				data, err := m.GetData(ctx, collection)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, id := range studentIDs(t, data) {
+					if id == erasedID {
+						t.Errorf("%s volvió a importar al estudiante suprimido", collection)
+					}
+				}
+			}
+			info, _ := m.GetData(ctx, "studentInfo")
+			if len(info) != 2 {
+				t.Errorf("studentInfo: %d documentos, se esperaban 2", len(info))
+			}
+		})
+	}
+}
+
+// rejectingDataset simula un validador que rechaza los documentos de una colección
+type rejectingDataset struct {
+	repository.DatasetRepository
+	collection string
+}
+
+func (d rejectingDataset) BatchInsert(ctx context.Context, database, collection string, documents []interface{}, batchSize int) error {
+	if collection == d.collection {
+		return fmt.Errorf("%d documentos rechazados por el validador de %s", len(documents), collection)
+	}
+	return d.DatasetRepository.BatchInsert(ctx, database, collection, documents, batchSize)
+}
+
+func TestLoadBatchDataRejectedRows(t *testing.T) {
+	m, repos := newTestModel(t, config.PseudonymizationOff)
+	m.repos.Dataset = rejectingDataset{DatasetRepository: repos.Dataset, collection: "studentAssessment"}
+	ctx := context.Background()
+
+	if err := m.LoadBatchData(ctx); err == nil || !strings.Contains(err.Error(), "rechazados") {
+		t.Fatalf("LoadBatchData = %v, se esperaba el error de las filas rechazadas", err)
+	}
+	if version, _ := repos.DatasetVersions.GetDatasetVersion(ctx, "test"); version != 0 {
+		t.Errorf("una carga con filas rechazadas registró la versión %d", version)
+	}
+}
+
+func TestGetOutcomes(t *testing.T) {
+	m, _ := newTestModel(t, config.PseudonymizationOff)
+	ctx := context.Background()
+	if err := m.LoadBatchData(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := m.GetOutcomes(ctx, []string{"gender"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Cached || report.DatasetVersion != 1 || len(report.Groups) != 2 {
+		t.Fatalf("informe inesperado: %+v", report)
+	}
+	female := report.Groups[0]
+	if female.Group["gender"] != "F" || female.Total != 2 || female.Counts["Pass"] != 1 || female.Counts["Withdrawn"] != 1 {
+		t.Errorf("grupo F inesperado: %+v", female)
+	}
+	if female.Percentages["Pass"] != 50 || female.Counts["Distinction"] != 0 {
+		t.Errorf("porcentajes del grupo F inesperados: %+v", female.Percentages)
+	}
+
+	cached, err := m.GetOutcomes(ctx, []string{"gender"})
+	if err != nil || !cached.Cached {
+		t.Errorf("la segunda consulta no salió de la caché: %v", err)
+	}
+}
+
+func TestGetOutcomesUnknownField(t *testing.T) {
+	m, _ := newTestModel(t, config.PseudonymizationOff)
+	_, err := m.GetOutcomes(context.Background(), []string{"id_student"})
+	if err == nil || !strings.Contains(err.Error(), "no soportado") {
+		t.Fatalf("GetOutcomes = %v, se esperaba un error de campo no soportado", err)
+	}
+}
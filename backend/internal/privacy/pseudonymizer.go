@@ -0,0 +1,184 @@
+package privacy
+
+import (
+	"backend/internal/config"
+	"backend/internal/entity"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// minKeyLength longitud mínima de la clave HMAC
+const minKeyLength = 32
+
+// StudentIDFields nombres de campo con identificadores de estudiante, tal como
+// se guardan en las colecciones de OULAD (idstudent) y en las de predicciones y
+// vistas (student_id)
+var StudentIDFields = []string{"idstudent", "student_id"}
+
+// Métricas del perfil de datos que revelan identificadores concretos
+var identifierMetrics = []string{"min", "max", "mean"}
+
+type rawIDsKey struct{}
+
+// WithRawIDs marca la petición como autorizada a recibir los id_student originales
+func WithRawIDs(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rawIDsKey{}, true)
+}
+
+// RawIDsAllowed indica si la petición puede recibir los id_student originales
+func RawIDsAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(rawIDsKey{}).(bool)
+	return allowed
+}
+
+// Pseudonymizer sustituye los id_student por un seudónimo estable derivado con
+// HMAC-SHA256. El seudónimo se trunca a 53 bits para que siga siendo un entero
+// representable en JSON y compatible con el esquema de las colecciones
+type Pseudonymizer struct {
+	key  []byte
+	mode string
+}
+
+// NewPseudonymizer valida el modo (off, load o export) y la clave
+func NewPseudonymizer(key, mode string) (*Pseudonymizer, error) {
+	switch mode {
+	case config.PseudonymizationOff:
+		return &Pseudonymizer{mode: mode}, nil
+	case config.PseudonymizationLoad, config.PseudonymizationExport:
+	default:
+		return nil, fmt.Errorf("modo de seudonimización no válido: %s", mode)
+	}
+	if len(key) < minKeyLength {
+		return nil, fmt.Errorf("la clave de seudonimización debe tener al menos %d caracteres", minKeyLength)
+	}
+	if config.PlaceholderSecret(key) {
+		return nil, fmt.Errorf("la clave de seudonimización es un valor de ejemplo; define PSEUDONYMIZATION_KEY")
+	}
+	return &Pseudonymizer{key: []byte(key), mode: mode}, nil
+}
+
+// Mode devuelve el modo configurado
+func (p *Pseudonymizer) Mode() string {
+	return p.mode
+}
+
+// Enabled indica si se generan seudónimos
+func (p *Pseudonymizer) Enabled() bool {
+	return p.mode != config.PseudonymizationOff
+}
+
+// AtLoad indica si los identificadores se seudonimizan antes de guardarlos
+func (p *Pseudonymizer) AtLoad() bool {
+	return p.mode == config.PseudonymizationLoad
+}
+
+// AtExport indica si hay que seudonimizar los identificadores de la respuesta
+func (p *Pseudonymizer) AtExport(ctx context.Context) bool {
+	return p.mode == config.PseudonymizationExport && !RawIDsAllowed(ctx)
+}
+
+// StudentID devuelve el seudónimo del estudiante; sin seudonimización devuelve el id
+func (p *Pseudonymizer) StudentID(id int) int {
+	if !p.Enabled() {
+		return id
+	}
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(strconv.Itoa(id)))
+	return int(binary.BigEndian.Uint64(mac.Sum(nil)[:8]) >> 11)
+}
+
+// ErasureSubject referencia al estudiante en la auditoría de supresiones. Es un
+// HMAC y no un hash simple: los id_student de OULAD son enteros pequeños y un
+// hash sin clave se invierte probando todos los valores
+func ErasureSubject(key []byte, id int) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("student:" + strconv.Itoa(id)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Document seudonimiza en el propio documento los campos de StudentIDFields,
+// recorriendo los subdocumentos y arrays
+func (p *Pseudonymizer) Document(document interface{}) {
+	switch doc := document.(type) {
+	case bson.D:
+		for i := range doc {
+			doc[i].Value = p.field(doc[i].Key, doc[i].Value)
+		}
+	case bson.M:
+		for key, value := range doc {
+			doc[key] = p.field(key, value)
+		}
+	case map[string]interface{}:
+		for key, value := range doc {
+			doc[key] = p.field(key, value)
+		}
+	case bson.A:
+		for _, value := range doc {
+			p.Document(value)
+		}
+	case []interface{}:
+		for _, value := range doc {
+			p.Document(value)
+		}
+	}
+}
+
+func (p *Pseudonymizer) field(key string, value interface{}) interface{} {
+	if !slices.Contains(StudentIDFields, key) {
+		p.Document(value)
+		return value
+	}
+	switch id := value.(type) {
+	case int32:
+		return int64(p.StudentID(int(id)))
+	case int64:
+		return int64(p.StudentID(int(id)))
+	case int:
+		return p.StudentID(id)
+	case float64:
+		return int64(p.StudentID(int(id)))
+	}
+	return value
+}
+
+// Profile seudonimiza los valores frecuentes de los campos de identificador y
+// descarta su rango e histograma, que contienen identificadores originales
+func (p *Pseudonymizer) Profile(profile *entity.DataProfile) {
+	for _, collection := range profile.Collections {
+		for i := range collection.Fields {
+			field := &collection.Fields[i]
+			if !slices.Contains(StudentIDFields, field.Field) {
+				continue
+			}
+			field.Min, field.Max, field.Mean, field.Histogram = nil, nil, nil, nil
+			for j := range field.TopValues {
+				field.TopValues[j].Value = p.field(field.Field, field.TopValues[j].Value)
+			}
+		}
+	}
+}
+
+// ProfileDiff descarta los cambios de rango de los campos de identificador
+func (p *Pseudonymizer) ProfileDiff(diff *entity.DataProfileDiff) {
+	diff.Changes = slices.DeleteFunc(diff.Changes, func(change entity.ProfileChange) bool {
+		return slices.Contains(StudentIDFields, change.Field) && slices.Contains(identifierMetrics, change.Metric)
+	})
+}
+
+// IntegrityReport seudonimiza las claves y documentos de muestra del informe
+func (p *Pseudonymizer) IntegrityReport(report *entity.IntegrityReport) {
+	for _, check := range report.Checks {
+		for _, sample := range check.Samples {
+			p.Document(sample.Key)
+			p.Document(sample.Document)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package privacy
+
+import (
+	"backend/internal/config"
+	"backend/internal/entity"
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const testKey = "test-pseudonymization-key-0123456789"
+
+func newTestPseudonymizer(t *testing.T, key, mode string) *Pseudonymizer {
+	t.Helper()
+	p, err := NewPseudonymizer(key, mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestNewPseudonymizer(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		mode    string
+		wantErr bool
+	}{
+		{"desactivado sin clave", "", config.PseudonymizationOff, false},
+		{"al cargar", testKey, config.PseudonymizationLoad, false},
+		{"al exportar", testKey, config.PseudonymizationExport, false},
+		{"modo no válido", testKey, "always", true},
+		{"clave corta", "short", config.PseudonymizationLoad, true},
+		{"clave de ejemplo", "change-me-pseudonymization-key-0123456789", config.PseudonymizationExport, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPseudonymizer(tt.key, tt.mode)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewPseudonymizer() error = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStudentID(t *testing.T) {
+	p := newTestPseudonymizer(t, testKey, config.PseudonymizationLoad)
+	other := newTestPseudonymizer(t, "another-pseudonymization-key-0123456789", config.PseudonymizationLoad)
+
+	id := p.StudentID(11391)
+	if id != p.StudentID(11391) {
+		t.Error("el seudónimo debería ser estable")
+	}
+	if id == 11391 || id == p.StudentID(28400) || id == other.StudentID(11391) {
+		t.Errorf("seudónimo %d no depende del id y de la clave", id)
+	}
+	// 53 bits: representable sin pérdida como número de JSON
+	if id < 0 || id >= 1<<53 {
+		t.Errorf("seudónimo fuera de rango: %d", id)
+	}
+	if off := newTestPseudonymizer(t, "", config.PseudonymizationOff); off.StudentID(11391) != 11391 {
+		t.Error("sin seudonimización el id no debería cambiar")
+	}
+}
+
+func TestErasureSubject(t *testing.T) {
+	key := []byte(testKey)
+	subject := ErasureSubject(key, 11391)
+	tests := []struct {
+		name  string
+		other string
+	}{
+		{"otro estudiante", ErasureSubject(key, 28400)},
+		{"otra clave", ErasureSubject([]byte("another-erasure-key-0123456789abcdef"), 11391)},
+		{"seudónimo", ErasureSubject(key, newTestPseudonymizer(t, testKey, config.PseudonymizationLoad).StudentID(11391))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.other == subject {
+				t.Errorf("ErasureSubject debería diferir: %s", subject)
+			}
+		})
+	}
+	if subject != ErasureSubject(key, 11391) || len(subject) != 64 {
+		t.Errorf("ErasureSubject = %s", subject)
+	}
+}
+
+func TestAtExport(t *testing.T) {
+	tests := []struct {
+		mode string
+		ctx  context.Context
+		want bool
+	}{
+		{config.PseudonymizationExport, context.Background(), true},
+		{config.PseudonymizationExport, WithRawIDs(context.Background()), false},
+		{config.PseudonymizationLoad, context.Background(), false},
+	}
+	for _, tt := range tests {
+		if got := newTestPseudonymizer(t, testKey, tt.mode).AtExport(tt.ctx); got != tt.want {
+			t.Errorf("AtExport en modo %s = %v, se esperaba %v", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestDocument(t *testing.T) {
+	p := newTestPseudonymizer(t, testKey, config.PseudonymizationExport)
+	want := int64(p.StudentID(11391))
+	document := bson.M{
+		"idstudent": int32(11391),
+		"score":     int32(11391),
+		"nested":    bson.D{{Key: "student_id", Value: 11391.0}},
+		"rows":      bson.A{bson.M{"student_id": int64(11391)}},
+	}
+	p.Document(document)
+
+	if document["idstudent"] != want || document["score"] != int32(11391) {
+		t.Errorf("documento = %v", document)
+	}
+	if nested := document["nested"].(bson.D); nested[0].Value != want {
+		t.Errorf("subdocumento = %v", nested)
+	}
+	if row := document["rows"].(bson.A)[0].(bson.M); row["student_id"] != want {
+		t.Errorf("array = %v", row)
+	}
+}
+
+func TestProfile(t *testing.T) {
+	p := newTestPseudonymizer(t, testKey, config.PseudonymizationExport)
+	value := 11391.0
+	profile := &entity.DataProfile{Collections: []entity.CollectionProfile{{Collection: "studentInfo", Fields: []entity.FieldProfile{
+		{Field: "idstudent", Min: &value, Max: &value, Mean: &value, Histogram: []entity.ProfileBin{{Min: value, Max: value, Count: 1}},
+			TopValues: []entity.ValueCount{{Value: int32(11391), Count: 1}}},
+		{Field: "score", Min: &value, TopValues: []entity.ValueCount{{Value: int32(11391), Count: 1}}},
+	}}}}
+	p.Profile(profile)
+
+	fields := profile.Collections[0].Fields
+	if fields[0].Min != nil || fields[0].Max != nil || fields[0].Mean != nil || fields[0].Histogram != nil {
+		t.Errorf("el campo de identificador conserva su rango: %+v", fields[0])
+	}
+	if fields[0].TopValues[0].Value != int64(p.StudentID(11391)) {
+		t.Errorf("valor frecuente sin seudonimizar: %v", fields[0].TopValues[0].Value)
+	}
+	if fields[1].Min == nil || fields[1].TopValues[0].Value != int32(11391) {
+		t.Errorf("el resto de campos no debería cambiar: %+v", fields[1])
+	}
+
+	diff := &entity.DataProfileDiff{Changes: []entity.ProfileChange{
+		{Field: "idstudent", Metric: "max"},
+		{Field: "idstudent", Metric: "distinct"},
+		{Field: "score", Metric: "max"},
+	}}
+	p.ProfileDiff(diff)
+	if len(diff.Changes) != 2 || diff.Changes[0].Metric != "distinct" || diff.Changes[1].Field != "score" {
+		t.Errorf("cambios = %+v", diff.Changes)
+	}
+}
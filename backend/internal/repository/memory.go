@@ -0,0 +1,428 @@
+package repository
+
+import (
+	"backend/internal/entity"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// memoryStore implementa todos los repositorios en memoria, separados por base
+// de datos; pensado para pruebas sin una instancia de MongoDB
+type memoryStore struct {
+	mu             sync.RWMutex
+	collections    map[string]map[string][]bson.M
+	versions       map[string]entity.DatasetVersion
+	runs           map[string]map[string]entity.PredictionRun
+	activeRuns     map[string]map[string]string
+	scoringConfigs map[string]map[string]entity.VleScoringConfig
+	cache          map[string]map[string]memoryCacheEntry
+	pseudonyms     map[string]map[int]entity.StudentPseudonym
+	users          map[string]map[string]entity.User
+	refreshTokens  map[string]map[string]entity.RefreshToken
+}
+
+type memoryCacheEntry struct {
+	version int
+	data    []byte
+}
+
+// NewMemoryRepositories crea repositorios vacíos que guardan los datos en memoria.
+// Las consultas de predicción, analítica, calidad de datos, migraciones y
+// supresión devuelven entity.ErrUnsupported; las pruebas que las necesiten
+// asignan su propio doble
+func NewMemoryRepositories() Repositories {
+	store := &memoryStore{
+		collections:    make(map[string]map[string][]bson.M),
+		versions:       make(map[string]entity.DatasetVersion),
+		runs:           make(map[string]map[string]entity.PredictionRun),
+		activeRuns:     make(map[string]map[string]string),
+		scoringConfigs: make(map[string]map[string]entity.VleScoringConfig),
+		cache:          make(map[string]map[string]memoryCacheEntry),
+		pseudonyms:     make(map[string]map[int]entity.StudentPseudonym),
+		users:          make(map[string]map[string]entity.User),
+		refreshTokens:  make(map[string]map[string]entity.RefreshToken),
+	}
+	unsupported := unsupportedStore{backend: "repositorio en memoria"}
+	return Repositories{
+		Dataset:          store,
+		DatasetVersions:  store,
+		PredictionRuns:   store,
+		VleScoringConfig: store,
+		AnalyticsCache:   store,
+		Pseudonyms:       store,
+		Users:            store,
+		Views:            store,
+		Predictions:      unsupported,
+		Analytics:        unsupported,
+		DataQuality:      unsupported,
+		Migrations:       unsupported,
+		Erasure:          unsupported,
+	}
+}
+
+// toDocument convierte un documento al formato almacenado (mismos nombres de
+// campo que en MongoDB)
+func toDocument(document interface{}) (bson.M, error) {
+	raw, err := bson.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("error al codificar el documento: %w", err)
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("error al decodificar el documento: %w", err)
+	}
+	return doc, nil
+}
+
+// BatchInsert inserta todos los documentos; el tamaño de lote no aplica en memoria
+func (s *memoryStore) BatchInsert(ctx context.Context, database, collection string, documents []interface{}, batchSize int) error {
+	docs := make([]bson.M, 0, len(documents))
+	for _, document := range documents {
+		doc, err := toDocument(document)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, doc)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.collections[database] == nil {
+		s.collections[database] = make(map[string][]bson.M)
+	}
+	s.collections[database][collection] = append(s.collections[database][collection], docs...)
+	return nil
+}
+
+func (s *memoryStore) GetData(ctx context.Context, database, collection string) ([]interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []interface{}
+	for _, doc := range s.collections[database][collection] {
+		copied := make(bson.M, len(doc))
+		for field, value := range doc {
+			copied[field] = value
+		}
+		results = append(results, copied)
+	}
+	return results, nil
+}
+
+func (s *memoryStore) GetAllCountData(ctx context.Context, database string, colls []string) (map[string]int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data := make(map[string]int64, len(colls))
+	for _, coll := range colls {
+		data[coll] = int64(len(s.collections[database][coll]))
+	}
+	return data, nil
+}
+
+func (s *memoryStore) CountBy(ctx context.Context, database, collection string, fields []string, filter Filter) ([]GroupCount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	groups := make(map[string]*GroupCount)
+	for _, doc := range s.collections[database][collection] {
+		if !matchesFilter(doc, filter) {
+			continue
+		}
+		key := make(map[string]interface{}, len(fields))
+		parts := make([]string, len(fields))
+		for i, field := range fields {
+			// Igual que $group, los campos ausentes no forman parte de la clave
+			if value, ok := doc[field]; ok && value != nil {
+				key[field] = value
+			}
+			parts[i] = fmt.Sprintf("%v", key[field])
+		}
+		groupKey := strings.Join(parts, "\x00")
+		if group, ok := groups[groupKey]; ok {
+			group.Count++
+			continue
+		}
+		groups[groupKey] = &GroupCount{Key: key, Count: 1}
+	}
+
+	results := make([]GroupCount, 0, len(groups))
+	for _, group := range groups {
+		results = append(results, *group)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		for _, field := range fields {
+			if order := compareValues(results[i].Key[field], results[j].Key[field]); order != 0 {
+				return order < 0
+			}
+		}
+		return false
+	})
+	return results, nil
+}
+
+// matchesFilter comprueba la igualdad de cada campo del filtro
+func matchesFilter(doc bson.M, filter Filter) bool {
+	for field, expected := range filter {
+		if compareValues(doc[field], expected) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// compareValues ordena como MongoDB los tipos habituales: ausentes primero,
+// después números (sin distinguir int32, int64 y float64) y luego cadenas
+func compareValues(a, b interface{}) int {
+	rankA, numberA, textA := valueOrder(a)
+	rankB, numberB, textB := valueOrder(b)
+	switch {
+	case rankA != rankB:
+		return rankA - rankB
+	case numberA < numberB:
+		return -1
+	case numberA > numberB:
+		return 1
+	default:
+		return strings.Compare(textA, textB)
+	}
+}
+
+func valueOrder(value interface{}) (int, float64, string) {
+	switch v := value.(type) {
+	case nil:
+		return 0, 0, ""
+	case int:
+		return 1, float64(v), ""
+	case int32:
+		return 1, float64(v), ""
+	case int64:
+		return 1, float64(v), ""
+	case float64:
+		return 1, v, ""
+	case string:
+		return 2, 0, v
+	case bool:
+		if v {
+			return 3, 1, ""
+		}
+		return 3, 0, ""
+	default:
+		return 4, 0, fmt.Sprintf("%v", v)
+	}
+}
+
+func (s *memoryStore) RegisterDatasetLoad(ctx context.Context, database string) (*entity.DatasetVersion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	version := s.versions[database]
+	version.Version++
+	version.LoadedAt = time.Now()
+	s.versions[database] = version
+	// Los resultados en caché de versiones anteriores ya no son válidos
+	for key, entry := range s.cache[database] {
+		if entry.version < version.Version {
+			delete(s.cache[database], key)
+		}
+	}
+	return &version, nil
+}
+
+func (s *memoryStore) GetDatasetVersion(ctx context.Context, database string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.versions[database].Version, nil
+}
+
+func (s *memoryStore) SavePredictionRun(ctx context.Context, database string, run entity.PredictionRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.runs[database] == nil {
+		s.runs[database] = make(map[string]entity.PredictionRun)
+	}
+	run.Active = false
+	s.runs[database][run.RunID] = run
+	return nil
+}
+
+func (s *memoryStore) GetPredictionRuns(ctx context.Context, database, kind string) ([]entity.PredictionRun, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var runs []entity.PredictionRun
+	for _, run := range s.runs[database] {
+		if kind != "" && run.Kind != kind {
+			continue
+		}
+		run.Active = run.RunID == s.activeRuns[database][run.Kind]
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].StartedAt.After(runs[j].StartedAt) })
+	return runs, nil
+}
+
+func (s *memoryStore) ActivatePredictionRun(ctx context.Context, database, runID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	run, ok := s.runs[database][runID]
+	if !ok {
+		return fmt.Errorf("ejecución de predicción no encontrada %s", runID)
+	}
+	if run.Status != RunStatusCompleted {
+		return fmt.Errorf("la ejecución %s no está completada (%s)", runID, run.Status)
+	}
+	if s.activeRuns[database] == nil {
+		s.activeRuns[database] = make(map[string]string)
+	}
+	s.activeRuns[database][run.Kind] = run.RunID
+	return nil
+}
+
+func (s *memoryStore) GetVleScoringConfigs(ctx context.Context, database string) ([]entity.VleScoringConfig, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	configs := map[string]entity.VleScoringConfig{DefaultVleScoringModule: DefaultVleScoringConfig()}
+	for module, cfg := range s.scoringConfigs[database] {
+		configs[module] = cfg
+	}
+	var results []entity.VleScoringConfig
+	for _, cfg := range configs {
+		results = append(results, cfg)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].CodeModule < results[j].CodeModule })
+	return results, nil
+}
+
+func (s *memoryStore) SaveVleScoringConfig(ctx context.Context, database string, scoringConfig entity.VleScoringConfig) error {
+	if err := ValidateVleScoringConfig(&scoringConfig); err != nil {
+		return err
+	}
+	scoringConfig.UpdatedAt = time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.scoringConfigs[database] == nil {
+		s.scoringConfigs[database] = make(map[string]entity.VleScoringConfig)
+	}
+	s.scoringConfigs[database][scoringConfig.CodeModule] = scoringConfig
+	return nil
+}
+
+func (s *memoryStore) ReadAnalyticsCache(ctx context.Context, database, key string, version int, out interface{}) (bool, error) {
+	s.mu.RLock()
+	entry, ok := s.cache[database][key]
+	s.mu.RUnlock()
+	if !ok || entry.version != version {
+		return false, nil
+	}
+	if err := bson.Unmarshal(entry.data, out); err != nil {
+		return false, fmt.Errorf("error al decodificar la caché %s: %w", key, err)
+	}
+	return true, nil
+}
+
+func (s *memoryStore) WriteAnalyticsCache(ctx context.Context, database, key string, version int, data interface{}) error {
+	raw, err := bson.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("error al guardar la caché %s: %w", key, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cache[database] == nil {
+		s.cache[database] = make(map[string]memoryCacheEntry)
+	}
+	s.cache[database][key] = memoryCacheEntry{version: version, data: raw}
+	return nil
+}
+
+func (s *memoryStore) SaveStudentPseudonyms(ctx context.Context, database string, pseudonyms []entity.StudentPseudonym) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pseudonyms[database] == nil {
+		s.pseudonyms[database] = make(map[int]entity.StudentPseudonym)
+	}
+	for _, pseudonym := range pseudonyms {
+		s.pseudonyms[database][pseudonym.Pseudonym] = pseudonym
+	}
+	return nil
+}
+
+func (s *memoryStore) GetStudentPseudonym(ctx context.Context, database string, pseudonym int) (*entity.StudentPseudonym, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result, ok := s.pseudonyms[database][pseudonym]
+	if !ok {
+		return nil, fmt.Errorf("seudónimo %w: %d", entity.ErrNotFound, pseudonym)
+	}
+	return &result, nil
+}
+
+func (s *memoryStore) GetUser(ctx context.Context, database, username string) (*entity.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	user, ok := s.users[database][username]
+	if !ok {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+func (s *memoryStore) SaveUser(ctx context.Context, database string, user entity.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.users[database] == nil {
+		s.users[database] = make(map[string]entity.User)
+	}
+	s.users[database][user.Username] = user
+	return nil
+}
+
+func (s *memoryStore) SaveRefreshToken(ctx context.Context, database string, token entity.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.refreshTokens[database] == nil {
+		s.refreshTokens[database] = make(map[string]entity.RefreshToken)
+	}
+	s.refreshTokens[database][token.Hash] = token
+	return nil
+}
+
+func (s *memoryStore) ConsumeRefreshToken(ctx context.Context, database, hash string) (*entity.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.refreshTokens[database][hash]
+	if !ok {
+		return nil, nil
+	}
+	delete(s.refreshTokens[database], hash)
+	return &token, nil
+}
+
+// RefreshMaterializedViews no hace nada: en memoria no hay vistas materializadas
+// y los gráficos se calculan sobre las colecciones
+func (s *memoryStore) RefreshMaterializedViews(ctx context.Context, database, name string) ([]entity.ViewMetadata, error) {
+	if name != "" {
+		return nil, fmt.Errorf("vista %w: %s", entity.ErrNotFound, name)
+	}
+	return []entity.ViewMetadata{}, nil
+}
+
+func (s *memoryStore) GetMaterializedViews(ctx context.Context, database string) ([]entity.ViewMetadata, error) {
+	return []entity.ViewMetadata{}, nil
+}
+
+func (s *memoryStore) GetViewMetadata(ctx context.Context, database, name string) (*entity.ViewMetadata, error) {
+	return nil, fmt.Errorf("vista %w: %s", entity.ErrNotFound, name)
+}
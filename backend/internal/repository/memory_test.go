@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"backend/internal/entity"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testDB = "test"
+
+func TestMemoryCountBy(t *testing.T) {
+	repos := NewMemoryRepositories()
+	ctx := context.Background()
+	documents := []interface{}{
+		entity.StudentInfo{CodeModule: "AAA", CodePresentation: "2013J", IdStudent: 1, FinalResult: "Pass"},
+		entity.StudentInfo{CodeModule: "AAA", CodePresentation: "2013J", IdStudent: 2, FinalResult: "Fail"},
+		entity.StudentInfo{CodeModule: "AAA", CodePresentation: "2014J", IdStudent: 3, FinalResult: "Pass"},
+		entity.StudentInfo{CodeModule: "BBB", CodePresentation: "2013J", IdStudent: 4, FinalResult: "Pass"},
+	}
+	if err := repos.Dataset.BatchInsert(ctx, testDB, "studentInfo", documents, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		fields []string
+		filter Filter
+		want   []GroupCount
+	}{
+		{"por resultado", []string{"finalresult"}, nil, []GroupCount{
+			{Key: map[string]interface{}{"finalresult": "Fail"}, Count: 1},
+			{Key: map[string]interface{}{"finalresult": "Pass"}, Count: 3},
+		}},
+		{"con filtro", []string{"codepresentation"}, Filter{"codemodule": "AAA"}, []GroupCount{
+			{Key: map[string]interface{}{"codepresentation": "2013J"}, Count: 2},
+			{Key: map[string]interface{}{"codepresentation": "2014J"}, Count: 1},
+		}},
+		{"filtro numérico sin distinguir tipos", []string{"codemodule"}, Filter{"idstudent": 4.0}, []GroupCount{
+			{Key: map[string]interface{}{"codemodule": "BBB"}, Count: 1},
+		}},
+		{"campo ausente", []string{"missing"}, Filter{"finalresult": "Pass"}, []GroupCount{
+			{Key: map[string]interface{}{}, Count: 3},
+		}},
+		{"sin coincidencias", []string{"codemodule"}, Filter{"codemodule": "CCC"}, []GroupCount{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repos.Dataset.CountBy(ctx, testDB, "studentInfo", tt.fields, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CountBy = %+v, se esperaba %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareValues(t *testing.T) {
+	tests := []struct {
+		a, b interface{}
+		want int
+	}{
+		{nil, int32(0), -1},
+		{int32(2), int64(2), 0},
+		{int64(2), 2.5, -1},
+		{3, "1", -1},
+		{"b", "a", 1},
+		{"z", true, -1},
+	}
+	for _, tt := range tests {
+		if got := compareValues(tt.a, tt.b); (got > 0) != (tt.want > 0) || (got < 0) != (tt.want < 0) {
+			t.Errorf("compareValues(%#v, %#v) = %d, se esperaba %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMemoryPredictionRuns(t *testing.T) {
+	repos := NewMemoryRepositories()
+	ctx := context.Background()
+	now := time.Now()
+	runs := []entity.PredictionRun{
+		{RunID: "old", Kind: "vle", Status: RunStatusCompleted, StartedAt: now.Add(-time.Hour)},
+		{RunID: "new", Kind: "vle", Status: RunStatusCompleted, StartedAt: now},
+		{RunID: "failed", Kind: "vle", Status: RunStatusFailed, StartedAt: now.Add(-time.Minute)},
+		{RunID: "other", Kind: "assessments", Status: RunStatusCompleted, StartedAt: now},
+	}
+	for _, run := range runs {
+		if err := repos.PredictionRuns.SavePredictionRun(ctx, testDB, run); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repos.PredictionRuns.ActivatePredictionRun(ctx, testDB, "old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.PredictionRuns.ActivatePredictionRun(ctx, testDB, "failed"); err == nil {
+		t.Error("no debería activarse una ejecución fallida")
+	}
+	if err := repos.PredictionRuns.ActivatePredictionRun(ctx, testDB, "missing"); err == nil {
+		t.Error("no debería activarse una ejecución inexistente")
+	}
+
+	got, err := repos.PredictionRuns.GetPredictionRuns(ctx, testDB, "vle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	var active []string
+	for _, run := range got {
+		ids = append(ids, run.RunID)
+		if run.Active {
+			active = append(active, run.RunID)
+		}
+	}
+	if !reflect.DeepEqual(ids, []string{"new", "failed", "old"}) || !reflect.DeepEqual(active, []string{"old"}) {
+		t.Errorf("ejecuciones %v, activas %v", ids, active)
+	}
+}
+
+func TestMemoryAnalyticsCache(t *testing.T) {
+	repos := NewMemoryRepositories()
+	ctx := context.Background()
+	version, err := repos.DatasetVersions.RegisterDatasetLoad(ctx, testDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type report struct {
+		Value int `bson:"value"`
+	}
+	if err := repos.AnalyticsCache.WriteAnalyticsCache(ctx, testDB, "key", version.Version, report{Value: 7}); err != nil {
+		t.Fatal(err)
+	}
+
+	var cached report
+	if found, err := repos.AnalyticsCache.ReadAnalyticsCache(ctx, testDB, "key", version.Version, &cached); err != nil || !found || cached.Value != 7 {
+		t.Fatalf("lectura de la caché: found = %v, valor = %d, err = %v", found, cached.Value, err)
+	}
+	// Una nueva carga invalida la caché de la versión anterior
+	if _, err := repos.DatasetVersions.RegisterDatasetLoad(ctx, testDB); err != nil {
+		t.Fatal(err)
+	}
+	if found, _ := repos.AnalyticsCache.ReadAnalyticsCache(ctx, testDB, "key", version.Version, &cached); found {
+		t.Error("la caché de una versión anterior debería descartarse")
+	}
+}
+
+func TestMemoryUnsupported(t *testing.T) {
+	repos := NewMemoryRepositories()
+	ctx := context.Background()
+	if _, err := repos.DataQuality.RunIntegrityCheck(ctx, testDB); !errors.Is(err, entity.ErrUnsupported) {
+		t.Errorf("RunIntegrityCheck() error = %v, se esperaba ErrUnsupported", err)
+	}
+	if _, err := repos.Pseudonyms.GetStudentPseudonym(ctx, testDB, 1); !errors.Is(err, entity.ErrNotFound) {
+		t.Errorf("GetStudentPseudonym() error = %v, se esperaba ErrNotFound", err)
+	}
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"backend/internal/entity"
+	"context"
+)
+
+// Filter restringe los documentos por igualdad de campo, usando los nombres de
+// campo almacenados (por ejemplo "codemodule")
+type Filter map[string]interface{}
+
+// GroupCount es el número de documentos de un grupo, indexado por los campos de agrupación
+type GroupCount struct {
+	Key   map[string]interface{}
+	Count int
+}
+
+// DatasetRepository persiste las colecciones de OULAD cargadas desde los CSV
+type DatasetRepository interface {
+	// BatchInsert devuelve error si algún documento no se guarda, incluidos los
+	// rechazados por la validación de esquema
+	BatchInsert(ctx context.Context, database, collection string, documents []interface{}, batchSize int) error
+	GetData(ctx context.Context, database, collection string) ([]interface{}, error)
+	GetAllCountData(ctx context.Context, database string, colls []string) (map[string]int64, error)
+	CountBy(ctx context.Context, database, collection string, fields []string, filter Filter) ([]GroupCount, error)
+}
+
+// DatasetVersionRepository registra cada carga del conjunto de datos
+type DatasetVersionRepository interface {
+	RegisterDatasetLoad(ctx context.Context, database string) (*entity.DatasetVersion, error)
+	GetDatasetVersion(ctx context.Context, database string) (int, error)
+}
+
+// PredictionRunRepository gestiona el historial de ejecuciones de predicción y la activa de cada tipo
+type PredictionRunRepository interface {
+	SavePredictionRun(ctx context.Context, database string, run entity.PredictionRun) error
+	GetPredictionRuns(ctx context.Context, database, kind string) ([]entity.PredictionRun, error)
+	ActivatePredictionRun(ctx context.Context, database, runID string) error
+}
+
+// VleScoringConfigRepository persiste la configuración de puntuación VLE por módulo
+type VleScoringConfigRepository interface {
+	GetVleScoringConfigs(ctx context.Context, database string) ([]entity.VleScoringConfig, error)
+	SaveVleScoringConfig(ctx context.Context, database string, scoringConfig entity.VleScoringConfig) error
+}
+
+// AnalyticsCacheRepository guarda resultados de analítica válidos para una versión del conjunto de datos
+type AnalyticsCacheRepository interface {
+	ReadAnalyticsCache(ctx context.Context, database, key string, version int, out interface{}) (bool, error)
+	WriteAnalyticsCache(ctx context.Context, database, key string, version int, data interface{}) error
+}
+
+// PseudonymRepository guarda la correspondencia entre seudónimos e id_student
+// para la reidentificación
+type PseudonymRepository interface {
+	SaveStudentPseudonyms(ctx context.Context, database string, pseudonyms []entity.StudentPseudonym) error
+	GetStudentPseudonym(ctx context.Context, database string, pseudonym int) (*entity.StudentPseudonym, error)
+}
+
+// UserRepository guarda los usuarios de la API y sus tokens de refresco. GetUser y
+// ConsumeRefreshToken devuelven nil sin error cuando no existen
+type UserRepository interface {
+	GetUser(ctx context.Context, database, username string) (*entity.User, error)
+	SaveUser(ctx context.Context, database string, user entity.User) error
+	SaveRefreshToken(ctx context.Context, database string, token entity.RefreshToken) error
+	ConsumeRefreshToken(ctx context.Context, database, hash string) (*entity.RefreshToken, error)
+}
+
+// PredictionRepository calcula y guarda las predicciones y evalúa los modelos
+type PredictionRepository interface {
+	ProcessDataPredictionAssessments(ctx context.Context, database string) ([]entity.ProcessedPredictionAssessmentResult, error)
+	ProcessDataVlePredictions(ctx context.Context, database string) ([]entity.ProcessedPredictionVleResult, error)
+	EvaluateModel(ctx context.Context, database, modelID string, request entity.ModelEvaluationRequest) (*entity.ModelEvaluation, error)
+	ExplainStudentPredictions(ctx context.Context, database string, query entity.ExplanationQuery) ([]entity.PredictionExplanation, error)
+}
+
+// AnalyticsRepository consultas de analítica sobre los datos cargados y la
+// ejecución de predicción activa
+type AnalyticsRepository interface {
+	GetScoreDistributionPredictionAssessments(ctx context.Context, database string, query entity.ScoreDistributionQuery) (*entity.ScoreDistribution, error)
+	GetAveragePredictedScoreByAssessmentType(ctx context.Context, database string) ([]entity.AssessmentTypeAverage, error)
+	GetStudentCountByAssessmentID(ctx context.Context, database string) ([]entity.AssessmentStudentCount, error)
+	GetActualVsPredicted(ctx context.Context, database string, query entity.ActualVsPredictedQuery) (*entity.ActualVsPredictedReport, error)
+	GetFairnessReport(ctx context.Context, database string, query entity.FairnessQuery) (*entity.FairnessReport, error)
+	GetEngagementSeries(ctx context.Context, database string, query entity.EngagementQuery) (*entity.EngagementSeries, error)
+	GetItemAnalysis(ctx context.Context, database string, query entity.ItemAnalysisQuery) (*entity.ItemAnalysisReport, error)
+	GetWithdrawalSurvival(ctx context.Context, database string, query entity.SurvivalQuery) (*entity.SurvivalReport, error)
+	GetFeatureCorrelations(ctx context.Context, database string, query entity.CorrelationQuery) (*entity.CorrelationReport, error)
+	ClusterStudents(ctx context.Context, database string, request entity.ClusteringRequest) (*entity.ClusteringResult, error)
+	GetStudentClusters(ctx context.Context, database, codeModule, codePresentation string) (*entity.ClusteringResult, error)
+}
+
+// DataQualityRepository comprobaciones de esquema, integridad y perfilado de los datos cargados
+type DataQualityRepository interface {
+	GetSchemaViolations(ctx context.Context, database string, query entity.SchemaViolationQuery) (*entity.SchemaViolationReport, error)
+	RunIntegrityCheck(ctx context.Context, database string) (*entity.IntegrityReport, error)
+	GetIntegrityReport(ctx context.Context, database string) (*entity.IntegrityReport, error)
+	ProfileDataset(ctx context.Context, database string) (*entity.DataProfile, error)
+	GetDataProfile(ctx context.Context, database string, version int) (*entity.DataProfile, error)
+	DiffDataProfiles(ctx context.Context, database string, from, to int) (*entity.DataProfileDiff, error)
+}
+
+// ViewRepository mantiene las vistas materializadas de los gráficos
+type ViewRepository interface {
+	RefreshMaterializedViews(ctx context.Context, database, name string) ([]entity.ViewMetadata, error)
+	GetMaterializedViews(ctx context.Context, database string) ([]entity.ViewMetadata, error)
+	GetViewMetadata(ctx context.Context, database, name string) (*entity.ViewMetadata, error)
+}
+
+// MigrationRepository aplica y consulta las migraciones de esquema
+type MigrationRepository interface {
+	GetMigrationStatus(ctx context.Context, database string) ([]entity.MigrationStatus, error)
+	Migrate(ctx context.Context, database string, request entity.MigrationRequest) (*entity.MigrationPlan, error)
+}
+
+// ErasureRepository suprime los datos de un estudiante y guarda la auditoría.
+// GetErasedSubjects devuelve los sujetos de la auditoría, con los que la carga
+// descarta a los estudiantes suprimidos
+type ErasureRepository interface {
+	EraseStudent(ctx context.Context, database string, request entity.ErasureRequest) (*entity.ErasureAuditEntry, error)
+	GetErasureAudit(ctx context.Context, database string) (*entity.ErasureAuditLog, error)
+	GetErasedSubjects(ctx context.Context, database string) (map[string]bool, error)
+}
+
+// Repositories agrupa los repositorios de persistencia que usa el modelo
+type Repositories struct {
+	Dataset          DatasetRepository
+	DatasetVersions  DatasetVersionRepository
+	PredictionRuns   PredictionRunRepository
+	VleScoringConfig VleScoringConfigRepository
+	AnalyticsCache   AnalyticsCacheRepository
+	Pseudonyms       PseudonymRepository
+	Users            UserRepository
+	Predictions      PredictionRepository
+	Analytics        AnalyticsRepository
+	DataQuality      DataQualityRepository
+	Views            ViewRepository
+	Migrations       MigrationRepository
+	Erasure          ErasureRepository
+}
+
+// DataCollections colecciones que se pueden descargar con GetData: las de OULAD
+// y las predicciones. Las internas (usuarios, tokens, seudónimos, auditoría)
+// nunca se exponen
+var DataCollections = map[string]bool{
+	"courses":                true,
+	"assessments":            true,
+	"vle":                    true,
+	"studentInfo":            true,
+	"studentRegistration":    true,
+	"studentAssessment":      true,
+	"studentVle":             true,
+	"prediction_assessments": true,
+	"prediction_vle":         true,
+}
+
+// StudentInfoFields campos de studentInfo disponibles para agrupar o filtrar,
+// indexados por su nombre en la API
+var StudentInfoFields = map[string]string{
+	"code_module":       "codemodule",
+	"code_presentation": "codepresentation",
+	"gender":            "gender",
+	"region":            "region",
+	"highest_education": "highesteducation",
+	"imd_band":          "imdband",
+	"age_band":          "ageband",
+	"disability":        "disability",
+}
+
+// FinalResults resultados finales de OULAD en studentInfo.final_result
+var FinalResults = []string{"Pass", "Fail", "Withdrawn", "Distinction"}
+
+// AssessmentTypes tipos de evaluación de OULAD en assessments.assessment_type
+var AssessmentTypes = []string{"TMA", "CMA", "Exam"}
+
+// ActivityTypes tipos de actividad de OULAD en vle.activity_type
+var ActivityTypes = []string{
+	"dataplus", "dualpane", "externalquiz", "folder", "forumng", "glossary", "homepage",
+	"htmlactivity", "oucollaborate", "oucontent", "ouelluminate", "ouwiki", "page", "questionnaire",
+	"quiz", "repeatactivity", "resource", "sharedsubpage", "subpage", "url",
+}
+
+// Estados de una ejecución de predicción
+const (
+	RunStatusRunning   = "running"
+	RunStatusCompleted = "completed"
+	RunStatusFailed    = "failed"
+)
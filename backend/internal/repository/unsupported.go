@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"backend/internal/entity"
+	"context"
+	"fmt"
+)
+
+// unsupportedStore implementa las consultas que dependen del motor de
+// almacenamiento (predicción, analítica, calidad de datos, migraciones y
+// supresión) devolviendo entity.ErrUnsupported, para que los repositorios en
+// memoria nunca queden sin asignar
+type unsupportedStore struct {
+	backend string
+}
+
+func (s unsupportedStore) unsupported(operation string) error {
+	return fmt.Errorf("%s %w (%s)", operation, entity.ErrUnsupported, s.backend)
+}
+
+func (s unsupportedStore) ProcessDataPredictionAssessments(ctx context.Context, database string) ([]entity.ProcessedPredictionAssessmentResult, error) {
+	return nil, s.unsupported("la predicción de evaluaciones")
+}
+
+func (s unsupportedStore) ProcessDataVlePredictions(ctx context.Context, database string) ([]entity.ProcessedPredictionVleResult, error) {
+	return nil, s.unsupported("la predicción VLE")
+}
+
+func (s unsupportedStore) EvaluateModel(ctx context.Context, database, modelID string, request entity.ModelEvaluationRequest) (*entity.ModelEvaluation, error) {
+	return nil, s.unsupported("la evaluación de modelos")
+}
+
+func (s unsupportedStore) ExplainStudentPredictions(ctx context.Context, database string, query entity.ExplanationQuery) ([]entity.PredictionExplanation, error) {
+	return nil, s.unsupported("la explicación de predicciones")
+}
+
+func (s unsupportedStore) GetScoreDistributionPredictionAssessments(ctx context.Context, database string, query entity.ScoreDistributionQuery) (*entity.ScoreDistribution, error) {
+	return nil, s.unsupported("la distribución de puntuaciones")
+}
+
+func (s unsupportedStore) GetAveragePredictedScoreByAssessmentType(ctx context.Context, database string) ([]entity.AssessmentTypeAverage, error) {
+	return nil, s.unsupported("la media por tipo de evaluación")
+}
+
+func (s unsupportedStore) GetStudentCountByAssessmentID(ctx context.Context, database string) ([]entity.AssessmentStudentCount, error) {
+	return nil, s.unsupported("el recuento por evaluación")
+}
+
+func (s unsupportedStore) GetActualVsPredicted(ctx context.Context, database string, query entity.ActualVsPredictedQuery) (*entity.ActualVsPredictedReport, error) {
+	return nil, s.unsupported("la comparación real frente a predicho")
+}
+
+func (s unsupportedStore) GetFairnessReport(ctx context.Context, database string, query entity.FairnessQuery) (*entity.FairnessReport, error) {
+	return nil, s.unsupported("el informe de equidad")
+}
+
+func (s unsupportedStore) GetEngagementSeries(ctx context.Context, database string, query entity.EngagementQuery) (*entity.EngagementSeries, error) {
+	return nil, s.unsupported("la serie de participación")
+}
+
+func (s unsupportedStore) GetItemAnalysis(ctx context.Context, database string, query entity.ItemAnalysisQuery) (*entity.ItemAnalysisReport, error) {
+	return nil, s.unsupported("el análisis de ítems")
+}
+
+func (s unsupportedStore) GetWithdrawalSurvival(ctx context.Context, database string, query entity.SurvivalQuery) (*entity.SurvivalReport, error) {
+	return nil, s.unsupported("el análisis de supervivencia")
+}
+
+func (s unsupportedStore) GetFeatureCorrelations(ctx context.Context, database string, query entity.CorrelationQuery) (*entity.CorrelationReport, error) {
+	return nil, s.unsupported("las correlaciones")
+}
+
+func (s unsupportedStore) ClusterStudents(ctx context.Context, database string, request entity.ClusteringRequest) (*entity.ClusteringResult, error) {
+	return nil, s.unsupported("el agrupamiento de estudiantes")
+}
+
+func (s unsupportedStore) GetStudentClusters(ctx context.Context, database, codeModule, codePresentation string) (*entity.ClusteringResult, error) {
+	return nil, s.unsupported("el agrupamiento de estudiantes")
+}
+
+func (s unsupportedStore) GetSchemaViolations(ctx context.Context, database string, query entity.SchemaViolationQuery) (*entity.SchemaViolationReport, error) {
+	return nil, s.unsupported("la validación de esquema")
+}
+
+func (s unsupportedStore) RunIntegrityCheck(ctx context.Context, database string) (*entity.IntegrityReport, error) {
+	return nil, s.unsupported("la comprobación de integridad")
+}
+
+func (s unsupportedStore) GetIntegrityReport(ctx context.Context, database string) (*entity.IntegrityReport, error) {
+	return nil, s.unsupported("la comprobación de integridad")
+}
+
+func (s unsupportedStore) ProfileDataset(ctx context.Context, database string) (*entity.DataProfile, error) {
+	return nil, s.unsupported("el perfilado de datos")
+}
+
+func (s unsupportedStore) GetDataProfile(ctx context.Context, database string, version int) (*entity.DataProfile, error) {
+	return nil, s.unsupported("el perfilado de datos")
+}
+
+func (s unsupportedStore) DiffDataProfiles(ctx context.Context, database string, from, to int) (*entity.DataProfileDiff, error) {
+	return nil, s.unsupported("el perfilado de datos")
+}
+
+func (s unsupportedStore) GetMigrationStatus(ctx context.Context, database string) ([]entity.MigrationStatus, error) {
+	return nil, s.unsupported("las migraciones de esquema")
+}
+
+func (s unsupportedStore) Migrate(ctx context.Context, database string, request entity.MigrationRequest) (*entity.MigrationPlan, error) {
+	return nil, s.unsupported("las migraciones de esquema")
+}
+
+func (s unsupportedStore) EraseStudent(ctx context.Context, database string, request entity.ErasureRequest) (*entity.ErasureAuditEntry, error) {
+	return nil, s.unsupported("la supresión de estudiantes")
+}
+
+func (s unsupportedStore) GetErasureAudit(ctx context.Context, database string) (*entity.ErasureAuditLog, error) {
+	return nil, s.unsupported("la auditoría de supresiones")
+}
+
+// GetErasedSubjects no devuelve ninguno: sin supresiones no hay nada que descartar
+func (s unsupportedStore) GetErasedSubjects(ctx context.Context, database string) (map[string]bool, error) {
+	return map[string]bool{}, nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"backend/internal/entity"
+	"fmt"
+)
+
+const (
+	DefaultVleScoringModule = "default"
+	NormalizationNone       = "none"
+	NormalizationZScore     = "zscore"
+	NormalizationPercentile = "percentile"
+)
+
+// DefaultVleScoringConfig pesos por defecto para los tipos de actividad de OULAD
+func DefaultVleScoringConfig() entity.VleScoringConfig {
+	return entity.VleScoringConfig{
+		CodeModule: DefaultVleScoringModule,
+		Weights: map[string]float64{
+			"quiz":           1.5,
+			"externalquiz":   1.5,
+			"questionnaire":  1.3,
+			"forumng":        1.2,
+			"oucollaborate":  1.2,
+			"ouelluminate":   1.2,
+			"ouwiki":         1.2,
+			"glossary":       1.1,
+			"oucontent":      1.0,
+			"resource":       1.0,
+			"htmlactivity":   1.0,
+			"dataplus":       1.0,
+			"dualpane":       1.0,
+			"page":           0.8,
+			"subpage":        0.8,
+			"sharedsubpage":  0.8,
+			"url":            0.8,
+			"folder":         0.8,
+			"repeatactivity": 0.8,
+			"homepage":       0.5,
+		},
+		DefaultWeight: 1.0,
+		Normalization: NormalizationNone,
+	}
+}
+
+// ValidateVleScoringConfig valida la configuración y completa los valores por defecto
+func ValidateVleScoringConfig(scoringConfig *entity.VleScoringConfig) error {
+	if scoringConfig.CodeModule == "" {
+		return fmt.Errorf("code_module es obligatorio")
+	}
+	switch scoringConfig.Normalization {
+	case "":
+		scoringConfig.Normalization = NormalizationNone
+	case NormalizationNone, NormalizationZScore, NormalizationPercentile:
+	default:
+		return fmt.Errorf("normalización no soportada: %s", scoringConfig.Normalization)
+	}
+	if scoringConfig.WeekDecay < 0 || scoringConfig.WeekDecay >= 1 {
+		return fmt.Errorf("week_decay debe estar en [0, 1): %v", scoringConfig.WeekDecay)
+	}
+	if scoringConfig.DefaultWeight < 0 {
+		return fmt.Errorf("default_weight no puede ser negativo: %v", scoringConfig.DefaultWeight)
+	}
+	positive := scoringConfig.DefaultWeight > 0
+	for activity, weight := range scoringConfig.Weights {
+		if weight < 0 {
+			return fmt.Errorf("el peso de %s no puede ser negativo: %v", activity, weight)
+		}
+		positive = positive || weight > 0
+	}
+	// Con todos los pesos a 0 la participación de todos los estudiantes sería 0
+	if !positive {
+		return fmt.Errorf("al menos un peso o default_weight debe ser positivo")
+	}
+	return nil
+}
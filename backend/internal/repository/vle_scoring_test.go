@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"backend/internal/entity"
+	"testing"
+)
+
+func TestValidateVleScoringConfig(t *testing.T) {
+	tests := []struct {
+		name              string
+		config            entity.VleScoringConfig
+		wantErr           bool
+		wantNormalization string
+	}{
+		{"por defecto", DefaultVleScoringConfig(), false, NormalizationNone},
+		{"normalización vacía", entity.VleScoringConfig{CodeModule: "AAA", DefaultWeight: 1}, false, NormalizationNone},
+		{"solo un peso positivo", entity.VleScoringConfig{CodeModule: "AAA", Weights: map[string]float64{"quiz": 2}, Normalization: NormalizationPercentile}, false, NormalizationPercentile},
+		{"sin módulo", entity.VleScoringConfig{DefaultWeight: 1}, true, ""},
+		{"normalización desconocida", entity.VleScoringConfig{CodeModule: "AAA", DefaultWeight: 1, Normalization: "minmax"}, true, ""},
+		{"decaimiento igual a 1", entity.VleScoringConfig{CodeModule: "AAA", DefaultWeight: 1, WeekDecay: 1}, true, ""},
+		{"decaimiento negativo", entity.VleScoringConfig{CodeModule: "AAA", DefaultWeight: 1, WeekDecay: -0.1}, true, ""},
+		{"peso por defecto negativo", entity.VleScoringConfig{CodeModule: "AAA", DefaultWeight: -1, Weights: map[string]float64{"quiz": 1}}, true, ""},
+		{"peso negativo", entity.VleScoringConfig{CodeModule: "AAA", DefaultWeight: 1, Weights: map[string]float64{"quiz": -1}}, true, ""},
+		{"todos los pesos a cero", entity.VleScoringConfig{CodeModule: "AAA", Weights: map[string]float64{"quiz": 0, "page": 0}}, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			err := ValidateVleScoringConfig(&config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateVleScoringConfig() error = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && config.Normalization != tt.wantNormalization {
+				t.Errorf("Normalization = %q, se esperaba %q", config.Normalization, tt.wantNormalization)
+			}
+		})
+	}
+}
+
+func TestDefaultVleScoringConfigActivityTypes(t *testing.T) {
+	config := DefaultVleScoringConfig()
+	for _, activity := range ActivityTypes {
+		if _, ok := config.Weights[activity]; !ok {
+			t.Errorf("la configuración por defecto no tiene peso para %s", activity)
+		}
+	}
+}
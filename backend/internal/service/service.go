@@ -1,61 +1,381 @@
 package service
 
 import (
+	"backend/internal/auth"
 	"backend/internal/entity"
 	"backend/internal/model"
+	"backend/internal/privacy"
+	"context"
+	"time"
 )
 
 type service struct {
-	model   model.Model
-	loggers *entity.Loggers
+	model         model.Model
+	loggers       *entity.Loggers
+	pseudonymizer *privacy.Pseudonymizer
+	authenticator *auth.Authenticator
 }
 type Service interface {
-	LoadBatchData() error
-	DownloadData() error
-	GetFiles() ([]*entity.FileInfo, error)
-	GetData(collection string) ([]interface{}, error)
-	GetAllCountData(collections []string) (map[string]int64, error)
-	ProcessDataPredictionAssessments() ([]entity.ProcessedPredictionAssessmentResult, error)
-	ProcessDataVlePredictions() ([]entity.ProcessedPredictionVleResult, error)
-	GetScoreDistributionPredictionAssessments() ([]entity.ScoreRangePredictionAssessments, error)
-	GetAveragePredictedScoreByAssessmentType() ([]entity.AssessmentTypeAverage, error)
-	GetStudentCountByAssessmentID() ([]entity.AssessmentStudentCount, error)
-}
-
-func NewService(model model.Model, loggers *entity.Loggers) Service {
+	LoadBatchData(ctx context.Context) error
+	DownloadData(ctx context.Context) error
+	GetFiles(ctx context.Context) ([]*entity.FileInfo, error)
+	GetData(ctx context.Context, collection string) ([]interface{}, error)
+	GetAllCountData(ctx context.Context, collections []string) (map[string]int64, error)
+	ProcessDataPredictionAssessments(ctx context.Context) ([]entity.ProcessedPredictionAssessmentResult, error)
+	ProcessDataVlePredictions(ctx context.Context) ([]entity.ProcessedPredictionVleResult, error)
+	GetScoreDistributionPredictionAssessments(ctx context.Context, query entity.ScoreDistributionQuery) (*entity.ScoreDistribution, error)
+	GetAveragePredictedScoreByAssessmentType(ctx context.Context) ([]entity.AssessmentTypeAverage, error)
+	GetStudentCountByAssessmentID(ctx context.Context) ([]entity.AssessmentStudentCount, error)
+	EvaluateModel(ctx context.Context, modelID string, request entity.ModelEvaluationRequest) (*entity.ModelEvaluation, error)
+	GetPredictionRuns(ctx context.Context, kind string) ([]entity.PredictionRun, error)
+	ActivatePredictionRun(ctx context.Context, runID string) error
+	GetVleScoringConfigs(ctx context.Context) ([]entity.VleScoringConfig, error)
+	SaveVleScoringConfig(ctx context.Context, scoringConfig entity.VleScoringConfig, rescore bool) (int, error)
+	GetActualVsPredicted(ctx context.Context, query entity.ActualVsPredictedQuery) (*entity.ActualVsPredictedReport, error)
+	GetFairnessReport(ctx context.Context, query entity.FairnessQuery) (*entity.FairnessReport, error)
+	GetOutcomes(ctx context.Context, groupBy []string) (*entity.OutcomeReport, error)
+	GetEngagementSeries(ctx context.Context, query entity.EngagementQuery) (*entity.EngagementSeries, error)
+	RefreshMaterializedViews(ctx context.Context, name string) ([]entity.ViewMetadata, error)
+	GetMaterializedViews(ctx context.Context) ([]entity.ViewMetadata, error)
+	GetViewMetadata(ctx context.Context, name string) (*entity.ViewMetadata, error)
+	GetItemAnalysis(ctx context.Context, query entity.ItemAnalysisQuery) (*entity.ItemAnalysisReport, error)
+	GetWithdrawalSurvival(ctx context.Context, query entity.SurvivalQuery) (*entity.SurvivalReport, error)
+	GetFeatureCorrelations(ctx context.Context, query entity.CorrelationQuery) (*entity.CorrelationReport, error)
+	ExplainStudentPredictions(ctx context.Context, query entity.ExplanationQuery) ([]entity.PredictionExplanation, error)
+	ClusterStudents(ctx context.Context, request entity.ClusteringRequest) (*entity.ClusteringResult, error)
+	GetStudentClusters(ctx context.Context, codeModule, codePresentation string) (*entity.ClusteringResult, error)
+	GetSchemaViolations(ctx context.Context, query entity.SchemaViolationQuery) (*entity.SchemaViolationReport, error)
+	RunIntegrityCheck(ctx context.Context) (*entity.IntegrityReport, error)
+	GetIntegrityReport(ctx context.Context) (*entity.IntegrityReport, error)
+	ProfileDataset(ctx context.Context) (*entity.DataProfile, error)
+	GetDataProfile(ctx context.Context, version int) (*entity.DataProfile, error)
+	DiffDataProfiles(ctx context.Context, from, to int) (*entity.DataProfileDiff, error)
+	GetStudentPseudonym(ctx context.Context, pseudonym int) (*entity.StudentPseudonym, error)
+	EraseStudent(ctx context.Context, studentID int) (*entity.ErasureAuditEntry, error)
+	GetErasureAudit(ctx context.Context) (*entity.ErasureAuditLog, error)
+	Login(ctx context.Context, request entity.LoginRequest) (*entity.AuthTokens, error)
+	RefreshTokens(ctx context.Context, request entity.RefreshRequest) (*entity.AuthTokens, error)
+	Logout(ctx context.Context, request entity.RefreshRequest) error
+	Authenticate(ctx context.Context, token string) (*auth.Claims, error)
+}
+
+func NewService(model model.Model, loggers *entity.Loggers, pseudonymizer *privacy.Pseudonymizer, authenticator *auth.Authenticator) Service {
 	return &service{
-		model:   model,
-		loggers: loggers,
+		model:         model,
+		loggers:       loggers,
+		pseudonymizer: pseudonymizer,
+		authenticator: authenticator,
 	}
 }
-func (s *service) LoadBatchData() error {
-	return s.model.LoadBatchData()
+func (s *service) LoadBatchData(ctx context.Context) error {
+	return s.model.LoadBatchData(ctx)
 }
-func (s *service) DownloadData() error {
-	return s.model.DownloadData()
+func (s *service) DownloadData(ctx context.Context) error {
+	return s.model.DownloadData(ctx)
 }
-func (s *service) GetFiles() ([]*entity.FileInfo, error) {
-	return s.model.GetFiles()
+func (s *service) GetFiles(ctx context.Context) ([]*entity.FileInfo, error) {
+	return s.model.GetFiles(ctx)
 }
-func (s *service) GetData(collection string) ([]interface{}, error) {
-	return s.model.GetData(collection)
+func (s *service) GetData(ctx context.Context, collection string) ([]interface{}, error) {
+	data, err := s.model.GetData(ctx, collection)
+	if err == nil && s.pseudonymizer.AtExport(ctx) {
+		s.pseudonymizer.Document(data)
+	}
+	return data, err
 }
-func (s *service) GetAllCountData(collections []string) (map[string]int64, error) {
-	return s.model.GetAllCountData(collections)
+func (s *service) GetAllCountData(ctx context.Context, collections []string) (map[string]int64, error) {
+	return s.model.GetAllCountData(ctx, collections)
 }
-func (s *service) ProcessDataPredictionAssessments() ([]entity.ProcessedPredictionAssessmentResult, error) {
-	return s.model.ProcessDataPredictionAssessments()
+func (s *service) ProcessDataPredictionAssessments(ctx context.Context) ([]entity.ProcessedPredictionAssessmentResult, error) {
+	results, err := s.model.ProcessDataPredictionAssessments(ctx)
+	if err == nil && s.pseudonymizer.AtExport(ctx) {
+		for i := range results {
+			results[i].StudentID = s.pseudonymizer.StudentID(results[i].StudentID)
+		}
+	}
+	return results, err
 }
-func (s *service) ProcessDataVlePredictions() ([]entity.ProcessedPredictionVleResult, error) {
-	return s.model.ProcessDataVlePredictions()
+func (s *service) ProcessDataVlePredictions(ctx context.Context) ([]entity.ProcessedPredictionVleResult, error) {
+	results, err := s.model.ProcessDataVlePredictions(ctx)
+	if err == nil && s.pseudonymizer.AtExport(ctx) {
+		for i := range results {
+			results[i].StudentID = s.pseudonymizer.StudentID(results[i].StudentID)
+		}
+	}
+	return results, err
+}
+func (s *service) GetScoreDistributionPredictionAssessments(ctx context.Context, query entity.ScoreDistributionQuery) (*entity.ScoreDistribution, error) {
+	return s.model.GetScoreDistributionPredictionAssessments(ctx, query)
 }
-func (s *service) GetScoreDistributionPredictionAssessments() ([]entity.ScoreRangePredictionAssessments, error) {
-	return s.model.GetScoreDistributionPredictionAssessments()
+func (s *service) GetAveragePredictedScoreByAssessmentType(ctx context.Context) ([]entity.AssessmentTypeAverage, error) {
+	return s.model.GetAveragePredictedScoreByAssessmentType(ctx)
 }
-func (s *service) GetAveragePredictedScoreByAssessmentType() ([]entity.AssessmentTypeAverage, error) {
-	return s.model.GetAveragePredictedScoreByAssessmentType()
+
+func (s *service) GetStudentCountByAssessmentID(ctx context.Context) ([]entity.AssessmentStudentCount, error) {
+	return s.model.GetStudentCountByAssessmentID(ctx)
+}
+
+func (s *service) EvaluateModel(ctx context.Context, modelID string, request entity.ModelEvaluationRequest) (*entity.ModelEvaluation, error) {
+	return s.model.EvaluateModel(ctx, modelID, request)
+}
+
+func (s *service) GetPredictionRuns(ctx context.Context, kind string) ([]entity.PredictionRun, error) {
+	return s.model.GetPredictionRuns(ctx, kind)
+}
+
+func (s *service) ActivatePredictionRun(ctx context.Context, runID string) error {
+	return s.model.ActivatePredictionRun(ctx, runID)
+}
+
+func (s *service) GetVleScoringConfigs(ctx context.Context) ([]entity.VleScoringConfig, error) {
+	return s.model.GetVleScoringConfigs(ctx)
+}
+
+// SaveVleScoringConfig guarda la configuración y, si se solicita, vuelve a
+// puntuar la participación VLE devolviendo el número de predicciones generadas
+func (s *service) SaveVleScoringConfig(ctx context.Context, scoringConfig entity.VleScoringConfig, rescore bool) (int, error) {
+	if err := s.model.SaveVleScoringConfig(ctx, scoringConfig); err != nil {
+		return 0, err
+	}
+	if !rescore {
+		return 0, nil
+	}
+	results, err := s.model.ProcessDataVlePredictions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return len(results), nil
+}
+
+func (s *service) GetActualVsPredicted(ctx context.Context, query entity.ActualVsPredictedQuery) (*entity.ActualVsPredictedReport, error) {
+	report, err := s.model.GetActualVsPredicted(ctx, query)
+	if err == nil && s.pseudonymizer.AtExport(ctx) {
+		for i := range report.Sample {
+			report.Sample[i].StudentID = s.pseudonymizer.StudentID(report.Sample[i].StudentID)
+		}
+	}
+	return report, err
+}
+
+func (s *service) GetFairnessReport(ctx context.Context, query entity.FairnessQuery) (*entity.FairnessReport, error) {
+	return s.model.GetFairnessReport(ctx, query)
 }
 
-func (s *service) GetStudentCountByAssessmentID() ([]entity.AssessmentStudentCount, error) {
-	return s.model.GetStudentCountByAssessmentID()
+func (s *service) GetOutcomes(ctx context.Context, groupBy []string) (*entity.OutcomeReport, error) {
+	return s.model.GetOutcomes(ctx, groupBy)
+}
+
+func (s *service) GetEngagementSeries(ctx context.Context, query entity.EngagementQuery) (*entity.EngagementSeries, error) {
+	studentID, err := s.requestStudentID(ctx, query.StudentID)
+	if err != nil {
+		return nil, err
+	}
+	query.StudentID = studentID
+	return s.model.GetEngagementSeries(ctx, query)
+}
+
+func (s *service) RefreshMaterializedViews(ctx context.Context, name string) ([]entity.ViewMetadata, error) {
+	return s.model.RefreshMaterializedViews(ctx, name)
+}
+
+func (s *service) GetMaterializedViews(ctx context.Context) ([]entity.ViewMetadata, error) {
+	return s.model.GetMaterializedViews(ctx)
+}
+
+func (s *service) GetViewMetadata(ctx context.Context, name string) (*entity.ViewMetadata, error) {
+	return s.model.GetViewMetadata(ctx, name)
+}
+
+func (s *service) GetItemAnalysis(ctx context.Context, query entity.ItemAnalysisQuery) (*entity.ItemAnalysisReport, error) {
+	return s.model.GetItemAnalysis(ctx, query)
+}
+
+func (s *service) GetWithdrawalSurvival(ctx context.Context, query entity.SurvivalQuery) (*entity.SurvivalReport, error) {
+	return s.model.GetWithdrawalSurvival(ctx, query)
+}
+
+func (s *service) GetFeatureCorrelations(ctx context.Context, query entity.CorrelationQuery) (*entity.CorrelationReport, error) {
+	return s.model.GetFeatureCorrelations(ctx, query)
+}
+
+func (s *service) ExplainStudentPredictions(ctx context.Context, query entity.ExplanationQuery) ([]entity.PredictionExplanation, error) {
+	pseudonym := query.StudentID
+	studentID, err := s.requestStudentID(ctx, query.StudentID)
+	if err != nil {
+		return nil, err
+	}
+	query.StudentID = studentID
+	explanations, err := s.model.ExplainStudentPredictions(ctx, query)
+	if err == nil && s.pseudonymizer.AtExport(ctx) {
+		for i := range explanations {
+			explanations[i].StudentID = pseudonym
+		}
+	}
+	return explanations, err
+}
+
+func (s *service) ClusterStudents(ctx context.Context, request entity.ClusteringRequest) (*entity.ClusteringResult, error) {
+	return s.model.ClusterStudents(ctx, request)
+}
+
+func (s *service) GetStudentClusters(ctx context.Context, codeModule, codePresentation string) (*entity.ClusteringResult, error) {
+	return s.model.GetStudentClusters(ctx, codeModule, codePresentation)
+}
+
+func (s *service) GetSchemaViolations(ctx context.Context, query entity.SchemaViolationQuery) (*entity.SchemaViolationReport, error) {
+	report, err := s.model.GetSchemaViolations(ctx, query)
+	if err == nil && s.pseudonymizer.AtExport(ctx) {
+		for _, collection := range report.Collections {
+			for _, sample := range collection.Samples {
+				s.pseudonymizer.Document(sample.Document)
+			}
+		}
+	}
+	return report, err
+}
+
+func (s *service) RunIntegrityCheck(ctx context.Context) (*entity.IntegrityReport, error) {
+	report, err := s.model.RunIntegrityCheck(ctx)
+	if err == nil && s.pseudonymizer.AtExport(ctx) {
+		s.pseudonymizer.IntegrityReport(report)
+	}
+	return report, err
+}
+
+func (s *service) GetIntegrityReport(ctx context.Context) (*entity.IntegrityReport, error) {
+	report, err := s.model.GetIntegrityReport(ctx)
+	if err == nil && s.pseudonymizer.AtExport(ctx) {
+		s.pseudonymizer.IntegrityReport(report)
+	}
+	return report, err
+}
+
+func (s *service) ProfileDataset(ctx context.Context) (*entity.DataProfile, error) {
+	profile, err := s.model.ProfileDataset(ctx)
+	if err == nil && s.pseudonymizer.AtExport(ctx) {
+		s.pseudonymizer.Profile(profile)
+	}
+	return profile, err
+}
+
+func (s *service) GetDataProfile(ctx context.Context, version int) (*entity.DataProfile, error) {
+	profile, err := s.model.GetDataProfile(ctx, version)
+	if err == nil && s.pseudonymizer.AtExport(ctx) {
+		s.pseudonymizer.Profile(profile)
+	}
+	return profile, err
+}
+
+func (s *service) DiffDataProfiles(ctx context.Context, from, to int) (*entity.DataProfileDiff, error) {
+	diff, err := s.model.DiffDataProfiles(ctx, from, to)
+	if err == nil && s.pseudonymizer.AtExport(ctx) {
+		s.pseudonymizer.ProfileDiff(diff)
+	}
+	return diff, err
+}
+
+func (s *service) GetStudentPseudonym(ctx context.Context, pseudonym int) (*entity.StudentPseudonym, error) {
+	s.loggers.InfoLogger.Printf("Reidentificación solicitada para el seudónimo %d", pseudonym)
+	return s.model.GetStudentPseudonym(ctx, pseudonym)
+}
+
+// EraseStudent recibe el id_student tal como lo ve el cliente
+func (s *service) EraseStudent(ctx context.Context, studentID int) (*entity.ErasureAuditEntry, error) {
+	storedID, err := s.requestStudentID(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	return s.model.EraseStudent(ctx, storedID)
+}
+
+func (s *service) GetErasureAudit(ctx context.Context) (*entity.ErasureAuditLog, error) {
+	return s.model.GetErasureAudit(ctx)
+}
+
+// requestStudentID traduce el id_student recibido en la petición: cuando las
+// respuestas se seudonimizan el cliente solo conoce el seudónimo
+func (s *service) requestStudentID(ctx context.Context, studentID int) (int, error) {
+	if studentID == 0 || !s.pseudonymizer.AtExport(ctx) {
+		return studentID, nil
+	}
+	pseudonym, err := s.model.GetStudentPseudonym(ctx, studentID)
+	if err != nil {
+		return 0, err
+	}
+	return pseudonym.IdStudent, nil
+}
+
+// Login comprueba las credenciales y emite un token de acceso y uno de refresco
+func (s *service) Login(ctx context.Context, request entity.LoginRequest) (*entity.AuthTokens, error) {
+	user, err := s.model.GetUser(ctx, request.Username)
+	if err != nil {
+		return nil, err
+	}
+	hash := ""
+	if user != nil {
+		hash = user.PasswordHash
+	}
+	if err := auth.CheckPassword(hash, request.Password); err != nil {
+		s.loggers.InfoLogger.Printf("Inicio de sesión fallido para %q", request.Username)
+		return nil, err
+	}
+	return s.issueTokens(ctx, user)
+}
+
+// RefreshTokens cambia un token de refresco por un nuevo par de tokens; el token
+// usado deja de ser válido y el rol se vuelve a leer del usuario
+func (s *service) RefreshTokens(ctx context.Context, request entity.RefreshRequest) (*entity.AuthTokens, error) {
+	token, err := s.model.ConsumeRefreshToken(ctx, auth.HashRefreshToken(request.RefreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || time.Now().After(token.ExpiresAt) {
+		return nil, auth.ErrInvalidRefreshToken
+	}
+	user, err := s.model.GetUser(ctx, token.Username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, auth.ErrInvalidRefreshToken
+	}
+	return s.issueTokens(ctx, user)
+}
+
+// Logout revoca el token de refresco; el de acceso caduca por sí solo
+func (s *service) Logout(ctx context.Context, request entity.RefreshRequest) error {
+	_, err := s.model.ConsumeRefreshToken(ctx, auth.HashRefreshToken(request.RefreshToken))
+	return err
+}
+
+func (s *service) Authenticate(ctx context.Context, token string) (*auth.Claims, error) {
+	return s.authenticator.ParseAccessToken(token)
+}
+
+func (s *service) issueTokens(ctx context.Context, user *entity.User) (*entity.AuthTokens, error) {
+	accessToken, err := s.authenticator.IssueAccessToken(user.Username, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	err = s.model.SaveRefreshToken(ctx, entity.RefreshToken{
+		Hash:      hash,
+		Username:  user.Username,
+		ExpiresAt: now.Add(s.authenticator.RefreshTTL()),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &entity.AuthTokens{
+		AccessToken:      accessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(s.authenticator.AccessTTL().Seconds()),
+		RefreshToken:     refreshToken,
+		RefreshExpiresIn: int(s.authenticator.RefreshTTL().Seconds()),
+	}, nil
 }
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"backend/internal/auth"
+	"backend/internal/config"
+	"backend/internal/entity"
+	"backend/internal/model"
+	"backend/internal/privacy"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	testKey    = "test-pseudonymization-key-0123456789"
+	testSecret = "test-jwt-signing-secret-0123456789abcdef"
+	testAudit  = "test-erasure-audit-key-0123456789abcdef"
+	testDB     = "test"
+)
+
+// erasureRecorder guarda la última petición de supresión
+type erasureRecorder struct {
+	request entity.ErasureRequest
+}
+
+func (r *erasureRecorder) EraseStudent(ctx context.Context, database string, request entity.ErasureRequest) (*entity.ErasureAuditEntry, error) {
+	r.request = request
+	return &entity.ErasureAuditEntry{Subject: request.Subject}, nil
+}
+
+func (r *erasureRecorder) GetErasedSubjects(ctx context.Context, database string) (map[string]bool, error) {
+	return map[string]bool{r.request.Subject: true}, nil
+}
+
+func (r *erasureRecorder) GetErasureAudit(ctx context.Context, database string) (*entity.ErasureAuditLog, error) {
+	return &entity.ErasureAuditLog{}, nil
+}
+
+func newTestService(t *testing.T, mode string, repos repository.Repositories) (Service, *privacy.Pseudonymizer) {
+	t.Helper()
+	viper.Reset()
+	viper.Set(config.DbnameQa, testDB)
+	t.Cleanup(viper.Reset)
+
+	key := testKey
+	if mode == config.PseudonymizationOff {
+		key = ""
+	}
+	pseudonymizer, err := privacy.NewPseudonymizer(key, mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authenticator, err := auth.NewAuthenticator(testSecret, time.Minute, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loggers := &entity.Loggers{
+		InfoLogger:  log.New(io.Discard, "", 0),
+		ErrorLogger: log.New(io.Discard, "", 0),
+	}
+	return NewService(model.NewModel(repos, loggers, pseudonymizer), loggers, pseudonymizer, authenticator), pseudonymizer
+}
+
+func saveUser(t *testing.T, repos repository.Repositories, username, password, role string) {
+	t.Helper()
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := entity.User{Username: username, PasswordHash: hash, Role: role, CreatedAt: time.Now()}
+	if err := repos.Users.SaveUser(context.Background(), testDB, user); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoginAndRefreshRotation(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	service, _ := newTestService(t, config.PseudonymizationOff, repos)
+	ctx := context.Background()
+	saveUser(t, repos, "ana", "correct-horse", config.RoleAnalyst)
+
+	if _, err := service.Login(ctx, entity.LoginRequest{Username: "ana", Password: "wrong"}); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("contraseña incorrecta: %v", err)
+	}
+	if _, err := service.Login(ctx, entity.LoginRequest{Username: "nadie", Password: "wrong"}); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("usuario inexistente: %v", err)
+	}
+
+	tokens, err := service.Login(ctx, entity.LoginRequest{Username: "ana", Password: "correct-horse"})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	claims, err := service.Authenticate(ctx, tokens.AccessToken)
+	if err != nil || claims.Subject != "ana" || claims.Role != config.RoleAnalyst {
+		t.Fatalf("Authenticate = %+v, %v", claims, err)
+	}
+
+	refreshed, err := service.RefreshTokens(ctx, entity.RefreshRequest{RefreshToken: tokens.RefreshToken})
+	if err != nil {
+		t.Fatalf("RefreshTokens: %v", err)
+	}
+	if refreshed.RefreshToken == tokens.RefreshToken {
+		t.Error("el token de refresco no se rotó")
+	}
+	// El token ya usado no vale una segunda vez
+	if _, err := service.RefreshTokens(ctx, entity.RefreshRequest{RefreshToken: tokens.RefreshToken}); !errors.Is(err, auth.ErrInvalidRefreshToken) {
+		t.Errorf("reutilización del token de refresco: %v", err)
+	}
+
+	if err := service.Logout(ctx, entity.RefreshRequest{RefreshToken: refreshed.RefreshToken}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.RefreshTokens(ctx, entity.RefreshRequest{RefreshToken: refreshed.RefreshToken}); !errors.Is(err, auth.ErrInvalidRefreshToken) {
+		t.Errorf("token de refresco revocado: %v", err)
+	}
+}
+
+func TestRefreshTokenExpired(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	service, _ := newTestService(t, config.PseudonymizationOff, repos)
+	ctx := context.Background()
+	saveUser(t, repos, "ana", "correct-horse", config.RoleAnalyst)
+
+	token, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired := entity.RefreshToken{Hash: hash, Username: "ana", ExpiresAt: time.Now().Add(-time.Minute)}
+	if err := repos.Users.SaveRefreshToken(ctx, testDB, expired); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.RefreshTokens(ctx, entity.RefreshRequest{RefreshToken: token}); !errors.Is(err, auth.ErrInvalidRefreshToken) {
+		t.Fatalf("token de refresco caducado: %v", err)
+	}
+}
+
+func TestGetDataPseudonymizesAtExport(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	service, pseudonymizer := newTestService(t, config.PseudonymizationExport, repos)
+	ctx := context.Background()
+	documents := []interface{}{entity.StudentInfo{IdStudent: 11391, CodeModule: "AAA", CodePresentation: "2013J"}}
+	if err := repos.Dataset.BatchInsert(ctx, testDB, "studentInfo", documents, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := service.GetData(ctx, "studentInfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := data[0].(bson.M)["idstudent"]; got != int64(pseudonymizer.StudentID(11391)) {
+		t.Errorf("idstudent = %v, se esperaba el seudónimo", got)
+	}
+
+	raw, err := service.GetData(privacy.WithRawIDs(ctx), "studentInfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := raw[0].(bson.M)["idstudent"]; got != int64(11391) && got != int32(11391) {
+		t.Errorf("con raw_ids idstudent = %v, se esperaba el id original", got)
+	}
+}
+
+func TestEraseStudentResolvesPseudonym(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	recorder := &erasureRecorder{}
+	repos.Erasure = recorder
+	service, pseudonymizer := newTestService(t, config.PseudonymizationExport, repos)
+	viper.Set(config.ErasureAuditKey, testAudit)
+	ctx := context.Background()
+
+	pseudonym := pseudonymizer.StudentID(11391)
+	record := entity.StudentPseudonym{Pseudonym: pseudonym, IdStudent: 11391, CreatedAt: time.Now()}
+	if err := repos.Pseudonyms.SaveStudentPseudonyms(ctx, testDB, []entity.StudentPseudonym{record}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.EraseStudent(ctx, pseudonym); err != nil {
+		t.Fatalf("EraseStudent: %v", err)
+	}
+	if recorder.request.StudentID != 11391 || recorder.request.Pseudonym != pseudonym {
+		t.Errorf("petición de supresión inesperada: %+v", recorder.request)
+	}
+	if recorder.request.Subject != privacy.ErasureSubject([]byte(testAudit), 11391) {
+		t.Errorf("el sujeto de la auditoría no es el HMAC del id con ERASURE_AUDIT_KEY: %s", recorder.request.Subject)
+	}
+	if _, err := service.EraseStudent(ctx, 42); !errors.Is(err, entity.ErrNotFound) {
+		t.Errorf("seudónimo desconocido: %v, se esperaba ErrNotFound", err)
+	}
+}
@@ -2,15 +2,22 @@ package main
 
 import (
 	"backend/internal/app"
+	"backend/internal/auth"
 	"backend/internal/client"
 	"backend/internal/config"
 	"backend/internal/entity"
 	"backend/internal/model"
+	"backend/internal/privacy"
 	"backend/internal/service"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -33,20 +40,64 @@ func main() {
 		}))
 		e.Use(middleware.Recover())
 		e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-			AllowOrigins: []string{"http://localhost:3000", "https://database.system.com"},
-			AllowMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
+			AllowOrigins:  []string{"http://localhost:3000", "https://database.system.com"},
+			AllowMethods:  []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
+			ExposeHeaders: app.ViewHeaders,
 		}))
+		// Contexto de las tareas del proceso que no dependen de una petición HTTP
+		ctx := context.Background()
 		//Client
-		client := client.NewMongoDBClient(loggers)
-		// Conectar a MongoDB
-		if err := client.Connect(); err != nil {
-			loggers.ErrorLogger.Fatalf("Error al conectar a MongoDB: %v", err)
+		client := client.NewClient(loggers)
+		if client == nil {
+			loggers.ErrorLogger.Fatalf("No se pudo crear el cliente de almacenamiento")
+		}
+		// Conectar a la base de datos
+		if err := client.Connect(ctx); err != nil {
+			loggers.ErrorLogger.Fatalf("Error al conectar a la base de datos: %v", err)
+		}
+		defer client.Disconnect(ctx)
+		// Seudonimización de id_student; la clave se suele pasar por variable de entorno
+		pseudonymizer, err := privacy.NewPseudonymizer(viper.GetString(config.PseudonymizationKey), viper.GetString(config.PseudonymizationMode))
+		if err != nil {
+			loggers.ErrorLogger.Fatalf("Error en la configuración de seudonimización: %v", err)
+		}
+		// Tokens de acceso firmados con JWT_SECRET, que se suele pasar por variable de entorno
+		authenticator, err := auth.NewAuthenticator(viper.GetString(config.JwtSecret),
+			time.Duration(viper.GetInt(config.AccessTokenTTLMinutes))*time.Minute,
+			time.Duration(viper.GetInt(config.RefreshTokenTTLHours))*time.Hour)
+		if err != nil {
+			loggers.ErrorLogger.Fatalf("Error en la configuración de autenticación: %v", err)
 		}
-		defer client.Disconnect()
 		//Model
-		model := model.NewModel(client, loggers)
+		model := model.NewModel(client.Repositories(), loggers, pseudonymizer)
+		// Migraciones de esquema desde la línea de comandos: main migrate [up|down|status] [-to N] [-dry-run]
+		if len(os.Args) > 1 && os.Args[1] == "migrate" {
+			if err := runMigrateCommand(ctx, model, os.Args[2:]); err != nil {
+				loggers.ErrorLogger.Fatalf("Error al ejecutar las migraciones: %v", err)
+			}
+			return
+		}
+		// Alta de usuarios de la API: main user -username U -password P [-role admin|analyst]
+		if len(os.Args) > 1 && os.Args[1] == "user" {
+			if err := runUserCommand(ctx, model, os.Args[2:]); err != nil {
+				loggers.ErrorLogger.Fatalf("Error al guardar el usuario: %v", err)
+			}
+			return
+		}
+		if viper.GetBool(config.MigrateOnStartup) {
+			plan, err := model.Migrate(ctx, entity.MigrationRequest{Direction: config.MigrationUp})
+			switch {
+			case errors.Is(err, entity.ErrUnsupported):
+				// El esquema SQL se crea completo al conectar
+				loggers.InfoLogger.Printf("Migraciones omitidas: %v", err)
+			case err != nil:
+				loggers.ErrorLogger.Fatalf("Error al aplicar las migraciones: %v", err)
+			default:
+				loggers.InfoLogger.Printf("Esquema en la versión %d (%d migraciones aplicadas)", plan.TargetVersion, len(plan.Migrations))
+			}
+		}
 		//Service
-		service := service.NewService(model, loggers)
+		service := service.NewService(model, loggers, pseudonymizer, authenticator)
 		//app
 		application := app.NewApp(service)
 		application.ConfigRoutes(e)
@@ -55,3 +106,80 @@ func main() {
 		e.Logger.Fatal(e.Start(server))
 	}
 }
+
+// runMigrateCommand muestra el estado de las migraciones o las aplica en el
+// sentido indicado; con -dry-run solo imprime el plan
+func runMigrateCommand(ctx context.Context, m model.Model, args []string) error {
+	command := config.MigrationUp
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	to := flags.Int("to", -1, "versión final; por defecto up aplica todas las pendientes y down revierte la última")
+	dryRun := flags.Bool("dry-run", false, "imprime el plan sin modificar la base de datos")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if command == "status" {
+		statuses, err := m.GetMigrationStatus(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pendiente"
+			if status.Applied {
+				state = fmt.Sprintf("aplicada %s (%d ms)", status.AppliedAt.Format("2006-01-02 15:04:05"), status.DurationMs)
+			}
+			fmt.Printf("%03d  %-30s  %s\n", status.Version, status.Name, state)
+		}
+		return nil
+	}
+
+	plan, err := m.Migrate(ctx, entity.MigrationRequest{Direction: command, Target: *to, DryRun: *dryRun})
+	if plan != nil {
+		mode := ""
+		if plan.DryRun {
+			mode = " (dry-run)"
+		}
+		fmt.Printf("Migración %s%s: versión %d -> %d\n", plan.Direction, mode, plan.CurrentVersion, plan.TargetVersion)
+		if len(plan.Migrations) == 0 {
+			fmt.Println("  Sin migraciones pendientes")
+		}
+		for _, migration := range plan.Migrations {
+			fmt.Printf("  %03d %s\n", migration.Version, migration.Name)
+			for _, operation := range migration.Operations {
+				fmt.Printf("      - %s\n", operation)
+			}
+		}
+	}
+	return err
+}
+
+// runUserCommand crea un usuario o reemplaza su contraseña y rol; la contraseña
+// se guarda con hash bcrypt
+func runUserCommand(ctx context.Context, m model.Model, args []string) error {
+	flags := flag.NewFlagSet("user", flag.ContinueOnError)
+	username := flags.String("username", "", "nombre de usuario")
+	password := flags.String("password", "", "contraseña (mínimo 8 caracteres)")
+	role := flags.String("role", config.RoleAnalyst, "rol: admin o analyst")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" || len(*password) < 8 {
+		return fmt.Errorf("se requieren -username y una -password de al menos 8 caracteres")
+	}
+	if *role != config.RoleAdmin && *role != config.RoleAnalyst {
+		return fmt.Errorf("rol no válido: %s", *role)
+	}
+	hash, err := auth.HashPassword(*password)
+	if err != nil {
+		return err
+	}
+	user := entity.User{Username: *username, PasswordHash: hash, Role: *role, CreatedAt: time.Now()}
+	if err := m.SaveUser(ctx, user); err != nil {
+		return err
+	}
+	fmt.Printf("Usuario %s guardado con el rol %s\n", user.Username, user.Role)
+	return nil
+}
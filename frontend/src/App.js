@@ -4,12 +4,15 @@ import LayoutComponent from './components/LayoutComponent/LayoutComponent';
 import { BrowserRouter as Router, Route, Sw, Routes } from 'react-router-dom';
 import MainContentComponent from './components/MainContentComponent/MainContentComponent';
 import InfoComponent from './components/InfoComponent/InfoComponent';
+import LoginComponent from './components/LoginComponent/LoginComponent';
+import RequireAuthComponent from './components/RequireAuthComponent/RequireAuthComponent';
 
 function App() {
   return (
     <Router>
         <Routes>
-            <Route path="/*" element={<LayoutComponent/>}>
+            <Route path="login" element={<LoginComponent/>} />
+            <Route path="/*" element={<RequireAuthComponent><LayoutComponent/></RequireAuthComponent>}>
               <Route path="" element={<MainContentComponent/>} />
               <Route path="info/download" element={<InfoComponent/>} />
               <Route path="info/upload" element={<InfoComponent/>} />
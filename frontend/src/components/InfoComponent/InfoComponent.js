@@ -2,9 +2,7 @@ import React, { useState, useEffect } from 'react';
 import Alert from '@mui/material/Alert';  
 import { Box, Button, Container } from '@mui/material';
 import { Link } from 'react-router-dom';
-import axios from 'axios';
-
-const apiURL = process.env.REACT_APP_BACKEND_URL;
+import api from '../../services/api';
 
 const InfoComponent = () => {
     const [message, setMessage] = useState('');
@@ -14,7 +12,7 @@ const InfoComponent = () => {
 
     const downloadFiles = async () => {
         try {
-            const response = await axios.get(`${apiURL}/download_data`);
+            const response = await api.get('/download_data');
             const data = response.data;
             console.log('Data:', data);
             setMessage(data.message);
@@ -32,7 +30,7 @@ const InfoComponent = () => {
 
     const uploadData = async () => {
         try {
-            const response = await axios.get(`${apiURL}/load_data`);
+            const response = await api.get('/load_data');
             const data = response.data;
             setMessage(data.message);
             setSeverity('success');
@@ -47,7 +45,7 @@ const InfoComponent = () => {
 
     const processPredictionsAssessments = async () => {
         try {
-            const response = await axios.post(`${apiURL}/process_data_prediction_assessments`);
+            const response = await api.post('/process_data_prediction_assessments');
             const data = response.data;
             setMessage(data.message);
             setSeverity('success');
@@ -62,7 +60,7 @@ const InfoComponent = () => {
 
     const processPredictionsVle = async () => {
         try {
-            const response = await axios.post(`${apiURL}/process_data_prediction_vle`);
+            const response = await api.post('/process_data_prediction_vle');
             const data = response.data;
             setMessage(data.message);
             setSeverity('success');
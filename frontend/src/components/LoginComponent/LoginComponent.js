@@ -0,0 +1,66 @@
+import React, { useState } from 'react';
+import { Alert, Box, Button, Container, TextField, Typography } from '@mui/material';
+import { Navigate, useNavigate } from 'react-router-dom';
+import { isAuthenticated, login } from '../../services/api';
+
+const LoginComponent = () => {
+    const [username, setUsername] = useState('');
+    const [password, setPassword] = useState('');
+    const [message, setMessage] = useState('');
+    const [loading, setLoading] = useState(false);
+    const navigate = useNavigate();
+
+    if (isAuthenticated()) {
+        return <Navigate to="/" replace />;
+    }
+
+    const handleSubmit = async (event) => {
+        event.preventDefault();
+        setLoading(true);
+        setMessage('');
+        try {
+            await login(username, password);
+            navigate('/', { replace: true });
+        } catch (error) {
+            console.error('Error logging in:', error);
+            setMessage(error.response?.data?.message || `Error logging in: ${error}`);
+            setLoading(false);
+        }
+    };
+
+    return (
+        <Container component="main" maxWidth="xs" sx={{ mt: 10, p: 3, bgcolor: 'background.paper', boxShadow: 3, borderRadius: 2 }}>
+            <Typography variant="h5" component="h1">
+                Sign in
+            </Typography>
+            <Box component="form" onSubmit={handleSubmit} sx={{ mt: 1 }}>
+                {message && <Alert severity="error">{message}</Alert>}
+                <TextField
+                    margin="normal"
+                    required
+                    fullWidth
+                    label="Username"
+                    autoComplete="username"
+                    autoFocus
+                    value={username}
+                    onChange={(event) => setUsername(event.target.value)}
+                />
+                <TextField
+                    margin="normal"
+                    required
+                    fullWidth
+                    label="Password"
+                    type="password"
+                    autoComplete="current-password"
+                    value={password}
+                    onChange={(event) => setPassword(event.target.value)}
+                />
+                <Button type="submit" fullWidth variant="contained" disabled={loading} sx={{ mt: 2 }}>
+                    Sign in
+                </Button>
+            </Box>
+        </Container>
+    );
+};
+
+export default LoginComponent;
@@ -1,11 +1,9 @@
 import React, { useState, useEffect } from 'react';
 import { Box, Container, Alert, Grid, AlertTitle, Table, TableContainer, TableHead, TableCell, TableBody, TableRow, Paper, Typography } from '@mui/material';
-import axios from 'axios';
+import api from '../../services/api';
 import ScorePieChartComponent from '../ScorePieChartComponent/ScorePieChartComponent';
 import AssessmentByIdBarChartComponent from '../AssessmentByIdBarChartComponent/AssessmentByIdBarChartComponent';
 
-const apiURL = process.env.REACT_APP_BACKEND_URL;
-
 const MainContentComponent = () => {
 
     const [files, setFiles] = useState([]);
@@ -26,7 +24,7 @@ const MainContentComponent = () => {
     useEffect(() => {
         const fetchFiles = async () => {
             try {
-                const response = await axios.get(`${apiURL}/get_files`);
+                const response = await api.get('/get_files');
                 let data = response.data;
                 if (!data) {
                     console.error('No data found');
@@ -45,7 +43,7 @@ const MainContentComponent = () => {
 
         const fetchData = async () => {
             try {
-                const response = await axios.post(`${apiURL}/get_all_data`, { collections });
+                const response = await api.post('/get_all_data', { collections });
                 console.log('Collections processed:', response.data);
                 setDataCourses(response.data.courses);
                 setDataStudentRegistration(response.data.studentRegistration);
@@ -63,9 +61,9 @@ const MainContentComponent = () => {
 
         const fetchPredictionAssessments = async () => {
             try {
-                const fetchPredictions = await axios.get(`${apiURL}/get_score_distribution_prediction_assessments`);
+                const fetchPredictions = await api.get('/get_score_distribution_prediction_assessments');
                 console.log('Predictions:', fetchPredictions.data);
-                setDataStudentAssessmentPrediction(fetchPredictions.data);
+                setDataStudentAssessmentPrediction(fetchPredictions.data.buckets);
                 setIsExistingData(true);
             } catch (error) {
                 console.error('Error fetching prediction data:', error);
@@ -75,7 +73,7 @@ const MainContentComponent = () => {
 
         const fetchAverageType = async () => {
             try {
-                const fetchAverageType = await axios.get(`${apiURL}/get_average_predicted_score_by_assessment_type`);
+                const fetchAverageType = await api.get('/get_average_predicted_score_by_assessment_type');
                 console.log('Average Types:', fetchAverageType.data);
                 setDataAverageType(fetchAverageType.data);
                 setIsExistingDataAverageType(true);
@@ -87,7 +85,7 @@ const MainContentComponent = () => {
 
         const fetchPredictionByAssessmentID = async () => {
             try {
-                const fetch = await axios.get(`${apiURL}/get_student_count_by_assessment_id`);
+                const fetch = await api.get('/get_student_count_by_assessment_id');
                 console.log('Assessments by ID:', fetch.data);
                 setDataCountByAssessmentID(fetch.data);
                 setIsExistingDataCountByAssessmentID(true);
@@ -0,0 +1,12 @@
+import React from 'react';
+import { Navigate } from 'react-router-dom';
+import { isAuthenticated } from '../../services/api';
+
+const RequireAuthComponent = ({ children }) => {
+    if (!isAuthenticated()) {
+        return <Navigate to="/login" replace />;
+    }
+    return children;
+};
+
+export default RequireAuthComponent;
@@ -0,0 +1,87 @@
+import axios from 'axios';
+
+const apiURL = process.env.REACT_APP_BACKEND_URL;
+
+const ACCESS_TOKEN_KEY = 'accessToken';
+const REFRESH_TOKEN_KEY = 'refreshToken';
+
+// Axios instance for every authenticated call to the backend
+const api = axios.create({ baseURL: apiURL });
+
+const saveTokens = (tokens) => {
+    localStorage.setItem(ACCESS_TOKEN_KEY, tokens.access_token);
+    localStorage.setItem(REFRESH_TOKEN_KEY, tokens.refresh_token);
+};
+
+const clearTokens = () => {
+    localStorage.removeItem(ACCESS_TOKEN_KEY);
+    localStorage.removeItem(REFRESH_TOKEN_KEY);
+};
+
+export const isAuthenticated = () => Boolean(localStorage.getItem(REFRESH_TOKEN_KEY));
+
+export const login = async (username, password) => {
+    const response = await axios.post(`${apiURL}/auth/login`, { username, password });
+    saveTokens(response.data);
+};
+
+export const logout = async () => {
+    const refreshToken = localStorage.getItem(REFRESH_TOKEN_KEY);
+    try {
+        if (refreshToken) {
+            await api.post('/auth/logout', { refresh_token: refreshToken });
+        }
+    } catch (error) {
+        console.error('Error logging out:', error);
+    } finally {
+        clearTokens();
+    }
+};
+
+// Refresh tokens are single use: concurrent 401s share the same refresh request
+let refreshRequest = null;
+
+const refreshTokens = () => {
+    if (!refreshRequest) {
+        const refreshToken = localStorage.getItem(REFRESH_TOKEN_KEY);
+        refreshRequest = axios.post(`${apiURL}/auth/refresh`, { refresh_token: refreshToken })
+            .then((response) => {
+                saveTokens(response.data);
+                return response.data.access_token;
+            })
+            .finally(() => {
+                refreshRequest = null;
+            });
+    }
+    return refreshRequest;
+};
+
+api.interceptors.request.use((config) => {
+    const accessToken = localStorage.getItem(ACCESS_TOKEN_KEY);
+    if (accessToken) {
+        config.headers.Authorization = `Bearer ${accessToken}`;
+    }
+    return config;
+});
+
+api.interceptors.response.use(
+    (response) => response,
+    async (error) => {
+        const request = error.config;
+        if (error.response?.status !== 401 || !request || request.retried || !isAuthenticated()) {
+            return Promise.reject(error);
+        }
+        request.retried = true;
+        try {
+            const accessToken = await refreshTokens();
+            request.headers.Authorization = `Bearer ${accessToken}`;
+            return api(request);
+        } catch (refreshError) {
+            clearTokens();
+            window.location.assign('/login');
+            return Promise.reject(refreshError);
+        }
+    }
+);
+
+export default api;
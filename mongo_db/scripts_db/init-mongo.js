@@ -24,4 +24,5 @@ if (db.system.users.find({ user: "dbuser" }).count() === 0) {
 // db.createCollection("predictions_assessments");
 // db.createCollection("predictions_vle");
 // db.createCollection("predictions_risks");
-db.login.insertOne({ user: "test", password: "new123" });
+// Los usuarios de la API no se crean aquí: el primer administrador se da de alta con
+// docker compose exec golang_backend_app ./main user -username <usuario> -password <contraseña> -role admin